@@ -0,0 +1,57 @@
+// Command ctcheck runs the static constant-time check of package ctcheck
+// on the packages in the given directories, which default to ./mlkem, and
+// prints the secret-dependent branches, indices and divisions it finds.
+// The exit status is 1 if there are any.
+//
+// Usage:
+//
+//	ctcheck ./mlkem
+//	ctcheck -v ./mlkem ./hybrid
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/haopining/Learn-Lattice-Based-Cryptography/ctcheck"
+)
+
+func main() {
+	verbose := flag.Bool("v", false, "print the packages analyzed")
+	flag.Parse()
+	log.SetFlags(0)
+	log.SetPrefix("ctcheck: ")
+
+	dirs := flag.Args()
+	if len(dirs) == 0 {
+		dirs = []string{"./mlkem"}
+	}
+	prog, err := ctcheck.Load(dirs...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *verbose {
+		var paths []string
+		for path := range prog.Packages {
+			paths = append(paths, path)
+		}
+		slices.Sort(paths)
+		fmt.Fprintln(os.Stderr, "packages:", strings.Join(paths, " "))
+	}
+	findings := ctcheck.Check(prog)
+	wd, _ := os.Getwd()
+	for _, f := range findings {
+		if rel, ok := strings.CutPrefix(f.Pos.Filename, wd+string(os.PathSeparator)); ok {
+			f.Pos.Filename = rel
+		}
+		fmt.Println(f)
+	}
+	if len(findings) > 0 {
+		fmt.Fprintf(os.Stderr, "%d findings\n", len(findings))
+		os.Exit(1)
+	}
+}
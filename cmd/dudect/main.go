@@ -0,0 +1,77 @@
+// Command dudect runs the constant-time tests of package dudect: each
+// target is timed on a fixed and a random class of inputs, and Welch's
+// t-test tells whether the two timing distributions differ.
+//
+// Usage:
+//
+//	dudect -list
+//	dudect -n 1000000
+//	dudect -target decaps -n 200000 -v
+//	dudect -target ntt -duration 10m
+//
+// A target passes if its largest |t| stays below 4.5. Timing is noisy: run
+// on an idle machine, with the CPU frequency pinned if possible, and
+// repeat a failure before believing it. A pass says only that no leakage
+// showed up after that many measurements on this machine and compiler.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/haopining/Learn-Lattice-Based-Cryptography/dudect"
+	"github.com/haopining/Learn-Lattice-Based-Cryptography/gauss"
+)
+
+func main() {
+	target := flag.String("target", "all", "target name or all")
+	n := flag.Int("n", 200000, "measurements per target")
+	batch := flag.Int("batch", 10000, "inputs prepared at a time")
+	duration := flag.Duration("duration", 0, "stop each target after this long (default no limit)")
+	seed := flag.String("seed", "", "seed for the inputs (default: crypto/rand)")
+	verbose := flag.Bool("v", false, "print progress after every batch")
+	list := flag.Bool("list", false, "list the targets")
+	flag.Parse()
+	log.SetFlags(0)
+	log.SetPrefix("dudect: ")
+
+	if *list {
+		for _, t := range dudect.Targets {
+			fmt.Printf("%-14s %s\n", t.Name, t.Doc)
+		}
+		return
+	}
+
+	r := gauss.NewRand(nil)
+	if *seed != "" {
+		r = gauss.NewSHAKE([]byte(*seed))
+	}
+	opts := dudect.Options{Measurements: *n, Batch: *batch, Duration: *duration}
+	if *verbose {
+		opts.Progress = func(res *dudect.Result) { fmt.Fprintln(os.Stderr, res) }
+	}
+	found := false
+	leaks := false
+	for _, t := range dudect.Targets {
+		if *target != "all" && *target != t.Name {
+			continue
+		}
+		found = true
+		start := time.Now()
+		res := dudect.Run(t, r, opts)
+		fmt.Printf("%v  [%v]\n", res, time.Since(start).Round(time.Millisecond))
+		// The leaky control is expected to fail.
+		if res.MaxT >= dudect.Evident && t.Name != "compare-naive" {
+			leaks = true
+		}
+	}
+	if !found {
+		log.Fatalf("unknown target %q", *target)
+	}
+	if leaks {
+		os.Exit(1)
+	}
+}
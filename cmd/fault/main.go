@@ -0,0 +1,102 @@
+// Command fault runs the fault-injection experiments of package fault:
+// each fault is injected into deterministic Dilithium-style signing under
+// each set of countermeasures, and the known attack for it is run on
+// what comes out.
+//
+// Usage:
+//
+//	fault
+//	fault -trials 200 -layer 7
+//	fault -seed lab
+//
+// The last column is RECOVERED when the attack found the key, INVALID
+// when it did not but invalid signatures were released, whose answers z
+// may still leak s_1, and safe otherwise.
+//
+// The command exits with status 1 unless the attacks recover the key
+// from unprotected signing and no key is recovered and no invalid
+// signature is released with every countermeasure on.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/haopining/Learn-Lattice-Based-Cryptography/fault"
+	"github.com/haopining/Learn-Lattice-Based-Cryptography/gauss"
+)
+
+func main() {
+	trials := flag.Int("trials", 100, "faulty signings per experiment")
+	layer := flag.Int("layer", 0, "NTT layer skipped by the butterfly fault, 0 to 7")
+	seed := flag.String("seed", "", "seed for the key, messages and signing randomness (default: crypto/rand)")
+	flag.Parse()
+	log.SetFlags(0)
+	log.SetPrefix("fault: ")
+
+	if *layer < 0 || *layer > 7 {
+		log.Fatalf("layer %d out of range", *layer)
+	}
+	r := gauss.NewRand(nil)
+	if *seed != "" {
+		r = gauss.NewSHAKE([]byte(*seed))
+	}
+	key := make([]byte, 32)
+	for i := range key {
+		key[i] = byte(r.Uint64())
+	}
+	sk, err := fault.NewPrivateKey(key)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	faults := []fault.Fault{
+		{Point: fault.Butterfly, Layer: *layer},
+		{Point: fault.Rejection},
+		{Point: fault.Nonce},
+	}
+	all := fault.Countermeasures{VerifyAfterSign: true, RedundantNTT: true, Randomized: true}
+	sets := []fault.Countermeasures{
+		{},
+		{VerifyAfterSign: true},
+		{RedundantNTT: true},
+		{Randomized: true},
+		all,
+	}
+	fmt.Printf("%-18s %-30s %8s %8s %8s  %s\n", "fault", "countermeasures", "detected", "released", "invalid", "status")
+	failed := false
+	for _, f := range faults {
+		for _, cm := range sets {
+			res, err := fault.Attack(sk, f, cm, *trials, r)
+			if err != nil {
+				log.Fatal(err)
+			}
+			status := "safe"
+			switch {
+			case res.Recovered:
+				status = "RECOVERED"
+			case res.Invalid > 0:
+				status = "INVALID"
+			}
+			fmt.Printf("%-18v %-30v %8d %8d %8d  %s\n", f, cm, res.Detected, res.Released, res.Invalid, status)
+			switch {
+			case cm == fault.Countermeasures{} && f.Point != fault.Rejection && !res.Recovered:
+				fmt.Printf("  the attack on %v failed without countermeasures\n", f)
+				failed = true
+			case cm == fault.Countermeasures{} && f.Point == fault.Rejection && res.Invalid == 0:
+				fmt.Println("  no signature escaped the rejection check")
+				failed = true
+			case cm == all && (res.Recovered || res.Invalid > 0):
+				fmt.Printf("  %v gets through every countermeasure\n", f)
+				failed = true
+			}
+		}
+	}
+	if failed {
+		fmt.Println("FAIL")
+		os.Exit(1)
+	}
+	fmt.Println("ok: every key-recovery fault is detected or defeated")
+}
@@ -0,0 +1,147 @@
+// Command gauss2d visualizes two-dimensional lattice reduction.
+//
+// It reads a 2-D integer basis, runs Lagrange–Gauss reduction and writes one
+// SVG picture per step: the lattice points, the fundamental parallelogram
+// of the current basis and the basis vectors, with the shortest vector
+// highlighted once it is found. All steps are also bundled into index.svg,
+// which can be stepped through in a browser with the arrow keys or by
+// clicking. Given a target point it additionally draws the solutions of
+// Babai's rounding and nearest plane algorithms for that target.
+//
+// Usage:
+//
+//	gauss2d -basis "19,8;45,20" -target "40.5,9.2" -out gauss2d-out
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/haopining/Learn-Lattice-Based-Cryptography/lattice"
+)
+
+func main() {
+	basisFlag := flag.String("basis", "19,8;45,20", "basis rows `b0x,b0y;b1x,b1y`")
+	targetFlag := flag.String("target", "", "optional target point `x,y` for Babai's algorithms")
+	outFlag := flag.String("out", "gauss2d-out", "output directory")
+	flag.Parse()
+	log.SetFlags(0)
+	log.SetPrefix("gauss2d: ")
+
+	b, err := parseBasis(*basisFlag)
+	if err != nil {
+		log.Fatal(err)
+	}
+	tr := &lattice.Trace{}
+	red, err := lattice.GaussReduce(b, tr)
+	if err != nil {
+		log.Fatal(err)
+	}
+	steps := lattice.Replay(b, tr.Steps)
+
+	var babai *babaiResult
+	if *targetFlag != "" {
+		t, err := parseFloats(*targetFlag)
+		if err != nil || len(t) != 2 {
+			log.Fatalf("bad target %q", *targetFlag)
+		}
+		if babai, err = solveBabai(red, t); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if err := os.MkdirAll(*outFlag, 0o755); err != nil {
+		log.Fatal(err)
+	}
+	v := newView(steps, babai)
+	for i, s := range steps {
+		caption := "initial basis"
+		if i > 0 {
+			caption = fmt.Sprintf("step %d: %s", i, tr.Steps[i-1])
+		}
+		if i == len(steps)-1 {
+			caption += " (reduced)"
+		}
+		fmt.Printf("%-32s b0=%v b1=%v\n", caption, s[0], s[1])
+		name := filepath.Join(*outFlag, fmt.Sprintf("step-%02d.svg", i))
+		if err := os.WriteFile(name, []byte(v.standalone(s, caption, i == len(steps)-1)), 0o644); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if babai != nil {
+		fmt.Printf("target %v: rounding -> %v, nearest plane -> %v\n", babai.target, babai.round, babai.plane)
+		name := filepath.Join(*outFlag, "babai.svg")
+		if err := os.WriteFile(name, []byte(v.babaiStandalone(red, babai)), 0o644); err != nil {
+			log.Fatal(err)
+		}
+	}
+	captions := make([]string, len(steps))
+	for i := range steps {
+		captions[i] = "initial basis"
+		if i > 0 {
+			captions[i] = fmt.Sprintf("step %d: %s", i, tr.Steps[i-1])
+		}
+	}
+	name := filepath.Join(*outFlag, "index.svg")
+	if err := os.WriteFile(name, []byte(v.interactive(steps, captions, red, babai)), 0o644); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("wrote %d steps to %s\n", len(steps), *outFlag)
+}
+
+func parseBasis(s string) (lattice.Basis, error) {
+	rows := strings.Split(s, ";")
+	if len(rows) != 2 {
+		return nil, fmt.Errorf("basis %q: want two rows separated by ';'", s)
+	}
+	out := make([][]int64, 2)
+	for i, r := range rows {
+		for _, f := range strings.Split(r, ",") {
+			x, err := strconv.ParseInt(strings.TrimSpace(f), 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("basis %q: %v", s, err)
+			}
+			out[i] = append(out[i], x)
+		}
+		if len(out[i]) != 2 {
+			return nil, fmt.Errorf("basis %q: row %d is not 2-dimensional", s, i)
+		}
+	}
+	return lattice.NewBasis(out), nil
+}
+
+func parseFloats(s string) ([]float64, error) {
+	var out []float64
+	for _, f := range strings.Split(s, ",") {
+		x, err := strconv.ParseFloat(strings.TrimSpace(f), 64)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, x)
+	}
+	return out, nil
+}
+
+type babaiResult struct {
+	target []float64
+	round  lattice.Vector
+	plane  lattice.Vector
+}
+
+func solveBabai(red lattice.Basis, t []float64) (*babaiResult, error) {
+	target := lattice.FloatTarget(t...)
+	round, err := lattice.BabaiRound(red, target)
+	if err != nil {
+		return nil, err
+	}
+	plane, err := lattice.BabaiNearestPlane(red, target)
+	if err != nil {
+		return nil, err
+	}
+	return &babaiResult{target: t, round: round, plane: plane}, nil
+}
@@ -0,0 +1,277 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"strings"
+
+	"github.com/haopining/Learn-Lattice-Based-Cryptography/lattice"
+)
+
+const (
+	canvas = 640.0
+	margin = 24.0
+	// maxPoints caps the number of lattice points drawn; skewed input
+	// bases can make the picture cover millions of them.
+	maxPoints = 6000
+)
+
+// view maps lattice coordinates to SVG coordinates. All pictures of one run
+// share a view so that stepping through them does not move the lattice.
+type view struct {
+	xmin, ymin, xmax, ymax float64
+	scale                  float64
+	points                 [][2]float64
+}
+
+func newView(steps []lattice.Basis, babai *babaiResult) *view {
+	v := &view{}
+	pts := [][2]float64{{0, 0}}
+	for _, b := range steps {
+		b0, b1 := vec(b[0]), vec(b[1])
+		pts = append(pts, b0, b1, add(b0, b1), neg(b0), neg(b1))
+	}
+	if babai != nil {
+		pts = append(pts, [2]float64{babai.target[0], babai.target[1]}, vec(babai.round), vec(babai.plane))
+	}
+	red := steps[len(steps)-1]
+	pad := 1.5 * math.Max(red[0].Norm(), red[1].Norm())
+	v.xmin, v.ymin = math.Inf(1), math.Inf(1)
+	v.xmax, v.ymax = math.Inf(-1), math.Inf(-1)
+	for _, p := range pts {
+		v.xmin, v.xmax = math.Min(v.xmin, p[0]-pad), math.Max(v.xmax, p[0]+pad)
+		v.ymin, v.ymax = math.Min(v.ymin, p[1]-pad), math.Max(v.ymax, p[1]+pad)
+	}
+	// Keep the aspect ratio 1:1 so that angles are not distorted.
+	w, h := v.xmax-v.xmin, v.ymax-v.ymin
+	if w > h {
+		v.ymin -= (w - h) / 2
+		v.ymax += (w - h) / 2
+	} else {
+		v.xmin -= (h - w) / 2
+		v.xmax += (h - w) / 2
+	}
+	v.scale = (canvas - 2*margin) / math.Max(w, h)
+	v.points = latticePoints(red, v)
+	return v
+}
+
+// latticePoints lists the lattice points inside the view, using the reduced
+// basis so that the coefficient ranges are tight.
+func latticePoints(red lattice.Basis, v *view) [][2]float64 {
+	b0, b1 := vec(red[0]), vec(red[1])
+	det := b0[0]*b1[1] - b0[1]*b1[0]
+	if (v.xmax-v.xmin)*(v.ymax-v.ymin)/math.Abs(det) > maxPoints {
+		return nil
+	}
+	// Coefficients (s, t) with s*b0 + t*b1 = p, for the four corners.
+	smin, smax := math.Inf(1), math.Inf(-1)
+	tmin, tmax := math.Inf(1), math.Inf(-1)
+	for _, c := range [][2]float64{{v.xmin, v.ymin}, {v.xmin, v.ymax}, {v.xmax, v.ymin}, {v.xmax, v.ymax}} {
+		s := (c[0]*b1[1] - c[1]*b1[0]) / det
+		t := (b0[0]*c[1] - b0[1]*c[0]) / det
+		smin, smax = math.Min(smin, s), math.Max(smax, s)
+		tmin, tmax = math.Min(tmin, t), math.Max(tmax, t)
+	}
+	var pts [][2]float64
+	for s := math.Floor(smin); s <= smax; s++ {
+		for t := math.Floor(tmin); t <= tmax; t++ {
+			p := add(scaled(b0, s), scaled(b1, t))
+			if p[0] >= v.xmin && p[0] <= v.xmax && p[1] >= v.ymin && p[1] <= v.ymax {
+				pts = append(pts, p)
+			}
+		}
+	}
+	return pts
+}
+
+func (v *view) x(p [2]float64) float64 { return margin + (p[0]-v.xmin)*v.scale }
+func (v *view) y(p [2]float64) float64 { return margin + (v.ymax-p[1])*v.scale }
+
+func (v *view) header(sb *strings.Builder, script bool) {
+	fmt.Fprintf(sb, `<svg xmlns="http://www.w3.org/2000/svg" width="%g" height="%g" viewBox="0 0 %g %g">`+"\n",
+		canvas, canvas+40, canvas, canvas+40)
+	sb.WriteString(`<style>
+.pt{fill:#555}.axis{stroke:#ccc;stroke-width:1}
+.cell{fill:#4a90d9;fill-opacity:.18;stroke:#4a90d9;stroke-width:1}
+.vec{stroke-width:2.5;marker-end:url(#arrow)}.b0{stroke:#d0021b}.b1{stroke:#2a7ab0}
+.short{stroke:#f5a623;stroke-width:4;marker-end:url(#arrow)}.ball{fill:none;stroke:#f5a623;stroke-dasharray:4 4}
+.plane{stroke:#7ed321;stroke-width:1;stroke-dasharray:6 3}.chosen{stroke:#417505;stroke-width:2}
+.round{fill:#f5a623;fill-opacity:.15;stroke:#f5a623}.target{stroke:#000;stroke-width:2}
+.sol{stroke-width:1.5}.label{font:14px sans-serif}.caption{font:16px sans-serif}
+</style>
+<defs><marker id="arrow" viewBox="0 0 10 10" refX="9" refY="5" markerWidth="6" markerHeight="6" orient="auto-start-reverse"><path d="M0,0L10,5L0,10z" fill="context-stroke"/></marker></defs>
+`)
+	if script {
+		sb.WriteString(`<script><![CDATA[
+var cur = 0;
+function show(i) {
+  var layers = document.querySelectorAll('.layer');
+  cur = (i + layers.length) % layers.length;
+  layers.forEach(function(l, j) { l.style.display = j == cur ? 'inline' : 'none'; });
+}
+document.addEventListener('keydown', function(e) {
+  if (e.key == 'ArrowRight' || e.key == ' ') show(cur + 1);
+  if (e.key == 'ArrowLeft') show(cur - 1);
+});
+document.addEventListener('click', function() { show(cur + 1); });
+window.addEventListener('load', function() { show(0); });
+]]></script>
+`)
+	}
+	sb.WriteString(`<rect width="100%" height="100%" fill="#fff"/>` + "\n")
+}
+
+func (v *view) background(sb *strings.Builder) {
+	o := [2]float64{0, 0}
+	fmt.Fprintf(sb, `<line class="axis" x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f"/>`+"\n",
+		v.x([2]float64{v.xmin, 0}), v.y(o), v.x([2]float64{v.xmax, 0}), v.y(o))
+	fmt.Fprintf(sb, `<line class="axis" x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f"/>`+"\n",
+		v.x(o), v.y([2]float64{0, v.ymin}), v.x(o), v.y([2]float64{0, v.ymax}))
+	for _, p := range v.points {
+		fmt.Fprintf(sb, `<circle class="pt" cx="%.1f" cy="%.1f" r="2.5"/>`+"\n", v.x(p), v.y(p))
+	}
+}
+
+func (v *view) polygon(sb *strings.Builder, class string, pts ...[2]float64) {
+	var coords []string
+	for _, p := range pts {
+		coords = append(coords, fmt.Sprintf("%.1f,%.1f", v.x(p), v.y(p)))
+	}
+	fmt.Fprintf(sb, `<polygon class="%s" points="%s"/>`+"\n", class, strings.Join(coords, " "))
+}
+
+func (v *view) line(sb *strings.Builder, class string, p, q [2]float64) {
+	fmt.Fprintf(sb, `<line class="%s" x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f"/>`+"\n",
+		class, v.x(p), v.y(p), v.x(q), v.y(q))
+}
+
+func (v *view) label(sb *strings.Builder, p [2]float64, text string) {
+	fmt.Fprintf(sb, `<text class="label" x="%.1f" y="%.1f">%s</text>`+"\n", v.x(p)+6, v.y(p)-6, text)
+}
+
+func (v *view) caption(sb *strings.Builder, text string) {
+	if v.points == nil {
+		text += " (too many lattice points to draw)"
+	}
+	fmt.Fprintf(sb, `<text class="caption" x="%g" y="%g">%s</text>`+"\n", margin, canvas+24, html.EscapeString(text))
+}
+
+// basis draws the fundamental parallelogram and the two basis vectors; for
+// the reduced basis it also marks the shortest vectors ±b0 and the circle
+// of radius lambda_1 that contains no other lattice point.
+func (v *view) basis(sb *strings.Builder, b lattice.Basis, final bool) {
+	o := [2]float64{0, 0}
+	b0, b1 := vec(b[0]), vec(b[1])
+	v.polygon(sb, "cell", o, b0, add(b0, b1), b1)
+	if final {
+		fmt.Fprintf(sb, `<circle class="ball" cx="%.1f" cy="%.1f" r="%.1f"/>`+"\n", v.x(o), v.y(o), b[0].Norm()*v.scale)
+		v.line(sb, "short", o, neg(b0))
+		v.line(sb, "short", o, b0)
+	}
+	v.line(sb, "vec b0", o, b0)
+	v.line(sb, "vec b1", o, b1)
+	v.label(sb, b0, "b0")
+	v.label(sb, b1, "b1")
+}
+
+// babai draws the target, the rounding cell around the rounding answer,
+// the hyperplanes (here lines) c*b1* + span(b0) of the nearest plane
+// algorithm with the chosen one emphasized, and both answers.
+func (v *view) babai(sb *strings.Builder, red lattice.Basis, r *babaiResult) {
+	b0, b1 := vec(red[0]), vec(red[1])
+	t := [2]float64{r.target[0], r.target[1]}
+	rv, pv := vec(r.round), vec(r.plane)
+	h0, h1 := scaled(b0, 0.5), scaled(b1, 0.5)
+	v.polygon(sb, "round", sub(sub(rv, h0), h1), sub(add(rv, h0), h1), add(add(rv, h0), h1), add(sub(rv, h0), h1))
+
+	// Every line k*b1 + span(b0) crossing the view; the one through the
+	// nearest-plane answer is the plane Babai picked.
+	long := scaled(b0, 4*(v.xmax-v.xmin+v.ymax-v.ymin)/math.Hypot(b0[0], b0[1]))
+	det := b0[0]*b1[1] - b0[1]*b1[0]
+	chosen := math.Round((b0[0]*pv[1] - b0[1]*pv[0]) / det)
+	kmin, kmax := math.Inf(1), math.Inf(-1)
+	for _, c := range [][2]float64{{v.xmin, v.ymin}, {v.xmin, v.ymax}, {v.xmax, v.ymin}, {v.xmax, v.ymax}} {
+		k := (b0[0]*c[1] - b0[1]*c[0]) / det
+		kmin, kmax = math.Min(kmin, k), math.Max(kmax, k)
+	}
+	if kmax-kmin < 200 {
+		for k := math.Ceil(kmin); k <= kmax; k++ {
+			p := scaled(b1, k)
+			class := "plane"
+			if k == chosen {
+				class = "plane chosen"
+			}
+			v.line(sb, class, sub(p, long), add(p, long))
+		}
+	}
+	v.line(sb, "sol round", t, rv)
+	v.line(sb, "sol chosen", t, pv)
+	fmt.Fprintf(sb, `<circle cx="%.1f" cy="%.1f" r="5" fill="#f5a623"/>`+"\n", v.x(rv), v.y(rv))
+	fmt.Fprintf(sb, `<circle cx="%.1f" cy="%.1f" r="5" fill="#417505"/>`+"\n", v.x(pv), v.y(pv))
+	x, y := v.x(t), v.y(t)
+	fmt.Fprintf(sb, `<path class="target" d="M%.1f,%.1fL%.1f,%.1fM%.1f,%.1fL%.1f,%.1f"/>`+"\n", x-6, y-6, x+6, y+6, x-6, y+6, x+6, y-6)
+	v.label(sb, t, "t")
+	v.label(sb, rv, "round")
+	v.label(sb, pv, "plane")
+}
+
+func (v *view) standalone(b lattice.Basis, caption string, final bool) string {
+	var sb strings.Builder
+	v.header(&sb, false)
+	v.background(&sb)
+	v.basis(&sb, b, final)
+	v.caption(&sb, caption)
+	sb.WriteString("</svg>\n")
+	return sb.String()
+}
+
+func (v *view) babaiStandalone(red lattice.Basis, r *babaiResult) string {
+	var sb strings.Builder
+	v.header(&sb, false)
+	v.background(&sb)
+	v.basis(&sb, red, false)
+	v.babai(&sb, red, r)
+	v.caption(&sb, fmt.Sprintf("Babai for t=(%g, %g): rounding %v, nearest plane %v", r.target[0], r.target[1], r.round, r.plane))
+	sb.WriteString("</svg>\n")
+	return sb.String()
+}
+
+// interactive bundles every step, and the Babai picture if any, as layers
+// of a single SVG; a small script shows one layer at a time.
+func (v *view) interactive(steps []lattice.Basis, captions []string, red lattice.Basis, r *babaiResult) string {
+	var sb strings.Builder
+	v.header(&sb, true)
+	v.background(&sb)
+	for i, b := range steps {
+		final := i == len(steps)-1
+		fmt.Fprintf(&sb, `<g class="layer" style="display:%s">`+"\n", map[bool]string{true: "inline", false: "none"}[i == 0])
+		v.basis(&sb, b, final)
+		c := captions[i]
+		if final {
+			c += " (reduced)"
+		}
+		v.caption(&sb, fmt.Sprintf("%s — %d/%d, click or use arrow keys", c, i+1, len(steps)))
+		sb.WriteString("</g>\n")
+	}
+	if r != nil {
+		sb.WriteString(`<g class="layer" style="display:none">` + "\n")
+		v.basis(&sb, red, false)
+		v.babai(&sb, red, r)
+		v.caption(&sb, fmt.Sprintf("Babai for t=(%g, %g): rounding %v, nearest plane %v", r.target[0], r.target[1], r.round, r.plane))
+		sb.WriteString("</g>\n")
+	}
+	sb.WriteString("</svg>\n")
+	return sb.String()
+}
+
+func vec(v lattice.Vector) [2]float64 {
+	f := v.Floats()
+	return [2]float64{f[0], f[1]}
+}
+
+func add(p, q [2]float64) [2]float64            { return [2]float64{p[0] + q[0], p[1] + q[1]} }
+func sub(p, q [2]float64) [2]float64            { return [2]float64{p[0] - q[0], p[1] - q[1]} }
+func neg(p [2]float64) [2]float64               { return [2]float64{-p[0], -p[1]} }
+func scaled(p [2]float64, s float64) [2]float64 { return [2]float64{s * p[0], s * p[1]} }
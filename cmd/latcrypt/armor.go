@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"io"
+	"strings"
+)
+
+// The armor is PEM-like text around the base64 of a binary file, in lines
+// of 64 characters, written and read as a stream:
+//
+//	-----BEGIN LATCRYPT MESSAGE-----
+//	TEFUQ1JZUFQBAEEAAQAC...
+//	-----END LATCRYPT MESSAGE-----
+//
+// Unlike PEM it allows no headers, and it has no size limit.
+
+const (
+	armorMessage   = "LATCRYPT MESSAGE"
+	armorSignature = "LATCRYPT SIGNATURE"
+)
+
+// armorWriter base64-encodes to an underlying writer, wrapping lines.
+type armorWriter struct {
+	w     io.Writer
+	label string
+	enc   io.WriteCloser
+	lines *lineWriter
+}
+
+// newArmorWriter writes the BEGIN line; Close flushes and writes the END
+// line, but does not close w.
+func newArmorWriter(w io.Writer, label string) (*armorWriter, error) {
+	if _, err := io.WriteString(w, "-----BEGIN "+label+"-----\n"); err != nil {
+		return nil, err
+	}
+	lines := &lineWriter{w: w}
+	return &armorWriter{w: w, label: label, enc: base64.NewEncoder(base64.StdEncoding, lines), lines: lines}, nil
+}
+
+func (a *armorWriter) Write(p []byte) (int, error) { return a.enc.Write(p) }
+
+func (a *armorWriter) Close() error {
+	if err := a.enc.Close(); err != nil {
+		return err
+	}
+	if a.lines.col > 0 {
+		if _, err := io.WriteString(a.w, "\n"); err != nil {
+			return err
+		}
+	}
+	_, err := io.WriteString(a.w, "-----END "+a.label+"-----\n")
+	return err
+}
+
+// lineWriter inserts a newline every 64 bytes.
+type lineWriter struct {
+	w   io.Writer
+	col int
+}
+
+func (l *lineWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	for len(p) > 0 {
+		k := min(64-l.col, len(p))
+		if _, err := l.w.Write(p[:k]); err != nil {
+			return 0, err
+		}
+		p = p[k:]
+		l.col += k
+		if l.col == 64 {
+			if _, err := io.WriteString(l.w, "\n"); err != nil {
+				return 0, err
+			}
+			l.col = 0
+		}
+	}
+	return n, nil
+}
+
+// armorLines returns the base64 text between the BEGIN and END lines,
+// without the newlines, and fails if the END line is missing.
+type armorLines struct {
+	r    *bufio.Reader
+	end  string
+	buf  []byte
+	done bool
+}
+
+func (a *armorLines) Read(p []byte) (int, error) {
+	for len(a.buf) == 0 {
+		if a.done {
+			return 0, io.EOF
+		}
+		line, err := a.r.ReadString('\n')
+		line = strings.TrimRight(line, "\r\n")
+		if line == a.end {
+			a.done = true
+			continue
+		}
+		if err == io.EOF {
+			return 0, errors.New("latcrypt: armor: missing END line")
+		}
+		if err != nil {
+			return 0, err
+		}
+		a.buf = []byte(line)
+	}
+	n := copy(p, a.buf)
+	a.buf = a.buf[n:]
+	return n, nil
+}
+
+// dearmor returns a reader of the binary contents of r: the decoded armor
+// with the label if r starts with its BEGIN line, and r itself otherwise.
+func dearmor(r io.Reader, label string) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	begin := "-----BEGIN " + label + "-----"
+	head, err := br.Peek(len(begin))
+	if err != nil || !bytes.Equal(head, []byte(begin)) {
+		// Too short to be armor, or binary.
+		return br, nil
+	}
+	line, err := br.ReadString('\n')
+	if err != nil || strings.TrimRight(line, "\r\n") != begin {
+		return nil, errors.New("latcrypt: armor: malformed BEGIN line")
+	}
+	lines := &armorLines{r: br, end: "-----END " + label + "-----"}
+	return base64.NewDecoder(base64.StdEncoding, lines), nil
+}
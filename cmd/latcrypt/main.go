@@ -0,0 +1,319 @@
+// Command latcrypt encrypts files to ML-KEM public keys and signs them
+// with ML-DSA.
+//
+// Keys are PEM files in the formats of package pqkeys: keygen writes the
+// private key to NAME.key, readable only by the owner, and the public key
+// to NAME.pub. Encryption is HPKE (package hpke) with ML-KEM, HKDF-SHA256
+// and AES-256-GCM, streamed in chunks of 64 KiB so that files of any size
+// go through in constant memory; the format is documented in stream.go.
+// Signatures are ML-DSA signatures, with the context string "latcrypt", of
+// the SHA-512 digest of the file, so that signing streams too.
+//
+// With -armor, encrypt and sign write base64 text between BEGIN and END
+// lines instead of binary; decrypt and verify recognize either form.
+// Without -in and -out, the commands read standard input and write
+// standard output.
+//
+// Usage:
+//
+//	latcrypt keygen -alg ML-KEM-768 -out alice
+//	latcrypt encrypt -to alice.pub -in report.pdf -out report.pdf.lc
+//	latcrypt decrypt -key alice.key -in report.pdf.lc -out report.pdf
+//	latcrypt keygen -alg ML-DSA-65 -out bob
+//	latcrypt sign -key bob.key -in report.pdf -out report.pdf.sig -armor
+//	latcrypt verify -pub bob.pub -in report.pdf -sig report.pdf.sig
+//
+// decrypt writes to a temporary file next to -out and renames it only
+// once the whole input has authenticated; to standard output it must write
+// plaintext as it goes, and a final error means the output is to be
+// discarded.
+package main
+
+import (
+	"crypto/sha512"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/haopining/Learn-Lattice-Based-Cryptography/mldsa"
+	"github.com/haopining/Learn-Lattice-Based-Cryptography/mlkem"
+	"github.com/haopining/Learn-Lattice-Based-Cryptography/pqkeys"
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("latcrypt: ")
+	if len(os.Args) < 2 {
+		usage()
+	}
+	args := os.Args[2:]
+	var err error
+	switch os.Args[1] {
+	case "keygen":
+		err = keygenCmd(args)
+	case "encrypt":
+		err = encryptCmd(args)
+	case "decrypt":
+		err = decryptCmd(args)
+	case "sign":
+		err = signCmd(args)
+	case "verify":
+		if err = verifyCmd(args); err == nil {
+			fmt.Fprintln(os.Stderr, "good signature")
+		}
+	default:
+		usage()
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: latcrypt keygen|encrypt|decrypt|sign|verify [flags]")
+	os.Exit(2)
+}
+
+// signatureContext is the ML-DSA context string of latcrypt signatures.
+const signatureContext = "latcrypt"
+
+func keygenCmd(args []string) error {
+	fs := flag.NewFlagSet("keygen", flag.ExitOnError)
+	alg := fs.String("alg", "ML-KEM-768", "ML-KEM-512, ML-KEM-768, ML-KEM-1024, ML-DSA-44, ML-DSA-65 or ML-DSA-87")
+	out := fs.String("out", "latcrypt", "write the keys to OUT.key and OUT.pub")
+	fs.Parse(args)
+	return keygen(*alg, *out)
+}
+
+func keygen(alg, out string) error {
+	var priv, pub any
+	var err error
+	switch alg {
+	case "ML-KEM-512", "ML-KEM-768", "ML-KEM-1024":
+		p := map[string]*mlkem.Params{"ML-KEM-512": mlkem.MLKEM512, "ML-KEM-768": mlkem.MLKEM768, "ML-KEM-1024": mlkem.MLKEM1024}[alg]
+		var dk *mlkem.DecapsulationKey
+		dk, err = mlkem.GenerateKey(p)
+		if err == nil {
+			priv, pub = dk, dk.EncapsulationKey()
+		}
+	case "ML-DSA-44", "ML-DSA-65", "ML-DSA-87":
+		p := map[string]*mldsa.Params{"ML-DSA-44": mldsa.MLDSA44, "ML-DSA-65": mldsa.MLDSA65, "ML-DSA-87": mldsa.MLDSA87}[alg]
+		var sk *mldsa.PrivateKey
+		sk, err = mldsa.GenerateKey(p)
+		if err == nil {
+			priv, pub = sk, sk.PublicKey()
+		}
+	default:
+		return fmt.Errorf("unknown algorithm %q", alg)
+	}
+	if err != nil {
+		return err
+	}
+	privPEM, err := pqkeys.MarshalPEM(priv)
+	if err != nil {
+		return err
+	}
+	pubPEM, err := pqkeys.MarshalPEM(pub)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(out+".key", privPEM, 0o600); err != nil {
+		return err
+	}
+	return os.WriteFile(out+".pub", pubPEM, 0o644)
+}
+
+// readKey parses the PEM key in the named file.
+func readKey(name string) (any, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	key, _, err := pqkeys.ParsePEM(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	return key, nil
+}
+
+func encryptCmd(args []string) error {
+	fs := flag.NewFlagSet("encrypt", flag.ExitOnError)
+	to := fs.String("to", "", "recipient's ML-KEM public key")
+	in := fs.String("in", "", "input file (default standard input)")
+	out := fs.String("out", "", "output file (default standard output)")
+	armor := fs.Bool("armor", false, "write base64 armor instead of binary")
+	fs.Parse(args)
+	key, err := readKey(*to)
+	if err != nil {
+		return err
+	}
+	ek, ok := key.(*mlkem.EncapsulationKey)
+	if !ok {
+		return fmt.Errorf("%s: not an ML-KEM public key", *to)
+	}
+	return withFiles(*in, *out, *armor, armorMessage, func(w io.Writer, r io.Reader) error {
+		return encrypt(w, ek, r)
+	})
+}
+
+func decryptCmd(args []string) error {
+	fs := flag.NewFlagSet("decrypt", flag.ExitOnError)
+	keyFile := fs.String("key", "", "ML-KEM private key")
+	in := fs.String("in", "", "input file (default standard input)")
+	out := fs.String("out", "", "output file (default standard output)")
+	fs.Parse(args)
+	key, err := readKey(*keyFile)
+	if err != nil {
+		return err
+	}
+	dk, ok := key.(*mlkem.DecapsulationKey)
+	if !ok {
+		return fmt.Errorf("%s: not an ML-KEM private key", *keyFile)
+	}
+	return withFiles(*in, *out, false, "", func(w io.Writer, r io.Reader) error {
+		r, err := dearmor(r, armorMessage)
+		if err != nil {
+			return err
+		}
+		return decrypt(w, dk, r)
+	})
+}
+
+func signCmd(args []string) error {
+	fs := flag.NewFlagSet("sign", flag.ExitOnError)
+	keyFile := fs.String("key", "", "ML-DSA private key")
+	in := fs.String("in", "", "file to sign (default standard input)")
+	out := fs.String("out", "", "signature file (default standard output)")
+	armor := fs.Bool("armor", false, "write base64 armor instead of binary")
+	fs.Parse(args)
+	key, err := readKey(*keyFile)
+	if err != nil {
+		return err
+	}
+	sk, ok := key.(*mldsa.PrivateKey)
+	if !ok {
+		return fmt.Errorf("%s: not an ML-DSA private key", *keyFile)
+	}
+	return withFiles(*in, *out, *armor, armorSignature, func(w io.Writer, r io.Reader) error {
+		return sign(w, sk, r)
+	})
+}
+
+func verifyCmd(args []string) error {
+	fs := flag.NewFlagSet("verify", flag.ExitOnError)
+	pubFile := fs.String("pub", "", "signer's ML-DSA public key")
+	in := fs.String("in", "", "signed file (default standard input)")
+	sigFile := fs.String("sig", "", "signature file")
+	fs.Parse(args)
+	key, err := readKey(*pubFile)
+	if err != nil {
+		return err
+	}
+	pk, ok := key.(*mldsa.PublicKey)
+	if !ok {
+		return fmt.Errorf("%s: not an ML-DSA public key", *pubFile)
+	}
+	sig, err := os.Open(*sigFile)
+	if err != nil {
+		return err
+	}
+	defer sig.Close()
+	return withFiles(*in, "", false, "", func(_ io.Writer, r io.Reader) error {
+		return verify(pk, r, sig)
+	})
+}
+
+// digest returns the SHA-512 digest of r, the message that latcrypt
+// signs.
+func digest(r io.Reader) ([]byte, error) {
+	h := sha512.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+func sign(w io.Writer, sk *mldsa.PrivateKey, r io.Reader) error {
+	d, err := digest(r)
+	if err != nil {
+		return err
+	}
+	sig, err := sk.Sign(nil, d, &mldsa.Options{Context: signatureContext})
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(sig)
+	return err
+}
+
+func verify(pk *mldsa.PublicKey, r, sig io.Reader) error {
+	d, err := digest(r)
+	if err != nil {
+		return err
+	}
+	sr, err := dearmor(sig, armorSignature)
+	if err != nil {
+		return err
+	}
+	// Read one byte more than a signature, to refuse trailing data.
+	s, err := io.ReadAll(io.LimitReader(sr, int64(pk.Params().SignatureSize())+1))
+	if err != nil {
+		return err
+	}
+	return mldsa.Verify(pk, d, s, []byte(signatureContext))
+}
+
+// withFiles runs f on the named input and output, standard input and
+// output for empty names, with the output armored if armor is set. A named
+// output is written to a temporary file, renamed into place only if f
+// succeeds.
+func withFiles(in, out string, armor bool, label string, f func(io.Writer, io.Reader) error) (err error) {
+	var r io.Reader = os.Stdin
+	if in != "" {
+		fin, err := os.Open(in)
+		if err != nil {
+			return err
+		}
+		defer fin.Close()
+		r = fin
+	}
+	var w io.Writer = os.Stdout
+	var tmp *os.File
+	if out != "" {
+		tmp, err = os.CreateTemp(filepath.Dir(out), filepath.Base(out)+".*.tmp")
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err != nil {
+				tmp.Close()
+				os.Remove(tmp.Name())
+			}
+		}()
+		w = tmp
+	}
+	if armor {
+		aw, err := newArmorWriter(w, label)
+		if err != nil {
+			return err
+		}
+		if err := f(aw, r); err != nil {
+			return err
+		}
+		if err := aw.Close(); err != nil {
+			return err
+		}
+	} else if err := f(w, r); err != nil {
+		return err
+	}
+	if tmp == nil {
+		return nil
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), out)
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/haopining/Learn-Lattice-Based-Cryptography/mldsa"
+	"github.com/haopining/Learn-Lattice-Based-Cryptography/mlkem"
+)
+
+// setup generates the keys of the tests in a temporary directory and
+// returns a function that names files in it: alice and eve have ML-KEM
+// keys, bob an ML-DSA key.
+func setup(t *testing.T) func(string) string {
+	dir := t.TempDir()
+	path := func(name string) string { return filepath.Join(dir, name) }
+	for _, k := range []struct{ alg, name string }{
+		{"ML-KEM-768", "alice"}, {"ML-KEM-512", "eve"}, {"ML-DSA-65", "bob"},
+	} {
+		if err := keygen(k.alg, path(k.name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return path
+}
+
+// write writes size random bytes to the named file and returns them.
+func write(t *testing.T, name string, size int) []byte {
+	data := make([]byte, size)
+	rand.Read(data)
+	if err := os.WriteFile(name, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestKeygen(t *testing.T) {
+	path := setup(t)
+	if fi, err := os.Stat(path("alice.key")); err != nil || fi.Mode().Perm() != 0o600 {
+		t.Errorf("private key file not created with mode 0600: %v", err)
+	}
+	for name, want := range map[string]any{
+		"alice.key": &mlkem.DecapsulationKey{},
+		"alice.pub": &mlkem.EncapsulationKey{},
+		"bob.key":   &mldsa.PrivateKey{},
+		"bob.pub":   &mldsa.PublicKey{},
+	} {
+		key, err := readKey(path(name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fmt.Sprintf("%T", key) != fmt.Sprintf("%T", want) {
+			t.Errorf("%s holds a %T, want %T", name, key, want)
+		}
+	}
+	if err := keygen("ML-KEM-9000", path("x")); err == nil {
+		t.Error("generated a key for an unknown algorithm")
+	}
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	path := setup(t)
+	for _, size := range []int{0, 1, 1000, chunkSize - 1, chunkSize, chunkSize + 1, 3*chunkSize + 12345} {
+		for _, armor := range []bool{false, true} {
+			data := write(t, path("plain"), size)
+			if err := encryptCmd([]string{"-to", path("alice.pub"), "-in", path("plain"), "-out", path("plain.lc"), fmt.Sprint("-armor=", armor)}); err != nil {
+				t.Fatal(err)
+			}
+			enc, err := os.ReadFile(path("plain.lc"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if armor != bytes.HasPrefix(enc, []byte("-----BEGIN "+armorMessage+"-----\n")) {
+				t.Errorf("%d bytes: armor %v, -armor=%v", size, !armor, armor)
+			}
+			if err := decryptCmd([]string{"-key", path("alice.key"), "-in", path("plain.lc"), "-out", path("plain.out")}); err != nil {
+				t.Fatalf("%d bytes, armor %v: %v", size, armor, err)
+			}
+			got, err := os.ReadFile(path("plain.out"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, data) {
+				t.Errorf("%d bytes, armor %v: decryption differs from the original", size, armor)
+			}
+		}
+	}
+}
+
+// TestDecryptRefuses decrypts broken versions of a ciphertext of 3.5
+// chunks, which must fail and leave no output behind.
+func TestDecryptRefuses(t *testing.T) {
+	path := setup(t)
+	write(t, path("plain"), 3*chunkSize+12345)
+	if err := encryptCmd([]string{"-to", path("alice.pub"), "-in", path("plain"), "-out", path("plain.lc")}); err != nil {
+		t.Fatal(err)
+	}
+	enc, err := os.ReadFile(path("plain.lc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tampered := bytes.Clone(enc)
+	tampered[len(tampered)/2] ^= 1
+	lastRecord := len(enc) - (12345 + tagSize + 5)
+	for name, b := range map[string][]byte{
+		"tampered":         tampered,
+		"truncated":        enc[:len(enc)-1],
+		"last record cut":  enc[:lastRecord],
+		"trailing data":    append(bytes.Clone(enc), 0),
+		"early final flag": setFinal(enc, lastRecord-(chunkSize+tagSize+5)),
+		"header only":      enc[:100],
+		"not a ciphertext": []byte("hello"),
+		"empty":            nil,
+	} {
+		if err := os.WriteFile(path("bad.lc"), b, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := decryptCmd([]string{"-key", path("alice.key"), "-in", path("bad.lc"), "-out", path(name)}); err == nil {
+			t.Errorf("%s ciphertext decrypted", name)
+		}
+		if _, err := os.Stat(path(name)); err == nil {
+			t.Errorf("%s ciphertext left an output file", name)
+		}
+	}
+	if err := decryptCmd([]string{"-key", path("eve.key"), "-in", path("plain.lc"), "-out", path("plain.out")}); err == nil {
+		t.Error("ciphertext decrypted with another key")
+	}
+	if err := decryptCmd([]string{"-key", path("bob.key"), "-in", path("plain.lc"), "-out", path("plain.out")}); err == nil {
+		t.Error("ML-DSA key accepted for decryption")
+	}
+	if err := encryptCmd([]string{"-to", path("bob.pub"), "-in", path("plain"), "-out", path("plain.lc")}); err == nil {
+		t.Error("encrypted to an ML-DSA key")
+	}
+}
+
+// setFinal returns enc up to the record at offset i, marked final: a
+// truncation that fixes up the flag but not the authentication tag.
+func setFinal(enc []byte, i int) []byte {
+	b := bytes.Clone(enc)
+	b[i] = 1
+	return b[:i+5+int(binary.BigEndian.Uint32(b[i+1:]))]
+}
+
+func TestSignVerify(t *testing.T) {
+	path := setup(t)
+	for _, size := range []int{0, 1000, 3*chunkSize + 12345} {
+		for _, armor := range []bool{false, true} {
+			data := write(t, path("plain"), size)
+			if err := signCmd([]string{"-key", path("bob.key"), "-in", path("plain"), "-out", path("plain.sig"), fmt.Sprint("-armor=", armor)}); err != nil {
+				t.Fatal(err)
+			}
+			sig, err := os.ReadFile(path("plain.sig"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if armor != bytes.HasPrefix(sig, []byte("-----BEGIN "+armorSignature+"-----\n")) {
+				t.Errorf("%d bytes: armor %v, -armor=%v", size, !armor, armor)
+			}
+			if err := verifyCmd([]string{"-pub", path("bob.pub"), "-in", path("plain"), "-sig", path("plain.sig")}); err != nil {
+				t.Fatalf("%d bytes, armor %v: %v", size, armor, err)
+			}
+
+			if size > 0 {
+				data[size/2] ^= 1
+				if err := os.WriteFile(path("forged"), data, 0o644); err != nil {
+					t.Fatal(err)
+				}
+				if err := verifyCmd([]string{"-pub", path("bob.pub"), "-in", path("forged"), "-sig", path("plain.sig")}); err == nil {
+					t.Errorf("%d bytes, armor %v: signature verified on a modified file", size, armor)
+				}
+			}
+			if !armor {
+				sig[len(sig)/2] ^= 1
+				if err := os.WriteFile(path("bad.sig"), sig, 0o644); err != nil {
+					t.Fatal(err)
+				}
+				if err := verifyCmd([]string{"-pub", path("bob.pub"), "-in", path("plain"), "-sig", path("bad.sig")}); err == nil {
+					t.Errorf("%d bytes: tampered signature verified", size)
+				}
+				sig[len(sig)/2] ^= 1
+				if err := os.WriteFile(path("long.sig"), append(sig, 0), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				if err := verifyCmd([]string{"-pub", path("bob.pub"), "-in", path("plain"), "-sig", path("long.sig")}); err == nil {
+					t.Errorf("%d bytes: signature with trailing data verified", size)
+				}
+			}
+		}
+	}
+	if err := signCmd([]string{"-key", path("alice.key"), "-in", path("plain"), "-out", path("plain.sig")}); err == nil {
+		t.Error("signed with an ML-KEM key")
+	}
+}
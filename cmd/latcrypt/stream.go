@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/haopining/Learn-Lattice-Based-Cryptography/hpke"
+	"github.com/haopining/Learn-Lattice-Based-Cryptography/mlkem"
+)
+
+// An encrypted file is a header and a sequence of records:
+//
+//	header  "LATCRYPT" || version (1) || kem_id (2) || kdf_id (2) || aead_id (2)
+//	        || len(enc) (2) || enc
+//	record  final (1) || len(ct) (4) || ct
+//
+// enc is the ML-KEM ciphertext that sets up an HPKE base-mode context
+// (ML-KEM, HKDF-SHA256, AES-256-GCM) whose info is the header up to the
+// AEAD identifier. Each record is a chunk of at most chunkSize bytes of
+// plaintext sealed by that context, with the final byte as additional
+// data; the last record, and only it, has final = 1, and may be empty.
+// Since HPKE numbers the messages of a context, records cannot be
+// reordered or dropped, and the final flag detects a truncated file.
+
+const (
+	magic     = "LATCRYPT"
+	version   = 1
+	chunkSize = 64 << 10
+	tagSize   = 16 // AES-GCM
+)
+
+var errFormat = errors.New("latcrypt: not an encrypted file, or a corrupted one")
+
+// suiteFor returns the HPKE suite of an ML-KEM parameter set.
+func suiteFor(p *mlkem.Params) hpke.Suite {
+	k := map[*mlkem.Params]*hpke.KEM{
+		mlkem.MLKEM512:  hpke.MLKEM512,
+		mlkem.MLKEM768:  hpke.MLKEM768,
+		mlkem.MLKEM1024: hpke.MLKEM1024,
+	}[p]
+	return hpke.Suite{KEM: k, KDF: hpke.HKDFSHA256, AEAD: hpke.AES256GCM}
+}
+
+// suiteHeader returns the header up to the AEAD identifier, the HPKE info.
+func suiteHeader(s hpke.Suite) []byte {
+	b := append([]byte(magic), version)
+	b = binary.BigEndian.AppendUint16(b, s.KEM.ID)
+	b = binary.BigEndian.AppendUint16(b, s.KDF.ID)
+	return binary.BigEndian.AppendUint16(b, s.AEAD.ID)
+}
+
+// encrypt encrypts r to ek and writes the result to w, one chunk at a
+// time.
+func encrypt(w io.Writer, ek *mlkem.EncapsulationKey, r io.Reader) error {
+	s := suiteFor(ek.Params())
+	info := suiteHeader(s)
+	enc, sender, err := hpke.NewSender(s, ek, info)
+	if err != nil {
+		return err
+	}
+	header := binary.BigEndian.AppendUint16(info, uint16(len(enc)))
+	if _, err := w.Write(append(header, enc...)); err != nil {
+		return err
+	}
+	buf := make([]byte, chunkSize)
+	for {
+		n, err := io.ReadFull(r, buf)
+		final := byte(0)
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			final = 1
+		} else if err != nil {
+			return err
+		}
+		ct, err := sender.Seal([]byte{final}, buf[:n])
+		if err != nil {
+			return err
+		}
+		record := binary.BigEndian.AppendUint32([]byte{final}, uint32(len(ct)))
+		if _, err := w.Write(append(record, ct...)); err != nil {
+			return err
+		}
+		if final == 1 {
+			return nil
+		}
+	}
+}
+
+// decrypt decrypts r with dk and writes the plaintext to w as the records
+// authenticate. On error, w may have received a prefix of the plaintext;
+// the caller must discard it.
+func decrypt(w io.Writer, dk *mlkem.DecapsulationKey, r io.Reader) error {
+	br := bufio.NewReader(r)
+	s := suiteFor(dk.Params())
+	info := suiteHeader(s)
+	header := make([]byte, len(info)+2)
+	if _, err := io.ReadFull(br, header); err != nil {
+		return errFormat
+	}
+	if string(header[:len(magic)]) != magic || header[len(magic)] != version {
+		return errFormat
+	}
+	if string(header[:len(info)]) != string(info) {
+		return fmt.Errorf("latcrypt: file is not encrypted to a %v key", dk.Params())
+	}
+	enc := make([]byte, binary.BigEndian.Uint16(header[len(info):]))
+	if _, err := io.ReadFull(br, enc); err != nil {
+		return errFormat
+	}
+	hdk, err := s.KEM.KEM.NewDecapsulationKey(dk.Bytes())
+	if err != nil {
+		return err
+	}
+	recipient, err := hpke.NewRecipient(s, hdk, enc, info)
+	if err != nil {
+		return err
+	}
+	ct := make([]byte, chunkSize+tagSize)
+	for {
+		var rec [5]byte
+		if _, err := io.ReadFull(br, rec[:]); err != nil {
+			return errFormat
+		}
+		final, n := rec[0], binary.BigEndian.Uint32(rec[1:])
+		if final > 1 || int(n) > len(ct) {
+			return errFormat
+		}
+		if _, err := io.ReadFull(br, ct[:n]); err != nil {
+			return errFormat
+		}
+		pt, err := recipient.Open([]byte{final}, ct[:n])
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(pt); err != nil {
+			return err
+		}
+		if final == 1 {
+			break
+		}
+	}
+	if _, err := br.ReadByte(); err != io.EOF {
+		return errors.New("latcrypt: data after the final record")
+	}
+	return nil
+}
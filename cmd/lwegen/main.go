@@ -0,0 +1,152 @@
+// Command lwegen generates and verifies LWE, Ring-LWE, Module-LWE and SIS
+// challenges in the format documented in package lwe.
+//
+// The gen subcommand writes the public challenge and, next to it, the
+// hidden solution with the suffix .solution.json. The verify subcommand
+// checks a candidate secret (LWE kinds) or short vector (SIS) against a
+// challenge.
+//
+// Usage:
+//
+//	lwegen gen -kind lwe -n 40 -m 80 -q 1601 -sigma 3 -seed demo -out chal.json
+//	lwegen gen -kind module-lwe -d 64 -k 2 -m 2 -q 3329 -sigma 1 -seed demo -out mlwe.json
+//	lwegen gen -kind sis -n 20 -m 60 -q 257 -sigma 2 -seed demo -out sis.json
+//	lwegen verify -challenge chal.json -solution chal.solution.json
+//
+// For plain LWE -n is the secret dimension; Module-LWE uses the rank -k
+// instead, and both ring kinds the degree -d. In every LWE kind -m is the
+// number of samples. For SIS, -n is the number of rows of A and -m the
+// length of z.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/haopining/Learn-Lattice-Based-Cryptography/lwe"
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("lwegen: ")
+	if len(os.Args) < 2 {
+		usage()
+	}
+	switch os.Args[1] {
+	case "gen":
+		gen(os.Args[2:])
+	case "verify":
+		verify(os.Args[2:])
+	default:
+		usage()
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: lwegen gen [flags] | lwegen verify -challenge FILE -solution FILE")
+	os.Exit(2)
+}
+
+func gen(args []string) {
+	fs := flag.NewFlagSet("gen", flag.ExitOnError)
+	kind := fs.String("kind", "lwe", "problem: lwe, ring-lwe, module-lwe or sis")
+	n := fs.Int("n", 32, "secret dimension (lwe) or rows of A (sis)")
+	k := fs.Int("k", 2, "module rank (module-lwe)")
+	d := fs.Int("d", 64, "ring degree (ring-lwe, module-lwe)")
+	m := fs.Int("m", 0, "samples (LWE kinds) or length of z (sis); default 2n for lwe, the rank for ring-lwe and module-lwe, 4n for sis")
+	q := fs.Int64("q", 3329, "modulus")
+	sigma := fs.Float64("sigma", 2, "width of the rounded Gaussian secret and error")
+	bound := fs.Float64("bound", 0, "accepted norm of e or z; default about twice the expected norm")
+	seed := fs.String("seed", "", "seed; the same seed gives the same challenge")
+	out := fs.String("out", "challenge.json", "output file")
+	fs.Parse(args)
+
+	p := lwe.Params{Kind: lwe.Kind(*kind), Q: *q, Sigma: *sigma, Bound: *bound, Seed: *seed, Samples: *m}
+	switch p.Kind {
+	case lwe.LWE:
+		p.Rank = *n
+		if p.Samples == 0 {
+			p.Samples = 2 * *n
+		}
+	case lwe.RingLWE, lwe.ModuleLWE:
+		p.Degree, p.Rank = *d, *k
+		if p.Kind == lwe.RingLWE {
+			p.Rank = 1
+		}
+		if p.Samples == 0 {
+			p.Samples = p.Rank
+		}
+	case lwe.SIS:
+		p.Samples, p.Rank = *n, *m
+		if p.Rank == 0 {
+			p.Rank = 4 * *n
+		}
+	}
+	inst, sol, err := lwe.Generate(p)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := writeFile(*out, inst); err != nil {
+		log.Fatal(err)
+	}
+	solName := strings.TrimSuffix(*out, ".json") + ".solution.json"
+	if err := writeFile(solName, sol); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("wrote %s (%s, d=%d, rank=%d, samples=%d, q=%d, bound=%g) and %s\n",
+		*out, inst.Kind, inst.Degree, inst.Rank, inst.Samples, inst.Q, inst.Bound, solName)
+}
+
+func verify(args []string) {
+	fs := flag.NewFlagSet("verify", flag.ExitOnError)
+	chal := fs.String("challenge", "challenge.json", "challenge file")
+	solFile := fs.String("solution", "", "candidate solution file")
+	fs.Parse(args)
+	if *solFile == "" {
+		usage()
+	}
+
+	f, err := os.Open(*chal)
+	if err != nil {
+		log.Fatal(err)
+	}
+	inst, err := lwe.ReadInstance(f)
+	f.Close()
+	if err != nil {
+		log.Fatalf("%s: %v", *chal, err)
+	}
+	f, err = os.Open(*solFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	sol, err := lwe.ReadSolution(f)
+	f.Close()
+	if err != nil {
+		log.Fatalf("%s: %v", *solFile, err)
+	}
+	norm, err := lwe.Verify(inst, sol)
+	if errors.Is(err, lwe.ErrRejected) {
+		fmt.Println("REJECTED:", err)
+		os.Exit(1)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("OK: norm %.2f <= bound %g\n", norm, inst.Bound)
+}
+
+func writeFile(name string, v any) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if err := lwe.WriteJSON(f, v); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,182 @@
+// Command sca runs the power analysis attacks of package sca on simulated
+// traces of ML-KEM: correlation power analysis of the base
+// multiplications of decryption, which recovers s-hat; a template attack
+// on the first layer of the NTT of key generation, which recovers the
+// secret s from a single trace; and a template attack on the message
+// decoding of decapsulation, which recovers the shared key from a single
+// trace.
+//
+// Usage:
+//
+//	sca -attack cpa -traces 100 -sigma 1
+//	sca -model hd -sigma 2 -profile 5000
+//	sca -attack template -sigma 3
+//
+// Raising -sigma shows how noise raises the number of traces the attacks
+// need, or lowers their single-trace success rate.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"log"
+	"math/bits"
+	"time"
+
+	"github.com/haopining/Learn-Lattice-Based-Cryptography/gauss"
+	"github.com/haopining/Learn-Lattice-Based-Cryptography/mlkem"
+	"github.com/haopining/Learn-Lattice-Based-Cryptography/sca"
+)
+
+func main() {
+	attack := flag.String("attack", "all", "cpa, template, message or all")
+	model := flag.String("model", "hw", "leakage model, hw (Hamming weight) or hd (Hamming distance)")
+	sigma := flag.Float64("sigma", 1, "standard deviation of the Gaussian noise")
+	level := flag.Int("params", 768, "ML-KEM parameter set: 512, 768 or 1024")
+	traces := flag.Int("traces", 50, "decryption traces for CPA")
+	profile := flag.Int("profile", 2000, "profiling traces for the template attacks")
+	targets := flag.Int("n", 20, "attacked traces for the template attacks")
+	seed := flag.String("seed", "", "seed for keys, inputs and noise (default: crypto/rand)")
+	flag.Parse()
+	log.SetFlags(0)
+	log.SetPrefix("sca: ")
+
+	r := gauss.NewRand(nil)
+	if *seed != "" {
+		r = gauss.NewSHAKE([]byte(*seed))
+	}
+	var m sca.Model
+	switch *model {
+	case "hw":
+		m = sca.HammingWeight
+	case "hd":
+		m = sca.HammingDistance
+	default:
+		log.Fatalf("unknown model %q", *model)
+	}
+	var params *mlkem.Params
+	switch *level {
+	case 512:
+		params = mlkem.MLKEM512
+	case 768:
+		params = mlkem.MLKEM768
+	case 1024:
+		params = mlkem.MLKEM1024
+	default:
+		log.Fatalf("unknown parameter set %d", *level)
+	}
+	fmt.Printf("%v, %v model, noise sigma %g\n", params, m, *sigma)
+	probe := sca.NewProbe(m, *sigma, r)
+
+	dk, err := mlkem.NewDecapsulationKey(params, bytesFrom(r, mlkem.SeedSize))
+	if err != nil {
+		log.Fatal(err)
+	}
+	dev, err := sca.NewDevice(dk)
+	if err != nil {
+		log.Fatal(err)
+	}
+	ran := false
+	if *attack == "all" || *attack == "cpa" {
+		ran = true
+		start := time.Now()
+		c, err := sca.Collect(dev, probe, *traces, r)
+		if err != nil {
+			log.Fatal(err)
+		}
+		guess := c.CPA()
+		secret := dev.Secret()
+		n := sca.Correct(guess, secret)
+		fmt.Printf("cpa:      %d traces, %d/%d coefficients of s-hat recovered", *traces, n, 256*params.K)
+		if n == 256*params.K {
+			s0 := mlkem.InvNTT(guess[0])
+			fmt.Printf(", key recovered; s_0 starts %v", centered(s0[:8]))
+		}
+		fmt.Printf("  [%v]\n", time.Since(start).Round(time.Millisecond))
+	}
+	if *attack == "all" || *attack == "template" {
+		ran = true
+		start := time.Now()
+		t, err := sca.ProfileNTT(probe, params.Eta1, *profile, r)
+		if err != nil {
+			log.Fatal(err)
+		}
+		coeffs, polys := 0, 0
+		for range *targets {
+			s := sca.SampleSecret(params.Eta1, r)
+			trace := probe.Record(func() { sca.NTT(probe, s) })
+			got := sca.AttackNTT(t, params.Eta1, trace)
+			n := sca.Correct([]mlkem.Poly{got}, []mlkem.Poly{s})
+			coeffs += n
+			if n == mlkem.N {
+				polys++
+			}
+		}
+		fmt.Printf("template: %d profiling traces, single-trace attack on %d NTTs of secrets: %.1f%% of coefficients, %d/%d secrets recovered  [%v]\n",
+			*profile, *targets, 100*float64(coeffs)/float64(mlkem.N**targets), polys, *targets, time.Since(start).Round(time.Millisecond))
+	}
+	if *attack == "all" || *attack == "message" {
+		ran = true
+		start := time.Now()
+		// The attacker profiles a device with a key of their own.
+		own, err := mlkem.NewDecapsulationKey(params, bytesFrom(r, mlkem.SeedSize))
+		if err != nil {
+			log.Fatal(err)
+		}
+		clone, err := sca.NewDevice(own)
+		if err != nil {
+			log.Fatal(err)
+		}
+		mt, err := sca.ProfileMessage(clone, own.EncapsulationKey(), probe, *profile/10, r)
+		if err != nil {
+			log.Fatal(err)
+		}
+		ek := dk.EncapsulationKey()
+		keys, bitErrors := 0, 0
+		for range *targets {
+			want, c := ek.Encapsulate()
+			trace, err := dev.Record(probe, c)
+			if err != nil {
+				log.Fatal(err)
+			}
+			off, _ := probe.Offset("decode")
+			key, msg, ok := mt.RecoverSharedKey(ek, c, trace, off)
+			plain, err := dev.Decrypt(nil, c)
+			if err != nil {
+				log.Fatal(err)
+			}
+			for i := range msg {
+				bitErrors += bits.OnesCount8(msg[i] ^ plain[i])
+			}
+			if ok && bytes.Equal(key, want) {
+				keys++
+			}
+		}
+		fmt.Printf("message:  %d profiling traces, single-trace attack on %d decapsulations: %d bit errors, %d/%d shared keys recovered  [%v]\n",
+			*profile/10, *targets, bitErrors, keys, *targets, time.Since(start).Round(time.Millisecond))
+	}
+	if !ran {
+		log.Fatalf("unknown attack %q", *attack)
+	}
+}
+
+func bytesFrom(r *gauss.Rand, n int) []byte {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = byte(r.Uint64())
+	}
+	return b
+}
+
+// centered returns the coefficients in (-q/2, q/2].
+func centered(f []uint16) []int {
+	c := make([]int, len(f))
+	for i, x := range f {
+		c[i] = int(x)
+		if c[i] > mlkem.Q/2 {
+			c[i] -= mlkem.Q
+		}
+	}
+	return c
+}
@@ -0,0 +1,760 @@
+package ctcheck
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"slices"
+	"strings"
+)
+
+// A Kind is a kind of finding.
+type Kind string
+
+const (
+	// Branch is a conditional branch on a secret: an if, for or switch
+	// condition, a range over a secret count, or a short-circuit && or ||
+	// whose left operand is secret.
+	Branch Kind = "branch"
+	// Index is a memory access at a secret index or slice bound.
+	Index Kind = "index"
+	// Division is an integer division or remainder of secret operands by
+	// a divisor that is not a constant. The gc compiler turns division by
+	// a constant into a multiplication, but a DIV instruction takes a time
+	// that depends on its operands on most CPUs.
+	Division Kind = "division"
+)
+
+// A Finding is a secret-dependent operation.
+type Finding struct {
+	Pos  token.Position
+	Kind Kind
+	// Func is the function of the finding, Expr the secret expression.
+	Func, Expr string
+	// Path lists the callers through which the secret reached Func, most
+	// recent first, if it came in through the parameters.
+	Path []string
+}
+
+func (f Finding) String() string {
+	s := fmt.Sprintf("%v: %s on secret %s in %s", f.Pos, f.Kind, f.Expr, f.Func)
+	if len(f.Path) > 0 {
+		s += " (reached from " + strings.Join(f.Path, " <- ") + ")"
+	}
+	return s
+}
+
+// Labels are bit sets: bit 0 stands for a secret from an annotation, and
+// bit k+1 for the k-th parameter of the function being analyzed, the
+// receiver of a method counting as parameter 0. Summaries of functions
+// are in terms of these labels, which makes them independent of the
+// callers; a parameter is secret if some caller passes a secret to it.
+type labels uint64
+
+const secret labels = 1
+
+func paramBit(k int) labels {
+	if k > 62 {
+		k = 62
+	}
+	return 1 << (k + 1)
+}
+
+// A summary is what a call to a function does to labels: which labels
+// reach its results, and which reach the memory behind each pointer,
+// slice or map parameter.
+type summary struct {
+	ret []labels
+	out []labels
+}
+
+type function struct {
+	decl    *ast.FuncDecl
+	pkg     *Package
+	obj     *types.Func
+	params  []*types.Var
+	secrets labels          // annotated secret parameters
+	public  map[string]bool // declassified names
+	sum     summary
+	tainted labels // parameters to which some caller passes a secret
+	via     *function
+}
+
+func (f *function) name() string {
+	name := f.obj.Name()
+	if recv := f.obj.Type().(*types.Signature).Recv(); recv != nil {
+		t := recv.Type()
+		if p, ok := t.(*types.Pointer); ok {
+			t = p.Elem()
+		}
+		if n, ok := t.(*types.Named); ok {
+			name = n.Obj().Name() + "." + name
+		}
+	}
+	return f.obj.Pkg().Name() + "." + name
+}
+
+type checker struct {
+	prog    *Program
+	funcs   map[*types.Func]*function
+	fields  map[*types.Var]bool
+	changed bool
+}
+
+// Check runs the analysis on every function of the program and returns
+// the findings in source order. Secrets come from the //ct:secret
+// annotations of the package documentation.
+func Check(prog *Program) []Finding {
+	c := &checker{prog: prog, funcs: map[*types.Func]*function{}, fields: map[*types.Var]bool{}}
+	c.collect()
+	all := make([]*function, 0, len(c.funcs))
+	for _, f := range c.funcs {
+		all = append(all, f)
+	}
+	slices.SortFunc(all, func(a, b *function) int { return int(a.decl.Pos() - b.decl.Pos()) })
+
+	// Summaries, to a fixed point over recursion.
+	for c.changed = true; c.changed; {
+		c.changed = false
+		for _, f := range all {
+			l := c.analyze(f)
+			sum := summary{ret: l.ret, out: make([]labels, len(f.params))}
+			for k, p := range f.params {
+				if mutable(p.Type()) {
+					sum.out[k] = l.whole[p] &^ f.initial(k)
+				}
+			}
+			for i := range sum.ret {
+				sum.ret[i] |= f.sum.ret[i]
+			}
+			for k := range sum.out {
+				sum.out[k] |= f.sum.out[k]
+			}
+			if !slices.Equal(sum.ret, f.sum.ret) || !slices.Equal(sum.out, f.sum.out) {
+				f.sum = sum
+				c.changed = true
+			}
+		}
+	}
+
+	// Secret parameters, to a fixed point over the call graph.
+	for c.changed = true; c.changed; {
+		c.changed = false
+		for _, f := range all {
+			l := c.analyze(f)
+			l.calls(func(callee *function, args []ast.Expr) {
+				for k, a := range args {
+					if l.secret(a) && callee.tainted&paramBit(min(k, len(callee.params)-1)) == 0 {
+						callee.tainted |= paramBit(min(k, len(callee.params)-1))
+						if callee.via == nil && callee != f {
+							callee.via = f
+						}
+						c.changed = true
+					}
+				}
+			})
+		}
+	}
+
+	var findings []Finding
+	seen := map[token.Pos]bool{}
+	for _, f := range all {
+		l := c.analyze(f)
+		l.report(func(pos token.Pos, kind Kind, e ast.Expr) {
+			if seen[pos] {
+				return
+			}
+			seen[pos] = true
+			fd := Finding{Pos: prog.Fset.Position(pos), Kind: kind, Func: f.name(), Expr: types.ExprString(e)}
+			if l.expr(e)&secret == 0 {
+				for g := f.via; g != nil && len(fd.Path) < 8; g = g.via {
+					fd.Path = append(fd.Path, g.name())
+					if g.tainted == 0 {
+						break
+					}
+				}
+			}
+			findings = append(findings, fd)
+		})
+	}
+	slices.SortFunc(findings, func(a, b Finding) int {
+		if a.Pos.Filename != b.Pos.Filename {
+			return strings.Compare(a.Pos.Filename, b.Pos.Filename)
+		}
+		return a.Pos.Offset - b.Pos.Offset
+	})
+	return findings
+}
+
+// collect finds the functions and the annotations.
+func (c *checker) collect() {
+	for _, p := range c.prog.Packages {
+		for _, file := range p.Files {
+			for _, d := range file.Decls {
+				switch d := d.(type) {
+				case *ast.FuncDecl:
+					if d.Body == nil {
+						continue
+					}
+					obj := p.Info.Defs[d.Name].(*types.Func)
+					f := &function{decl: d, pkg: p, obj: obj, public: map[string]bool{}}
+					sig := obj.Type().(*types.Signature)
+					f.sum.ret = make([]labels, sig.Results().Len())
+					if sig.Recv() != nil {
+						f.params = append(f.params, sig.Recv())
+					}
+					for i := range sig.Params().Len() {
+						f.params = append(f.params, sig.Params().At(i))
+					}
+					for _, name := range directive(d.Doc, "ct:secret") {
+						for k, v := range f.params {
+							if v.Name() == name {
+								f.secrets |= paramBit(k)
+							}
+						}
+					}
+					for _, name := range directive(d.Doc, "ct:public") {
+						f.public[name] = true
+					}
+					f.sum.out = make([]labels, len(f.params))
+					c.funcs[obj] = f
+				case *ast.GenDecl:
+					ast.Inspect(d, func(n ast.Node) bool {
+						field, ok := n.(*ast.Field)
+						if !ok {
+							return true
+						}
+						if directive(field.Doc, "ct:secret") != nil || directive(field.Comment, "ct:secret") != nil {
+							for _, name := range field.Names {
+								c.fields[p.Info.Defs[name].(*types.Var)] = true
+							}
+						}
+						return true
+					})
+				}
+			}
+		}
+	}
+}
+
+// directive returns the words after //name in the comment group, and a
+// non-nil empty slice for a bare //name.
+func directive(g *ast.CommentGroup, name string) []string {
+	if g == nil {
+		return nil
+	}
+	var words []string
+	for _, cm := range g.List {
+		if rest, ok := strings.CutPrefix(cm.Text, "//"+name); ok && (rest == "" || rest[0] == ' ') {
+			words = append(words, strings.Fields(rest)...)
+			if words == nil {
+				words = []string{}
+			}
+		}
+	}
+	return words
+}
+
+// mutable reports whether a callee can write through a value of type t
+// into memory the caller sees.
+func mutable(t types.Type) bool {
+	switch t.Underlying().(type) {
+	case *types.Pointer, *types.Slice, *types.Map, *types.Interface, *types.Chan:
+		return true
+	}
+	return false
+}
+
+// local is the analysis of one function body: the labels stored in its
+// variables, computed to a fixed point over its loops. A variable is
+// split by its fields, so that a secret stored in dk.seed does not make
+// dk.ek secret; the elements of an array or slice are not split.
+type local struct {
+	c     *checker
+	f     *function
+	info  *types.Info
+	slots map[slot]labels
+	whole map[types.Object]labels // union of the slots of a variable
+	ret   []labels
+	grew  bool
+}
+
+// A slot is a variable, or a field of it: the first field selected from
+// the variable, as ek in dk.ek.p or s in dk.s[i].
+type slot struct {
+	obj   types.Object
+	field *types.Var
+}
+
+func (c *checker) analyze(f *function) *local {
+	l := &local{c: c, f: f, info: f.pkg.Info, slots: map[slot]labels{}, whole: map[types.Object]labels{}}
+	for k, p := range f.params {
+		l.slots[slot{p, nil}] = f.initial(k)
+		l.whole[p] = f.initial(k)
+	}
+	res := f.obj.Type().(*types.Signature).Results()
+	for l.grew = true; l.grew; {
+		l.grew = false
+		l.ret = make([]labels, res.Len())
+		ast.Inspect(f.decl.Body, l.propagate)
+	}
+	for i := range res.Len() {
+		l.ret[i] |= l.whole[res.At(i)]
+	}
+	return l
+}
+
+// initial returns the labels of the k-th parameter on entry.
+func (f *function) initial(k int) labels {
+	if f.secrets&paramBit(k) != 0 {
+		return paramBit(k) | secret
+	}
+	return paramBit(k)
+}
+
+// mask is the set of labels that are secret in this function.
+func (l *local) mask() labels { return secret | l.f.tainted }
+
+func (l *local) secret(e ast.Expr) bool { return l.expr(e)&l.mask() != 0 }
+
+func (l *local) object(id *ast.Ident) types.Object {
+	if obj := l.info.Uses[id]; obj != nil {
+		return obj
+	}
+	return l.info.Defs[id]
+}
+
+// path returns the slot that e reads or writes, as the slot of dk and s
+// for dk.s[i], *dk.s or dk.s[i:j], and the labels of the indices on the
+// way. It returns a nil object if e is not stored in a variable.
+func (l *local) path(e ast.Expr) (s slot, idx labels) {
+	switch e := e.(type) {
+	case *ast.Ident:
+		if obj, ok := l.object(e).(*types.Var); ok {
+			s.obj = obj
+		}
+	case *ast.ParenExpr:
+		return l.path(e.X)
+	case *ast.SelectorExpr:
+		if sel := l.info.Selections[e]; sel != nil && sel.Kind() == types.FieldVal {
+			s, idx = l.path(e.X)
+			if s.obj != nil && s.field == nil {
+				s.field = sel.Obj().(*types.Var)
+			}
+		}
+	case *ast.IndexExpr:
+		s, idx = l.path(e.X)
+		idx |= l.expr(e.Index)
+	case *ast.SliceExpr:
+		s, idx = l.path(e.X)
+		idx |= l.expr(e.Low) | l.expr(e.High) | l.expr(e.Max)
+	case *ast.StarExpr:
+		return l.path(e.X)
+	case *ast.UnaryExpr:
+		if e.Op == token.AND {
+			return l.path(e.X)
+		}
+	}
+	return s, idx
+}
+
+func (l *local) load(s slot) labels {
+	if s.field == nil {
+		return l.whole[s.obj]
+	}
+	return l.slots[slot{s.obj, nil}] | l.slots[s]
+}
+
+func (l *local) taint(e ast.Expr, ls labels) {
+	s, _ := l.path(e)
+	if s.obj == nil || ls == 0 || l.f.public[s.obj.Name()] {
+		return
+	}
+	if s.field != nil && l.c.fields[s.field.Origin()] {
+		return // secret anyway
+	}
+	if l.slots[s]|ls != l.slots[s] {
+		l.slots[s] |= ls
+		l.whole[s.obj] |= ls
+		l.grew = true
+	}
+}
+
+// expr returns the labels of the value of e, applying the effects of the
+// calls in it.
+func (l *local) expr(e ast.Expr) labels {
+	switch e := e.(type) {
+	case *ast.Ident:
+		return l.whole[l.object(e)]
+	case *ast.ParenExpr:
+		return l.expr(e.X)
+	case *ast.CompositeLit:
+		var ls labels
+		for _, elt := range e.Elts {
+			ls |= l.expr(elt)
+		}
+		return ls
+	case *ast.KeyValueExpr:
+		return l.expr(e.Key) | l.expr(e.Value)
+	case *ast.SelectorExpr:
+		sel := l.info.Selections[e]
+		if sel == nil {
+			return l.expr(e.Sel)
+		}
+		var ls labels
+		if s, idx := l.path(e); s.obj != nil {
+			ls = l.load(s) | idx
+		} else {
+			ls = l.expr(e.X)
+		}
+		if v, ok := sel.Obj().(*types.Var); ok && l.c.fields[v.Origin()] {
+			ls |= secret
+		}
+		return ls
+	case *ast.IndexExpr:
+		if tv, ok := l.info.Types[e.Index]; ok && tv.IsType() {
+			return 0 // instantiation
+		}
+		return l.expr(e.X) | l.expr(e.Index)
+	case *ast.SliceExpr:
+		return l.expr(e.X) | l.expr(e.Low) | l.expr(e.High) | l.expr(e.Max)
+	case *ast.StarExpr:
+		return l.expr(e.X)
+	case *ast.UnaryExpr:
+		return l.expr(e.X)
+	case *ast.BinaryExpr:
+		return l.expr(e.X) | l.expr(e.Y)
+	case *ast.TypeAssertExpr:
+		return l.expr(e.X)
+	case *ast.CallExpr:
+		var ls labels
+		for _, r := range l.call(e) {
+			ls |= r
+		}
+		return ls
+	}
+	return 0
+}
+
+// callee returns the function a call statically calls, if it is one of
+// the analyzed functions, and the arguments in the order of its params.
+func (l *local) callee(call *ast.CallExpr) (*function, []ast.Expr) {
+	fun := ast.Unparen(call.Fun)
+	switch f := fun.(type) {
+	case *ast.IndexExpr:
+		fun = f.X
+	case *ast.IndexListExpr:
+		fun = f.X
+	}
+	var obj types.Object
+	var recv ast.Expr
+	switch f := fun.(type) {
+	case *ast.Ident:
+		obj = l.info.Uses[f]
+	case *ast.SelectorExpr:
+		if sel := l.info.Selections[f]; sel != nil {
+			if sel.Kind() != types.MethodVal {
+				return nil, nil
+			}
+			obj, recv = sel.Obj(), f.X
+		} else {
+			obj = l.info.Uses[f.Sel]
+		}
+	}
+	fn, ok := obj.(*types.Func)
+	if !ok {
+		return nil, nil
+	}
+	target := l.c.funcs[fn.Origin()]
+	if target == nil {
+		return nil, nil
+	}
+	args := call.Args
+	if recv != nil {
+		args = append([]ast.Expr{recv}, args...)
+	}
+	return target, args
+}
+
+// call returns the labels of the results of a call.
+func (l *local) call(call *ast.CallExpr) []labels {
+	if tv, ok := l.info.Types[call.Fun]; ok && tv.IsType() {
+		return []labels{l.expr(call.Args[0])} // conversion
+	}
+	if id, ok := ast.Unparen(call.Fun).(*ast.Ident); ok {
+		if b, ok := l.info.Uses[id].(*types.Builtin); ok {
+			var ls labels
+			for _, a := range call.Args {
+				ls |= l.expr(a)
+			}
+			switch b.Name() {
+			case "len", "cap", "new", "make":
+				return []labels{0}
+			case "copy":
+				l.taint(call.Args[0], l.expr(call.Args[1]))
+				return []labels{0}
+			}
+			return []labels{ls}
+		}
+	}
+	if f, args := l.callee(call); f != nil {
+		argLabels := make([]labels, len(args))
+		for k, a := range args {
+			argLabels[k] = l.expr(a)
+		}
+		// translate turns labels of the callee into labels of the caller.
+		translate := func(ls labels) labels {
+			out := ls & secret
+			for k, al := range argLabels {
+				if ls&paramBit(min(k, len(f.params)-1)) != 0 {
+					out |= al
+				}
+			}
+			return out
+		}
+		for k, a := range args {
+			if k < len(f.sum.out) {
+				l.taint(a, translate(f.sum.out[min(k, len(f.params)-1)]))
+			}
+		}
+		rs := make([]labels, len(f.sum.ret))
+		for i, r := range f.sum.ret {
+			rs[i] = translate(r)
+		}
+		return rs
+	}
+	// An unknown function: a function value, an interface method or a
+	// function outside the module. Its results depend on all its operands,
+	// except for errors. A method may absorb its operands into its
+	// receiver, as a hash does, and the functions and methods of writers
+	// may store them into their mutable arguments, as Read squeezes a hash
+	// into a buffer.
+	var ls labels
+	var recv ast.Expr
+	name := ""
+	switch f := ast.Unparen(call.Fun).(type) {
+	case *ast.SelectorExpr:
+		name = f.Sel.Name
+		if l.info.Selections[f] != nil {
+			recv = f.X
+			ls |= l.expr(f.X)
+		}
+	case *ast.Ident:
+		name = f.Name
+	}
+	if recv == nil {
+		ls |= l.expr(call.Fun)
+	}
+	for _, a := range call.Args {
+		ls |= l.expr(a)
+	}
+	if recv != nil {
+		l.taint(recv, ls)
+	}
+	if writers[name] {
+		for _, a := range call.Args {
+			if tv, ok := l.info.Types[a]; ok && mutable(tv.Type) {
+				l.taint(a, ls)
+			}
+		}
+	}
+	var results []types.Type
+	switch t := l.info.Types[call].Type.(type) {
+	case *types.Tuple:
+		for i := range t.Len() {
+			results = append(results, t.At(i).Type())
+		}
+	default:
+		results = append(results, t)
+	}
+	rs := make([]labels, len(results))
+	for i, t := range results {
+		if !types.Identical(t, errorType) {
+			rs[i] = ls
+		}
+	}
+	return rs
+}
+
+var errorType = types.Universe.Lookup("error").Type()
+
+// writers are the names of the functions and methods outside the module
+// that write into their arguments.
+var writers = map[string]bool{
+	"Read":             true,
+	"ReadFull":         true,
+	"ReadAt":           true,
+	"XORKeyStream":     true,
+	"XORBytes":         true,
+	"ConstantTimeCopy": true,
+	"Encrypt":          true,
+	"Decrypt":          true,
+	"CryptBlocks":      true,
+}
+
+// propagate applies the assignments of n to the labels.
+func (l *local) propagate(n ast.Node) bool {
+	switch n := n.(type) {
+	case *ast.AssignStmt:
+		if len(n.Lhs) == len(n.Rhs) {
+			for i, lhs := range n.Lhs {
+				l.taint(lhs, l.expr(n.Rhs[i]))
+			}
+		} else {
+			for i, ls := range l.tuple(n.Rhs[0], len(n.Lhs)) {
+				l.taint(n.Lhs[i], ls)
+			}
+		}
+		for _, lhs := range n.Lhs {
+			l.expr(lhs)
+		}
+		return false
+	case *ast.ValueSpec:
+		if len(n.Values) == len(n.Names) {
+			for i, v := range n.Values {
+				l.taint(n.Names[i], l.expr(v))
+			}
+		} else if len(n.Values) == 1 {
+			for i, ls := range l.tuple(n.Values[0], len(n.Names)) {
+				l.taint(n.Names[i], ls)
+			}
+		}
+		return false
+	case *ast.RangeStmt:
+		ls := l.expr(n.X)
+		if n.Key != nil {
+			if tv, ok := l.info.Types[n.X]; ok {
+				switch tv.Type.Underlying().(type) {
+				case *types.Basic, *types.Map:
+					l.taint(n.Key, ls)
+				}
+			}
+		}
+		if n.Value != nil {
+			l.taint(n.Value, ls)
+		}
+	case *ast.ReturnStmt:
+		if len(n.Results) == len(l.ret) {
+			for i, r := range n.Results {
+				l.ret[i] |= l.expr(r)
+			}
+		} else if len(n.Results) == 1 {
+			for i, ls := range l.tuple(n.Results[0], len(l.ret)) {
+				l.ret[i] |= ls
+			}
+		}
+		return false
+	case *ast.ExprStmt, *ast.GoStmt, *ast.DeferStmt:
+		// Calls for their effects.
+		ast.Inspect(n, func(m ast.Node) bool {
+			if call, ok := m.(*ast.CallExpr); ok {
+				l.expr(call)
+				return false
+			}
+			return true
+		})
+		return false
+	}
+	return true
+}
+
+// tuple returns the labels of the n values of e, a call or a comma-ok
+// expression.
+func (l *local) tuple(e ast.Expr, n int) []labels {
+	if call, ok := ast.Unparen(e).(*ast.CallExpr); ok {
+		if rs := l.call(call); len(rs) == n {
+			return rs
+		}
+	}
+	ls := l.expr(e)
+	rs := make([]labels, n)
+	for i := range rs {
+		rs[i] = ls
+	}
+	return rs
+}
+
+// calls calls fn for every call to an analyzed function in the body.
+func (l *local) calls(fn func(callee *function, args []ast.Expr)) {
+	ast.Inspect(l.f.decl.Body, func(n ast.Node) bool {
+		if call, ok := n.(*ast.CallExpr); ok {
+			if f, args := l.callee(call); f != nil {
+				fn(f, args)
+			}
+		}
+		return true
+	})
+}
+
+// report calls fn for every secret-dependent operation of the body.
+func (l *local) report(fn func(pos token.Pos, kind Kind, e ast.Expr)) {
+	ast.Inspect(l.f.decl.Body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.IfStmt:
+			if l.secret(n.Cond) {
+				fn(n.Cond.Pos(), Branch, n.Cond)
+			}
+		case *ast.ForStmt:
+			if n.Cond != nil && l.secret(n.Cond) {
+				fn(n.Cond.Pos(), Branch, n.Cond)
+			}
+		case *ast.RangeStmt:
+			if tv, ok := l.info.Types[n.X]; ok && l.secret(n.X) {
+				if b, ok := tv.Type.Underlying().(*types.Basic); ok && b.Info()&types.IsInteger != 0 {
+					fn(n.X.Pos(), Branch, n.X)
+				}
+			}
+		case *ast.SwitchStmt:
+			if n.Tag != nil && l.secret(n.Tag) {
+				fn(n.Tag.Pos(), Branch, n.Tag)
+			}
+			for _, s := range n.Body.List {
+				for _, e := range s.(*ast.CaseClause).List {
+					if l.secret(e) {
+						fn(e.Pos(), Branch, e)
+					}
+				}
+			}
+		case *ast.BinaryExpr:
+			switch n.Op {
+			case token.LAND, token.LOR:
+				if l.secret(n.X) {
+					fn(n.X.Pos(), Branch, n.X)
+				}
+			case token.QUO, token.REM:
+				if l.divides(n.X, n.Y) {
+					fn(n.OpPos, Division, n)
+				}
+			}
+		case *ast.AssignStmt:
+			if (n.Tok == token.QUO_ASSIGN || n.Tok == token.REM_ASSIGN) && l.divides(n.Lhs[0], n.Rhs[0]) {
+				fn(n.TokPos, Division, n.Lhs[0])
+			}
+		case *ast.IndexExpr:
+			if tv, ok := l.info.Types[n.Index]; ok && !tv.IsType() && l.secret(n.Index) {
+				fn(n.Index.Pos(), Index, n.Index)
+			}
+		case *ast.SliceExpr:
+			for _, b := range []ast.Expr{n.Low, n.High, n.Max} {
+				if b != nil && l.secret(b) {
+					fn(b.Pos(), Index, b)
+				}
+			}
+		}
+		return true
+	})
+}
+
+// divides reports whether x / y is a variable-time integer division of
+// secret operands.
+func (l *local) divides(x, y ast.Expr) bool {
+	tv, ok := l.info.Types[y]
+	if !ok || tv.Value != nil {
+		return false
+	}
+	if b, ok := tv.Type.Underlying().(*types.Basic); !ok || b.Info()&types.IsInteger == 0 {
+		return false
+	}
+	return l.secret(x) || l.secret(y)
+}
@@ -0,0 +1,69 @@
+package ctcheck
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// wants returns the kinds of finding that the comments "// want kind" of
+// the file expect, by line.
+func wants(t *testing.T, name string) map[int]Kind {
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	want := map[int]Kind{}
+	sc := bufio.NewScanner(f)
+	for line := 1; sc.Scan(); line++ {
+		if _, kind, ok := strings.Cut(sc.Text(), "// want "); ok {
+			want[line] = Kind(kind)
+		}
+	}
+	return want
+}
+
+// TestFixture checks the findings on testdata/fixture against its
+// annotations: a secret branch, index and division each, the same on a
+// public operand or a constant divisor, a secret field reaching another
+// function, and a //ct:public variable.
+func TestFixture(t *testing.T) {
+	prog, err := Load("testdata/fixture")
+	if err != nil {
+		t.Fatal(err)
+	}
+	name, err := filepath.Abs("testdata/fixture/fixture.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := wants(t, name)
+	for _, f := range Check(prog) {
+		if f.Pos.Filename != name || want[f.Pos.Line] != f.Kind {
+			t.Errorf("unexpected finding %v", f)
+			continue
+		}
+		delete(want, f.Pos.Line)
+		if f.Func == "fixture.get" && !slices.Equal(f.Path, []string{"fixture.key.lookup"}) {
+			t.Errorf("finding in get reached from %v, want lookup", f.Path)
+		}
+	}
+	for line, kind := range want {
+		t.Errorf("%s:%d: missing %s finding", name, line, kind)
+	}
+}
+
+// TestMLKEM checks that package mlkem, the default target of cmd/ctcheck,
+// has no findings.
+func TestMLKEM(t *testing.T) {
+	prog, err := Load("../mlkem")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range Check(prog) {
+		t.Error(f)
+	}
+}
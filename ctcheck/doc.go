@@ -0,0 +1,67 @@
+// Package ctcheck is a static check for constant-time code: it finds the
+// conditional branches, memory indices and variable-time divisions that
+// depend on secrets, the leaks that the dudect package can only hope to
+// measure.
+//
+// Secrets are marked in the source with comment directives, which go doc
+// hides:
+//
+//	// DecapsulationKey is an ML-KEM private key, kept with its seed.
+//	type DecapsulationKey struct {
+//		seed [SeedSize]byte //ct:secret
+//		...
+//	}
+//
+//	// NewDecapsulationKey expands the 64-byte seed d || z into a key.
+//	//
+//	//ct:secret seed
+//	//ct:public rho ek
+//	func NewDecapsulationKey(p *Params, seed []byte) (*DecapsulationKey, error)
+//
+// "//ct:secret" on a struct field makes every value read from the field
+// secret; in the doc comment of a function, "//ct:secret a b" makes the
+// parameters a and b secret. "//ct:public x" declassifies the variables
+// named x in a function, for values that are computed from secrets but
+// are public by design: rho is hashed from the secret seed and published
+// in the encapsulation key, whose t = As + e is public as well.
+//
+// Secrets flow through assignments, operators, conversions, composite
+// literals, indexing and calls. The package needs nothing outside the
+// standard library: Load type-checks the packages of the module from
+// source with go/types, and Check walks their syntax trees. Each function
+// is summarized once, by which of its parameters reach its results and
+// the memory behind its pointer and slice parameters, so a call is
+// followed through the callee without analyzing it again for every
+// caller; a parameter is secret in the callee if any caller passes a
+// secret to it. Calls to functions outside the module are assumed to mix
+// all their operands into their results (except errors) and into their
+// receiver, and the functions of a short list, such as Read, to write
+// into their arguments.
+//
+// The findings, with the chain of callers through which the secret came
+// in, are:
+//
+//   - Branch: if, for and switch conditions, && and || (which branch on
+//     their left operand), and range over a secret count;
+//   - Index: a secret index or slice bound, which selects a cache line;
+//   - Division: / and % of secret operands by a divisor that is not a
+//     constant. The compiler turns division by a constant into a
+//     multiplication, as in barrett of package mlkem, but a DIV
+//     instruction takes a time that depends on its operands.
+//
+// The analysis is conservative in some ways and not in others. Elements of
+// arrays and slices are not told apart, and the fields of a variable only
+// at the first level, so a secret stored in one element makes all of them
+// secret. Calls through function values and interfaces are not followed,
+// and a closure is analyzed as part of the function that contains it.
+// Package-level variables carry no secrets. len and cap are public, which
+// is true of the code here but not of every slice built from a secret. A
+// clean report is therefore evidence, not proof, and it says nothing about
+// what the compiler makes of branch-free code.
+//
+// The ML-KEM code passes the check. The range checks of ByteEncode and
+// ByteDecode in package encoding are branches on their inputs, so package
+// mlkem encodes s-hat, messages and ciphertexts with its own unchecked
+// byteEncode and byteDecode, and leaves encoding.ByteDecode to the public
+// encapsulation key.
+package ctcheck
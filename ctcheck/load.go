@@ -0,0 +1,149 @@
+package ctcheck
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// A Package is a type-checked package of the module under analysis.
+type Package struct {
+	Path  string
+	Files []*ast.File
+	Types *types.Package
+	Info  *types.Info
+}
+
+// A Program is a set of packages of one module, loaded from source with
+// their dependencies inside the module; packages outside the module, such
+// as the standard library, come from export data and are not analyzed.
+type Program struct {
+	Fset     *token.FileSet
+	Packages map[string]*Package
+	module   string
+	root     string
+	std      types.Importer
+}
+
+// Load loads the packages in the given directories, which must belong to
+// one module, and the packages of the module they import.
+func Load(dirs ...string) (*Program, error) {
+	if len(dirs) == 0 {
+		return nil, errors.New("ctcheck: no packages")
+	}
+	abs, err := filepath.Abs(dirs[0])
+	if err != nil {
+		return nil, err
+	}
+	root, module, err := findModule(abs)
+	if err != nil {
+		return nil, err
+	}
+	fset := token.NewFileSet()
+	prog := &Program{
+		Fset:     fset,
+		Packages: map[string]*Package{},
+		module:   module,
+		root:     root,
+		std:      importer.ForCompiler(fset, "gc", nil),
+	}
+	for _, dir := range dirs {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return nil, err
+		}
+		rel, err := filepath.Rel(root, abs)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			return nil, fmt.Errorf("ctcheck: %s is outside module %s", dir, module)
+		}
+		path := module
+		if rel != "." {
+			path += "/" + filepath.ToSlash(rel)
+		}
+		if _, err := prog.load(path); err != nil {
+			return nil, err
+		}
+	}
+	return prog, nil
+}
+
+// findModule returns the directory of the go.mod above dir and its module
+// path.
+func findModule(dir string) (root, module string, err error) {
+	for d := dir; ; d = filepath.Dir(d) {
+		f, err := os.Open(filepath.Join(d, "go.mod"))
+		if err == nil {
+			defer f.Close()
+			s := bufio.NewScanner(f)
+			for s.Scan() {
+				if m, ok := strings.CutPrefix(strings.TrimSpace(s.Text()), "module "); ok {
+					return d, strings.Trim(strings.TrimSpace(m), `"`), nil
+				}
+			}
+			return "", "", fmt.Errorf("ctcheck: no module line in %s", f.Name())
+		}
+		if filepath.Dir(d) == d {
+			return "", "", fmt.Errorf("ctcheck: no go.mod above %s", dir)
+		}
+	}
+}
+
+// Import implements types.Importer for the packages being type-checked.
+func (prog *Program) Import(path string) (*types.Package, error) {
+	if path == prog.module || strings.HasPrefix(path, prog.module+"/") {
+		p, err := prog.load(path)
+		if err != nil {
+			return nil, err
+		}
+		return p.Types, nil
+	}
+	return prog.std.Import(path)
+}
+
+func (prog *Program) load(path string) (*Package, error) {
+	if p, ok := prog.Packages[path]; ok {
+		if p == nil {
+			return nil, fmt.Errorf("ctcheck: import cycle through %s", path)
+		}
+		return p, nil
+	}
+	prog.Packages[path] = nil
+	dir := filepath.Join(prog.root, filepath.FromSlash(strings.TrimPrefix(path, prog.module)))
+	bp, err := build.ImportDir(dir, 0)
+	if err != nil {
+		return nil, err
+	}
+	p := &Package{
+		Path: path,
+		Info: &types.Info{
+			Types:      map[ast.Expr]types.TypeAndValue{},
+			Defs:       map[*ast.Ident]types.Object{},
+			Uses:       map[*ast.Ident]types.Object{},
+			Selections: map[*ast.SelectorExpr]*types.Selection{},
+			Instances:  map[*ast.Ident]types.Instance{},
+		},
+	}
+	for _, name := range bp.GoFiles {
+		f, err := parser.ParseFile(prog.Fset, filepath.Join(dir, name), nil, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		p.Files = append(p.Files, f)
+	}
+	conf := types.Config{Importer: prog}
+	p.Types, err = conf.Check(path, prog.Fset, p.Files, p.Info)
+	if err != nil {
+		return nil, err
+	}
+	prog.Packages[path] = p
+	return p, nil
+}
@@ -0,0 +1,59 @@
+// Package fixture holds the annotated code of the ctcheck tests: each
+// line that the check must flag ends in a comment "want" and the kind of
+// the finding, and no other line may be flagged.
+package fixture
+
+type key struct {
+	s []int32 //ct:secret
+	n int
+}
+
+var table [256]byte
+
+//ct:secret x
+func branch(x int, n int) int {
+	if x > 0 { // want branch
+		return 1
+	}
+	for i := 0; i < x; i++ { // want branch
+	}
+	switch x { // want branch
+	case 1:
+		return 2
+	}
+	if n > 0 {
+		return 3
+	}
+	return x &^ (x >> 63)
+}
+
+//ct:secret x
+func index(x int, n int) byte {
+	return table[x&0xff] ^ table[n] // want index
+}
+
+//ct:secret x
+func division(x, d int) int {
+	return x/3 + x%d + d/7 // want division
+}
+
+// lookup indexes the table with a field of k, through get.
+func (k *key) lookup() byte {
+	return get(int(k.s[0]), k.n)
+}
+
+func get(v, n int) byte {
+	return table[v&0xff] + byte(n) // want index
+}
+
+// public declassifies h, which is computed from the secret seed.
+//
+//ct:secret seed
+//ct:public h
+func public(seed []byte) byte {
+	h := int(seed[0]) * 31
+	if h > 0 {
+		return table[h&0xff]
+	}
+	return 0
+}
@@ -0,0 +1,32 @@
+// Package dudect tests code for constant-time behaviour statistically,
+// after "dude, is my code constant time?" (Reparaz, Balasch and
+// Verbauwhede, 2017).
+//
+// The idea needs no model of the CPU. A target is run many times on inputs
+// of two classes, usually one fixed input (such as zero, or a valid
+// ciphertext) and fresh random inputs, interleaved at random. If the
+// running time does not depend on the input, the two timing distributions
+// are the same, and Welch's t-test on their means finds no difference; a
+// large |t| is evidence that the time depends on the data. The tutorial
+// code of python/karatsuba.py and python/toom3.py, which chooses its
+// algorithm with tests such as if x < 1000, is the kind of code this
+// catches when the branch depends on a secret.
+//
+// Run keeps the tests of dudect: the t-test on all measurements, on the
+// measurements below each of a range of percentiles (cropping the tail of
+// interrupts, which hides small effects), and a second-order test on the
+// squared deviations, for differences of variance. The largest |t| is the
+// result: below Evident = 4.5 nothing shows; above Certain = 10 the
+// target leaks.
+//
+// Targets are ready-made for the ML-KEM code: the NTT and its inverse,
+// basemul (MultiplyNTTs), CBD sampling and decapsulation, whose implicit
+// rejection must take the same time for valid and invalid ciphertexts.
+// Two controls check the harness itself: an early-exit comparison, which
+// must be reported, and subtle.ConstantTimeCompare, which must not.
+//
+// A statistical test shows leakage only if it is large enough for the
+// number of measurements, and only on the machine and compiler that ran
+// it; a pass is not a proof. Package ctcheck looks for the same leaks
+// statically, in the source.
+package dudect
@@ -0,0 +1,185 @@
+package dudect
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/haopining/Learn-Lattice-Based-Cryptography/gauss"
+)
+
+// A Target is code under test. Setup is called once per run and returns
+// the generator of inputs of a class, 0 for the fixed class and 1 for the
+// random class, and the function under test. Inputs are generated before
+// the measurements, so only run is timed.
+type Target struct {
+	Name  string
+	Doc   string
+	Setup func(r *gauss.Rand) (input func(class int) any, run func(in any))
+}
+
+// Thresholds on |t| of the dudect paper: below 4.5 no leakage is evident;
+// above 10 the timings certainly depend on the class.
+const (
+	Evident = 4.5
+	Certain = 10
+)
+
+// welch accumulates the mean and variance of the two classes with
+// Welford's online algorithm.
+type welch struct {
+	n, mean, m2 [2]float64
+}
+
+func (w *welch) push(x float64, class int) {
+	w.n[class]++
+	d := x - w.mean[class]
+	w.mean[class] += d / w.n[class]
+	w.m2[class] += d * (x - w.mean[class])
+}
+
+// t returns Welch's t statistic, (mean_0 - mean_1) divided by its
+// standard error.
+func (w *welch) t() float64 {
+	if w.n[0] < 2 || w.n[1] < 2 {
+		return 0
+	}
+	v0 := w.m2[0] / (w.n[0] - 1)
+	v1 := w.m2[1] / (w.n[1] - 1)
+	if v0+v1 == 0 {
+		return 0
+	}
+	return (w.mean[0] - w.mean[1]) / math.Sqrt(v0/w.n[0]+v1/w.n[1])
+}
+
+// Like dudect, each run keeps several tests: one on all measurements, one
+// per cropping threshold, on the measurements below that percentile,
+// which removes the long tail of interrupts and cache misses that hides
+// small differences, and a second-order test on the squared deviation from
+// the mean, which catches a difference of variance.
+const crops = 20
+
+// percentiles returns the cropping thresholds of dudect,
+// 1 - 0.5^(10 (i+1) / crops), as values of the sorted sample.
+func percentiles(sample []float64) []float64 {
+	s := slices.Clone(sample)
+	slices.Sort(s)
+	p := make([]float64, crops)
+	for i := range p {
+		q := 1 - math.Pow(0.5, 10*float64(i+1)/crops)
+		p[i] = s[int(q*float64(len(s)-1))]
+	}
+	return p
+}
+
+// A Result is the outcome of a run.
+type Result struct {
+	Target       string
+	Measurements int
+	// MaxT is the largest |t| over the tests, found by Test, a name such
+	// as "all", "crop 95.3%" or "second order".
+	MaxT float64
+	Test string
+	// Means are the mean times of the fixed and random classes in
+	// nanoseconds, over all measurements.
+	Means [2]float64
+}
+
+// Verdict reports the conclusion for MaxT.
+func (r *Result) Verdict() string {
+	switch {
+	case r.MaxT >= Certain:
+		return "LEAKS"
+	case r.MaxT >= Evident:
+		return "possible leakage"
+	}
+	return "no leakage evident"
+}
+
+func (r *Result) String() string {
+	return fmt.Sprintf("%-14s %9d measurements  fixed %8.0f ns  random %8.0f ns  max |t| %7.2f (%s)  %s",
+		r.Target, r.Measurements, r.Means[0], r.Means[1], r.MaxT, r.Test, r.Verdict())
+}
+
+// Options control a run.
+type Options struct {
+	// Measurements is the number of timed calls, and Batch the number of
+	// inputs prepared at a time. The first batch only warms up and sets
+	// the cropping thresholds.
+	Measurements, Batch int
+	// Duration, if positive, stops the run early when it is exceeded.
+	Duration time.Duration
+	// Progress, if not nil, is called after every batch.
+	Progress func(*Result)
+}
+
+// Run measures t. The class of each call is drawn at random, so drifts of
+// the machine affect both classes alike.
+func Run(t *Target, r *gauss.Rand, opts Options) *Result {
+	if opts.Batch <= 0 {
+		opts.Batch = 10000
+	}
+	input, run := t.Setup(r)
+	classes := make([]int, opts.Batch)
+	inputs := make([]any, opts.Batch)
+	times := make([]float64, opts.Batch)
+	var (
+		all, second welch
+		cropped     [crops]welch
+		thresholds  []float64
+		res         = &Result{Target: t.Name}
+		start       = time.Now()
+	)
+	for res.Measurements < opts.Measurements {
+		for i := range classes {
+			classes[i] = int(r.Bit())
+			inputs[i] = input(classes[i])
+		}
+		for i, in := range inputs {
+			t0 := time.Now()
+			run(in)
+			times[i] = float64(time.Since(t0))
+		}
+		if thresholds == nil {
+			thresholds = percentiles(times)
+			continue
+		}
+		for i, x := range times {
+			c := classes[i]
+			all.push(x, c)
+			for j, p := range thresholds {
+				if x < p {
+					cropped[j].push(x, c)
+				}
+			}
+		}
+		// The second-order test centers on the class means, once there
+		// are enough measurements to know them.
+		if all.n[0] > float64(opts.Batch) {
+			for i, x := range times {
+				d := x - all.mean[classes[i]]
+				second.push(d*d, classes[i])
+			}
+		}
+		res.Measurements += len(times)
+		res.Means = all.mean
+		res.MaxT, res.Test = math.Abs(all.t()), "all"
+		for j := range cropped {
+			if t := math.Abs(cropped[j].t()); t > res.MaxT {
+				q := 1 - math.Pow(0.5, 10*float64(j+1)/crops)
+				res.MaxT, res.Test = t, fmt.Sprintf("crop %.1f%%", 100*q)
+			}
+		}
+		if t := math.Abs(second.t()); t > res.MaxT {
+			res.MaxT, res.Test = t, "second order"
+		}
+		if opts.Progress != nil {
+			opts.Progress(res)
+		}
+		if opts.Duration > 0 && time.Since(start) > opts.Duration {
+			break
+		}
+	}
+	return res
+}
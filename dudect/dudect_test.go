@@ -0,0 +1,108 @@
+package dudect
+
+import (
+	"math"
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/haopining/Learn-Lattice-Based-Cryptography/gauss"
+)
+
+// TestWelch checks t against a value computed by hand: the classes
+// {1, 2, 3, 4} and {2, 4, 6, 8} have means 2.5 and 5 and variances 5/3
+// and 20/3, so t = -2.5 / sqrt(5/12 + 20/12) = -sqrt(3).
+func TestWelch(t *testing.T) {
+	var w welch
+	for _, x := range []float64{1, 2, 3, 4} {
+		w.push(x, 0)
+		w.push(2*x, 1)
+	}
+	if got := w.t(); math.Abs(got+math.Sqrt(3)) > 1e-12 {
+		t.Errorf("t = %v, want -sqrt(3)", got)
+	}
+
+	var few welch
+	few.push(1, 0)
+	few.push(2, 0)
+	few.push(3, 1)
+	if got := few.t(); got != 0 {
+		t.Errorf("t with one sample in a class = %v, want 0", got)
+	}
+	var flat welch
+	for range 3 {
+		flat.push(1, 0)
+		flat.push(2, 1)
+	}
+	if got := flat.t(); got != 0 {
+		t.Errorf("t with zero variance = %v, want 0", got)
+	}
+}
+
+// TestPercentiles checks that the thresholds are the dudect percentiles of
+// the sample, in increasing order, and that the sample is left alone.
+func TestPercentiles(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	sample := make([]float64, 1000)
+	for i, j := range r.Perm(len(sample)) {
+		sample[i] = float64(j)
+	}
+	orig := slices.Clone(sample)
+	p := percentiles(sample)
+	if len(p) != crops {
+		t.Fatalf("%d thresholds, want %d", len(p), crops)
+	}
+	if !slices.Equal(sample, orig) {
+		t.Error("percentiles modified its input")
+	}
+	if !slices.IsSorted(p) {
+		t.Errorf("thresholds are not in increasing order: %v", p)
+	}
+	for i, x := range p {
+		q := 1 - math.Pow(0.5, 10*float64(i+1)/crops)
+		if want := math.Floor(q * 999); x != want {
+			t.Errorf("threshold %d = %v, want %v", i, x, want)
+		}
+	}
+}
+
+// TestRun checks that the leaking control is caught quickly, and runs
+// every other target briefly to make sure it works, without judging it:
+// timings on a shared machine are too noisy for a pass/fail verdict.
+func TestRun(t *testing.T) {
+	r := gauss.NewSHAKE([]byte("dudect"))
+	progress := 0
+	res := Run(ByName("compare-naive"), r, Options{
+		Measurements: 20000, Batch: 2000,
+		Progress: func(*Result) { progress++ },
+	})
+	if res.MaxT < Certain || res.Verdict() != "LEAKS" {
+		t.Errorf("compare-naive: %v", res)
+	}
+	if res.Measurements != 20000 || progress != 10 {
+		t.Errorf("%d measurements in %d batches, want 20000 in 10", res.Measurements, progress)
+	}
+	if res.Means[0] <= res.Means[1] {
+		t.Errorf("comparing equal inputs took %.0f ns, random ones %.0f ns", res.Means[0], res.Means[1])
+	}
+	for _, tg := range Targets {
+		res := Run(tg, r, Options{Measurements: 200, Batch: 100})
+		if res.Measurements != 200 || math.IsNaN(res.MaxT) {
+			t.Errorf("%s: %v", tg.Name, res)
+		}
+	}
+}
+
+// TestByName checks that every target is found under its name.
+func TestByName(t *testing.T) {
+	for _, tg := range Targets {
+		if got := ByName(tg.Name); got != tg {
+			t.Errorf("ByName(%q) = %v", tg.Name, got)
+		}
+	}
+	for _, name := range []string{"", "NTT", "compare"} {
+		if got := ByName(name); got != nil {
+			t.Errorf("ByName(%q) = %v, want nil", name, got.Name)
+		}
+	}
+}
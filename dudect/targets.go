@@ -0,0 +1,180 @@
+package dudect
+
+import (
+	"bytes"
+	"crypto/subtle"
+
+	"github.com/haopining/Learn-Lattice-Based-Cryptography/gauss"
+	"github.com/haopining/Learn-Lattice-Based-Cryptography/mlkem"
+)
+
+// sink keeps the results of the targets alive, so that the compiler
+// cannot drop the calls.
+var sink uint16
+
+func randomPoly(r *gauss.Rand) *mlkem.Poly {
+	var f mlkem.Poly
+	for i := range f {
+		f[i] = uint16(r.Below(mlkem.Q))
+	}
+	return &f
+}
+
+func randomBytes(r *gauss.Rand, n int) []byte {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = byte(r.Uint64())
+	}
+	return b
+}
+
+// Every input is a fresh allocation, in the fixed class too: reusing one
+// fixed input would keep it in the cache and make the fixed class faster
+// for reasons that have nothing to do with the code under test.
+
+// Targets are the ready-made targets: the ML-KEM building blocks that
+// touch secrets, and two controls to check the harness, one that leaks
+// and one that does not.
+var Targets = []*Target{
+	{
+		Name: "ntt",
+		Doc:  "mlkem.NTT of the zero polynomial vs a uniform one",
+		Setup: func(r *gauss.Rand) (func(int) any, func(any)) {
+			input := func(class int) any {
+				if class == 0 {
+					return new(mlkem.Poly)
+				}
+				return randomPoly(r)
+			}
+			run := func(in any) {
+				f := mlkem.NTT(*in.(*mlkem.Poly))
+				sink ^= f[0]
+			}
+			return input, run
+		},
+	},
+	{
+		Name: "invntt",
+		Doc:  "mlkem.InvNTT of the zero polynomial vs a uniform one",
+		Setup: func(r *gauss.Rand) (func(int) any, func(any)) {
+			input := func(class int) any {
+				if class == 0 {
+					return new(mlkem.Poly)
+				}
+				return randomPoly(r)
+			}
+			run := func(in any) {
+				f := mlkem.InvNTT(*in.(*mlkem.Poly))
+				sink ^= f[0]
+			}
+			return input, run
+		},
+	},
+	{
+		Name: "basemul",
+		Doc:  "mlkem.MultiplyNTTs by a fixed public polynomial, of a zero vs a uniform secret",
+		Setup: func(r *gauss.Rand) (func(int) any, func(any)) {
+			a := randomPoly(r)
+			input := func(class int) any {
+				if class == 0 {
+					return new(mlkem.Poly)
+				}
+				return randomPoly(r)
+			}
+			run := func(in any) {
+				f := mlkem.MultiplyNTTs(a, in.(*mlkem.Poly))
+				sink ^= f[0]
+			}
+			return input, run
+		},
+	},
+	{
+		Name: "cbd",
+		Doc:  "mlkem.SamplePolyCBD with eta = 2 of all-zero vs uniform PRF output",
+		Setup: func(r *gauss.Rand) (func(int) any, func(any)) {
+			input := func(class int) any {
+				if class == 0 {
+					return make([]byte, 64*2)
+				}
+				return randomBytes(r, 64*2)
+			}
+			run := func(in any) {
+				f := mlkem.SamplePolyCBD(2, in.([]byte), nil)
+				sink ^= f[0]
+			}
+			return input, run
+		},
+	},
+	{
+		Name: "decaps",
+		Doc:  "ML-KEM-768 decapsulation of a valid ciphertext vs random ones (implicit rejection)",
+		Setup: func(r *gauss.Rand) (func(int) any, func(any)) {
+			dk, err := mlkem.NewDecapsulationKey(mlkem.MLKEM768, randomBytes(r, mlkem.SeedSize))
+			if err != nil {
+				panic(err)
+			}
+			_, valid := dk.EncapsulationKey().EncapsulateInternal(randomBytes(r, 32))
+			input := func(class int) any {
+				if class == 0 {
+					return bytes.Clone(valid)
+				}
+				return randomBytes(r, len(valid))
+			}
+			run := func(in any) {
+				k, _ := dk.Decapsulate(in.([]byte))
+				sink ^= uint16(k[0])
+			}
+			return input, run
+		},
+	},
+	{
+		Name: "compare-naive",
+		Doc:  "control: early-exit comparison with a secret, equal vs random input; must leak",
+		Setup: compareSetup(func(a, b []byte) bool {
+			for i := range a {
+				if a[i] != b[i] {
+					return false
+				}
+			}
+			return true
+		}),
+	},
+	{
+		Name: "compare-ct",
+		Doc:  "control: subtle.ConstantTimeCompare with a secret, equal vs random input",
+		Setup: compareSetup(func(a, b []byte) bool {
+			return subtle.ConstantTimeCompare(a, b) == 1
+		}),
+	},
+}
+
+// compareSetup returns the setup of a comparison target: a 4096-byte
+// secret is compared with itself or with random bytes, where an
+// early-exit comparison stops at the first byte.
+func compareSetup(equal func(a, b []byte) bool) func(*gauss.Rand) (func(int) any, func(any)) {
+	return func(r *gauss.Rand) (func(int) any, func(any)) {
+		secret := randomBytes(r, 4096)
+		input := func(class int) any {
+			if class == 0 {
+				return bytes.Clone(secret)
+			}
+			return randomBytes(r, len(secret))
+		}
+		run := func(in any) {
+			if equal(secret, in.([]byte)) {
+				sink++
+			}
+		}
+		return input, run
+	}
+}
+
+// ByName returns the target with the given name, or nil.
+func ByName(name string) *Target {
+	for _, t := range Targets {
+		if t.Name == name {
+			return t
+		}
+	}
+	return nil
+}
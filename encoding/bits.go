@@ -0,0 +1,61 @@
+package encoding
+
+import "errors"
+
+var (
+	// ErrLength is returned for input of the wrong size.
+	ErrLength = errors.New("encoding: wrong input length")
+	// ErrNonCanonical is returned for input that is not the encoding of
+	// any value, or not the unique one.
+	ErrNonCanonical = errors.New("encoding: non-canonical encoding")
+	// ErrRange is returned when a value to encode is out of range.
+	ErrRange = errors.New("encoding: value out of range")
+)
+
+// packBits concatenates the low bits bits of every value, least
+// significant bit first, into len(v) bits / 8 bytes; len(v) bits must be a
+// multiple of 8. This is the bit order of both FIPS 203 and FIPS 204.
+func packBits(v []uint32, bits int) []byte {
+	out := make([]byte, len(v)*bits/8)
+	var acc uint64
+	n, pos := 0, 0
+	for _, x := range v {
+		acc |= uint64(x) << n
+		n += bits
+		for n >= 8 {
+			out[pos] = byte(acc)
+			pos++
+			acc >>= 8
+			n -= 8
+		}
+	}
+	return out
+}
+
+// unpackBits is the inverse of packBits for count values.
+func unpackBits(b []byte, bits, count int) []uint32 {
+	v := make([]uint32, count)
+	mask := uint64(1)<<bits - 1
+	var acc uint64
+	n, pos := 0, 0
+	for i := range v {
+		for n < bits {
+			acc |= uint64(b[pos]) << n
+			pos++
+			n += 8
+		}
+		v[i] = uint32(acc & mask)
+		acc >>= bits
+		n -= bits
+	}
+	return v
+}
+
+// bitLen returns the number of bits of x.
+func bitLen(x uint32) int {
+	n := 0
+	for ; x > 0; x >>= 1 {
+		n++
+	}
+	return n
+}
@@ -0,0 +1,24 @@
+// Package encoding serializes ring elements the way the standardized
+// lattice schemes do, bit-exactly:
+//
+//   - ML-KEM (FIPS 203): ByteEncode_d and ByteDecode_d pack 256
+//     coefficients of d bits, d = 1..12, and Compress_d and Decompress_d
+//     round between Z_q and Z_(2^d) for the ciphertext.
+//   - ML-DSA (FIPS 204): SimpleBitPack and BitPack pack coefficients in
+//     [0, b] and [-a, b], and HintBitPack stores the sparse hint vector as
+//     a list of positions.
+//   - Falcon: signatures are compressed with a Golomb–Rice style code, a
+//     sign bit, the 7 low bits of the absolute value and the high bits in
+//     unary.
+//
+// Every format has exactly one encoding per value, and the decoders check
+// it: an ML-KEM key coefficient >= q, an out-of-range ML-DSA coefficient,
+// hint positions out of order, a Falcon "minus zero" or nonzero padding are
+// all rejected with ErrNonCanonical. Accepting several encodings of one
+// value makes signatures malleable and keys hard to compare, so a decoder
+// that accepts b must be such that encoding the result gives back b.
+//
+// The Fuzz targets of the package tests check both properties, round trip
+// and canonicity, from seed corpora of edge cases; go test -fuzz explores
+// further.
+package encoding
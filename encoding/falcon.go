@@ -0,0 +1,133 @@
+package encoding
+
+import "errors"
+
+// ErrTooLong is returned by FalconCompress when the encoding does not fit
+// in the requested length; the signer then restarts with a fresh salt.
+var ErrTooLong = errors.New("encoding: compressed signature too long")
+
+// falconMax bounds the absolute value of the coefficients, as in the
+// reference implementation: larger ones cannot appear in a valid
+// signature and would make the unary part unbounded.
+const falconMax = 2047
+
+// bitWriter and bitReader process a stream most significant bit first,
+// the bit order of Falcon.
+type bitWriter struct {
+	out []byte
+	acc uint32
+	n   int
+}
+
+func (w *bitWriter) write(v uint32, bits int) {
+	for i := bits - 1; i >= 0; i-- {
+		w.acc = w.acc<<1 | v>>uint(i)&1
+		if w.n++; w.n == 8 {
+			w.out = append(w.out, byte(w.acc))
+			w.acc, w.n = 0, 0
+		}
+	}
+}
+
+func (w *bitWriter) flush() []byte {
+	if w.n > 0 {
+		w.out = append(w.out, byte(w.acc<<uint(8-w.n)))
+		w.acc, w.n = 0, 0
+	}
+	return w.out
+}
+
+type bitReader struct {
+	in  []byte
+	pos int // in bits
+}
+
+func (r *bitReader) read() (uint32, bool) {
+	if r.pos >= 8*len(r.in) {
+		return 0, false
+	}
+	b := uint32(r.in[r.pos/8]>>(7-uint(r.pos%8))) & 1
+	r.pos++
+	return b, true
+}
+
+// FalconCompress encodes the signature polynomial s (Falcon
+// specification, Algorithm 17). Each coefficient becomes a sign bit, the 7
+// low bits of |s_i|, and |s_i| >> 7 zeros followed by a one; since the
+// coefficients are Gaussian with sigma around 165, the unary part is
+// usually one or two bits and a coefficient costs about 9 bits instead of
+// 12. The result is padded with zeros to slen bytes, or left at its
+// minimal length if slen is 0.
+func FalconCompress(s []int16, slen int) ([]byte, error) {
+	var w bitWriter
+	for _, x := range s {
+		sign, m := uint32(0), int32(x)
+		if m < 0 {
+			sign, m = 1, -m
+		}
+		if m > falconMax {
+			return nil, ErrRange
+		}
+		w.write(sign, 1)
+		w.write(uint32(m)&127, 7)
+		w.write(0, int(m>>7))
+		w.write(1, 1)
+	}
+	out := w.flush()
+	if slen == 0 {
+		return out, nil
+	}
+	if len(out) > slen {
+		return nil, ErrTooLong
+	}
+	return append(out, make([]byte, slen-len(out))...), nil
+}
+
+// FalconDecompress decodes n coefficients (Falcon specification,
+// Algorithm 18). It rejects every encoding FalconCompress cannot produce:
+// a negative zero, a coefficient above 2047, running out of input, and any
+// nonzero bit after the last coefficient, in its final byte or in the
+// padding.
+func FalconDecompress(b []byte, n int) ([]int16, error) {
+	r := bitReader{in: b}
+	s := make([]int16, n)
+	for i := range s {
+		var head uint32
+		for range 8 {
+			bit, ok := r.read()
+			if !ok {
+				return nil, ErrLength
+			}
+			head = head<<1 | bit
+		}
+		sign, m := head>>7, head&127
+		for {
+			bit, ok := r.read()
+			if !ok {
+				return nil, ErrLength
+			}
+			if bit == 1 {
+				break
+			}
+			if m += 128; m > falconMax {
+				return nil, ErrNonCanonical
+			}
+		}
+		if sign == 1 && m == 0 {
+			return nil, ErrNonCanonical
+		}
+		s[i] = int16(m)
+		if sign == 1 {
+			s[i] = -s[i]
+		}
+	}
+	for {
+		bit, ok := r.read()
+		if !ok {
+			return s, nil
+		}
+		if bit != 0 {
+			return nil, ErrNonCanonical
+		}
+	}
+}
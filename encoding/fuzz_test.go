@@ -0,0 +1,379 @@
+package encoding
+
+import (
+	"bytes"
+	"crypto/sha3"
+	"encoding/binary"
+	"errors"
+	"slices"
+	"testing"
+)
+
+// The Fuzz targets come in pairs. The Unpack/Decode target of a format
+// decodes arbitrary bytes and checks canonicity: an accepted input must be
+// given back by encoding the result, and a rejected one must fail with one
+// of the package's errors. The Pack/Encode target expands its input into
+// valid values and checks that they survive a round trip. The seed corpora
+// hold the edge cases, such as the first out-of-range value of every field,
+// and valid encodings for the mutator to flip bits in; go test runs them
+// all, and go test -fuzz explores from there.
+
+// values expands seed into n uniform values below m.
+func values(seed []byte, n int, m uint32) []uint32 {
+	buf := sha3.SumSHAKE128(seed, 4*n)
+	v := make([]uint32, n)
+	for i := range v {
+		v[i] = uint32(uint64(binary.LittleEndian.Uint32(buf[4*i:])) * uint64(m) >> 32)
+	}
+	return v
+}
+
+// fit returns b cut or zero-extended to n bytes.
+func fit(b []byte, n int) []byte {
+	out := make([]byte, n)
+	copy(out, b)
+	return out
+}
+
+// canonical checks that decode accepts b only if encode gives it back, and
+// rejects it only with a package error.
+func canonical[T any](t *testing.T, b []byte, decode func([]byte) (T, error), encode func(T) ([]byte, error)) {
+	t.Helper()
+	v, err := decode(b)
+	if err != nil {
+		if !errors.Is(err, ErrNonCanonical) && !errors.Is(err, ErrLength) {
+			t.Fatalf("decoding %x: unexpected error %v", b, err)
+		}
+		return
+	}
+	back, err := encode(v)
+	if err != nil {
+		t.Fatalf("cannot re-encode the decoding of %x: %v", b, err)
+	}
+	if !bytes.Equal(back, b) {
+		t.Fatalf("accepted the non-canonical encoding %x (canonical %x)", b, back)
+	}
+}
+
+// put returns a d-bit encoding of 256 coefficients that are all x except
+// for the first and the last, which are y.
+func put(d int, x, y uint32) []byte {
+	v := make([]uint32, N)
+	for i := range v {
+		v[i] = x
+	}
+	v[0], v[N-1] = y, y
+	return packBits(v, d)
+}
+
+func u16s(v []uint32) []uint16 {
+	f := make([]uint16, len(v))
+	for i, x := range v {
+		f[i] = uint16(x)
+	}
+	return f
+}
+
+func i32s(v []uint32, shift int64) []int32 {
+	f := make([]int32, len(v))
+	for i, x := range v {
+		f[i] = int32(int64(x) + shift)
+	}
+	return f
+}
+
+func FuzzByteDecode(f *testing.F) {
+	f.Add(uint8(12), make([]byte, 32*12))
+	f.Add(uint8(12), bytes.Repeat([]byte{0xff}, 32*12))
+	f.Add(uint8(12), put(12, 0, Q-1))
+	f.Add(uint8(12), put(12, 0, Q))
+	f.Add(uint8(12), put(12, Q-1, 1<<12-1))
+	f.Add(uint8(1), bytes.Repeat([]byte{0xff}, 32))
+	f.Add(uint8(11), put(11, 1<<11-1, 0))
+	f.Add(uint8(4), []byte{})
+	f.Add(uint8(10), make([]byte, 32*10+1))
+	f.Fuzz(func(t *testing.T, d uint8, b []byte) {
+		d = 1 + d%12
+		dec := func(b []byte) ([]uint16, error) { return ByteDecode(int(d), b) }
+		enc := func(f []uint16) ([]byte, error) { return ByteEncode(int(d), f) }
+		canonical(t, b, dec, enc)
+		canonical(t, fit(b, 32*int(d)), dec, enc)
+	})
+}
+
+func FuzzByteEncode(f *testing.F) {
+	for d := range uint8(12) {
+		f.Add(d, []byte{d})
+	}
+	f.Fuzz(func(t *testing.T, d uint8, seed []byte) {
+		d = 1 + d%12
+		v := u16s(values(seed, N, modulus(int(d))))
+		b, err := ByteEncode(int(d), v)
+		if err != nil {
+			t.Fatalf("d=%d: %v", d, err)
+		}
+		if len(b) != 32*int(d) {
+			t.Fatalf("d=%d: encoding of %d bytes", d, len(b))
+		}
+		got, err := ByteDecode(int(d), b)
+		if err != nil || !slices.Equal(v, got) {
+			t.Fatalf("d=%d: round trip failed: %v", d, err)
+		}
+		if int(d) == 12 {
+			v[N/2] = Q
+			if _, err := ByteEncode(12, v); !errors.Is(err, ErrRange) {
+				t.Fatalf("encoding q with d=12: %v", err)
+			}
+		}
+	})
+}
+
+func FuzzCompress(f *testing.F) {
+	for _, x := range []uint16{0, 1, Q/2 - 1, Q / 2, Q/2 + 1, Q - 1} {
+		for _, d := range []uint8{1, 4, 5, 10, 11} {
+			f.Add(d, x)
+		}
+	}
+	f.Fuzz(func(t *testing.T, d uint8, x uint16) {
+		d = 1 + d%11
+		x %= Q
+		y := Compress(int(d), x)
+		if y >= 1<<d {
+			t.Fatalf("Compress(%d, %d) = %d out of range", d, x, y)
+		}
+		// The error bound of FIPS 203: |x' - x mod+- q| <= round(q / 2^(d+1)).
+		diff := (int(Decompress(int(d), y)) - int(x) + Q) % Q
+		if diff > Q/2 {
+			diff = Q - diff
+		}
+		if bound := (Q + 1<<d) >> (d + 1); diff > bound {
+			t.Fatalf("d=%d: x=%d decompresses %d away, bound %d", d, x, diff, bound)
+		}
+		y = uint16(x) & (1<<d - 1)
+		if c := Compress(int(d), Decompress(int(d), y)); c != y {
+			t.Fatalf("d=%d: Compress(Decompress(%d)) = %d", d, y, c)
+		}
+	})
+}
+
+// simpleBounds are the b of SimpleBitPack in ML-DSA: t_1, and w_1 for the
+// two values of gamma_2.
+var simpleBounds = []uint32{1<<10 - 1, 43, 15}
+
+func FuzzSimpleBitUnpack(f *testing.F) {
+	// w_1 for gamma_2 = (q-1)/88 has 6-bit fields up to 43.
+	f.Add(uint8(1), put(6, 0, 43))
+	f.Add(uint8(1), put(6, 0, 44))
+	f.Add(uint8(1), put(6, 43, 63))
+	f.Add(uint8(0), bytes.Repeat([]byte{0xff}, 320))
+	f.Add(uint8(2), bytes.Repeat([]byte{0xff}, 128))
+	f.Add(uint8(2), make([]byte, 127))
+	f.Fuzz(func(t *testing.T, i uint8, v []byte) {
+		b := simpleBounds[int(i)%len(simpleBounds)]
+		dec := func(v []byte) ([]int32, error) { return SimpleBitUnpack(v, b, N) }
+		enc := func(w []int32) ([]byte, error) { return SimpleBitPack(w, b) }
+		canonical(t, v, dec, enc)
+		canonical(t, fit(v, N*bitLen(b)/8), dec, enc)
+	})
+}
+
+func FuzzSimpleBitPack(f *testing.F) {
+	for i := range uint8(len(simpleBounds)) {
+		f.Add(i, []byte{i})
+	}
+	f.Fuzz(func(t *testing.T, i uint8, seed []byte) {
+		b := simpleBounds[int(i)%len(simpleBounds)]
+		w := i32s(values(seed, N, b+1), 0)
+		v, err := SimpleBitPack(w, b)
+		if err != nil {
+			t.Fatalf("b=%d: %v", b, err)
+		}
+		got, err := SimpleBitUnpack(v, b, N)
+		if err != nil || !slices.Equal(w, got) {
+			t.Fatalf("b=%d: round trip failed: %v", b, err)
+		}
+		w[N/2] = int32(b) + 1
+		if _, err := SimpleBitPack(w, b); !errors.Is(err, ErrRange) {
+			t.Fatalf("b=%d: packing b+1: %v", b, err)
+		}
+	})
+}
+
+// packBounds are the (a, b) of BitPack in ML-DSA: the secrets for eta = 2
+// and 4, t_0, and z for the two values of gamma_1.
+var packBounds = [][2]uint32{{2, 2}, {4, 4}, {1<<12 - 1, 1 << 12}, {1<<17 - 1, 1 << 17}, {1<<19 - 1, 1 << 19}}
+
+func FuzzBitUnpack(f *testing.F) {
+	// eta = 2 stores b - w in 3 bits, so 5, 6 and 7 are out of range.
+	f.Add(uint8(0), put(3, 0, 4))
+	f.Add(uint8(0), put(3, 0, 5))
+	f.Add(uint8(0), put(3, 4, 7))
+	// eta = 4 stores up to 8 in 4 bits.
+	f.Add(uint8(1), put(4, 0, 8))
+	f.Add(uint8(1), put(4, 0, 9))
+	f.Add(uint8(2), bytes.Repeat([]byte{0xff}, 416))
+	f.Add(uint8(3), make([]byte, 576))
+	f.Add(uint8(4), bytes.Repeat([]byte{0xff}, 640))
+	f.Fuzz(func(t *testing.T, i uint8, v []byte) {
+		ab := packBounds[int(i)%len(packBounds)]
+		a, b := ab[0], ab[1]
+		dec := func(v []byte) ([]int32, error) { return BitUnpack(v, a, b, N) }
+		enc := func(w []int32) ([]byte, error) { return BitPack(w, a, b) }
+		canonical(t, v, dec, enc)
+		canonical(t, fit(v, N*bitLen(a+b)/8), dec, enc)
+	})
+}
+
+func FuzzBitPack(f *testing.F) {
+	for i := range uint8(len(packBounds)) {
+		f.Add(i, []byte{i})
+	}
+	f.Fuzz(func(t *testing.T, i uint8, seed []byte) {
+		ab := packBounds[int(i)%len(packBounds)]
+		a, b := ab[0], ab[1]
+		w := i32s(values(seed, N, a+b+1), -int64(a))
+		v, err := BitPack(w, a, b)
+		if err != nil {
+			t.Fatalf("a=%d b=%d: %v", a, b, err)
+		}
+		got, err := BitUnpack(v, a, b, N)
+		if err != nil || !slices.Equal(w, got) {
+			t.Fatalf("a=%d b=%d: round trip failed: %v", a, b, err)
+		}
+		w[N/2] = -int32(a) - 1
+		if _, err := BitPack(w, a, b); !errors.Is(err, ErrRange) {
+			t.Fatalf("a=%d b=%d: packing -a-1: %v", a, b, err)
+		}
+	})
+}
+
+// hintParams are the (k, omega) of ML-DSA-44, -65 and -87.
+var hintParams = [][2]int{{4, 80}, {6, 55}, {8, 75}}
+
+// hint returns the hint encoding for k = 4, omega = 80 of the given
+// positions and counts.
+func hint(pos []byte, counts ...byte) []byte {
+	y := make([]byte, 80+4)
+	copy(y, pos)
+	copy(y[80:], counts)
+	return y
+}
+
+func FuzzHintBitUnpack(f *testing.F) {
+	f.Add(uint8(0), hint(nil, 0, 0, 0, 0))
+	f.Add(uint8(0), hint([]byte{1, 2, 3}, 2, 3, 3, 3))
+	// Positions out of order, repeated, and a decreasing count.
+	f.Add(uint8(0), hint([]byte{2, 1}, 2, 2, 2, 2))
+	f.Add(uint8(0), hint([]byte{5, 5}, 2, 2, 2, 2))
+	f.Add(uint8(0), hint([]byte{1, 2}, 2, 1, 2, 2))
+	// A count above omega, and a nonzero unused position.
+	f.Add(uint8(0), hint(nil, 0, 0, 0, 81))
+	f.Add(uint8(0), hint([]byte{0, 0, 7}, 0, 0, 0, 0))
+	// Every position used, in every polynomial.
+	full := make([]byte, 80)
+	for i := range full {
+		full[i] = byte(i % 20)
+	}
+	f.Add(uint8(0), hint(full, 20, 40, 60, 80))
+	f.Fuzz(func(t *testing.T, i uint8, y []byte) {
+		p := hintParams[int(i)%len(hintParams)]
+		k, omega := p[0], p[1]
+		dec := func(y []byte) ([][]int32, error) { return HintBitUnpack(y, k, omega) }
+		enc := func(h [][]int32) ([]byte, error) { return HintBitPack(h, omega) }
+		canonical(t, y, dec, enc)
+		canonical(t, fit(y, omega+k), dec, enc)
+	})
+}
+
+func FuzzHintBitPack(f *testing.F) {
+	for i := range uint8(len(hintParams)) {
+		f.Add(i, []byte{i})
+	}
+	f.Fuzz(func(t *testing.T, i uint8, seed []byte) {
+		p := hintParams[int(i)%len(hintParams)]
+		k, omega := p[0], p[1]
+		h := make([][]int32, k)
+		for i := range h {
+			h[i] = make([]int32, N)
+		}
+		// Up to omega ones at random positions.
+		pos := values(seed, omega+1, uint32(k*N))
+		for _, x := range pos[1 : 1+int(pos[0])%(omega+1)] {
+			h[x/N][x%N] = 1
+		}
+		y, err := HintBitPack(h, omega)
+		if err != nil {
+			t.Fatalf("k=%d: %v", k, err)
+		}
+		got, err := HintBitUnpack(y, k, omega)
+		if err != nil {
+			t.Fatalf("k=%d: round trip failed: %v", k, err)
+		}
+		for i := range h {
+			if !slices.Equal(h[i], got[i]) {
+				t.Fatalf("k=%d: round trip changed polynomial %d", k, i)
+			}
+		}
+	})
+}
+
+func FuzzFalconDecompress(f *testing.F) {
+	// Two coefficients: 0 and 1, each a sign bit, 7 low bits and a
+	// terminating one, then zero padding.
+	f.Add(uint8(0), []byte{0x00, 0x80, 0xc0, 0x00})
+	// A "minus zero", a nonzero padding bit, and a truncated input.
+	f.Add(uint8(0), []byte{0x80, 0x80, 0xc0, 0x00})
+	f.Add(uint8(0), []byte{0x00, 0x80, 0xc0, 0x01})
+	f.Add(uint8(0), []byte{0x00, 0x80})
+	// 2047, the largest coefficient: 15 zeros before the one.
+	f.Add(uint8(0), []byte{0x7f, 0x00, 0x01, 0x00, 0x80, 0x00})
+	// 2175, one unary zero too many.
+	f.Add(uint8(0), []byte{0x7f, 0x00, 0x00, 0x80, 0x40, 0x00})
+	f.Add(uint8(8), make([]byte, 666))
+	f.Fuzz(func(t *testing.T, logn uint8, b []byte) {
+		n := 1 << (1 + logn%9) // 2 .. 512
+		dec := func(b []byte) ([]int16, error) { return FalconDecompress(b, n) }
+		// The canonical encoding of a decoded value is padded to the
+		// length of the input, as signatures of a fixed length are.
+		enc := func(s []int16) ([]byte, error) { return FalconCompress(s, len(b)) }
+		canonical(t, b, dec, enc)
+	})
+}
+
+func FuzzFalconCompress(f *testing.F) {
+	for logn := range uint8(9) {
+		f.Add(logn, []byte{logn})
+	}
+	f.Fuzz(func(t *testing.T, logn uint8, seed []byte) {
+		n := 1 << (1 + logn%9)
+		// Mostly small coefficients, as in signatures, with some up to
+		// 2047.
+		v := values(seed, 2*n, 1<<16)
+		s := make([]int16, n)
+		for i := range s {
+			m := int32(v[2*i] % 300)
+			if v[2*i]>>12 == 0 {
+				m = int32(v[2*i] % 2048)
+			}
+			if v[2*i+1]&1 == 1 {
+				m = -m
+			}
+			s[i] = int16(m)
+		}
+		b, err := FalconCompress(s, 0)
+		if err != nil {
+			t.Fatalf("n=%d: %v", n, err)
+		}
+		got, err := FalconDecompress(b, n)
+		if err != nil || !slices.Equal(s, got) {
+			t.Fatalf("n=%d: round trip failed: %v", n, err)
+		}
+		if _, err := FalconCompress(s, len(b)-1); !errors.Is(err, ErrTooLong) {
+			t.Fatalf("n=%d: compressing into too short a buffer: %v", n, err)
+		}
+		s[n/2] = falconMax + 1
+		if _, err := FalconCompress(s, 0); !errors.Is(err, ErrRange) {
+			t.Fatalf("n=%d: compressing 2048: %v", n, err)
+		}
+	})
+}
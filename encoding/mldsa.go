@@ -0,0 +1,134 @@
+package encoding
+
+// SimpleBitPack packs coefficients in [0, b] with bitlen(b) bits each
+// (FIPS 204, Algorithm 16), as used for t_1 (b = 2^10 - 1) and w_1.
+func SimpleBitPack(w []int32, b uint32) ([]byte, error) {
+	bits := bitLen(b)
+	if bits == 0 || len(w)*bits%8 != 0 {
+		return nil, ErrLength
+	}
+	v := make([]uint32, len(w))
+	for i, x := range w {
+		if x < 0 || uint32(x) > b {
+			return nil, ErrRange
+		}
+		v[i] = uint32(x)
+	}
+	return packBits(v, bits), nil
+}
+
+// SimpleBitUnpack is the inverse of SimpleBitPack for n coefficients
+// (FIPS 204, Algorithm 18). When b + 1 is not a power of two some bit
+// patterns exceed b; they are rejected with ErrNonCanonical.
+func SimpleBitUnpack(v []byte, b uint32, n int) ([]int32, error) {
+	bits := bitLen(b)
+	if bits == 0 || n*bits%8 != 0 || len(v) != n*bits/8 {
+		return nil, ErrLength
+	}
+	w := make([]int32, n)
+	for i, x := range unpackBits(v, bits, n) {
+		if x > b {
+			return nil, ErrNonCanonical
+		}
+		w[i] = int32(x)
+	}
+	return w, nil
+}
+
+// BitPack packs coefficients in [-a, b] as b - w_i with bitlen(a + b) bits
+// each (FIPS 204, Algorithm 17), as used for the secrets s_1, s_2
+// (a = b = eta), t_0 (a = 2^12 - 1, b = 2^12) and the response z
+// (a = gamma_1 - 1, b = gamma_1).
+func BitPack(w []int32, a, b uint32) ([]byte, error) {
+	bits := bitLen(a + b)
+	if bits == 0 || len(w)*bits%8 != 0 {
+		return nil, ErrLength
+	}
+	v := make([]uint32, len(w))
+	for i, x := range w {
+		if int64(x) < -int64(a) || int64(x) > int64(b) {
+			return nil, ErrRange
+		}
+		v[i] = uint32(int64(b) - int64(x))
+	}
+	return packBits(v, bits), nil
+}
+
+// BitUnpack is the inverse of BitPack for n coefficients (FIPS 204,
+// Algorithm 19), rejecting stored values above a + b with
+// ErrNonCanonical. FIPS 204 leaves them to the caller (they occur for
+// eta = 2 and 4, whose 3- and 4-bit fields hold up to 7 and 15); here they
+// are refused, since they cannot come from BitPack.
+func BitUnpack(v []byte, a, b uint32, n int) ([]int32, error) {
+	bits := bitLen(a + b)
+	if bits == 0 || n*bits%8 != 0 || len(v) != n*bits/8 {
+		return nil, ErrLength
+	}
+	w := make([]int32, n)
+	for i, x := range unpackBits(v, bits, n) {
+		if x > a+b {
+			return nil, ErrNonCanonical
+		}
+		w[i] = int32(int64(b) - int64(x))
+	}
+	return w, nil
+}
+
+// HintBitPack encodes the hint vector h, k polynomials with coefficients 0
+// or 1 and at most omega ones in total, into omega + k bytes (FIPS 204,
+// Algorithm 20): the positions of the ones, polynomial after polynomial,
+// followed by the running count at the end of each polynomial.
+func HintBitPack(h [][]int32, omega int) ([]byte, error) {
+	y := make([]byte, omega+len(h))
+	index := 0
+	for i, p := range h {
+		if len(p) != N {
+			return nil, ErrLength
+		}
+		for j, x := range p {
+			switch {
+			case x == 0:
+				continue
+			case x != 1 || index == omega:
+				return nil, ErrRange
+			}
+			y[index] = byte(j)
+			index++
+		}
+		y[omega+i] = byte(index)
+	}
+	return y, nil
+}
+
+// HintBitUnpack decodes k hint polynomials (FIPS 204, Algorithm 21). It
+// enforces the checks that make the encoding unique, and hence ML-DSA
+// signatures strongly unforgeable: the counts never decrease nor exceed
+// omega, the positions within a polynomial are strictly increasing, and
+// the unused position bytes are zero.
+func HintBitUnpack(y []byte, k, omega int) ([][]int32, error) {
+	if len(y) != omega+k {
+		return nil, ErrLength
+	}
+	h := make([][]int32, k)
+	index := 0
+	for i := range h {
+		h[i] = make([]int32, N)
+		end := int(y[omega+i])
+		if end < index || end > omega {
+			return nil, ErrNonCanonical
+		}
+		first := index
+		for ; index < end; index++ {
+			if index > first && y[index-1] >= y[index] {
+				return nil, ErrNonCanonical
+			}
+			h[i][y[index]] = 1
+		}
+	}
+	for ; index < omega; index++ {
+		if y[index] != 0 {
+			return nil, ErrNonCanonical
+		}
+	}
+	return h, nil
+}
@@ -0,0 +1,86 @@
+package encoding
+
+// N is the number of coefficients of the ML-KEM and ML-DSA polynomials,
+// and Q the ML-KEM modulus.
+const (
+	N = 256
+	Q = 3329
+)
+
+// ByteEncode packs the 256 coefficients of f into 32 d bytes, d bits each
+// (FIPS 203, Algorithm 5). The coefficients must be below 2^d, or below q
+// for d = 12.
+func ByteEncode(d int, f []uint16) ([]byte, error) {
+	if d < 1 || d > 12 || len(f) != N {
+		return nil, ErrLength
+	}
+	m := modulus(d)
+	v := make([]uint32, N)
+	for i, x := range f {
+		if uint32(x) >= m {
+			return nil, ErrRange
+		}
+		v[i] = uint32(x)
+	}
+	return packBits(v, d), nil
+}
+
+// ByteDecode unpacks 32 d bytes into 256 coefficients (FIPS 203,
+// Algorithm 6). For d = 12, where 12-bit values can exceed q, it returns
+// ErrNonCanonical for any coefficient >= q instead of reducing it: this is
+// the modulus check FIPS 203 requires on encapsulation keys.
+func ByteDecode(d int, b []byte) ([]uint16, error) {
+	if d < 1 || d > 12 || len(b) != 32*d {
+		return nil, ErrLength
+	}
+	m := modulus(d)
+	f := make([]uint16, N)
+	for i, x := range unpackBits(b, d, N) {
+		if x >= m {
+			return nil, ErrNonCanonical
+		}
+		f[i] = uint16(x)
+	}
+	return f, nil
+}
+
+// modulus returns the range of d-bit coefficients: 2^d, or q for d = 12.
+func modulus(d int) uint32 {
+	if d == 12 {
+		return Q
+	}
+	return 1 << d
+}
+
+// Compress returns round(2^d x / q) mod 2^d for x in [0, q) and d < 12
+// (FIPS 203, equation 4.7), in integer arithmetic: the division by q is
+// exact rounding of (2^d x + q/2) / q, with no floating point involved.
+func Compress(d int, x uint16) uint16 {
+	y := (uint32(x)<<d + Q/2) / Q
+	return uint16(y & (1<<d - 1))
+}
+
+// Decompress returns round(q y / 2^d) (FIPS 203, equation 4.8), the
+// element of Z_q closest to the center of the interval Compress maps to y.
+// Decompress(Compress(x)) differs from x by at most round(q / 2^(d+1)).
+func Decompress(d int, y uint16) uint16 {
+	return uint16((uint32(y)*Q + 1<<(d-1)) >> d)
+}
+
+// CompressPoly applies Compress to every coefficient.
+func CompressPoly(d int, f []uint16) []uint16 {
+	g := make([]uint16, len(f))
+	for i, x := range f {
+		g[i] = Compress(d, x)
+	}
+	return g
+}
+
+// DecompressPoly applies Decompress to every coefficient.
+func DecompressPoly(d int, f []uint16) []uint16 {
+	g := make([]uint16, len(f))
+	for i, y := range f {
+		g[i] = Decompress(d, y)
+	}
+	return g
+}
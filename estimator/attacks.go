@@ -0,0 +1,228 @@
+package estimator
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/haopining/Learn-Lattice-Based-Cryptography/lattice"
+)
+
+// MinBlockSize is the smallest block size the estimates consider. Below it
+// the limit formula for delta(beta) is meaningless and BKZ is cheap anyway.
+const MinBlockSize = 50
+
+// Result is the estimated cost of one attack with its optimal parameters.
+type Result struct {
+	Attack string
+	// Beta is the BKZ block size and Dim the dimension of the lattice that
+	// is reduced.
+	Beta, Dim int
+	// M is the number of samples used.
+	M int
+	// Guess is the number of secret coefficients guessed (hybrid only).
+	Guess int
+	// Cost is log2 of the cost under the model; +Inf if the attack does
+	// not succeed for any block size.
+	Cost float64
+}
+
+func (r Result) String() string {
+	if math.IsInf(r.Cost, 1) {
+		return fmt.Sprintf("%-7s no solution", r.Attack)
+	}
+	s := fmt.Sprintf("%-7s beta=%4d d=%4d m=%4d", r.Attack, r.Beta, r.Dim, r.M)
+	if r.Attack == "hybrid" {
+		s += fmt.Sprintf(" g=%4d", r.Guess)
+	}
+	return s + fmt.Sprintf(" cost=2^%.1f", r.Cost)
+}
+
+// logDelta caches ln delta(beta) for beta up to the largest dimension used.
+type logDelta []float64
+
+func newLogDelta(maxDim int) logDelta {
+	t := make(logDelta, maxDim+1)
+	for beta := MinBlockSize; beta <= maxDim; beta++ {
+		t[beta] = math.Log(lattice.BKZDelta(beta))
+	}
+	return t
+}
+
+// Primal estimates the uSVP attack on Kannan's embedding of m <= M
+// samples, a lattice of dimension d = m + n + 1. The secret coordinates are
+// scaled by sigma_e/sigma_s so that the short vector (e, s, 1) has
+// expected length sigma_e sqrt(d), giving covolume q^m (sigma_e/sigma_s)^n.
+// BKZ-beta finds the vector when (ADPS16)
+//
+//	sigma_e sqrt(beta) <= delta^(2 beta - d) vol^(1/d).
+func Primal(p Params, model CostModel) Result {
+	best := Result{Attack: "primal", Cost: math.Inf(1)}
+	M := p.samples()
+	ld := newLogDelta(M + p.N + 1)
+	se := math.Log(p.Error.Stddev())
+	scale := se - math.Log(p.Secret.Stddev())
+	for m := 1; m <= M; m++ {
+		d := m + p.N + 1
+		logVol := float64(m)*math.Log(p.Q) + float64(p.N)*scale
+		for beta := MinBlockSize; beta <= d; beta++ {
+			lhs := se + 0.5*math.Log(float64(beta))
+			rhs := float64(2*beta-d)*ld[beta] + logVol/float64(d)
+			if lhs > rhs {
+				continue
+			}
+			if c := model.Cost(beta, d); c < best.Cost {
+				best = Result{Attack: "primal", Beta: beta, Dim: d, M: m, Cost: c}
+			}
+			break
+		}
+	}
+	return best
+}
+
+// Dual estimates the dual distinguishing attack. A short vector (x, y) of
+// the lattice { (x, y) in Z^(m+n) : x^T A = y^T mod q } has length
+// delta^(d-1) vol^(1/d) after BKZ-beta, where d = m + n and, with y scaled
+// by sigma_s/sigma_e, vol = q^n (sigma_s/sigma_e)^n. Then <x, b> =
+// <x, e> + <y, s> mod q is a Gaussian of width ||(x, y)|| sigma_e, which
+// tells it apart from uniform with advantage
+//
+//	eps = exp(-2 pi^2 (||(x, y)|| sigma_e / q)^2).
+//
+// About 1/eps^2 such vectors are needed; one sieve call provides
+// 2^(0.2075 beta) of them, so the cost is repeated only for the rest.
+func Dual(p Params, model CostModel) Result {
+	best := Result{Attack: "dual", Cost: math.Inf(1)}
+	M := p.samples()
+	ld := newLogDelta(M + p.N)
+	scale := math.Log(p.Secret.Stddev()) - math.Log(p.Error.Stddev())
+	for m := 1; m <= M; m++ {
+		d := m + p.N
+		logVol := float64(p.N)*math.Log(p.Q) + float64(p.N)*scale
+		for beta := MinBlockSize; beta <= d; beta++ {
+			logLen := float64(d-1)*ld[beta] + logVol/float64(d)
+			tau := math.Exp(logLen) * p.Error.Stddev() / p.Q
+			log2Eps := -2 * math.Pi * math.Pi * tau * tau * math.Log2E
+			reps := math.Max(0, -2*log2Eps-0.2075*float64(beta))
+			if c := model.Cost(beta, d) + reps; c < best.Cost {
+				best = Result{Attack: "dual", Beta: beta, Dim: d, M: m, Cost: c}
+			}
+			if reps == 0 {
+				// Larger blocks only cost more.
+				break
+			}
+		}
+	}
+	return best
+}
+
+// Hybrid estimates Howgrave-Graham's hybrid attack without the
+// meet-in-the-middle speedup. It guesses g secret coefficients, at a cost
+// of 2^(g H) for entropy H per coefficient, and decodes the remaining
+// LWE instance in dimension d = m + n - g with BKZ-beta and Babai's nearest
+// plane. Under the GSA the reduced basis has lengths r_i = ||b*_i||, and
+// nearest plane recovers the error with probability
+//
+//	P = prod_i erf( r_i / (2 sqrt(2) sigma_e) ),
+//
+// so the cost is (2^BKZ + 2^(g H) d^2) / P. The optimum over (m, g, beta)
+// is found on a coarse grid refined once around its minimum.
+func Hybrid(p Params, model CostModel) Result {
+	M := p.samples()
+	ld := newLogDelta(M + p.N)
+	eval := func(m, g int) Result {
+		d := m + p.N - g
+		logVol := float64(m)*math.Log(p.Q) + float64(p.N-g)*(math.Log(p.Error.Stddev())-math.Log(p.Secret.Stddev()))
+		w := 2 * math.Sqrt2 * p.Error.Stddev()
+		cost := func(beta int) float64 {
+			// The GSA profile of lattice.GSA, from the shortest b*_i up;
+			// once erf is 1 to double precision the rest adds nothing.
+			n, l := float64(d), ld[beta]
+			log2P := 0.0
+			for i := d - 1; i >= 0; i-- {
+				x := math.Exp(n*l+logVol/n-2*n/(n-1)*float64(i)*l) / w
+				if x > 6 {
+					break
+				}
+				log2P += log2Erf(x)
+			}
+			guess := float64(g)*p.Secret.Entropy + 2*math.Log2(float64(d))
+			return log2Add(model.Cost(beta, d), guess) - log2P
+		}
+		if d < MinBlockSize {
+			return Result{Attack: "hybrid", Cost: math.Inf(1)}
+		}
+		beta := ternaryMin(MinBlockSize, d, cost)
+		return Result{Attack: "hybrid", Beta: beta, Dim: d, M: m, Guess: g, Cost: cost(beta)}
+	}
+	best := Result{Attack: "hybrid", Cost: math.Inf(1)}
+	search := func(mLo, mHi, mStep, gLo, gHi, gStep int) {
+		for m := max(mLo, 1); m <= min(mHi, M); m += mStep {
+			for g := max(gLo, 0); g <= min(gHi, p.N-1); g += gStep {
+				if r := eval(m, g); r.Cost < best.Cost {
+					best = r
+				}
+			}
+		}
+	}
+	mStep, gStep := max(1, M/16), max(1, p.N/32)
+	search(mStep, M, mStep, 0, p.N-1, gStep)
+	if math.IsInf(best.Cost, 1) {
+		return best
+	}
+	m, g := best.M, best.Guess
+	search(m-mStep, m+mStep, max(1, mStep/8), g-gStep, g+gStep, max(1, gStep/8))
+	return best
+}
+
+// Estimate runs all three attacks.
+func Estimate(p Params, model CostModel) []Result {
+	return []Result{Primal(p, model), Dual(p, model), Hybrid(p, model)}
+}
+
+// Best returns the cheapest of the results.
+func Best(rs []Result) Result {
+	b := rs[0]
+	for _, r := range rs[1:] {
+		if r.Cost < b.Cost {
+			b = r
+		}
+	}
+	return b
+}
+
+// ternaryMin returns the integer in [lo, hi] minimizing a unimodal f.
+func ternaryMin(lo, hi int, f func(int) float64) int {
+	for hi-lo > 2 {
+		m1 := lo + (hi-lo)/3
+		m2 := hi - (hi-lo)/3
+		if f(m1) <= f(m2) {
+			hi = m2
+		} else {
+			lo = m1
+		}
+	}
+	best := lo
+	for x := lo + 1; x <= hi; x++ {
+		if f(x) < f(best) {
+			best = x
+		}
+	}
+	return best
+}
+
+// log2Erf returns log2(erf(x)) for x > 0 without underflow for tiny x,
+// where erf(x) ~ 2x/sqrt(pi).
+func log2Erf(x float64) float64 {
+	if x < 1e-8 {
+		return math.Log2(2 * x / math.Sqrt(math.Pi))
+	}
+	return math.Log2(math.Erf(x))
+}
+
+// log2Add returns log2(2^a + 2^b).
+func log2Add(a, b float64) float64 {
+	if a < b {
+		a, b = b, a
+	}
+	return a + math.Log2(1+math.Exp2(b-a))
+}
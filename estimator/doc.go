@@ -0,0 +1,35 @@
+// Package estimator estimates the cost of lattice attacks on LWE parameter
+// sets in the core-SVP methodology.
+//
+// Every attack is reduced to running BKZ with some block size beta on a
+// lattice built from the LWE samples. Under the geometric series assumption
+// BKZ-beta reaches the root-Hermite factor delta(beta) of lattice.BKZDelta,
+// which predicts the whole Gram–Schmidt profile of the reduced basis, and
+// so whether the attack succeeds. The cost of BKZ-beta is then summarized
+// by the cost of a single call to an SVP oracle in dimension beta, for
+// instance 2^(0.292 beta) for classical sieving. This "core-SVP" cost
+// ignores the number of SVP calls and all polynomial factors, so it is a
+// conservative lower bound rather than a running time; it is the number
+// the NIST submissions use to compare themselves.
+//
+// Three attacks are estimated:
+//
+//   - Primal (uSVP): Kannan's embedding of (A, b) contains the unusually
+//     short vector (e, s, 1). Following Alkim, Ducas, Pöppelmann and
+//     Schwabe (ADPS16), BKZ-beta finds it once the projection of that
+//     vector onto the last beta Gram–Schmidt vectors is shorter than the
+//     last beta-th vector of the reduced basis.
+//   - Dual: a short vector v of the dual lattice turns every sample into
+//     <v, b> = <v, e> mod q, which is distinguishable from uniform with
+//     advantage exp(-2 pi^2 (||v|| sigma / q)^2). Short vectors come out
+//     of the sieve 2^(0.2075 beta) at a time, so the attack repeats only
+//     when it needs more than that.
+//   - Hybrid: guess g secret coefficients exhaustively and decode the rest
+//     with BKZ-beta followed by Babai's nearest plane, whose success
+//     probability follows from the same GSA profile (Howgrave-Graham).
+//
+// All costs are log2 of operations. Schemes lists the NIST candidates with
+// their published core-SVP numbers; the primal estimate reproduces those
+// of Kyber, Dilithium and Saber, and the NTRU and FrodoKEM sets are
+// marked as not validated.
+package estimator
@@ -0,0 +1,74 @@
+package estimator
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSchemes(t *testing.T) {
+	for _, s := range Schemes {
+		r, ok := s.Check()
+		switch {
+		case !s.Validated:
+			if ok {
+				t.Errorf("%s: Check passed an unvalidated set", s.Params.Name)
+			}
+			t.Logf("%s: unvalidated, primal estimate 2^%.1f, published 2^%.0f (%+.1f bits)",
+				s.Params.Name, r.Cost, s.Published, r.Cost-s.Published)
+		case !ok:
+			t.Errorf("%s: primal estimate 2^%.1f, published 2^%.0f (tolerance %g)",
+				s.Params.Name, r.Cost, s.Published, Tolerance)
+		}
+		if s.Validated != (s.Family == "Kyber" || s.Family == "Dilithium" || s.Family == "Saber") {
+			t.Errorf("%s: Validated is %v", s.Params.Name, s.Validated)
+		}
+	}
+}
+
+// TestAttacks checks that every attack gives a finite cost that grows
+// with the dimension.
+func TestAttacks(t *testing.T) {
+	attacks := []struct {
+		name   string
+		attack func(Params, CostModel) Result
+	}{{"primal", Primal}, {"dual", Dual}, {"hybrid", Hybrid}}
+	for _, a := range attacks {
+		prev := math.Inf(-1)
+		for _, n := range []int{256, 512, 768, 1024} {
+			p := Params{Name: "test", N: n, M: n, Q: 3329, Secret: CenteredBinomial(2), Error: CenteredBinomial(2)}
+			r := a.attack(p, CoreSVPClassical)
+			if math.IsInf(r.Cost, 0) || math.IsNaN(r.Cost) {
+				t.Fatalf("%s, n = %d: cost %v", a.name, n, r.Cost)
+			}
+			if r.Cost <= prev {
+				t.Errorf("%s: cost 2^%.1f at n = %d, 2^%.1f below", a.name, r.Cost, n, prev)
+			}
+			if r.Beta < MinBlockSize || r.Beta > r.Dim || r.M < 1 || r.M > n {
+				t.Errorf("%s, n = %d: %v out of range", a.name, n, r)
+			}
+			prev = r.Cost
+		}
+	}
+}
+
+// TestBest checks that the best attack on every scheme is never worse
+// than the primal one. The hybrid attack takes about a second a set.
+func TestBest(t *testing.T) {
+	if testing.Short() {
+		t.Skip("hybrid estimates of every scheme")
+	}
+	for _, s := range Schemes {
+		t.Run(s.Params.Name, func(t *testing.T) {
+			t.Parallel()
+			rs := Estimate(s.Params, CoreSVPClassical)
+			if b := Best(rs); b.Cost > rs[0].Cost {
+				t.Errorf("best 2^%.1f above primal 2^%.1f", b.Cost, rs[0].Cost)
+			}
+			for _, r := range rs {
+				if math.IsNaN(r.Cost) || math.IsInf(r.Cost, 0) {
+					t.Errorf("%v", r)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package estimator
+
+import "math"
+
+// CostModel gives log2 of the cost of BKZ with block size beta in a lattice
+// of dimension d.
+type CostModel struct {
+	Name string
+	Cost func(beta, d int) float64
+}
+
+var (
+	// CoreSVPClassical is one call to the classical sieve of Becker, Ducas,
+	// Gama and Laarhoven (BDGL16), 2^(0.292 beta).
+	CoreSVPClassical = CostModel{"core-SVP classical (0.292b)", func(beta, d int) float64 {
+		return 0.292 * float64(beta)
+	}}
+	// CoreSVPQuantum is one call to Laarhoven's quantum sieve,
+	// 2^(0.265 beta).
+	CoreSVPQuantum = CostModel{"core-SVP quantum (0.265b)", func(beta, d int) float64 {
+		return 0.265 * float64(beta)
+	}}
+	// CoreSVPParanoid is the "paranoid" lower bound of ADPS16: the sieve
+	// only needs to produce its 2^(0.2075 beta) output vectors, for free.
+	CoreSVPParanoid = CostModel{"core-SVP paranoid (0.2075b)", func(beta, d int) float64 {
+		return 0.2075 * float64(beta)
+	}}
+	// SieveBDGL16 is the practical classical sieve cost 2^(0.292 beta +
+	// 16.4) times 8d SVP calls for a full BKZ run, the model of Albrecht et
+	// al., "Estimate all the {LWE, NTRU} schemes!".
+	SieveBDGL16 = CostModel{"BDGL16 sieve x 8d calls", func(beta, d int) float64 {
+		return 0.292*float64(beta) + 16.4 + math.Log2(8*float64(d))
+	}}
+)
+
+// Models lists the built-in cost models.
+var Models = []CostModel{CoreSVPClassical, CoreSVPQuantum, CoreSVPParanoid, SieveBDGL16}
@@ -0,0 +1,125 @@
+package estimator
+
+import (
+	"fmt"
+	"math"
+)
+
+// Distribution describes the coefficients of a secret or error by the two
+// numbers the estimates need.
+type Distribution struct {
+	Name string
+	// Variance of a single coefficient.
+	Variance float64
+	// Entropy of a single coefficient in bits, the cost of guessing it in
+	// the hybrid attack.
+	Entropy float64
+}
+
+// Stddev returns the standard deviation of the distribution.
+func (d Distribution) Stddev() float64 { return math.Sqrt(d.Variance) }
+
+func (d Distribution) String() string { return d.Name }
+
+// CenteredBinomial is CBD_eta, the difference of two sums of eta bits, as
+// used by Kyber and Saber. Its variance is eta/2.
+func CenteredBinomial(eta int) Distribution {
+	p := make([]float64, 2*eta+1)
+	for k := 0; k <= 2*eta; k++ {
+		// P(X = k - eta) = C(2 eta, k) / 4^eta
+		lc, _ := math.Lgamma(float64(2*eta + 1))
+		la, _ := math.Lgamma(float64(k + 1))
+		lb, _ := math.Lgamma(float64(2*eta - k + 1))
+		p[k] = math.Exp(lc - la - lb - float64(2*eta)*math.Ln2)
+	}
+	return Distribution{
+		Name:     fmt.Sprintf("CBD(%d)", eta),
+		Variance: float64(eta) / 2,
+		Entropy:  entropy(p),
+	}
+}
+
+// UniformRange is the uniform distribution on the integers [a, b].
+func UniformRange(a, b int) Distribution {
+	k := float64(b - a + 1)
+	mean := float64(a+b) / 2
+	// Variance of a discrete uniform on k points, plus the shift of the
+	// mean away from zero, so that it is E[x^2].
+	return Distribution{
+		Name:     fmt.Sprintf("U[%d,%d]", a, b),
+		Variance: (k*k-1)/12 + mean*mean,
+		Entropy:  math.Log2(k),
+	}
+}
+
+// Gaussian is a rounded or discrete Gaussian of standard deviation sigma.
+func Gaussian(sigma float64) Distribution {
+	return Distribution{
+		Name:     fmt.Sprintf("N(%.3g)", sigma),
+		Variance: sigma * sigma,
+		Entropy:  0.5 * math.Log2(2*math.Pi*math.E*sigma*sigma),
+	}
+}
+
+// FixedWeight is a ternary vector of length n with exactly w non-zero
+// coefficients ±1, as in NTRU-HPS.
+func FixedWeight(n, w int) Distribution {
+	p1 := float64(w) / float64(n)
+	return Distribution{
+		Name:     fmt.Sprintf("T(%d/%d)", w, n),
+		Variance: p1,
+		Entropy:  entropy([]float64{1 - p1, p1 / 2, p1 / 2}),
+	}
+}
+
+// Rounding is the error of rounding from Z_q to Z_p (p | q), as in LWR
+// schemes such as Saber: uniform over q/p consecutive values.
+func Rounding(q, p int) Distribution {
+	k := float64(q / p)
+	return Distribution{
+		Name:     fmt.Sprintf("R(%d->%d)", q, p),
+		Variance: (k*k - 1) / 12,
+		Entropy:  math.Log2(k),
+	}
+}
+
+// Scaled multiplies every coefficient by c, as for NTRU-HRSS where
+// g = (x - 1) g' roughly doubles the variance.
+func (d Distribution) Scaled(name string, c float64) Distribution {
+	return Distribution{Name: name, Variance: c * c * d.Variance, Entropy: d.Entropy + math.Log2(math.Abs(c))}
+}
+
+func entropy(p []float64) float64 {
+	h := 0.0
+	for _, x := range p {
+		if x > 0 {
+			h -= x * math.Log2(x)
+		}
+	}
+	return h
+}
+
+// Params is an LWE instance b = A s + e mod q with A in Z_q^(m x n).
+// Ring and module instances are described by their dimension as plain LWE
+// (n = rank * degree), which is how the core-SVP estimates treat them.
+type Params struct {
+	Name string
+	// N is the secret dimension.
+	N int
+	// M is the number of samples available; 0 means N.
+	M      int
+	Q      float64
+	Secret Distribution
+	Error  Distribution
+}
+
+func (p Params) samples() int {
+	if p.M == 0 {
+		return p.N
+	}
+	return p.M
+}
+
+func (p Params) String() string {
+	return fmt.Sprintf("n=%d m=%d q=%g s~%v e~%v", p.N, p.samples(), p.Q, p.Secret, p.Error)
+}
@@ -0,0 +1,97 @@
+package estimator
+
+import "math"
+
+// Scheme is a published parameter set viewed as an LWE instance, with the
+// classical core-SVP cost of the primal attack claimed in its NIST round-3
+// specification.
+type Scheme struct {
+	Family string
+	Params Params
+	// Published is the claimed classical core-SVP bit security of the
+	// primal attack.
+	Published float64
+	// Validated reports whether Primal with CoreSVPClassical reproduces
+	// Published. It does for Kyber, Dilithium and Saber, whose
+	// specifications use exactly the ADPS16 methodology of Primal. The
+	// NTRU and FrodoKEM specifications do not, their sets are not
+	// modeled closely enough to reproduce the figures, and Check does
+	// not judge them.
+	Validated bool
+}
+
+// Tolerance is how far, in bits, Primal may be from Published for a
+// validated set: the rounding of the published figures and of the block
+// size.
+const Tolerance = 1.5
+
+// Check compares the classical primal estimate with the published figure.
+// It reports false for a set that is not Validated, whatever the
+// estimate; r.Cost - s.Published is then only the raw difference.
+func (s Scheme) Check() (r Result, ok bool) {
+	r = Primal(s.Params, CoreSVPClassical)
+	return r, s.Validated && math.Abs(r.Cost-s.Published) <= Tolerance
+}
+
+func kyber(name string, k, eta int, pub float64) Scheme {
+	n := 256 * k
+	return Scheme{"Kyber", Params{Name: name, N: n, M: n, Q: 3329,
+		Secret: CenteredBinomial(eta), Error: CenteredBinomial(eta)}, pub, true}
+}
+
+// The Module-LWE part of the public key t = A s1 + s2, ignoring the
+// rounding of t that only makes the attack harder.
+func dilithium(name string, k, l, eta int, pub float64) Scheme {
+	u := UniformRange(-eta, eta)
+	return Scheme{"Dilithium", Params{Name: name, N: 256 * l, M: 256 * k, Q: 8380417,
+		Secret: u, Error: u}, pub, true}
+}
+
+// Saber is Module-LWR: the error is the rounding from q = 2^13 to p = 2^10.
+func saber(name string, l, mu int, pub float64) Scheme {
+	n := 256 * l
+	return Scheme{"Saber", Params{Name: name, N: n, M: n, Q: 8192,
+		Secret: CenteredBinomial(mu / 2), Error: Rounding(8192, 1024)}, pub, true}
+}
+
+// NTRU is the n-sample LWE-like problem h f = g mod q (with h scaled by
+// 1/3, which is invertible modulo the power-of-two q). The NTRU
+// specification reduces the NTRU lattice of the key (f, g) itself, with
+// the fixed-weight and (x - 1)-structured g taken into account exactly;
+// Primal only sees a variance-matched error and lands 2 to 8 bits above
+// it, by an amount that varies between the sets, so they are not
+// validated.
+func ntru(name string, n int, q float64, g Distribution, pub float64) Scheme {
+	return Scheme{"NTRU", Params{Name: name, N: n, M: n, Q: q,
+		Secret: UniformRange(-1, 1), Error: g}, pub, false}
+}
+
+// FrodoKEM has n + 8 samples: the n rows of the public key and the 8 of a
+// ciphertext. The error is modeled as a Gaussian of the same deviation as
+// Frodo's table distribution. The FrodoKEM specification's primal figures
+// are 8 to 10 bits above Primal for all three sets; its script differs in
+// ways this package does not reproduce, so they are not validated.
+func frodo(name string, n int, q, sigma, pub float64) Scheme {
+	g := Gaussian(sigma)
+	return Scheme{"Frodo", Params{Name: name, N: n, M: n + 8, Q: q, Secret: g, Error: g}, pub, false}
+}
+
+// Schemes lists the NIST round-3 lattice parameter sets.
+var Schemes = []Scheme{
+	kyber("Kyber512", 2, 3, 118),
+	kyber("Kyber768", 3, 2, 182),
+	kyber("Kyber1024", 4, 2, 256),
+	dilithium("Dilithium2", 4, 4, 2, 123),
+	dilithium("Dilithium3", 6, 5, 4, 182),
+	dilithium("Dilithium5", 8, 7, 2, 252),
+	saber("LightSaber", 2, 10, 118),
+	saber("Saber", 3, 8, 189),
+	saber("FireSaber", 4, 6, 260),
+	ntru("ntruhps2048509", 509, 2048, FixedWeight(509, 2048/8-2), 106),
+	ntru("ntruhps2048677", 677, 2048, FixedWeight(677, 2048/8-2), 145),
+	ntru("ntruhps4096821", 821, 4096, FixedWeight(821, 4096/8-2), 179),
+	ntru("ntruhrss701", 701, 8192, UniformRange(-1, 1).Scaled("(x-1)T", math.Sqrt2), 136),
+	frodo("FrodoKEM-640", 640, 32768, 2.8, 150),
+	frodo("FrodoKEM-976", 976, 65536, 2.3, 216),
+	frodo("FrodoKEM-1344", 1344, 65536, 1.4, 282),
+}
@@ -0,0 +1,28 @@
+// Package falcon implements the Falcon signature scheme (FN-DSA) at its
+// two security levels, Falcon-512 and Falcon-1024.
+//
+// Falcon is a GPV hash-and-sign signature over NTRU lattices in
+// R = Z[x]/(x^n + 1) with q = 12289. The public key is h = g / f mod q for
+// small f and g, and the private key the short basis
+//
+//	B = [[g, -f], [G, -F]],   f G - g F = q,
+//
+// of the lattice {(s1, s2) : s1 + s2 h = 0 mod q}. NTRUSolve finds F and G
+// by descending through the field norms to degree 1, where the equation is
+// a Bezout identity, and Babai-reducing the solution on the way back up.
+//
+// To sign, the message is hashed with a random salt to a point c of R_q,
+// and the signer samples a lattice vector v close to (c, 0) from a
+// discrete Gaussian of width sigma; s = (c, 0) - v is short and satisfies
+// s1 + s2 h = c. The sampler is Klein's randomized nearest plane
+// (gauss.Klein) made fast: the Gram–Schmidt data of B lives in the ffLDL
+// tree, a recursive LDL* decomposition in the FFT domain, and fast Fourier
+// sampling walks it in O(n log n), drawing the integer samples with the
+// FACCT sampler of package gauss. The signature is the salt and s2,
+// compressed with encoding.FalconCompress and padded to a fixed size.
+//
+// Keys are generated from a 32-byte seed, which is also their serialized
+// private form; the resulting keys are not those of the reference
+// implementation for the same seed. The private keys implement
+// crypto.Signer.
+package falcon
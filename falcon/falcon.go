@@ -0,0 +1,350 @@
+package falcon
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/sha3"
+	"crypto/subtle"
+	"errors"
+	"io"
+	"math"
+	"math/bits"
+
+	"github.com/haopining/Learn-Lattice-Based-Cryptography/encoding"
+	"github.com/haopining/Learn-Lattice-Based-Cryptography/gauss"
+)
+
+// SeedSize is the size of the private key seed, and NonceSize that of the
+// random salt r that starts every signature.
+const (
+	SeedSize  = 32
+	NonceSize = 40
+)
+
+// Params is a Falcon parameter set (Falcon specification, Table 3.3).
+type Params struct {
+	Name string
+	N    int
+	// Sigma is the width of the signatures and SigmaMin the smallest width
+	// of the integer samples, which NewPrivateKey enforces on every leaf
+	// of the ffLDL tree; Bound is beta^2, the largest squared norm of a
+	// valid (s1, s2).
+	Sigma, SigmaMin float64
+	Bound           int64
+	// SignatureSize is the length of the padded signatures: header, salt
+	// and compressed s2.
+	SignatureSize int
+}
+
+// The two standard parameter sets.
+var (
+	Falcon512 = &Params{Name: "Falcon-512", N: 512,
+		Sigma: 165.7366171829776, SigmaMin: 1.2778336969128337, Bound: 34034726, SignatureSize: 666}
+	Falcon1024 = &Params{Name: "Falcon-1024", N: 1024,
+		Sigma: 168.38857144654395, SigmaMin: 1.298280334344292, Bound: 70265242, SignatureSize: 1280}
+)
+
+func (p *Params) String() string { return p.Name }
+
+func (p *Params) logn() int { return bits.TrailingZeros(uint(p.N)) }
+
+// PublicKeySize returns 1 + 14 n / 8: a header byte and the 14-bit
+// coefficients of h.
+func (p *Params) PublicKeySize() int { return 1 + 14*p.N/8 }
+
+var (
+	// ErrInvalidSignature is returned by Verify for any signature that
+	// does not verify, whatever the reason.
+	ErrInvalidSignature = errors.New("falcon: invalid signature")
+	errKeySize          = errors.New("falcon: invalid key size")
+)
+
+// PublicKey is a Falcon public key h = g / f mod q.
+type PublicKey struct {
+	p   *Params
+	h   []uint32
+	raw []byte
+}
+
+// PrivateKey is a Falcon private key, kept with its seed. Besides the NTRU
+// basis B = [[g, -f], [G, -F]] it holds the ffLDL tree of B B*, computed
+// once.
+type PrivateKey struct {
+	seed [SeedSize]byte
+	// The basis in the FFT domain.
+	b00, b01, b10, b11 fftPoly
+	tree               *tree
+	pub                *PublicKey
+}
+
+// GenerateKey returns a key for p from a fresh seed.
+func GenerateKey(p *Params) (*PrivateKey, error) {
+	seed := make([]byte, SeedSize)
+	if _, err := io.ReadFull(rand.Reader, seed); err != nil {
+		return nil, err
+	}
+	return NewPrivateKey(p, seed)
+}
+
+// NewPrivateKey derives a key from a seed, which drives the samples of
+// NTRUGen through SHAKE256. Expect this to take up to a few seconds for
+// Falcon-1024: NTRUSolve works with integers of thousands of bits.
+func NewPrivateKey(p *Params, seed []byte) (*PrivateKey, error) {
+	if len(seed) != SeedSize {
+		return nil, errKeySize
+	}
+	sk := &PrivateKey{}
+	copy(sk.seed[:], seed)
+	r := gauss.NewSHAKE(seed)
+	for {
+		f, g, F, G := ntruGen(p.N, r)
+		// B = [[g, -f], [G, -F]] and its Gram matrix B B*, whose ffLDL
+		// tree drives the sampler; the leaves hold sigma / sqrt(D).
+		sk.b00, sk.b01 = fftInts(g), fftInts(f).scale(-1)
+		sk.b10, sk.b11 = fftInts(G), fftInts(F).scale(-1)
+		g00 := sk.b00.mul(sk.b00.adj()).add(sk.b01.mul(sk.b01.adj()))
+		g01 := sk.b00.mul(sk.b10.adj()).add(sk.b01.mul(sk.b11.adj()))
+		g11 := sk.b10.mul(sk.b10.adj()).add(sk.b11.mul(sk.b11.adj()))
+		sk.tree = ffLDL(g00, g01, g11, p.Sigma)
+		// A leaf narrower than SigmaMin means a Gram–Schmidt vector too
+		// long for the signatures to hide the basis; the bound of ntruGen
+		// should rule it out, but the key is drawn again if not.
+		if sk.tree.minSigma() < p.SigmaMin {
+			continue
+		}
+		h, _ := divq(modq(g), modq(f))
+		sk.pub = newPublicKey(p, h)
+		return sk, nil
+	}
+}
+
+func newPublicKey(p *Params, h []uint32) *PublicKey {
+	raw := []byte{byte(p.logn())}
+	var acc uint64
+	var n int
+	for _, c := range h {
+		acc = acc<<14 | uint64(c)
+		for n += 14; n >= 8; n -= 8 {
+			raw = append(raw, byte(acc>>(n-8)))
+		}
+	}
+	return &PublicKey{p: p, h: h, raw: raw}
+}
+
+// NewPublicKey parses an encoded public key: the header byte log2(n)
+// followed by the coefficients of h in 14 bits each, most significant bit
+// first. Coefficients >= q are rejected.
+func NewPublicKey(p *Params, b []byte) (*PublicKey, error) {
+	if len(b) != p.PublicKeySize() || b[0] != byte(p.logn()) {
+		return nil, errKeySize
+	}
+	h := make([]uint32, p.N)
+	var acc uint64
+	var n, k int
+	for _, x := range b[1:] {
+		acc = acc<<8 | uint64(x)
+		if n += 8; n >= 14 {
+			n -= 14
+			h[k] = uint32(acc>>n) & (1<<14 - 1)
+			if h[k] >= Q {
+				return nil, errors.New("falcon: public key coefficient out of range")
+			}
+			k++
+		}
+	}
+	return newPublicKey(p, h), nil
+}
+
+// Params returns the parameter set of the key.
+func (pk *PublicKey) Params() *Params { return pk.p }
+
+// Bytes returns the encoded key.
+func (pk *PublicKey) Bytes() []byte { return append([]byte(nil), pk.raw...) }
+
+// Equal reports whether pk and x are the same key.
+func (pk *PublicKey) Equal(x crypto.PublicKey) bool {
+	xx, ok := x.(*PublicKey)
+	return ok && pk.p == xx.p && subtle.ConstantTimeCompare(pk.raw, xx.raw) == 1
+}
+
+// Params returns the parameter set of the key.
+func (sk *PrivateKey) Params() *Params { return sk.pub.p }
+
+// Bytes returns the seed.
+func (sk *PrivateKey) Bytes() []byte { return append([]byte(nil), sk.seed[:]...) }
+
+// PublicKey returns the public key.
+func (sk *PrivateKey) PublicKey() *PublicKey { return sk.pub }
+
+// Public returns the public key, for crypto.Signer.
+func (sk *PrivateKey) Public() crypto.PublicKey { return sk.pub }
+
+// Equal reports whether sk and x are the same key, derived from the same
+// seed.
+func (sk *PrivateKey) Equal(x crypto.PrivateKey) bool {
+	xx, ok := x.(*PrivateKey)
+	return ok && sk.pub.p == xx.pub.p && subtle.ConstantTimeCompare(sk.seed[:], xx.seed[:]) == 1
+}
+
+// tree is a node of the ffLDL tree: the L factor l10 of the LDL*
+// decomposition of a 2x2 Gram matrix, and the trees of the two halves of
+// its diagonal. A leaf only holds the width sigma / sqrt(D) of its integer
+// sample.
+type tree struct {
+	l10         fftPoly
+	left, right *tree
+	sigma       float64
+}
+
+// ffLDL returns the ffLDL tree of the self-adjoint matrix [[g00, g01],
+// [g01*, g11]] (Falcon specification, Algorithm 9). G = L D L* with
+// L = [[1, 0], [l10, 1]], l10 = g01* / g00, and D = diag(g00, g11 - l10
+// l10* g00); each diagonal entry d, split as d(x) = d0(x^2) + x d1(x^2),
+// is itself the Gram matrix [[d0, d1], [d1*, d0]] of half the degree.
+func ffLDL(g00, g01, g11 fftPoly, sigma float64) *tree {
+	l10 := g01.adj().div(g00)
+	d11 := g11.sub(l10.mul(l10.adj()).mul(g00))
+	return &tree{l10: l10, left: ffChild(g00, sigma), right: ffChild(d11, sigma)}
+}
+
+// minSigma returns the smallest leaf width of the tree.
+func (t *tree) minSigma() float64 {
+	if t.left == nil {
+		return t.sigma
+	}
+	return math.Min(t.left.minSigma(), t.right.minSigma())
+}
+
+func ffChild(d fftPoly, sigma float64) *tree {
+	if len(d) == 1 {
+		return &tree{sigma: sigma / math.Sqrt(real(d[0]))}
+	}
+	d0, d1 := splitFFT(d)
+	return ffLDL(d0, d1, d0, sigma)
+}
+
+// ffSampling returns z close to t, drawn from the discrete Gaussian over
+// Z^2n centered at t with the covariance of the tree (Falcon
+// specification, Algorithm 11). It is Klein's randomized nearest plane
+// over the Gram–Schmidt basis the tree describes, run as a recursion on
+// halves as the FFT is: z1 first, then t0 moved by (t1 - z1) l10, then z0.
+func ffSampling(t0, t1 fftPoly, t *tree, r *gauss.Rand) (z0, z1 fftPoly) {
+	z1 = ffSampleChild(t1, t.right, r)
+	t0 = t0.add(t1.sub(z1).mul(t.l10))
+	z0 = ffSampleChild(t0, t.left, r)
+	return z0, z1
+}
+
+func ffSampleChild(t fftPoly, c *tree, r *gauss.Rand) fftPoly {
+	if c.left == nil {
+		s, err := gauss.NewFACCT(c.sigma, real(t[0]), r)
+		if err != nil {
+			panic(err)
+		}
+		return fftPoly{complex(float64(s.Sample()), 0)}
+	}
+	t0, t1 := splitFFT(t)
+	z0, z1 := ffSampling(t0, t1, c, r)
+	return mergeFFT(z0, z1)
+}
+
+// hashToPoint hashes salt || message to a uniform c in R_q (Falcon
+// specification, Algorithm 3): 16-bit big-endian values from SHAKE256,
+// kept if below 5 q so that reducing them modulo q introduces no bias.
+func hashToPoint(n int, salt, message []byte) []uint32 {
+	h := sha3.NewSHAKE256()
+	h.Write(salt)
+	h.Write(message)
+	c := make([]uint32, 0, n)
+	var buf [2]byte
+	for len(c) < n {
+		h.Read(buf[:])
+		if t := uint32(buf[0])<<8 | uint32(buf[1]); t < 5*Q {
+			c = append(c, t%Q)
+		}
+	}
+	return c
+}
+
+// Sign implements crypto.Signer. Falcon hashes the message itself, so
+// message is the full message and opts must be nil or have HashFunc() ==
+// 0. The salt and the Gaussian samples are drawn from rand, or from
+// crypto/rand if rand is nil.
+func (sk *PrivateKey) Sign(rand io.Reader, message []byte, opts crypto.SignerOpts) ([]byte, error) {
+	if opts != nil && opts.HashFunc() != 0 {
+		return nil, errors.New("falcon: cannot sign a pre-hashed message")
+	}
+	return sk.sign(message, gauss.NewRand(rand)), nil
+}
+
+// sign is the Falcon signing algorithm (Falcon specification, Algorithm
+// 10). The target (c, 0) is written in the basis B as t = (c, 0) B^-1 =
+// (-c F / q, c f / q), ffSampling finds an integer z near t, and
+// s = (c, 0) - z B is a short vector with s1 + s2 h = c mod q. Only s2 is
+// sent; an s that is too long, or does not compress into the fixed
+// signature size, is thrown away and signing restarts with a fresh salt,
+// as in the reference implementation, so that no two attempts share c.
+func (sk *PrivateKey) sign(message []byte, r *gauss.Rand) []byte {
+	p := sk.pub.p
+	salt := make([]byte, NonceSize)
+	for {
+		for i := range salt {
+			salt[i] = byte(r.Uint64())
+		}
+		c := hashToPoint(p.N, salt, message)
+		cf := make([]float64, p.N)
+		for i, x := range c {
+			cf[i] = float64(x)
+		}
+		ch := fft(cf)
+		t0 := ch.mul(sk.b11).scale(1.0 / Q)
+		t1 := ch.mul(sk.b01).scale(-1.0 / Q)
+		z0, z1 := ffSampling(t0, t1, sk.tree, r)
+		v0 := ifft(z0.mul(sk.b00).add(z1.mul(sk.b10)))
+		v1 := ifft(z0.mul(sk.b01).add(z1.mul(sk.b11)))
+		s2 := make([]int16, p.N)
+		var norm int64
+		for i := range s2 {
+			s1 := int64(c[i]) - int64(math.Round(v0[i]))
+			s2[i] = int16(-math.Round(v1[i]))
+			norm += s1*s1 + int64(s2[i])*int64(s2[i])
+		}
+		if norm > p.Bound {
+			continue
+		}
+		enc, err := encoding.FalconCompress(s2, p.SignatureSize-1-NonceSize)
+		if err != nil {
+			continue
+		}
+		sig := append([]byte{0x30 + byte(p.logn())}, salt...)
+		return append(sig, enc...)
+	}
+}
+
+// Verify reports whether sig is a valid signature of message (Falcon
+// specification, Algorithm 16): s1 = c - s2 h mod q, and (s1, s2) must be
+// no longer than beta.
+func Verify(pk *PublicKey, message, sig []byte) error {
+	p := pk.p
+	if len(sig) != p.SignatureSize || sig[0] != 0x30+byte(p.logn()) {
+		return ErrInvalidSignature
+	}
+	s2, err := encoding.FalconDecompress(sig[1+NonceSize:], p.N)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	c := hashToPoint(p.N, sig[1:1+NonceSize], message)
+	s2h := mulPolyq(modq(s2), pk.h)
+	var norm int64
+	for i := range c {
+		s1 := int64((c[i] + Q - s2h[i]) % Q)
+		if s1 > Q/2 {
+			s1 -= Q
+		}
+		norm += s1*s1 + int64(s2[i])*int64(s2[i])
+	}
+	if norm > p.Bound {
+		return ErrInvalidSignature
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package falcon
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/sha3"
+	"errors"
+	"testing"
+)
+
+var testKeys = map[*Params]*PrivateKey{}
+
+// testKey derives a key from a fixed seed, once per parameter set.
+// Falcon-1024 key generation takes a few seconds, so -short keeps to
+// Falcon-512.
+func testKey(t *testing.T, p *Params) *PrivateKey {
+	t.Helper()
+	if p == Falcon1024 && testing.Short() {
+		t.Skip("Falcon-1024 key generation is slow")
+	}
+	if sk, ok := testKeys[p]; ok {
+		return sk
+	}
+	seed := bytes.Repeat([]byte{byte(p.N >> 8)}, SeedSize)
+	sk, err := NewPrivateKey(p, seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testKeys[p] = sk
+	return sk
+}
+
+// TestSignVerify signs through crypto.Signer and checks that a wrong
+// message, a modified salt or s2 and a truncated or padded signature are
+// all rejected.
+func TestSignVerify(t *testing.T) {
+	for _, p := range []*Params{Falcon512, Falcon1024} {
+		t.Run(p.Name, func(t *testing.T) {
+			sk := testKey(t, p)
+			var signer crypto.Signer = sk
+			pk := signer.Public().(*PublicKey)
+			msg := []byte("message")
+			sig, err := signer.Sign(nil, msg, crypto.Hash(0))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(sig) != p.SignatureSize {
+				t.Errorf("signature is %d bytes, want %d", len(sig), p.SignatureSize)
+			}
+			if err := Verify(pk, msg, sig); err != nil {
+				t.Fatal(err)
+			}
+			salt := bytes.Clone(sig)
+			salt[1] ^= 1
+			body := bytes.Clone(sig)
+			body[1+NonceSize] ^= 0x40
+			for name, c := range map[string]struct{ msg, sig []byte }{
+				"wrong message": {[]byte("massage"), sig},
+				"modified salt": {msg, salt},
+				"modified s2":   {msg, body},
+				"truncated":     {msg, sig[:len(sig)-1]},
+				"padded":        {msg, append(bytes.Clone(sig), 0)},
+				"empty":         {msg, nil},
+			} {
+				if err := Verify(pk, c.msg, c.sig); !errors.Is(err, ErrInvalidSignature) {
+					t.Errorf("%s: Verify = %v, want ErrInvalidSignature", name, err)
+				}
+			}
+			if _, err := sk.Sign(nil, msg, crypto.SHA256); err == nil {
+				t.Error("Sign accepted a pre-hashed message")
+			}
+		})
+	}
+}
+
+// TestSigmaMin checks that every leaf of the ffLDL tree is at least
+// SigmaMin wide, which is the condition sigma / ||b~_i|| >= SigmaMin of the
+// specification, and that the tree has one leaf per coordinate.
+func TestSigmaMin(t *testing.T) {
+	for _, p := range []*Params{Falcon512, Falcon1024} {
+		t.Run(p.Name, func(t *testing.T) {
+			sk := testKey(t, p)
+			var leaves func(*tree) int
+			leaves = func(n *tree) int {
+				if n.left == nil {
+					if n.sigma < p.SigmaMin || n.sigma > p.Sigma {
+						t.Errorf("leaf width %v outside [SigmaMin, Sigma]", n.sigma)
+					}
+					return 1
+				}
+				return leaves(n.left) + leaves(n.right)
+			}
+			if got := leaves(sk.tree); got != 2*p.N {
+				t.Errorf("tree has %d leaves, want %d", got, 2*p.N)
+			}
+			if m := sk.tree.minSigma(); m < p.SigmaMin {
+				t.Errorf("minSigma = %v < SigmaMin = %v", m, p.SigmaMin)
+			}
+		})
+	}
+}
+
+// TestKeys checks that keys derive deterministically from the seed and
+// round-trip through their encodings.
+func TestKeys(t *testing.T) {
+	sk := testKey(t, Falcon512)
+	sk2, err := NewPrivateKey(Falcon512, sk.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sk.Equal(sk2) || !sk.PublicKey().Equal(sk2.Public()) {
+		t.Error("the same seed gave a different key")
+	}
+	pk, err := NewPublicKey(Falcon512, sk.PublicKey().Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pk.Equal(sk.PublicKey()) || len(pk.Bytes()) != Falcon512.PublicKeySize() {
+		t.Error("public key does not round-trip")
+	}
+	if _, err := NewPublicKey(Falcon512, pk.Bytes()[1:]); err == nil {
+		t.Error("NewPublicKey accepted a short key")
+	}
+	if _, err := NewPrivateKey(Falcon512, sk.Bytes()[1:]); err == nil {
+		t.Error("NewPrivateKey accepted a short seed")
+	}
+}
+
+// TestSignRandomness checks that signing is a function of the random
+// source: the same stream gives the same signature, and fresh randomness a
+// fresh salt.
+func TestSignRandomness(t *testing.T) {
+	sk := testKey(t, Falcon512)
+	stream := func() *sha3.SHAKE {
+		h := sha3.NewSHAKE256()
+		h.Write([]byte("falcon"))
+		return h
+	}
+	msg := []byte("message")
+	a, _ := sk.Sign(stream(), msg, nil)
+	b, _ := sk.Sign(stream(), msg, nil)
+	c, _ := sk.Sign(nil, msg, nil)
+	if !bytes.Equal(a, b) {
+		t.Error("the same random stream gave different signatures")
+	}
+	if bytes.Equal(a[1:1+NonceSize], c[1:1+NonceSize]) {
+		t.Error("two signatures share a salt")
+	}
+	for _, s := range [][]byte{a, c} {
+		if err := Verify(sk.PublicKey(), msg, s); err != nil {
+			t.Error(err)
+		}
+	}
+}
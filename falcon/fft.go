@@ -0,0 +1,165 @@
+package falcon
+
+import (
+	"math"
+	"math/cmplx"
+)
+
+// A real polynomial f of degree < n in Q[x]/(x^n + 1) is represented in
+// the FFT domain by its values at the n roots of x^n + 1, ordered so that
+// positions 2i and 2i+1 hold a root zeta and its opposite -zeta, and
+// zeta^2 is root i of x^(n/2) + 1. Writing f(x) = f0(x^2) + x f1(x^2),
+//
+//	f(zeta) = f0(zeta^2) + zeta f1(zeta^2),   f(-zeta) = f0(zeta^2) - zeta f1(zeta^2),
+//
+// so splitting f into f0 and f1, and merging them back, are one butterfly
+// per pair in the FFT domain. This is the recursive structure the ffLDL
+// tree and fast Fourier sampling are built on.
+
+// fftPoly is a polynomial in the FFT domain.
+type fftPoly []complex128
+
+// rootAngles returns the angles, in units of pi, of the roots of x^n + 1
+// in the order above: -1 for n = 1, then both square roots of each root of
+// the half size.
+func rootAngles(n int) []float64 {
+	if n == 1 {
+		return []float64{1}
+	}
+	half := rootAngles(n / 2)
+	a := make([]float64, n)
+	for i, t := range half {
+		a[2*i] = t / 2
+		a[2*i+1] = t/2 + 1
+	}
+	return a
+}
+
+// roots[n] lists the roots of x^n + 1, for every power of two up to 1024.
+var roots = func() map[int][]complex128 {
+	m := make(map[int][]complex128)
+	for n := 1; n <= 1024; n *= 2 {
+		r := make([]complex128, n)
+		for i, t := range rootAngles(n) {
+			r[i] = complex(math.Cos(math.Pi*t), math.Sin(math.Pi*t))
+		}
+		m[n] = r
+	}
+	return m
+}()
+
+// fft returns the FFT representation of the real coefficients f.
+func fft(f []float64) fftPoly {
+	n := len(f)
+	if n == 1 {
+		return fftPoly{complex(f[0], 0)}
+	}
+	f0 := make([]float64, n/2)
+	f1 := make([]float64, n/2)
+	for i := range f0 {
+		f0[i], f1[i] = f[2*i], f[2*i+1]
+	}
+	return mergeFFT(fft(f0), fft(f1))
+}
+
+// ifft returns the real coefficients of f, dropping the imaginary parts
+// left by rounding.
+func ifft(f fftPoly) []float64 {
+	n := len(f)
+	if n == 1 {
+		return []float64{real(f[0])}
+	}
+	f0, f1 := splitFFT(f)
+	c0, c1 := ifft(f0), ifft(f1)
+	c := make([]float64, n)
+	for i := range c0 {
+		c[2*i], c[2*i+1] = c0[i], c1[i]
+	}
+	return c
+}
+
+// splitFFT returns f0 and f1 with f(x) = f0(x^2) + x f1(x^2).
+func splitFFT(f fftPoly) (f0, f1 fftPoly) {
+	n := len(f)
+	r := roots[n]
+	f0 = make(fftPoly, n/2)
+	f1 = make(fftPoly, n/2)
+	for i := range f0 {
+		a, b := f[2*i], f[2*i+1]
+		f0[i] = (a + b) / 2
+		f1[i] = (a - b) / (2 * r[2*i])
+	}
+	return f0, f1
+}
+
+// mergeFFT is the inverse of splitFFT.
+func mergeFFT(f0, f1 fftPoly) fftPoly {
+	n := 2 * len(f0)
+	r := roots[n]
+	f := make(fftPoly, n)
+	for i := range f0 {
+		t := r[2*i] * f1[i]
+		f[2*i] = f0[i] + t
+		f[2*i+1] = f0[i] - t
+	}
+	return f
+}
+
+func fftInts[T int16 | int32 | int64](f []T) fftPoly {
+	c := make([]float64, len(f))
+	for i, x := range f {
+		c[i] = float64(x)
+	}
+	return fft(c)
+}
+
+func (f fftPoly) add(g fftPoly) fftPoly {
+	h := make(fftPoly, len(f))
+	for i := range h {
+		h[i] = f[i] + g[i]
+	}
+	return h
+}
+
+func (f fftPoly) sub(g fftPoly) fftPoly {
+	h := make(fftPoly, len(f))
+	for i := range h {
+		h[i] = f[i] - g[i]
+	}
+	return h
+}
+
+func (f fftPoly) mul(g fftPoly) fftPoly {
+	h := make(fftPoly, len(f))
+	for i := range h {
+		h[i] = f[i] * g[i]
+	}
+	return h
+}
+
+func (f fftPoly) div(g fftPoly) fftPoly {
+	h := make(fftPoly, len(f))
+	for i := range h {
+		h[i] = f[i] / g[i]
+	}
+	return h
+}
+
+// adj returns the adjoint f*(x) = f(1/x), whose values are the complex
+// conjugates of those of f.
+func (f fftPoly) adj() fftPoly {
+	h := make(fftPoly, len(f))
+	for i := range h {
+		h[i] = cmplx.Conj(f[i])
+	}
+	return h
+}
+
+// scale returns c f.
+func (f fftPoly) scale(c complex128) fftPoly {
+	h := make(fftPoly, len(f))
+	for i := range h {
+		h[i] = c * f[i]
+	}
+	return h
+}
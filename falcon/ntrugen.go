@@ -0,0 +1,262 @@
+package falcon
+
+import (
+	"math"
+	"math/big"
+
+	"github.com/haopining/Learn-Lattice-Based-Cryptography/gauss"
+)
+
+// bigPoly is a polynomial of Z[x]/(x^n + 1) with arbitrary-precision
+// coefficients, for NTRUSolve, whose intermediate values grow to thousands
+// of bits.
+type bigPoly []*big.Int
+
+func newBigPoly(n int) bigPoly {
+	p := make(bigPoly, n)
+	for i := range p {
+		p[i] = new(big.Int)
+	}
+	return p
+}
+
+func bigFromInts(f []int64) bigPoly {
+	p := make(bigPoly, len(f))
+	for i, x := range f {
+		p[i] = big.NewInt(x)
+	}
+	return p
+}
+
+// mul returns a b in Z[x]/(x^n + 1): the full product by Karatsuba,
+// folded negacyclically.
+func (a bigPoly) mul(b bigPoly) bigPoly {
+	n := len(a)
+	full := karatsuba(a, b)
+	c := newBigPoly(n)
+	for k, x := range full {
+		if k < n {
+			c[k].Add(c[k], x)
+		} else {
+			c[k-n].Sub(c[k-n], x)
+		}
+	}
+	return c
+}
+
+// karatsuba returns the 2n-1 coefficients of a b, for len(a) = len(b) = n,
+// with three half-size products instead of four, as poly.Karatsuba does
+// modulo q.
+func karatsuba(a, b bigPoly) bigPoly {
+	n := len(a)
+	c := newBigPoly(2*n - 1)
+	if n <= 16 || n%2 == 1 {
+		t := new(big.Int)
+		for i, ai := range a {
+			for j, bj := range b {
+				c[i+j].Add(c[i+j], t.Mul(ai, bj))
+			}
+		}
+		return c
+	}
+	h := n / 2
+	sum := func(x bigPoly) bigPoly {
+		s := newBigPoly(h)
+		for i := range s {
+			s[i].Add(x[i], x[i+h])
+		}
+		return s
+	}
+	lo := karatsuba(a[:h], b[:h])
+	hi := karatsuba(a[h:], b[h:])
+	mid := karatsuba(sum(a), sum(b))
+	for i := range mid {
+		mid[i].Sub(mid[i], lo[i])
+		mid[i].Sub(mid[i], hi[i])
+		c[i].Add(c[i], lo[i])
+		c[i+h].Add(c[i+h], mid[i])
+		c[i+n].Add(c[i+n], hi[i])
+	}
+	return c
+}
+
+// fieldNorm returns N(f) = f0^2 - x f1^2 for f(x) = f0(x^2) + x f1(x^2),
+// the norm of f from Q[x]/(x^n + 1) down to Q[x]/(x^(n/2) + 1): the
+// product of f(x) and its Galois conjugate f(-x), as a polynomial in x^2.
+func (f bigPoly) fieldNorm() bigPoly {
+	h := len(f) / 2
+	f0, f1 := make(bigPoly, h), make(bigPoly, h)
+	for i := range h {
+		f0[i], f1[i] = f[2*i], f[2*i+1]
+	}
+	s0, s1 := f0.mul(f0), f1.mul(f1)
+	// x f1^2 wraps its top coefficient around with a minus sign.
+	r := newBigPoly(h)
+	r[0].Add(s0[0], s1[h-1])
+	for i := 1; i < h; i++ {
+		r[i].Sub(s0[i], s1[i-1])
+	}
+	return r
+}
+
+// lift returns f(x^2), of twice the degree.
+func (f bigPoly) lift() bigPoly {
+	r := newBigPoly(2 * len(f))
+	for i, x := range f {
+		r[2*i].Set(x)
+	}
+	return r
+}
+
+// conj returns the Galois conjugate f(-x).
+func (f bigPoly) conj() bigPoly {
+	r := newBigPoly(len(f))
+	for i, x := range f {
+		if i%2 == 1 {
+			r[i].Neg(x)
+		} else {
+			r[i].Set(x)
+		}
+	}
+	return r
+}
+
+func (f bigPoly) maxBits() int {
+	m := 0
+	for _, x := range f {
+		m = max(m, x.BitLen())
+	}
+	return m
+}
+
+// approx returns the coefficients of f shifted right by s bits, as floats.
+func (f bigPoly) approx(s int) []float64 {
+	c := make([]float64, len(f))
+	t := new(big.Int)
+	for i, x := range f {
+		c[i] = float64(t.Rsh(x, uint(s)).Int64())
+	}
+	return c
+}
+
+// ntruSolve finds F and G with f G - g F = q (Falcon specification,
+// Algorithm 6), by the recursion of Pornin and Prest: solve the equation
+// for the field norms N(f), N(g) in half the degree, lift the solution
+// F', G' back as F = F'(x^2) g(-x) and G = G'(x^2) f(-x), and reduce it
+// against (f, g). At degree 1 it is the extended Euclidean algorithm,
+// which fails when gcd(f, g) != 1.
+func ntruSolve(f, g bigPoly) (F, G bigPoly, ok bool) {
+	if len(f) == 1 {
+		u, v := new(big.Int), new(big.Int)
+		d := new(big.Int).GCD(u, v, f[0], g[0])
+		if d.Cmp(big.NewInt(1)) != 0 {
+			return nil, nil, false
+		}
+		// u f + v g = 1, so f (q u) - g (-q v) = q.
+		q := big.NewInt(Q)
+		return bigPoly{v.Neg(v.Mul(v, q))}, bigPoly{u.Mul(u, q)}, true
+	}
+	Fp, Gp, ok := ntruSolve(f.fieldNorm(), g.fieldNorm())
+	if !ok {
+		return nil, nil, false
+	}
+	F = Fp.lift().mul(g.conj())
+	G = Gp.lift().mul(f.conj())
+	reduce(f, g, F, G)
+	return F, G, true
+}
+
+// reduce subtracts k (f, g) from (F, G) for k = round((F f* + G g*) /
+// (f f* + g g*)), Babai's round-off against the basis vector (f, g),
+// until (F, G) is no larger than (f, g). The quotient is computed in the
+// FFT domain on the top 53 bits of the coefficients; a large (F, G) takes
+// several rounds, each removing about 53 - log2(n) bits.
+func reduce(f, g, F, G bigPoly) {
+	size := max(53, f.maxBits(), g.maxBits())
+	fa := fft(f.approx(size - 53))
+	ga := fft(g.approx(size - 53))
+	den := fa.mul(fa.adj()).add(ga.mul(ga.adj()))
+	for {
+		Size := max(53, F.maxBits(), G.maxBits())
+		if Size < size {
+			return
+		}
+		Fa := fft(F.approx(Size - 53))
+		Ga := fft(G.approx(Size - 53))
+		num := Fa.mul(fa.adj()).add(Ga.mul(ga.adj()))
+		kf := ifft(num.div(den))
+		k := newBigPoly(len(f))
+		zero := true
+		for i, x := range kf {
+			k[i].SetInt64(int64(math.Round(x)))
+			zero = zero && k[i].Sign() == 0
+		}
+		if zero {
+			return
+		}
+		fk, gk := f.mul(k), g.mul(k)
+		for i := range F {
+			F[i].Sub(F[i], fk[i].Lsh(fk[i], uint(Size-size)))
+			G[i].Sub(G[i], gk[i].Lsh(gk[i], uint(Size-size)))
+		}
+	}
+}
+
+// gsNorm returns the squared norm of the longer Gram–Schmidt vector of
+// the NTRU basis [[g, -f], [G, -F]], computed from f and g alone: the
+// first vector is (g, -f) and the second (q f*/(f f* + g g*),
+// q g*/(f f* + g g*)) in the FFT domain.
+func gsNorm(f, g []int64) float64 {
+	n := float64(len(f))
+	var a float64
+	for i := range f {
+		a += float64(f[i]*f[i] + g[i]*g[i])
+	}
+	ff, gf := fftInts(f), fftInts(g)
+	den := ff.mul(ff.adj()).add(gf.mul(gf.adj()))
+	var b float64
+	for i := range den {
+		// |q f*(z)/den(z)|^2 + |q g*(z)/den(z)|^2 = q^2/den(z); by
+		// Parseval, the squared norm is the mean of the squared values.
+		b += Q * Q / real(den[i])
+	}
+	return max(a, b/n)
+}
+
+// ntruGen returns a Falcon basis (f, g, F, G) drawn from r (Falcon
+// specification, Algorithm 5): f and g come from D_{Z,sigma_fg} with
+// sigma_fg = 1.17 sqrt(q / 2n), and are kept only if the basis they make
+// is short enough for signing, f is invertible modulo q, and NTRUSolve
+// succeeds with a solution that fits in 32 bits.
+func ntruGen(n int, r *gauss.Rand) (f, g, F, G []int64) {
+	sigma := 1.17 * math.Sqrt(Q/float64(2*n))
+	z, err := gauss.NewFACCT(sigma, 0, r)
+	if err != nil {
+		panic(err)
+	}
+	sample := func() []int64 {
+		p := make([]int64, n)
+		for i := range p {
+			p[i] = z.Sample()
+		}
+		return p
+	}
+	for {
+		f, g = sample(), sample()
+		if gsNorm(f, g) > 1.17*1.17*Q {
+			continue
+		}
+		if _, ok := divq(modq(g), modq(f)); !ok {
+			continue
+		}
+		bF, bG, ok := ntruSolve(bigFromInts(f), bigFromInts(g))
+		if !ok || bF.maxBits() > 31 || bG.maxBits() > 31 {
+			continue
+		}
+		F, G = make([]int64, n), make([]int64, n)
+		for i := range F {
+			F[i], G[i] = bF[i].Int64(), bG[i].Int64()
+		}
+		return f, g, F, G
+	}
+}
@@ -0,0 +1,112 @@
+package falcon
+
+import "math/bits"
+
+// Q is the Falcon modulus, 12289 = 3 * 2^12 + 1. Since 2048 divides q - 1,
+// x^n + 1 splits into linear factors modulo q for n up to 1024, and
+// R_q = Z_q[x]/(x^n + 1) has a complete NTT like ML-DSA's.
+const Q = 12289
+
+func mulq(a, b uint32) uint32 { return a * b % Q }
+
+func powq(a, e uint32) uint32 {
+	r := uint32(1)
+	for ; e > 0; e >>= 1 {
+		if e&1 == 1 {
+			r = mulq(r, a)
+		}
+		a = mulq(a, a)
+	}
+	return r
+}
+
+// zetas returns psi^bitrev(k) for k < n, psi being a primitive 2n-th root
+// of unity: 11 generates Z_q^*, so 11^((q-1)/2n) has order exactly 2n.
+func zetas(n int) []uint32 {
+	psi := powq(11, uint32((Q-1)/(2*n)))
+	logn := bits.TrailingZeros(uint(n))
+	z := make([]uint32, n)
+	for k := range z {
+		r := bits.Reverse32(uint32(k)) >> (32 - logn)
+		z[k] = powq(psi, r)
+	}
+	return z
+}
+
+// nttq evaluates f at the roots of x^n + 1, in place.
+func nttq(f []uint32) {
+	n := len(f)
+	z := zetas(n)
+	m := 0
+	for l := n / 2; l >= 1; l /= 2 {
+		for start := 0; start < n; start += 2 * l {
+			m++
+			for j := start; j < start+l; j++ {
+				t := mulq(z[m], f[j+l])
+				f[j+l] = (f[j] + Q - t) % Q
+				f[j] = (f[j] + t) % Q
+			}
+		}
+	}
+}
+
+// invNTTq is the inverse of nttq, in place.
+func invNTTq(f []uint32) {
+	n := len(f)
+	z := zetas(n)
+	m := n
+	for l := 1; l < n; l *= 2 {
+		for start := 0; start < n; start += 2 * l {
+			m--
+			zi := Q - z[m]
+			for j := start; j < start+l; j++ {
+				t := f[j]
+				f[j] = (t + f[j+l]) % Q
+				f[j+l] = mulq(zi, (t+Q-f[j+l])%Q)
+			}
+		}
+	}
+	ninv := powq(uint32(n), Q-2)
+	for i := range f {
+		f[i] = mulq(f[i], ninv)
+	}
+}
+
+// modq maps signed coefficients to [0, q).
+func modq[T int16 | int32 | int64](f []T) []uint32 {
+	r := make([]uint32, len(f))
+	for i, x := range f {
+		r[i] = uint32((int64(x)%Q + Q) % Q)
+	}
+	return r
+}
+
+// divq returns g / f in R_q, or false if f is not invertible, that is if
+// one of its NTT values is zero.
+func divq(g, f []uint32) ([]uint32, bool) {
+	fh := append([]uint32(nil), f...)
+	gh := append([]uint32(nil), g...)
+	nttq(fh)
+	nttq(gh)
+	for i := range fh {
+		if fh[i] == 0 {
+			return nil, false
+		}
+		gh[i] = mulq(gh[i], powq(fh[i], Q-2))
+	}
+	invNTTq(gh)
+	return gh, true
+}
+
+// mulPolyq returns f g in R_q.
+func mulPolyq(f, g []uint32) []uint32 {
+	fh := append([]uint32(nil), f...)
+	gh := append([]uint32(nil), g...)
+	nttq(fh)
+	nttq(gh)
+	for i := range fh {
+		fh[i] = mulq(fh[i], gh[i])
+	}
+	invNTTq(fh)
+	return fh
+}
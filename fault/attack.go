@@ -0,0 +1,141 @@
+package fault
+
+import (
+	"errors"
+
+	"github.com/haopining/Learn-Lattice-Based-Cryptography/gauss"
+)
+
+// Recover is the differential key recovery of Bruinderink and Pessl
+// ("Differential Fault Attacks on Deterministic Lattice Signatures",
+// TCHES 2018): two signatures a and b with the same mask y but different
+// challenges give z_a - z_b = (c_a - c_b) s_1, which the NTT turns into
+// coefficient-wise divisions. s_2 follows as t - A s_1. The key returned
+// signs, with K zero. Recover fails if the challenges are equal, if c_a -
+// c_b is not invertible, or if the masks differ, which gives no short s_1.
+func Recover(pk *PublicKey, a, b *Signature) (*PrivateKey, bool) {
+	ca, cb := ntt(sampleInBall(a.C)), ntt(sampleInBall(b.C))
+	dc := ca.sub(&cb)
+	for i := range dc {
+		if dc[i] == 0 {
+			return nil, false
+		}
+		dc[i] = fieldInv(dc[i])
+	}
+	sk := &PrivateKey{pub: pk, key: make([]byte, 32)}
+	for j := range l {
+		dz := a.Z[j].sub(&b.Z[j])
+		dz = ntt(dz)
+		s := dz.mulNTT(&dc)
+		sk.s1Hat = append(sk.s1Hat, s)
+		sk.s1 = append(sk.s1, invNTT(s))
+		if sk.s1[j].infNorm() > eta {
+			return nil, false
+		}
+	}
+	for i := range k {
+		as := invNTT(dot(pk.a[i], sk.s1Hat))
+		s := pk.t[i].sub(&as)
+		if s.infNorm() > eta {
+			return nil, false
+		}
+		sk.s2 = append(sk.s2, s)
+		sk.s2Hat = append(sk.s2Hat, ntt(s))
+	}
+	return sk, true
+}
+
+// Result is the outcome of an attack: a fault injected into Trials
+// signings under a set of countermeasures.
+type Result struct {
+	Fault           Fault
+	Countermeasures Countermeasures
+	Trials          int
+	// Detected counts the signings stopped with ErrFault, Released those
+	// that returned a signature and Invalid the released signatures that
+	// do not verify, such as those a skipped rejection check let out.
+	Detected, Released, Invalid int
+	// Recovered reports whether the attack found the key, confirmed by
+	// forging a signature that verifies.
+	Recovered bool
+}
+
+// Attack runs the known attack for the fault f against sk, with r
+// supplying messages and the randomness of randomized signing:
+//
+//   - Butterfly: each message is signed twice, once with the fault, which
+//     changes the commitment and so the challenge but not the mask y of
+//     deterministic signing; Recover solves the pair.
+//   - Nonce: messages are signed with the fault, which makes the mask
+//     independent of the message, and Recover is tried on every pair of
+//     signatures, which share y when they were accepted at the same
+//     attempt of the loop.
+//   - Rejection: the signatures released by the skipped check are
+//     counted. Those outside the bound on z depend on s_1; the statistical
+//     attacks that turn them into a key need far more signatures than a
+//     simulation runs, so Attack does not try to recover it.
+func Attack(sk *PrivateKey, f Fault, cm Countermeasures, trials int, r *gauss.Rand) (*Result, error) {
+	s := &Signer{Key: sk, Countermeasures: cm, Rand: r}
+	pk := sk.pub
+	res := &Result{Fault: f, Countermeasures: cm, Trials: trials}
+	var released []*Signature
+	message := func() []byte {
+		m := make([]byte, 16)
+		for i := range m {
+			m[i] = byte(r.Uint64())
+		}
+		return m
+	}
+	try := func(a, b *Signature) error {
+		if res.Recovered {
+			return nil
+		}
+		key, ok := Recover(pk, a, b)
+		if !ok {
+			return nil
+		}
+		msg := message()
+		sig, err := (&Signer{Key: key}).Sign(msg, nil)
+		if err != nil {
+			return err
+		}
+		res.Recovered = Verify(pk, msg, sig) == nil
+		return nil
+	}
+	for range trials {
+		msg := message()
+		var good *Signature
+		if f.Point == Butterfly {
+			var err error
+			if good, err = s.Sign(msg, nil); err != nil {
+				return nil, err
+			}
+		}
+		sig, err := s.Sign(msg, &f)
+		if errors.Is(err, ErrFault) {
+			res.Detected++
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		res.Released++
+		if Verify(pk, msg, sig) != nil {
+			res.Invalid++
+		}
+		switch f.Point {
+		case Butterfly:
+			err = try(good, sig)
+		case Nonce:
+			for _, prev := range released {
+				if err = try(prev, sig); err != nil {
+					break
+				}
+			}
+			released = append(released, sig)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
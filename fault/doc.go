@@ -0,0 +1,34 @@
+// Package fault simulates fault injection on lattice signatures, for
+// coursework on physical attacks: a Dilithium-style signer whose
+// instructions can be skipped at named points, the known key-recovery
+// attacks on the faulty output, and the usual countermeasures.
+//
+// The signer has the parameters and sampling of ML-DSA-44 but keeps the
+// public key t = A s_1 + s_2 whole, which removes the hints and leaves
+// the signing loop of the Dilithium paper. Its NTT follows the outline of
+// python/ntt.py, layers of split steps and merge steps over bit-reversed
+// roots, so that a fault can skip one layer. Signing is deterministic by
+// default, and neither it nor Verify is constant time: the model is an
+// attacker who glitches the device, not one who times it.
+//
+// A Fault skips one instruction, once per signing:
+//
+//   - Butterfly skips a layer of the NTT of the mask y. The commitment and
+//     the challenge change while y does not, and a correct signature of
+//     the same message gives z - z' = (c - c') s_1 (Bruinderink and
+//     Pessl, TCHES 2018).
+//   - Rejection skips the rejection check, releasing answers z whose
+//     distribution depends on s_1.
+//   - Nonce skips the message in the derivation of the mask seed, so that
+//     any two messages signed at the same attempt of the loop share y.
+//
+// Countermeasures, each a field of Countermeasures, catch or blunt them:
+// verifying every signature before releasing it catches the faults that
+// make a signature invalid, the butterfly fault and most skipped
+// rejections; computing every NTT twice catches the butterfly fault, a
+// single fault hitting one of the two computations; randomized (hedged)
+// signing gives every signing a fresh mask, which defeats the
+// differential attacks, including the nonce fault, whose signatures are
+// valid and so pass verification. Attack runs an experiment and reports
+// what was detected, released and recovered.
+package fault
@@ -0,0 +1,158 @@
+package fault
+
+import (
+	"testing"
+
+	"github.com/haopining/Learn-Lattice-Based-Cryptography/gauss"
+)
+
+// mulSchoolbook returns f g mod x^256 + 1, to check the NTT against.
+func mulSchoolbook(f, g *Poly) Poly {
+	var h Poly
+	for i := range f {
+		for j := range g {
+			t := fieldMul(f[i], g[j])
+			if i+j < N {
+				h[i+j] = fieldAdd(h[i+j], t)
+			} else {
+				h[i+j-N] = fieldSub(h[i+j-N], t)
+			}
+		}
+	}
+	return h
+}
+
+// TestNTT checks that the NTT multiplies as the schoolbook product does
+// and that the inverse NTT inverts it.
+func TestNTT(t *testing.T) {
+	r := gauss.NewSHAKE([]byte("ntt"))
+	for range 20 {
+		var f, g Poly
+		for i := range f {
+			f[i], g[i] = uint32(r.Below(Q)), uint32(r.Below(Q))
+		}
+		fh, gh := ntt(f), ntt(g)
+		if invNTT(fh.mulNTT(&gh)) != mulSchoolbook(&f, &g) {
+			t.Fatal("NTT product differs from the schoolbook product")
+		}
+		if invNTT(fh) != f {
+			t.Fatal("inverse NTT does not invert the NTT")
+		}
+	}
+}
+
+// TestSign checks that signatures made without faults verify, under every
+// countermeasure, and only for their message.
+func TestSign(t *testing.T) {
+	r := gauss.NewSHAKE([]byte("sign"))
+	for range 4 {
+		seed := make([]byte, 32)
+		msg := make([]byte, 16)
+		for i := range seed {
+			seed[i] = byte(r.Uint64())
+		}
+		for i := range msg {
+			msg[i] = byte(r.Uint64())
+		}
+		sk, err := NewPrivateKey(seed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s := &Signer{Key: sk, Countermeasures: Countermeasures{true, true, true}, Rand: r}
+		sig, err := s.Sign(msg, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := Verify(sk.pub, msg, sig); err != nil {
+			t.Fatal(err)
+		}
+		msg[0] ^= 1
+		if Verify(sk.pub, msg, sig) == nil {
+			t.Fatal("signature verifies for another message")
+		}
+	}
+}
+
+// attack runs Attack with a key and randomness drawn from a fixed seed.
+func attack(t *testing.T, f Fault, cm Countermeasures, trials int) *Result {
+	r := gauss.NewSHAKE([]byte(f.String()))
+	seed := make([]byte, 32)
+	for i := range seed {
+		seed[i] = byte(r.Uint64())
+	}
+	sk, err := NewPrivateKey(seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := Attack(sk, f, cm, trials, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
+
+// TestUnprotected checks that the attacks recover the key from
+// deterministic signing without countermeasures, and that a skipped
+// rejection check releases invalid signatures.
+func TestUnprotected(t *testing.T) {
+	for _, f := range []Fault{
+		{Point: Butterfly, Layer: 0},
+		{Point: Butterfly, Layer: 7},
+		{Point: Nonce},
+	} {
+		res := attack(t, f, Countermeasures{}, 50)
+		if !res.Recovered {
+			t.Errorf("%v: key not recovered from %d signatures", f, res.Released)
+		}
+		if res.Detected != 0 {
+			t.Errorf("%v: %d faults detected without countermeasures", f, res.Detected)
+		}
+	}
+	if res := attack(t, Fault{Point: Rejection}, Countermeasures{}, 50); res.Invalid == 0 {
+		t.Error("no invalid signature released with the rejection check skipped")
+	}
+}
+
+// TestEachCountermeasure checks each countermeasure alone against the
+// fault it is meant for: the redundant NTT detects every skipped
+// butterfly layer, verification after signing releases no invalid
+// signature, and randomized signing defeats the nonce attack.
+func TestEachCountermeasure(t *testing.T) {
+	for _, layer := range []int{0, 7} {
+		f := Fault{Point: Butterfly, Layer: layer}
+		if res := attack(t, f, Countermeasures{RedundantNTT: true}, 50); res.Detected != res.Trials || res.Recovered {
+			t.Errorf("%v with RedundantNTT: %d of %d detected, recovered %v", f, res.Detected, res.Trials, res.Recovered)
+		}
+	}
+	if res := attack(t, Fault{Point: Rejection}, Countermeasures{VerifyAfterSign: true}, 50); res.Invalid != 0 || res.Detected == 0 {
+		t.Errorf("rejection fault with VerifyAfterSign: %d detected, %d invalid signatures released", res.Detected, res.Invalid)
+	}
+	// Randomized signing detects nothing; the attack sees every signature
+	// and still fails.
+	if res := attack(t, Fault{Point: Nonce}, Countermeasures{Randomized: true}, 50); res.Recovered || res.Released != res.Trials {
+		t.Errorf("nonce fault with Randomized: %d of %d released, recovered %v", res.Released, res.Trials, res.Recovered)
+	}
+}
+
+// TestCountermeasures checks that with every countermeasure on, no fault
+// recovers the key or releases an invalid signature.
+func TestCountermeasures(t *testing.T) {
+	all := Countermeasures{VerifyAfterSign: true, RedundantNTT: true, Randomized: true}
+	for _, f := range []Fault{
+		{Point: Butterfly, Layer: 0},
+		{Point: Butterfly, Layer: 7},
+		{Point: Rejection},
+		{Point: Nonce},
+	} {
+		res := attack(t, f, all, 50)
+		if res.Recovered {
+			t.Errorf("%v: key recovered", f)
+		}
+		if res.Invalid != 0 {
+			t.Errorf("%v: %d invalid signatures released", f, res.Invalid)
+		}
+		if res.Detected+res.Released != res.Trials {
+			t.Errorf("%v: %d detected and %d released of %d", f, res.Detected, res.Released, res.Trials)
+		}
+	}
+}
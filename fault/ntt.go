@@ -0,0 +1,169 @@
+package fault
+
+// N is the degree of the ring and Q its modulus, those of ML-DSA.
+const (
+	N = 256
+	Q = 8380417
+)
+
+// Poly is an element of Z_q[x]/(x^256 + 1), in the coefficient or the NTT
+// domain, with coefficients in [0, q).
+type Poly [N]uint32
+
+func fieldAdd(a, b uint32) uint32 { return (a + b) % Q }
+func fieldSub(a, b uint32) uint32 { return (a + Q - b) % Q }
+func fieldMul(a, b uint32) uint32 { return uint32(uint64(a) * uint64(b) % Q) }
+
+func fieldInv(a uint32) uint32 {
+	r := uint32(1)
+	for e := uint32(Q - 2); e > 0; e >>= 1 {
+		if e&1 == 1 {
+			r = fieldMul(r, a)
+		}
+		a = fieldMul(a, a)
+	}
+	return r
+}
+
+// centered maps a in [0, q) to the representative in (-q/2, q/2].
+func centered(a uint32) int32 {
+	if a > Q/2 {
+		return int32(a) - Q
+	}
+	return int32(a)
+}
+
+func fieldFromInt(a int32) uint32 {
+	if a < 0 {
+		return uint32(a + Q)
+	}
+	return uint32(a)
+}
+
+// infNorm returns the largest |a mod+- q| of f.
+func (f *Poly) infNorm() uint32 {
+	var m uint32
+	for _, a := range f {
+		x := centered(a)
+		m = max(m, uint32(max(x, -x)))
+	}
+	return m
+}
+
+func (f *Poly) add(g *Poly) Poly {
+	var h Poly
+	for i := range h {
+		h[i] = fieldAdd(f[i], g[i])
+	}
+	return h
+}
+
+func (f *Poly) sub(g *Poly) Poly {
+	var h Poly
+	for i := range h {
+		h[i] = fieldSub(f[i], g[i])
+	}
+	return h
+}
+
+// mulNTT returns the coefficient-wise product of two NTT-domain elements.
+func (f *Poly) mulNTT(g *Poly) Poly {
+	var h Poly
+	for i := range h {
+		h[i] = fieldMul(f[i], g[i])
+	}
+	return h
+}
+
+// bitReverse reverses the 8 bits of k.
+func bitReverse(k int) int {
+	r := 0
+	for b := range 8 {
+		r |= (k >> b & 1) << (7 - b)
+	}
+	return r
+}
+
+// zetas[k] = 1753^bitReverse(k), 1753 being a primitive 512-th root of
+// unity, and zetasInv their inverses.
+var zetas, zetasInv = func() (z, zi [N]uint32) {
+	for k := range z {
+		z[k] = 1
+		for range bitReverse(k) {
+			z[k] = fieldMul(z[k], 1753)
+		}
+		zi[k] = fieldInv(z[k])
+	}
+	return z, zi
+}()
+
+// The NTT follows the outline of python/ntt.py, split and merge steps
+// over bit-reversed roots. Layer i of the NTT splits each of its 2^i
+// blocks, f mod x^2l - zeta^2 with l = 128 >> i, into f mod x^l - zeta
+// and f mod x^l + zeta, zeta = zetas[2^i + block]; the inverse merges the
+// halves back, layer 7 first. Eight layers take x^256 + 1 down to linear
+// factors, so products in the NTT domain are coefficient-wise.
+
+// split runs layer i of the NTT, the butterflies
+// (a, b) -> (a + zeta b, a - zeta b).
+func split(f *Poly, i int) {
+	l := N >> (i + 1)
+	for start, k := 0, 1<<i; start < N; start, k = start+2*l, k+1 {
+		z := zetas[k]
+		for j := start; j < start+l; j++ {
+			t := fieldMul(z, f[j+l])
+			f[j+l] = fieldSub(f[j], t)
+			f[j] = fieldAdd(f[j], t)
+		}
+	}
+}
+
+// merge undoes split up to a factor of 2: (a, b) -> (a + b, (a - b) /
+// zeta).
+func merge(f *Poly, i int) {
+	l := N >> (i + 1)
+	for start, k := 0, 1<<i; start < N; start, k = start+2*l, k+1 {
+		z := zetasInv[k]
+		for j := start; j < start+l; j++ {
+			a, b := f[j], f[j+l]
+			f[j] = fieldAdd(a, b)
+			f[j+l] = fieldMul(z, fieldSub(a, b))
+		}
+	}
+}
+
+func ntt(f Poly) Poly {
+	for i := range 8 {
+		split(&f, i)
+	}
+	return f
+}
+
+// invNTT merges the eight layers and scales by 256^-1 = 8347681.
+func invNTT(f Poly) Poly {
+	for i := 7; i >= 0; i-- {
+		merge(&f, i)
+	}
+	for j := range f {
+		f[j] = fieldMul(f[j], 8347681)
+	}
+	return f
+}
+
+func nttVec(v []Poly) []Poly {
+	out := make([]Poly, len(v))
+	for i := range v {
+		out[i] = ntt(v[i])
+	}
+	return out
+}
+
+// dot returns sum_j a_j b_j in the NTT domain.
+func dot(a, b []Poly) Poly {
+	var acc Poly
+	for j := range a {
+		t := a[j].mulNTT(&b[j])
+		acc = acc.add(&t)
+	}
+	return acc
+}
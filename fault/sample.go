@@ -0,0 +1,149 @@
+package fault
+
+import (
+	"crypto/sha3"
+	"encoding/binary"
+)
+
+// h returns n bytes of SHAKE256 of the concatenated inputs.
+func h(n int, in ...[]byte) []byte {
+	s := sha3.NewSHAKE256()
+	for _, b := range in {
+		s.Write(b)
+	}
+	out := make([]byte, n)
+	s.Read(out)
+	return out
+}
+
+// expandA returns A-hat, each entry sampled in the NTT domain from
+// SHAKE128(rho || j || i) with 23-bit candidates, as in ML-DSA.
+func expandA(rho []byte) [][]Poly {
+	a := make([][]Poly, k)
+	for i := range a {
+		a[i] = make([]Poly, l)
+		for j := range a[i] {
+			s := sha3.NewSHAKE128()
+			s.Write(rho)
+			s.Write([]byte{byte(j), byte(i)})
+			var buf [3]byte
+			for c := 0; c < N; {
+				s.Read(buf[:])
+				z := uint32(buf[0]) | uint32(buf[1])<<8 | uint32(buf[2]&0x7f)<<16
+				if z < Q {
+					a[i][j][c] = z
+					c++
+				}
+			}
+		}
+	}
+	return a
+}
+
+// expandS returns s_1 and s_2 with coefficients uniform in [-2, 2]: a
+// nibble z < 15 of SHAKE256(rho' || r) gives 2 - (z mod 5).
+func expandS(rho []byte) (s1, s2 []Poly) {
+	for r := range k + l {
+		s := sha3.NewSHAKE256()
+		s.Write(rho)
+		s.Write([]byte{byte(r), 0})
+		var f Poly
+		var b [1]byte
+		for c := 0; c < N; {
+			s.Read(b[:])
+			for _, z := range []byte{b[0] & 15, b[0] >> 4} {
+				if z < 15 && c < N {
+					f[c] = fieldFromInt(2 - int32(z%5))
+					c++
+				}
+			}
+		}
+		if r < l {
+			s1 = append(s1, f)
+		} else {
+			s2 = append(s2, f)
+		}
+	}
+	return s1, s2
+}
+
+// expandMask returns the mask y with coefficients in (-gamma1, gamma1],
+// gamma1 - x for 18-bit values x of SHAKE256(rho” || kappa + r).
+func expandMask(rho []byte, kappa int) []Poly {
+	y := make([]Poly, l)
+	for r := range y {
+		var n [2]byte
+		binary.LittleEndian.PutUint16(n[:], uint16(kappa+r))
+		buf := h(32*18, rho, n[:])
+		for i := range y[r] {
+			bit := 18 * i
+			b := buf[bit/8:]
+			x := (uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16) >> (bit % 8) & (1<<18 - 1)
+			y[r][i] = fieldFromInt(gamma1 - int32(x))
+		}
+	}
+	return y
+}
+
+// sampleInBall returns the challenge c: tau coefficients +-1 placed by a
+// Fisher–Yates shuffle driven by SHAKE256(seed), the rest zero.
+func sampleInBall(seed []byte) Poly {
+	s := sha3.NewSHAKE256()
+	s.Write(seed)
+	var signs [8]byte
+	s.Read(signs[:])
+	bits := binary.LittleEndian.Uint64(signs[:])
+	var c Poly
+	var b [1]byte
+	for i := N - tau; i < N; i++ {
+		for {
+			s.Read(b[:])
+			if int(b[0]) <= i {
+				break
+			}
+		}
+		j := b[0]
+		c[i] = c[j]
+		c[j] = 1
+		if bits&1 == 1 {
+			c[j] = Q - 1
+		}
+		bits >>= 1
+	}
+	return c
+}
+
+// decompose splits r in [0, q) as r1 2 gamma2 + r0 with r0 in (-gamma2,
+// gamma2], the top value of r1 wrapping to 0 with r0 decreased by one
+// (FIPS 204, Algorithm 36).
+func decompose(r uint32) (r1 uint32, r0 int32) {
+	r0 = int32(r % (2 * gamma2))
+	if r0 > gamma2 {
+		r0 -= 2 * gamma2
+	}
+	if int32(r)-r0 == Q-1 {
+		return 0, r0 - 1
+	}
+	return uint32(int32(r)-r0) / (2 * gamma2), r0
+}
+
+// highBits returns the high bits of every coefficient of w, one byte
+// each, the commitment w_1 that the challenge hashes.
+func highBits(w *Poly) []byte {
+	b := make([]byte, N)
+	for i, x := range w {
+		r1, _ := decompose(x)
+		b[i] = byte(r1)
+	}
+	return b
+}
+
+// lowNorm returns the largest |r0| of the coefficients of w.
+func lowNorm(w *Poly) uint32 {
+	var m int32
+	for _, x := range w {
+		_, r0 := decompose(x)
+		m = max(m, r0, -r0)
+	}
+	return uint32(m)
+}
@@ -0,0 +1,298 @@
+package fault
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/haopining/Learn-Lattice-Based-Cryptography/gauss"
+)
+
+// The parameters of ML-DSA-44 (FIPS 204, Table 1): A is k x l, the
+// secrets have coefficients in [-eta, eta], the challenge tau of +-1, the
+// mask is below gamma1, and beta = tau eta bounds c s_1 and c s_2.
+const (
+	k      = 4
+	l      = 4
+	eta    = 2
+	tau    = 39
+	gamma1 = 1 << 17
+	gamma2 = (Q - 1) / 88
+	beta   = tau * eta
+)
+
+var (
+	// ErrFault is returned by Sign when a countermeasure catches a fault.
+	ErrFault = errors.New("fault: fault detected while signing")
+	// ErrInvalidSignature is returned by Verify.
+	ErrInvalidSignature = errors.New("fault: invalid signature")
+	errSeedSize         = errors.New("fault: seed must be 32 bytes")
+)
+
+// PublicKey is a public key t = A s_1 + s_2. Unlike ML-DSA, t is kept
+// whole rather than split into t_1 and t_0, which does away with the
+// hints and leaves the signing loop as in the Dilithium paper.
+type PublicKey struct {
+	a    [][]Poly // A-hat
+	t    []Poly
+	tHat []Poly
+	tr   []byte // H(rho || t)
+}
+
+// PrivateKey is a signing key.
+type PrivateKey struct {
+	pub          *PublicKey
+	key          []byte // K, the key of the mask seed
+	s1, s2       []Poly
+	s1Hat, s2Hat []Poly
+}
+
+// NewPrivateKey expands a 32-byte seed into a key.
+func NewPrivateKey(seed []byte) (*PrivateKey, error) {
+	if len(seed) != 32 {
+		return nil, errSeedSize
+	}
+	ex := h(128, seed)
+	rho := ex[:32]
+	pk := &PublicKey{a: expandA(rho)}
+	sk := &PrivateKey{pub: pk, key: ex[96:]}
+	sk.s1, sk.s2 = expandS(ex[32:96])
+	sk.s1Hat, sk.s2Hat = nttVec(sk.s1), nttVec(sk.s2)
+	raw := append([]byte(nil), rho...)
+	for i := range k {
+		t := invNTT(dot(pk.a[i], sk.s1Hat))
+		t = t.add(&sk.s2[i])
+		pk.t = append(pk.t, t)
+		for _, x := range t {
+			raw = append(raw, byte(x), byte(x>>8), byte(x>>16))
+		}
+	}
+	pk.tHat = nttVec(pk.t)
+	pk.tr = h(64, raw)
+	return sk, nil
+}
+
+// PublicKey returns the public key.
+func (sk *PrivateKey) PublicKey() *PublicKey { return sk.pub }
+
+// Equal reports whether sk and x have the same secrets s_1 and s_2.
+func (sk *PrivateKey) Equal(x *PrivateKey) bool {
+	for j := range sk.s1 {
+		if sk.s1[j] != x.s1[j] {
+			return false
+		}
+	}
+	for i := range sk.s2 {
+		if sk.s2[i] != x.s2[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Signature is a signature (c-tilde, z), z in coefficient form.
+type Signature struct {
+	C []byte
+	Z []Poly
+}
+
+// A Point is an instruction of the signing routine that a fault can
+// skip.
+type Point int
+
+const (
+	// Butterfly skips one layer of butterflies in the NTT of the mask.
+	Butterfly Point = iota + 1
+	// Rejection skips the rejection check of z and of the low bits of
+	// w - c s_2.
+	Rejection
+	// Nonce skips the absorption of the message into the mask seed,
+	// rho'' = H(K || rnd || mu), which then ignores the message.
+	Nonce
+)
+
+func (p Point) String() string {
+	switch p {
+	case Butterfly:
+		return "butterfly"
+	case Rejection:
+		return "rejection"
+	case Nonce:
+		return "nonce"
+	}
+	return fmt.Sprintf("Point(%d)", int(p))
+}
+
+// A Fault skips the instruction at Point once, the first time signing
+// reaches it: the NTT layer Layer, 0 to 7, of the first polynomial of
+// the mask for Butterfly; the check of the first attempt for Rejection.
+type Fault struct {
+	Point Point
+	Layer int
+}
+
+func (f Fault) String() string {
+	if f.Point == Butterfly {
+		return fmt.Sprintf("butterfly layer %d", f.Layer)
+	}
+	return f.Point.String()
+}
+
+// Countermeasures are the optional defenses of Signer.
+type Countermeasures struct {
+	// VerifyAfterSign verifies every signature before releasing it.
+	VerifyAfterSign bool
+	// RedundantNTT computes every NTT of signing twice and compares.
+	RedundantNTT bool
+	// Randomized mixes 32 fresh random bytes into the mask seed, the
+	// hedged signing of FIPS 204, instead of 32 zero bytes.
+	Randomized bool
+}
+
+func (c Countermeasures) String() string {
+	var s []string
+	if c.VerifyAfterSign {
+		s = append(s, "verify")
+	}
+	if c.RedundantNTT {
+		s = append(s, "redundant-ntt")
+	}
+	if c.Randomized {
+		s = append(s, "randomized")
+	}
+	if s == nil {
+		return "none"
+	}
+	return strings.Join(s, "+")
+}
+
+// A Signer signs with Key, with the chosen countermeasures; Rand is the
+// randomness of randomized signing.
+type Signer struct {
+	Key *PrivateKey
+	Countermeasures
+	Rand *gauss.Rand
+}
+
+// signing is the state of one call of Sign: the fault to inject, until
+// it fires.
+type signing struct {
+	fault *Fault
+	fired bool
+	cm    Countermeasures
+}
+
+// fire reports whether the fault skips the instruction at p, layer i.
+func (s *signing) fire(p Point, i int) bool {
+	f := s.fault
+	if s.fired || f == nil || f.Point != p || p == Butterfly && f.Layer != i {
+		return false
+	}
+	s.fired = true
+	return true
+}
+
+// ntt is the NTT of signing, with the butterfly faults and the
+// redundant computation.
+func (s *signing) ntt(f Poly) (Poly, error) {
+	g := f
+	for i := range 8 {
+		if !s.fire(Butterfly, i) {
+			split(&g, i)
+		}
+	}
+	if s.cm.RedundantNTT && ntt(f) != g {
+		return g, ErrFault
+	}
+	return g, nil
+}
+
+// Sign signs msg, injecting the fault f if it is not nil. The loop is
+// that of ML-DSA (FIPS 204, Algorithm 7) without the hints: commit to
+// the high bits of w = A y, derive the challenge c, answer z = y + c s_1,
+// and start over if z or the low bits of w - c s_2 could leak the key.
+func (s *Signer) Sign(msg []byte, f *Fault) (*Signature, error) {
+	sk, pk := s.Key, s.Key.pub
+	run := &signing{fault: f, cm: s.Countermeasures}
+	mu := h(64, pk.tr, msg)
+	rnd := make([]byte, 32)
+	if s.Randomized {
+		for i := range rnd {
+			rnd[i] = byte(s.Rand.Uint64())
+		}
+	}
+	rhoPP := h(64, sk.key, rnd, mu)
+	if run.fire(Nonce, 0) {
+		rhoPP = h(64, sk.key, rnd)
+	}
+	for kappa := 0; ; kappa += l {
+		y := expandMask(rhoPP, kappa)
+		yHat := make([]Poly, l)
+		for j := range y {
+			var err error
+			if yHat[j], err = run.ntt(y[j]); err != nil {
+				return nil, err
+			}
+		}
+		w := make([]Poly, k)
+		var w1 []byte
+		for i := range w {
+			w[i] = invNTT(dot(pk.a[i], yHat))
+			w1 = append(w1, highBits(&w[i])...)
+		}
+		cTilde := h(32, mu, w1)
+		cHat, err := run.ntt(sampleInBall(cTilde))
+		if err != nil {
+			return nil, err
+		}
+
+		z := make([]Poly, l)
+		ok := true
+		for j := range z {
+			cs1 := invNTT(cHat.mulNTT(&sk.s1Hat[j]))
+			z[j] = y[j].add(&cs1)
+			ok = ok && z[j].infNorm() < gamma1-beta
+		}
+		for i := 0; ok && i < k; i++ {
+			cs2 := invNTT(cHat.mulNTT(&sk.s2Hat[i]))
+			r := w[i].sub(&cs2)
+			ok = ok && lowNorm(&r) < gamma2-beta
+		}
+		if skip := run.fire(Rejection, 0); !ok && !skip {
+			continue
+		}
+		sig := &Signature{C: cTilde, Z: z}
+		if s.VerifyAfterSign && Verify(pk, msg, sig) != nil {
+			return nil, ErrFault
+		}
+		return sig, nil
+	}
+}
+
+// Verify reports whether sig is a valid signature of msg: z is short and
+// the high bits of A z - c t = w - c s_2 hash back to c-tilde.
+func Verify(pk *PublicKey, msg []byte, sig *Signature) error {
+	if len(sig.C) != 32 || len(sig.Z) != l {
+		return ErrInvalidSignature
+	}
+	for j := range sig.Z {
+		if sig.Z[j].infNorm() >= gamma1-beta {
+			return ErrInvalidSignature
+		}
+	}
+	mu := h(64, pk.tr, msg)
+	cHat := ntt(sampleInBall(sig.C))
+	zHat := nttVec(sig.Z)
+	var w1 []byte
+	for i := range k {
+		az := dot(pk.a[i], zHat)
+		ct := cHat.mulNTT(&pk.tHat[i])
+		w := invNTT(az.sub(&ct))
+		w1 = append(w1, highBits(&w)...)
+	}
+	if subtle.ConstantTimeCompare(sig.C, h(32, mu, w1)) != 1 {
+		return ErrInvalidSignature
+	}
+	return nil
+}
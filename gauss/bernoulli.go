@@ -0,0 +1,166 @@
+package gauss
+
+import "math"
+
+// sigma2 is the width of the base distribution D_{Z+,sigma2}, for which
+// rho(x) = exp(-x^2 / (2 sigma2^2)) = 2^(-x^2).
+var sigma2 = 1 / math.Sqrt(2*math.Ln2)
+
+// baseTail bounds the base samples, 2^(-14^2) being negligible.
+const baseTail = 13
+
+// baseTable samples D_{Z+,sigma2}. It does not depend on sigma, so every
+// expander shares it.
+var baseTable = func() *cdtTable {
+	w := make([]float64, baseTail+1)
+	for x := range w {
+		w[x] = math.Exp2(-float64(x * x))
+	}
+	return newCDTTable(0, w)
+}()
+
+// expander is the rejection sampler shared by Bernoulli and FACCT. A base
+// sample x from D_{Z+,sigma2} and a uniform y in [0, k) give u = k x + y,
+// and a random bit b folds u onto both sides of the center:
+//
+//	w = b + (2b - 1) u,   that is  w = 1 + u  or  w = -u.
+//
+// Every integer w arises from exactly one (x, y, b), with probability
+// proportional to 2^(-x^2). Accepting it with probability
+//
+//	exp(-t),   t = (w - f)^2 / (2 sigma^2) - x^2 ln 2,
+//
+// where f in [0, 1) is the fractional part of the center, leaves w
+// distributed as D_{Z,sigma,f}, and floor(center) + w as D_{Z,sigma,c}.
+// Since |w - f| >= u >= k x, t is non-negative as soon as
+// k >= sigma / sigma2; for k = 1 and f = 0 this is the BLISS sampler.
+type expander struct {
+	sigma float64
+	floor int64
+	frac  float64
+	k     int64
+	base  *cdtTable
+	r     *Rand
+}
+
+func newExpander(sigma, center float64, r *Rand) (*expander, error) {
+	if err := checkSigma(sigma, center); err != nil {
+		return nil, err
+	}
+	fl := math.Floor(center)
+	return &expander{
+		sigma: sigma,
+		floor: int64(fl),
+		frac:  center - fl,
+		k:     int64(math.Ceil(sigma / sigma2)),
+		base:  baseTable,
+		r:     r,
+	}, nil
+}
+
+// exponent returns t for the proposal (x, w).
+func (e *expander) exponent(x, w int64) float64 {
+	d := float64(w) - e.frac
+	return math.Max(0, d*d/(2*e.sigma*e.sigma)-float64(x*x)*math.Ln2)
+}
+
+// sample draws proposals until accept(t) succeeds. All arithmetic on the
+// proposal is branch-free; only the number of rounds varies, and it is
+// independent of the value finally returned.
+func (e *expander) sample(accept func(r *Rand, t float64) bool) int64 {
+	for {
+		x := e.base.sample(e.r)
+		y := int64(e.r.Below(uint64(e.k)))
+		b := int64(e.r.Bit())
+		u := e.k*x + y
+		w := b + (2*b-1)*u
+		if accept(e.r, e.exponent(x, w)) {
+			return e.floor + w
+		}
+	}
+}
+
+// dist returns the output distribution when exp(-t) is replaced by
+// prob(t), the acceptance probability an implementation really achieves.
+func (e *expander) dist(prob func(t float64) float64) Dist {
+	base := e.base.dist()
+	top := e.k*int64(len(base.P)-1) + e.k - 1 // largest u
+	d := Dist{Lo: e.floor - top, P: make([]float64, 2*top+2)}
+	for x, px := range base.P {
+		for y := int64(0); y < e.k; y++ {
+			u := e.k*int64(x) + y
+			for _, w := range []int64{1 + u, -u} {
+				d.P[e.floor+w-d.Lo] += px * prob(e.exponent(int64(x), w))
+			}
+		}
+	}
+	d.normalize()
+	return d
+}
+
+// Bernoulli exponents are rounded to berFrac fractional bits; exponents of
+// berBits-berFrac integer bits or more are rejected outright.
+const (
+	berFrac = 40
+	berBits = 52
+)
+
+// berTable[i] = floor(2^64 exp(-2^(i - berFrac))), the biases of the
+// Bernoulli trials whose product is exp(-t).
+var berTable = func() (t [berBits]uint64) {
+	for i := range t {
+		t[i] = uint64(math.Ldexp(math.Exp(-math.Ldexp(1, i-berFrac)), 64))
+	}
+	return t
+}()
+
+func berExponent(t float64) uint64 {
+	t = math.Min(t, 1<<(berBits-berFrac))
+	return min(uint64(math.Round(math.Ldexp(t, berFrac))), 1<<berBits-1)
+}
+
+// Bernoulli samples D_{Z,sigma,c} in the style of BLISS: the acceptance
+// probability exp(-t) is the product of exp(-2^i) over the bits of t, and
+// each factor is a Bernoulli trial with a precomputed bias. All berBits
+// trials are run for every proposal and masked by the bits of t.
+type Bernoulli struct {
+	e *expander
+}
+
+// NewBernoulli returns a Bernoulli sampler for D_{Z,sigma,center} drawing
+// from r.
+func NewBernoulli(sigma, center float64, r *Rand) (*Bernoulli, error) {
+	e, err := newExpander(sigma, center, r)
+	if err != nil {
+		return nil, err
+	}
+	return &Bernoulli{e}, nil
+}
+
+// Sample returns one sample.
+func (s *Bernoulli) Sample() int64 {
+	return s.e.sample(func(r *Rand, t float64) bool {
+		x := berExponent(t)
+		acc := uint64(1)
+		for i := range berTable {
+			bit := x >> uint(i) & 1
+			acc &= (1 ^ bit) | ctLess(r.Uint64(), berTable[i])
+		}
+		return acc == 1
+	})
+}
+
+// Distribution returns the distribution with the rounded exponents and
+// table entries.
+func (s *Bernoulli) Distribution() Dist {
+	return s.e.dist(func(t float64) float64 {
+		x := berExponent(t)
+		p := 1.0
+		for i, c := range berTable {
+			if x>>uint(i)&1 == 1 {
+				p *= math.Ldexp(float64(c), -64)
+			}
+		}
+		return p
+	})
+}
@@ -0,0 +1,82 @@
+package gauss
+
+import (
+	"math/big"
+)
+
+// cdtTable inverts a cumulative distribution table with 63-bit entries:
+// cdf[i] = round(2^63 * Pr[X <= lo + i]), without the final entry 2^63.
+type cdtTable struct {
+	lo  int64
+	cdf []uint64
+}
+
+// newCDTTable builds the table of the distribution proportional to w on
+// lo, lo+1, .... The running sum is kept in 128-bit precision so that the
+// tails are not lost to float64 rounding.
+func newCDTTable(lo int64, w []float64) *cdtTable {
+	total := new(big.Float).SetPrec(128)
+	for _, x := range w {
+		total.Add(total, big.NewFloat(x))
+	}
+	scale := new(big.Float).SetPrec(128).Quo(big.NewFloat(0x1p63), total)
+	t := &cdtTable{lo: lo, cdf: make([]uint64, len(w)-1)}
+	sum := new(big.Float).SetPrec(128)
+	for i := range t.cdf {
+		sum.Add(sum, big.NewFloat(w[i]))
+		v := new(big.Float).SetPrec(128).Mul(sum, scale)
+		v.Add(v, big.NewFloat(0.5))
+		t.cdf[i], _ = v.Uint64()
+	}
+	return t
+}
+
+// sample returns lo plus the number of entries cdf[i] <= r for a uniform
+// 63-bit r. Every entry is compared, whatever the result.
+func (t *cdtTable) sample(r *Rand) int64 {
+	u := r.Uint64() >> 1
+	var z uint64
+	for _, c := range t.cdf {
+		z += 1 ^ ctLess(u, c)
+	}
+	return t.lo + int64(z)
+}
+
+// dist returns the exact distribution of sample.
+func (t *cdtTable) dist() Dist {
+	d := Dist{Lo: t.lo, P: make([]float64, len(t.cdf)+1)}
+	prev := uint64(0)
+	for i := range d.P {
+		c := uint64(1) << 63
+		if i < len(t.cdf) {
+			c = t.cdf[i]
+		}
+		d.P[i] = float64(c-prev) / (1 << 63)
+		prev = c
+	}
+	return d
+}
+
+// CDT samples D_{Z,sigma,c} by inversion of a cumulative distribution
+// table over the tail-cut support. Its table has about 26*sigma entries,
+// so it suits the small widths of LWE errors; every sample scans all of
+// them.
+type CDT struct {
+	t *cdtTable
+	r *Rand
+}
+
+// NewCDT returns a CDT sampler for D_{Z,sigma,center} drawing from r.
+func NewCDT(sigma, center float64, r *Rand) (*CDT, error) {
+	if err := checkSigma(sigma, center); err != nil {
+		return nil, err
+	}
+	lo, hi := support(sigma, center)
+	return &CDT{t: newCDTTable(lo, Ideal(sigma, center, lo, hi).P), r: r}, nil
+}
+
+// Sample returns one sample.
+func (s *CDT) Sample() int64 { return s.t.sample(s.r) }
+
+// Distribution returns the distribution defined by the rounded table.
+func (s *CDT) Distribution() Dist { return s.t.dist() }
@@ -0,0 +1,97 @@
+package gauss
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// TailCut is the number of standard deviations kept on each side of the
+// center. The mass beyond 13 sigma is below 2^-120.
+const TailCut = 13
+
+// ErrSigma is returned for a non-positive or non-finite width.
+var ErrSigma = errors.New("gauss: sigma must be positive and finite")
+
+// Sampler draws integers from a discrete Gaussian.
+type Sampler interface {
+	// Sample returns one sample.
+	Sample() int64
+	// Distribution returns the exact output distribution of the sampler,
+	// including the effect of every rounded table entry.
+	Distribution() Dist
+}
+
+// Dist is a distribution on the integers lo, lo+1, ..., lo+len(P)-1.
+type Dist struct {
+	Lo int64
+	P  []float64
+}
+
+// Hi returns the largest integer in the support range.
+func (d Dist) Hi() int64 { return d.Lo + int64(len(d.P)) - 1 }
+
+// Prob returns Pr[z], which is 0 outside the range.
+func (d Dist) Prob(z int64) float64 {
+	if z < d.Lo || z > d.Hi() {
+		return 0
+	}
+	return d.P[z-d.Lo]
+}
+
+// Mean returns the expectation of d.
+func (d Dist) Mean() float64 {
+	m := 0.0
+	for i, p := range d.P {
+		m += p * float64(d.Lo+int64(i))
+	}
+	return m
+}
+
+// Stddev returns the standard deviation of d.
+func (d Dist) Stddev() float64 {
+	m, v := d.Mean(), 0.0
+	for i, p := range d.P {
+		x := float64(d.Lo+int64(i)) - m
+		v += p * x * x
+	}
+	return math.Sqrt(v)
+}
+
+func (d *Dist) normalize() {
+	s := 0.0
+	for _, p := range d.P {
+		s += p
+	}
+	for i := range d.P {
+		d.P[i] /= s
+	}
+}
+
+// Ideal returns D_{Z,sigma,c} restricted to [lo, hi]. The normalization
+// also runs over [lo, hi]; with the tail cut the difference to the true
+// distribution is far below float64 precision.
+func Ideal(sigma, center float64, lo, hi int64) Dist {
+	d := Dist{Lo: lo, P: make([]float64, hi-lo+1)}
+	for i := range d.P {
+		x := float64(lo+int64(i)) - center
+		d.P[i] = math.Exp(-x * x / (2 * sigma * sigma))
+	}
+	d.normalize()
+	return d
+}
+
+// support returns the tail-cut range [c - TailCut*sigma, c + TailCut*sigma].
+func support(sigma, center float64) (lo, hi int64) {
+	return int64(math.Floor(center - TailCut*sigma)), int64(math.Ceil(center + TailCut*sigma))
+}
+
+func checkSigma(sigma, center float64) error {
+	if !(sigma > 0) || math.IsInf(sigma, 0) {
+		return ErrSigma
+	}
+	if math.IsNaN(center) || math.IsInf(center, 0) {
+		return fmt.Errorf("gauss: invalid center %v", center)
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+// Package gauss samples the discrete Gaussian distribution over the
+// integers,
+//
+//	D_{Z,sigma,c}(z) = rho(z) / sum_k rho(k),   rho(z) = exp(-(z - c)^2 / (2 sigma^2)),
+//
+// which lattice schemes use for secrets, errors and signatures. Four
+// classic samplers are provided, all for an arbitrary width sigma and
+// real center c:
+//
+//   - CDT: inversion of a cumulative distribution table, scanning the whole
+//     table for every sample.
+//   - KnuthYao: a walk down the discrete distribution generating tree of the
+//     binary expansions of the probabilities, visiting every node.
+//   - Bernoulli: the BLISS approach. A sample from the narrow distribution
+//     D_{Z+,sigma_2} with sigma_2 = 1/sqrt(2 ln 2), where rho(x) = 2^(-x^2),
+//     is stretched by an integer factor k and randomized, and the result is
+//     accepted with a probability exp(-t) computed as a product of
+//     Bernoulli trials with precomputed biases exp(-2^i).
+//   - FACCT: the same structure, but exp(-t) is evaluated with a fixed
+//     polynomial in floating point, as proposed by Zhao, Steinfeld and
+//     Sakzad, which is faster and needs far less randomness.
+//
+// The samplers are written to run in time independent of their output: they
+// only use table scans of fixed length, masks instead of branches on
+// secret values, and rejection loops whose number of iterations is
+// independent of the accepted value. Their only secret-dependent variable
+// is the number of rejections, which reveals nothing about the sample.
+//
+// On top of them, Klein samples the discrete Gaussian D_{L,sigma,c} over an
+// arbitrary lattice by randomized nearest plane (the GPV sampler), and
+// Peikert by perturbation and randomized rounding (the convolution
+// sampler); the package tests check their output mean and width along the
+// Gram–Schmidt directions. Skewed samples integer vectors with a given
+// covariance matrix.
+//
+// Every sampler over Z also reports the exact distribution it implements, after
+// all the rounding of its tables. Renyi and KL compare it with the ideal
+// one, and ChiSquared tests real samples against it; the package tests run
+// both on every sampler, offline.
+package gauss
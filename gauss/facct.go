@@ -0,0 +1,67 @@
+package gauss
+
+import "math"
+
+// expDegree is the degree of the Taylor polynomial for exp(-r) on
+// [0, ln 2); its truncation error is below (ln 2)^17/17! < 2^-60.
+const expDegree = 16
+
+// invFact[i] = 1/i!.
+var invFact = func() (c [expDegree + 1]float64) {
+	c[0] = 1
+	for i := 1; i <= expDegree; i++ {
+		c[i] = c[i-1] / float64(i)
+	}
+	return c
+}()
+
+// facctThreshold returns z such that exp(-t) ~ z / 2^63. With
+// t = s ln 2 + r, exp(-t) = 2^(-s) exp(-r): the polynomial gives exp(-r)
+// in (1/2, 1] and the power of two is a shift. The same floating-point
+// operations run for every t.
+func facctThreshold(t float64) uint64 {
+	// Beyond t = 64 the result is 0 anyway; the cap keeps s small.
+	t = math.Min(t, 64)
+	s := math.Floor(t / math.Ln2)
+	r := t - s*math.Ln2
+	p := invFact[expDegree]
+	for i := expDegree - 1; i >= 0; i-- {
+		p = p*(-r) + invFact[i]
+	}
+	z := uint64(math.Ldexp(p, 63))
+	return z >> uint(min(int64(s), 63))
+}
+
+// FACCT samples D_{Z,sigma,c} with the sampler of Zhao, Steinfeld and
+// Sakzad ("FACCT: FAst, Compact, and Constant-Time Discrete Gaussian
+// Sampler over Integers"). It shares the proposal of Bernoulli, but
+// evaluates exp(-t) as a fixed polynomial and decides acceptance with a
+// single comparison against 63 random bits, instead of berBits Bernoulli
+// trials of 64 bits each.
+type FACCT struct {
+	e *expander
+}
+
+// NewFACCT returns a FACCT sampler for D_{Z,sigma,center} drawing from r.
+func NewFACCT(sigma, center float64, r *Rand) (*FACCT, error) {
+	e, err := newExpander(sigma, center, r)
+	if err != nil {
+		return nil, err
+	}
+	return &FACCT{e}, nil
+}
+
+// Sample returns one sample.
+func (s *FACCT) Sample() int64 {
+	return s.e.sample(func(r *Rand, t float64) bool {
+		return ctLess(r.Uint64()>>1, facctThreshold(t)) == 1
+	})
+}
+
+// Distribution returns the distribution with the polynomial approximation
+// and the 63-bit threshold.
+func (s *FACCT) Distribution() Dist {
+	return s.e.dist(func(t float64) float64 {
+		return math.Ldexp(float64(facctThreshold(t)), -63)
+	})
+}
@@ -0,0 +1,70 @@
+package gauss
+
+import "math"
+
+// KnuthYao samples D_{Z,sigma,c} with the Knuth–Yao algorithm. The
+// probabilities are written in binary, p_i = 0.b_{i,0} b_{i,1} ..., as the
+// rows of a 64-column matrix. Reading one random bit per column walks down
+// the discrete distribution generating tree: the distance d to the right
+// edge of the tree doubles and gains the bit, and every 1 in the column,
+// scanned from the bottom row up, consumes one node; the row whose node
+// brings d to -1 is the sample. This uses close to the entropy of the
+// distribution in random bits.
+//
+// The classic implementation stops as soon as a row is found, which leaks
+// the sample through timing. Here every column and every row is scanned
+// and the result is latched with masks.
+type KnuthYao struct {
+	lo int64
+	p  []uint64 // p[i] = floor(2^64 * Pr[lo + i])
+	r  *Rand
+}
+
+// NewKnuthYao returns a Knuth–Yao sampler for D_{Z,sigma,center} drawing
+// from r.
+func NewKnuthYao(sigma, center float64, r *Rand) (*KnuthYao, error) {
+	if err := checkSigma(sigma, center); err != nil {
+		return nil, err
+	}
+	lo, hi := support(sigma, center)
+	d := Ideal(sigma, center, lo, hi)
+	s := &KnuthYao{lo: lo, p: make([]uint64, len(d.P)), r: r}
+	for i, x := range d.P {
+		// A point mass, for a tiny sigma, is capped just below 1 so that
+		// it fits in 64 bits.
+		s.p[i] = uint64(math.Min(math.Ldexp(x, 64), math.Nextafter(0x1p64, 0)))
+	}
+	return s, nil
+}
+
+// Sample returns one sample. If the walk falls off the truncated tree,
+// which happens with probability 1 - sum p_i < len(p)/2^64, it restarts.
+func (s *KnuthYao) Sample() int64 {
+	n := int64(len(s.p))
+	for {
+		var d, found, res int64
+		for col := 63; col >= 0; col-- {
+			d = 2*d + int64(s.r.Bit())
+			for row := n - 1; row >= 0; row-- {
+				d -= int64(s.p[row] >> uint(col) & 1)
+				hit := ctEq(d, -1) &^ found
+				res = ctSelect(hit, row, res)
+				found |= hit
+			}
+		}
+		if found == 1 {
+			return s.lo + res
+		}
+	}
+}
+
+// Distribution returns the distribution of the truncated probabilities,
+// renormalized for the restarts.
+func (s *KnuthYao) Distribution() Dist {
+	d := Dist{Lo: s.lo, P: make([]float64, len(s.p))}
+	for i, x := range s.p {
+		d.P[i] = float64(x)
+	}
+	d.normalize()
+	return d
+}
@@ -0,0 +1,334 @@
+package gauss
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/haopining/Learn-Lattice-Based-Cryptography/lattice"
+)
+
+// DefaultEpsilon is the smoothing error used when none is given: the
+// samplers below are within statistical distance about 2^-64 of
+// D_{L,sigma,c} per coordinate once their widths exceed the smoothing
+// parameter for this epsilon.
+const DefaultEpsilon = 0x1p-64
+
+// ErrCenter is returned when a center does not match the dimension of the
+// lattice.
+var ErrCenter = errors.New("gauss: center has the wrong dimension")
+
+// Smoothing returns the smoothing parameter eta_eps(Z) expressed as a
+// standard deviation,
+//
+//	eta_eps(Z) <= sqrt(ln(2 + 2/eps) / pi) / sqrt(2 pi),
+//
+// about 1.51 for eps = 2^-64. Above it, D_{Z,sigma,c} has almost exactly
+// the mean c and variance sigma^2 of the continuous Gaussian, whatever c.
+// The bound of the literature is for the width s = sqrt(2 pi) sigma.
+func Smoothing(eps float64) float64 {
+	return math.Sqrt(math.Log(2+2/eps)/math.Pi) / math.Sqrt(2*math.Pi)
+}
+
+// sampleZ draws from D_{Z,sigma,center} with a FACCT sampler built on the
+// spot, which is cheap since the expanders share their base table. The
+// lattice samplers need a fresh width and center for every coordinate.
+func sampleZ(r *Rand, sigma, center float64) int64 {
+	e, err := newExpander(sigma, center, r)
+	if err != nil {
+		panic(err)
+	}
+	return (&FACCT{e}).Sample()
+}
+
+// combine returns sum_i z_i b_i.
+func combine(b lattice.Basis, z []int64) lattice.Vector {
+	v := lattice.ZeroVector(b.Dim())
+	for i, zi := range z {
+		if zi != 0 {
+			v = v.AddMul(big.NewInt(zi), b[i])
+		}
+	}
+	return v
+}
+
+// Klein samples D_{L(B),sigma,c} with Klein's randomized nearest plane
+// algorithm, in the form analyzed by Gentry, Peikert and Vaikuntanathan
+// ("Trapdoors for hard lattices and new cryptographic constructions").
+// It is Babai's nearest plane with the rounding replaced by sampling:
+// from the last Gram–Schmidt vector down to the first,
+//
+//	c'_i = <c, b*_i> / ||b*_i||^2,   sigma_i = sigma / ||b*_i||,
+//	z_i <- D_{Z,sigma_i,c'_i},        c <- c - z_i b_i,
+//
+// and the output is sum_i z_i b_i. Each step picks the hyperplane
+// z_i b*_i + span(b_0..b_{i-1}) with probability proportional to its
+// Gaussian mass, so the output follows D_{L,sigma,c} up to statistical
+// distance about n eps as long as every sigma_i is above the smoothing
+// parameter, that is sigma >= max_i ||b*_i|| eta_eps(Z) (MinWidth). A
+// reduced basis, with a flatter profile, therefore allows narrower
+// samples; this is what makes a short basis a trapdoor.
+type Klein struct {
+	b     lattice.Basis
+	g     *lattice.GSOFloat
+	sigma float64
+	r     *Rand
+}
+
+// NewKlein returns a Klein sampler of width sigma for the lattice of b,
+// whose float64 Gram–Schmidt data g is computed if nil. The basis may have
+// any rank; centers outside its span are projected onto it. sigma is not
+// checked against MinWidth, so that the effect of a too narrow width can be
+// observed.
+func NewKlein(b lattice.Basis, g *lattice.GSOFloat, sigma float64, r *Rand) (*Klein, error) {
+	if err := checkSigma(sigma, 0); err != nil {
+		return nil, err
+	}
+	if g == nil {
+		g = b.GramSchmidtFloat()
+	}
+	if len(g.Norm2) != b.Rank() {
+		return nil, fmt.Errorf("gauss: Gram–Schmidt data for rank %d, basis of rank %d", len(g.Norm2), b.Rank())
+	}
+	for i, n2 := range g.Norm2 {
+		if !(n2 > 0) {
+			return nil, fmt.Errorf("gauss: basis vector %d is dependent on the previous ones", i)
+		}
+	}
+	return &Klein{b: b, g: g, sigma: sigma, r: r}, nil
+}
+
+// MinWidth returns max_i ||b*_i|| Smoothing(eps), the width above which
+// the output distribution is guaranteed to be close to D_{L,sigma,c}.
+func (k *Klein) MinWidth(eps float64) float64 {
+	m := 0.0
+	for _, n2 := range k.g.Norm2 {
+		m = math.Max(m, n2)
+	}
+	return math.Sqrt(m) * Smoothing(eps)
+}
+
+// Sample returns a lattice point distributed as D_{L,sigma,center}.
+func (k *Klein) Sample(center []float64) (lattice.Vector, error) {
+	z, err := k.SampleCoeffs(center)
+	if err != nil {
+		return nil, err
+	}
+	return combine(k.b, z), nil
+}
+
+// SampleCoeffs is Sample returning the coefficients of the point in the
+// basis instead of the point itself.
+func (k *Klein) SampleCoeffs(center []float64) ([]int64, error) {
+	if len(center) != k.b.Dim() {
+		return nil, ErrCenter
+	}
+	n := k.b.Rank()
+	// The coordinates of the center along the b*_i are computed once; each
+	// step then updates them by the Gram–Schmidt coefficients of b_i
+	// instead of subtracting b_i in the full dimension.
+	c := make([]float64, n)
+	for i := range c {
+		dot := 0.0
+		for j, x := range k.g.BStar[i] {
+			dot += x * center[j]
+		}
+		c[i] = dot / k.g.Norm2[i]
+	}
+	z := make([]int64, n)
+	for i := n - 1; i >= 0; i-- {
+		z[i] = sampleZ(k.r, k.sigma/math.Sqrt(k.g.Norm2[i]), c[i])
+		// b_i = b*_i + sum_{j<i} mu_ij b*_j.
+		for j := 0; j < i; j++ {
+			c[j] -= float64(z[i]) * k.g.Mu[i][j]
+		}
+	}
+	return z, nil
+}
+
+// Peikert samples D_{L(B),sigma,c} with Peikert's convolution sampler ("An
+// efficient and parallel Gaussian sampler for lattices"). Randomized
+// rounding of the coordinates, z_i <- D_{Z,w,y_i}, turns a point y B into
+// a lattice point z B whose offset (z - y) B has the skewed covariance
+// w^2 B^T B. A continuous perturbation x_2 of covariance
+//
+//	Sigma_2 = sigma^2 I - w^2 B^T B
+//
+// subtracted from the center beforehand makes the total spherical:
+//
+//	y = (c - x_2) B^-1,   z_i <- D_{Z,w,y_i},   output z B,
+//
+// so the output follows D_{L,sigma,c} when w is above the smoothing
+// parameter of Z and Sigma_2 is positive definite, that is sigma > w s_1(B)
+// for the largest singular value s_1(B). The bound is weaker than Klein's,
+// since s_1(B) >= max ||b*_i||, but every step is a matrix-vector product
+// and the perturbation does not depend on c, so it can be precomputed
+// offline and the rounding done coordinate-wise in parallel.
+type Peikert struct {
+	b        lattice.Basis
+	inv      [][]float64 // B^-1
+	chol     [][]float64 // lower-triangular L with L L^T = Sigma_2
+	sigma, w float64
+	r        *Rand
+}
+
+// NewPeikert returns a convolution sampler of width sigma for the lattice
+// of the square basis b, rounding with width w, or Smoothing(DefaultEpsilon)
+// if w is 0. It fails if sigma is too small for Sigma_2 to be positive
+// definite.
+func NewPeikert(b lattice.Basis, sigma, w float64, r *Rand) (*Peikert, error) {
+	if err := checkSigma(sigma, 0); err != nil {
+		return nil, err
+	}
+	if w == 0 {
+		w = Smoothing(DefaultEpsilon)
+	}
+	if err := checkSigma(w, 0); err != nil {
+		return nil, err
+	}
+	n := b.Rank()
+	if n == 0 || b.Dim() != n {
+		return nil, lattice.ErrNotSquare
+	}
+	rows := b.Floats()
+	inv, ok := invert(rows)
+	if !ok {
+		return nil, errors.New("gauss: basis is singular")
+	}
+	// Sigma_2 = sigma^2 I - w^2 B^T B.
+	s2 := make([][]float64, n)
+	for i := range s2 {
+		s2[i] = make([]float64, n)
+		for j := range s2[i] {
+			dot := 0.0
+			for _, row := range rows {
+				dot += row[i] * row[j]
+			}
+			s2[i][j] = -w * w * dot
+		}
+		s2[i][i] += sigma * sigma
+	}
+	chol, ok := cholesky(s2)
+	if !ok {
+		return nil, fmt.Errorf("gauss: sigma = %g is below w s_1(B) for rounding width w = %g", sigma, w)
+	}
+	return &Peikert{b: b, inv: inv, chol: chol, sigma: sigma, w: w, r: r}, nil
+}
+
+// Perturbation returns a continuous Gaussian vector of covariance Sigma_2,
+// the offline part of the sampler.
+func (p *Peikert) Perturbation() []float64 {
+	n := len(p.chol)
+	g := make([]float64, n)
+	for i := range g {
+		g[i] = p.r.Normal()
+	}
+	x := make([]float64, n)
+	for i, row := range p.chol {
+		for j := 0; j <= i; j++ {
+			x[i] += row[j] * g[j]
+		}
+	}
+	return x
+}
+
+// Sample returns a lattice point distributed as D_{L,sigma,center}.
+func (p *Peikert) Sample(center []float64) (lattice.Vector, error) {
+	z, err := p.SampleCoeffs(center)
+	if err != nil {
+		return nil, err
+	}
+	return combine(p.b, z), nil
+}
+
+// SampleCoeffs is Sample returning the coefficients of the point in the
+// basis instead of the point itself.
+func (p *Peikert) SampleCoeffs(center []float64) ([]int64, error) {
+	return p.Round(center, p.Perturbation())
+}
+
+// Round is the online part of the sampler: it returns the coefficients of
+// a lattice point near center - x2, for a perturbation x2 from
+// Perturbation.
+func (p *Peikert) Round(center, x2 []float64) ([]int64, error) {
+	n := len(p.inv)
+	if len(center) != n || len(x2) != n {
+		return nil, ErrCenter
+	}
+	z := make([]int64, n)
+	for i := range z {
+		y := 0.0
+		for j := range n {
+			y += (center[j] - x2[j]) * p.inv[j][i]
+		}
+		z[i] = sampleZ(p.r, p.w, y)
+	}
+	return z, nil
+}
+
+// invert returns the inverse of the square matrix a by Gauss–Jordan
+// elimination with partial pivoting.
+func invert(a [][]float64) ([][]float64, bool) {
+	n := len(a)
+	m := make([][]float64, n)
+	for i := range m {
+		m[i] = make([]float64, 2*n)
+		copy(m[i], a[i])
+		m[i][n+i] = 1
+	}
+	for c := range n {
+		p := c
+		for r := c + 1; r < n; r++ {
+			if math.Abs(m[r][c]) > math.Abs(m[p][c]) {
+				p = r
+			}
+		}
+		if m[p][c] == 0 {
+			return nil, false
+		}
+		m[c], m[p] = m[p], m[c]
+		for r := range n {
+			if r == c || m[r][c] == 0 {
+				continue
+			}
+			f := m[r][c] / m[c][c]
+			for k := c; k < 2*n; k++ {
+				m[r][k] -= f * m[c][k]
+			}
+		}
+	}
+	inv := make([][]float64, n)
+	for i := range inv {
+		inv[i] = make([]float64, n)
+		for j := range n {
+			inv[i][j] = m[i][n+j] / m[i][i]
+		}
+	}
+	return inv, true
+}
+
+// cholesky returns the lower-triangular L with L L^T = a, or false if a is
+// not positive definite.
+func cholesky(a [][]float64) ([][]float64, bool) {
+	n := len(a)
+	l := make([][]float64, n)
+	for i := range l {
+		l[i] = make([]float64, n)
+		for j := 0; j <= i; j++ {
+			s := a[i][j]
+			for k := 0; k < j; k++ {
+				s -= l[i][k] * l[j][k]
+			}
+			if i == j {
+				if !(s > 0) {
+					return nil, false
+				}
+				l[i][i] = math.Sqrt(s)
+			} else {
+				l[i][j] = s / l[j][j]
+			}
+		}
+	}
+	return l, true
+}
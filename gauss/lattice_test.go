@@ -0,0 +1,109 @@
+package gauss
+
+import (
+	"math"
+	"testing"
+
+	"github.com/haopining/Learn-Lattice-Based-Cryptography/lattice"
+)
+
+// widths draws n samples and measures the offsets v - c along the unit
+// Gram–Schmidt directions b*_i / ||b*_i||, where an ideal D_{L,sigma,c}
+// above smoothing has independent N(0, sigma^2) coordinates. It returns
+// the empirical width in every direction and two p-values: of the mean,
+// n ||mean - c||^2 / sigma^2 being chi-squared with rank degrees of
+// freedom, and of the widths, the normalized deviations of the sums of
+// squared offsets, each chi-squared with n degrees of freedom, combined
+// into a chi-squared test with rank degrees of freedom.
+func widths(t *testing.T, sample func([]float64) (lattice.Vector, error), b lattice.Basis, sigma float64, center []float64, n int) (w []float64, meanP, widthP float64) {
+	g := b.GramSchmidtFloat()
+	k := b.Rank()
+	sum := make([]float64, k)
+	sum2 := make([]float64, k)
+	for range n {
+		v, err := sample(center)
+		if err != nil {
+			t.Fatal(err)
+		}
+		x := v.Floats()
+		for i, bs := range g.BStar {
+			e := 0.0
+			for j := range bs {
+				e += (x[j] - center[j]) * bs[j]
+			}
+			e /= math.Sqrt(g.Norm2[i])
+			sum[i] += e
+			sum2[i] += e * e
+		}
+	}
+	var off2, dev float64
+	for i := range k {
+		m := sum[i] / float64(n)
+		off2 += m * m
+		w = append(w, math.Sqrt(sum2[i]/float64(n)))
+		d := (sum2[i]/(sigma*sigma) - float64(n)) / math.Sqrt(2*float64(n))
+		dev += d * d
+	}
+	meanP = gammaQ(float64(k)/2, float64(n)*off2/(sigma*sigma)/2)
+	widthP = gammaQ(float64(k)/2, dev/2)
+	return w, meanP, widthP
+}
+
+func TestLatticeSamplers(t *testing.T) {
+	cases := []struct {
+		basis  lattice.Basis
+		center []float64
+		sigma  float64
+	}{
+		{lattice.NewBasis([][]int64{{4, 1, 0}, {1, 5, 2}, {0, 2, 6}}), []float64{0.5, 0.5, 0}, 20},
+		{lattice.NewBasis([][]int64{{7, 0, 0, 0}, {3, 11, 0, 0}, {-2, 5, 9, 0}, {1, -4, 2, 13}}), []float64{1.25, -3, 0.5, 7}, 40},
+	}
+	const n = 20000
+	for ci, c := range cases {
+		klein, err := NewKlein(c.basis, nil, c.sigma, NewSHAKE([]byte("klein")))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.sigma < klein.MinWidth(DefaultEpsilon) {
+			t.Fatalf("case %d: sigma %v below Klein's minimum %v", ci, c.sigma, klein.MinWidth(DefaultEpsilon))
+		}
+		peikert, err := NewPeikert(c.basis, c.sigma, 0, NewSHAKE([]byte("peikert")))
+		if err != nil {
+			t.Fatal(err)
+		}
+		samplers := []struct {
+			name   string
+			sample func([]float64) (lattice.Vector, error)
+		}{
+			{"klein", klein.Sample},
+			{"peikert", peikert.Sample},
+		}
+		for _, s := range samplers {
+			w, meanP, widthP := widths(t, s.sample, c.basis, c.sigma, c.center, n)
+			if meanP < 1e-3 {
+				t.Errorf("%s case %d: mean off the center, p = %.2g", s.name, ci, meanP)
+			}
+			if widthP < 1e-3 {
+				t.Errorf("%s case %d: widths %.4f, want %v, p = %.2g", s.name, ci, w, c.sigma, widthP)
+			}
+			for i, x := range w {
+				if math.Abs(x/c.sigma-1) > 0.03 {
+					t.Errorf("%s case %d: width %.4f along b*_%d, want %v", s.name, ci, x, i, c.sigma)
+				}
+			}
+		}
+	}
+}
+
+// TestKleinTooNarrow checks that the width test notices Klein's sampler
+// below its minimum width, where D_{L,sigma,c} is no longer smooth.
+func TestKleinTooNarrow(t *testing.T) {
+	b := lattice.NewBasis([][]int64{{4, 1, 0}, {1, 5, 2}, {0, 2, 6}})
+	klein, err := NewKlein(b, nil, 0.5, NewSHAKE([]byte("narrow")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, meanP, widthP := widths(t, klein.Sample, b, 0.5, []float64{0.5, 0.5, 0}, 20000); meanP > 1e-6 && widthP > 1e-6 {
+		t.Errorf("too narrow a width passed: p(mean) = %.2g, p(width) = %.2g", meanP, widthP)
+	}
+}
@@ -0,0 +1,99 @@
+package gauss
+
+import (
+	"crypto/rand"
+	"crypto/sha3"
+	"encoding/binary"
+	"io"
+	"math"
+	"math/bits"
+)
+
+const bufSize = 1024
+
+// Rand is a buffered source of random bits for the samplers.
+type Rand struct {
+	r    io.Reader
+	buf  [bufSize]byte
+	pos  int
+	word uint64
+	left int
+}
+
+// NewRand reads randomness from r, or from crypto/rand if r is nil.
+func NewRand(r io.Reader) *Rand {
+	if r == nil {
+		r = rand.Reader
+	}
+	return &Rand{r: r, pos: bufSize}
+}
+
+// NewSHAKE returns a deterministic source expanding seed with SHAKE256,
+// for reproducible experiments.
+func NewSHAKE(seed []byte) *Rand {
+	h := sha3.NewSHAKE256()
+	h.Write(seed)
+	return NewRand(h)
+}
+
+// Uint64 returns 64 uniform bits.
+func (r *Rand) Uint64() uint64 {
+	if r.pos == len(r.buf) {
+		if _, err := io.ReadFull(r.r, r.buf[:]); err != nil {
+			panic("gauss: random source failed: " + err.Error())
+		}
+		r.pos = 0
+	}
+	x := binary.LittleEndian.Uint64(r.buf[r.pos:])
+	r.pos += 8
+	return x
+}
+
+// Bit returns one uniform bit.
+func (r *Rand) Bit() uint64 {
+	if r.left == 0 {
+		r.word, r.left = r.Uint64(), 64
+	}
+	b := r.word & 1
+	r.word >>= 1
+	r.left--
+	return b
+}
+
+// Below returns a value in [0, k) as the high word of a 64x64-bit product,
+// which needs no rejection loop; its bias is below k/2^64.
+func (r *Rand) Below(k uint64) uint64 {
+	hi, _ := bits.Mul64(r.Uint64(), k)
+	return hi
+}
+
+// Float64 returns a uniform value in [0, 1) with 53 random bits.
+func (r *Rand) Float64() float64 {
+	return float64(r.Uint64()>>11) * 0x1p-53
+}
+
+// Normal returns a standard normal value by the Box–Muller transform. It
+// is used for the continuous perturbations of the lattice samplers, which
+// need no more than float64 accuracy.
+func (r *Rand) Normal() float64 {
+	u := 1 - r.Float64() // in (0, 1], so the logarithm is finite
+	return math.Sqrt(-2*math.Log(u)) * math.Cos(2*math.Pi*r.Float64())
+}
+
+// ctEq returns 1 if a == b and 0 otherwise, without branching.
+func ctEq(a, b int64) int64 {
+	x := uint64(a ^ b)
+	return int64(1 ^ ((x | -x) >> 63))
+}
+
+// ctLess returns 1 if a < b and 0 otherwise: the borrow of a - b.
+func ctLess(a, b uint64) uint64 {
+	_, borrow := bits.Sub64(a, b, 0)
+	return borrow
+}
+
+// ctSelect returns x if bit is 1 and y if it is 0.
+func ctSelect(bit, x, y int64) int64 {
+	m := -bit
+	return (x & m) | (y &^ m)
+}
@@ -0,0 +1,59 @@
+package gauss
+
+import "fmt"
+
+// Skewed samples the non-spherical integer Gaussian D_{Z^m, sqrt(Sigma)},
+// of density proportional to exp(-x^T Sigma^-1 x / 2), by the method of
+// Peikert's convolution sampler: a continuous Gaussian y of covariance
+// Sigma - w^2 I, then randomized rounding z_i <- D_{Z,w,y_i}. Adding the
+// rounding noise w^2 I restores the covariance Sigma, and the result is
+// close to the discrete distribution when w is above the smoothing
+// parameter of Z. Trapdoor samplers use it for their perturbations.
+type Skewed struct {
+	chol [][]float64
+	w    float64
+	r    *Rand
+}
+
+// NewSkewed returns a sampler for D_{Z^m, sqrt(cov)} rounding with width w,
+// or Smoothing(DefaultEpsilon) if w is 0. It fails unless cov - w^2 I is
+// positive definite.
+func NewSkewed(cov [][]float64, w float64, r *Rand) (*Skewed, error) {
+	if w == 0 {
+		w = Smoothing(DefaultEpsilon)
+	}
+	if err := checkSigma(w, 0); err != nil {
+		return nil, err
+	}
+	c := make([][]float64, len(cov))
+	for i := range c {
+		if len(cov[i]) != len(cov) {
+			return nil, fmt.Errorf("gauss: covariance is not square")
+		}
+		c[i] = append([]float64(nil), cov[i]...)
+		c[i][i] -= w * w
+	}
+	chol, ok := cholesky(c)
+	if !ok {
+		return nil, fmt.Errorf("gauss: covariance minus %g^2 I is not positive definite", w)
+	}
+	return &Skewed{chol: chol, w: w, r: r}, nil
+}
+
+// Sample returns one vector.
+func (s *Skewed) Sample() []int64 {
+	n := len(s.chol)
+	g := make([]float64, n)
+	for i := range g {
+		g[i] = s.r.Normal()
+	}
+	z := make([]int64, n)
+	for i, row := range s.chol {
+		y := 0.0
+		for j := 0; j <= i; j++ {
+			y += row[j] * g[j]
+		}
+		z[i] = sampleZ(s.r, s.w, y)
+	}
+	return z
+}
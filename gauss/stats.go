@@ -0,0 +1,155 @@
+package gauss
+
+import "math"
+
+// The divergences below measure how far the exact distribution P of a
+// sampler is from the ideal Q. They are tiny for a good sampler, so they
+// are computed from the relative errors delta = (P - Q)/Q with log1p and
+// expm1, and the first-order term sum_x Q delta = sum P - sum Q, which is
+// zero for two distributions but only up to rounding, is left out.
+// Otherwise the float64 rounding of the two normalizations, about 1e-16,
+// would swamp the result.
+
+// Renyi returns R_a(P || Q) - 1 for the Rényi divergence of order a > 1,
+//
+//	R_a(P || Q) = ( sum_x P(x)^a / Q(x)^(a-1) )^(1/(a-1)),
+//
+// or max_x P(x)/Q(x) - 1 for a = +Inf. It is +Inf if P has mass where Q
+// has none. Security proofs multiply success probabilities by R_a, so a
+// sampler with R_a - 1 = 2^-k costs about 2^-k in security.
+func Renyi(p, q Dist, a float64) float64 {
+	inf := math.IsInf(a, 1)
+	m, s := -1.0, 0.0
+	for z := min(p.Lo, q.Lo); z <= max(p.Hi(), q.Hi()); z++ {
+		pz, qz := p.Prob(z), q.Prob(z)
+		if qz == 0 {
+			if pz > 0 {
+				return math.Inf(1)
+			}
+			continue
+		}
+		d := (pz - qz) / qz
+		if inf {
+			m = math.Max(m, d)
+			continue
+		}
+		// Q (1 + delta)^a = Q (1 + a delta + ...).
+		s += qz * (math.Expm1(a*math.Log1p(d)) - a*d)
+	}
+	if inf {
+		return m
+	}
+	return math.Expm1(math.Log1p(s) / (a - 1))
+}
+
+// KL returns the Kullback–Leibler divergence D(P || Q) in nats.
+func KL(p, q Dist) float64 {
+	s := 0.0
+	for z := min(p.Lo, q.Lo); z <= max(p.Hi(), q.Hi()); z++ {
+		pz, qz := p.Prob(z), q.Prob(z)
+		if qz == 0 {
+			if pz > 0 {
+				return math.Inf(1)
+			}
+			continue
+		}
+		d := (pz - qz) / qz
+		// P log(P/Q) = Q ((1 + delta) log1p(delta)), whose first-order
+		// term is Q delta.
+		t := -d
+		if d > -1 {
+			t = (1+d)*math.Log1p(d) - d
+		}
+		s += qz * t
+	}
+	return s
+}
+
+// ChiSquared tests whether the counts, counts[i] occurrences of lo + i,
+// fit the distribution q. Values with expected count below 5 are pooled
+// into the two tails, as the test requires. It returns the statistic, the
+// degrees of freedom and the p-value, the probability that a true sample
+// from q gives a statistic at least as large.
+func ChiSquared(lo int64, counts []int, q Dist) (stat float64, dof int, pvalue float64) {
+	n := 0
+	for _, c := range counts {
+		n += c
+	}
+	observed := func(z int64) float64 {
+		if z < lo || z >= lo+int64(len(counts)) {
+			return 0
+		}
+		return float64(counts[z-lo])
+	}
+	zlo, zhi := min(lo, q.Lo), max(lo+int64(len(counts))-1, q.Hi())
+	var binsO, binsE []float64
+	var accO, accE float64
+	for z := zlo; z <= zhi; z++ {
+		accO += observed(z)
+		accE += float64(n) * q.Prob(z)
+		if accE >= 5 {
+			binsO = append(binsO, accO)
+			binsE = append(binsE, accE)
+			accO, accE = 0, 0
+		}
+	}
+	if len(binsE) == 0 {
+		return 0, 0, 1
+	}
+	// Fold the right tail into the last bin.
+	binsO[len(binsO)-1] += accO
+	binsE[len(binsE)-1] += accE
+	for i := range binsO {
+		d := binsO[i] - binsE[i]
+		stat += d * d / binsE[i]
+	}
+	dof = len(binsO) - 1
+	if dof == 0 {
+		return stat, 0, 1
+	}
+	return stat, dof, gammaQ(float64(dof)/2, stat/2)
+}
+
+// gammaQ is the regularized upper incomplete gamma function Q(a, x), by
+// its series for x < a + 1 and its continued fraction otherwise.
+func gammaQ(a, x float64) float64 {
+	if x <= 0 {
+		return 1
+	}
+	lg, _ := math.Lgamma(a)
+	if x < a+1 {
+		sum, term := 1/a, 1/a
+		for k := 1; k < 1000; k++ {
+			term *= x / (a + float64(k))
+			sum += term
+			if term < sum*1e-16 {
+				break
+			}
+		}
+		return 1 - sum*math.Exp(-x+a*math.Log(x)-lg)
+	}
+	// Modified Lentz evaluation of the continued fraction.
+	const tiny = 1e-300
+	b := x + 1 - a
+	c, d := 1/tiny, 1/b
+	h := d
+	for k := 1; k < 1000; k++ {
+		an := -float64(k) * (float64(k) - a)
+		b += 2
+		d = an*d + b
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = b + an/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		del := d * c
+		h *= del
+		if math.Abs(del-1) < 1e-16 {
+			break
+		}
+	}
+	return math.Exp(-x+a*math.Log(x)-lg) * h
+}
@@ -0,0 +1,96 @@
+package gauss
+
+import (
+	"math"
+	"testing"
+)
+
+var samplers = []struct {
+	name string
+	make func(sigma, center float64, r *Rand) (Sampler, error)
+}{
+	{"cdt", func(s, c float64, r *Rand) (Sampler, error) { return NewCDT(s, c, r) }},
+	{"knuthyao", func(s, c float64, r *Rand) (Sampler, error) { return NewKnuthYao(s, c, r) }},
+	{"bernoulli", func(s, c float64, r *Rand) (Sampler, error) { return NewBernoulli(s, c, r) }},
+	{"facct", func(s, c float64, r *Rand) (Sampler, error) { return NewFACCT(s, c, r) }},
+}
+
+// sample draws n samples of s and counts them over the support of q.
+func sample(s Sampler, q Dist, n int) []int {
+	counts := make([]int, len(q.P))
+	for range n {
+		if z := s.Sample(); z >= q.Lo && z <= q.Hi() {
+			counts[z-q.Lo]++
+		}
+	}
+	return counts
+}
+
+// TestSamplers compares every sampler with D_{Z,sigma,c}: its exact
+// distribution by the Rényi and Kullback–Leibler divergences, and its
+// samples, from a fixed SHAKE seed, by a chi-squared test.
+func TestSamplers(t *testing.T) {
+	cases := []struct{ sigma, center float64 }{
+		{3.2, 0},
+		{3.2, 0.25},
+		{19.5, -0.4},
+	}
+	for _, sm := range samplers {
+		for _, c := range cases {
+			s, err := sm.make(c.sigma, c.center, NewSHAKE([]byte("gauss "+sm.name)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			p := s.Distribution()
+			lo, hi := support(c.sigma, c.center)
+			q := Ideal(c.sigma, c.center, min(lo, p.Lo), max(hi, p.Hi()))
+			if r2 := Renyi(p, q, 2); r2 > 0x1p-50 {
+				t.Errorf("%s sigma %v center %v: R_2 - 1 = %.3g, want below 2^-50", sm.name, c.sigma, c.center, r2)
+			}
+			if kl := KL(p, q); kl > 0x1p-50 {
+				t.Errorf("%s sigma %v center %v: KL = %.3g, want below 2^-50", sm.name, c.sigma, c.center, kl)
+			}
+			stat, dof, pv := ChiSquared(q.Lo, sample(s, q, 50000), q)
+			if pv < 1e-3 {
+				t.Errorf("%s sigma %v center %v: chi2 = %.1f with %d dof, p = %.2g", sm.name, c.sigma, c.center, stat, dof, pv)
+			}
+		}
+	}
+}
+
+// TestChiSquaredRejects checks that the chi-squared test tells a wrong
+// width apart.
+func TestChiSquaredRejects(t *testing.T) {
+	s, err := NewFACCT(3.2, 0, NewSHAKE([]byte("gauss reject")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := Ideal(3.5, 0, -50, 50)
+	if stat, dof, pv := ChiSquared(q.Lo, sample(s, q, 50000), q); pv > 1e-6 {
+		t.Errorf("samples of width 3.2 fit width 3.5: chi2 = %.1f with %d dof, p = %.2g", stat, dof, pv)
+	}
+}
+
+func TestDivergences(t *testing.T) {
+	q := Ideal(3.2, 0, -50, 50)
+	for _, a := range []float64{2, 64, math.Inf(1)} {
+		if r := Renyi(q, q, a); math.Abs(r) > 1e-15 {
+			t.Errorf("R_%v(Q || Q) - 1 = %g", a, r)
+		}
+	}
+	if kl := KL(q, q); math.Abs(kl) > 1e-15 {
+		t.Errorf("KL(Q || Q) = %g", kl)
+	}
+	// Q has no mass outside [-5, 5].
+	narrow := Ideal(3.2, 0, -5, 5)
+	if !math.IsInf(Renyi(q, narrow, 2), 1) || !math.IsInf(KL(q, narrow), 1) {
+		t.Error("divergences finite where Q has no mass")
+	}
+	// Against a wider Gaussian the divergences are positive and ordered:
+	// KL <= log R_2 <= log R_inf.
+	wide := Ideal(3.3, 0, -50, 50)
+	kl, r2, rinf := KL(q, wide), Renyi(q, wide, 2), Renyi(q, wide, math.Inf(1))
+	if !(kl > 0 && kl <= math.Log1p(r2) && math.Log1p(r2) <= math.Log1p(rinf)) {
+		t.Errorf("KL = %g, log R_2 = %g, log R_inf = %g not increasing", kl, math.Log1p(r2), math.Log1p(rinf))
+	}
+}
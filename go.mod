@@ -0,0 +1,3 @@
+module github.com/haopining/Learn-Lattice-Based-Cryptography
+
+go 1.24
@@ -0,0 +1,211 @@
+package hpke
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"math/bits"
+)
+
+// ChaCha20-Poly1305 (RFC 8439) is not in the standard library before Go
+// 1.26, and this module has no dependencies, so here is a plain
+// implementation: portable, constant-time, and slow next to assembly.
+
+var errOpen = errors.New("hpke: message authentication failed")
+
+type chacha20poly1305 struct {
+	key [8]uint32
+}
+
+func newChaCha20Poly1305(key []byte) (cipher.AEAD, error) {
+	if len(key) != 32 {
+		return nil, errors.New("hpke: ChaCha20-Poly1305 key must be 32 bytes")
+	}
+	c := &chacha20poly1305{}
+	for i := range c.key {
+		c.key[i] = binary.LittleEndian.Uint32(key[4*i:])
+	}
+	return c, nil
+}
+
+func (c *chacha20poly1305) NonceSize() int { return 12 }
+func (c *chacha20poly1305) Overhead() int  { return 16 }
+
+func (c *chacha20poly1305) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	var block [64]byte
+	c.block(&block, 0, nonce)
+	out := append(dst, make([]byte, len(plaintext)+16)...)
+	ct := out[len(dst) : len(dst)+len(plaintext)]
+	c.xorKeyStream(ct, plaintext, nonce)
+	tag := poly1305(block[:32], additionalData, ct)
+	copy(out[len(dst)+len(plaintext):], tag[:])
+	return out
+}
+
+func (c *chacha20poly1305) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(ciphertext) < 16 {
+		return nil, errOpen
+	}
+	ct, tag := ciphertext[:len(ciphertext)-16], ciphertext[len(ciphertext)-16:]
+	var block [64]byte
+	c.block(&block, 0, nonce)
+	want := poly1305(block[:32], additionalData, ct)
+	if subtle.ConstantTimeCompare(want[:], tag) != 1 {
+		return nil, errOpen
+	}
+	out := append(dst, make([]byte, len(ct))...)
+	c.xorKeyStream(out[len(dst):], ct, nonce)
+	return out, nil
+}
+
+// xorKeyStream encrypts src into dst with the key stream from block
+// counter 1, block 0 being the Poly1305 key.
+func (c *chacha20poly1305) xorKeyStream(dst, src, nonce []byte) {
+	var block [64]byte
+	for i := 0; i < len(src); i += 64 {
+		c.block(&block, uint32(1+i/64), nonce)
+		subtle.XORBytes(dst[i:], src[i:min(i+64, len(src))], block[:])
+	}
+}
+
+// block is the ChaCha20 block function (RFC 8439, Section 2.3).
+func (c *chacha20poly1305) block(out *[64]byte, counter uint32, nonce []byte) {
+	s := [16]uint32{0x61707865, 0x3320646e, 0x79622d32, 0x6b206574}
+	copy(s[4:12], c.key[:])
+	s[12] = counter
+	for i := range 3 {
+		s[13+i] = binary.LittleEndian.Uint32(nonce[4*i:])
+	}
+	x := s
+	for range 10 {
+		quarterRound(&x, 0, 4, 8, 12)
+		quarterRound(&x, 1, 5, 9, 13)
+		quarterRound(&x, 2, 6, 10, 14)
+		quarterRound(&x, 3, 7, 11, 15)
+		quarterRound(&x, 0, 5, 10, 15)
+		quarterRound(&x, 1, 6, 11, 12)
+		quarterRound(&x, 2, 7, 8, 13)
+		quarterRound(&x, 3, 4, 9, 14)
+	}
+	for i := range x {
+		binary.LittleEndian.PutUint32(out[4*i:], x[i]+s[i])
+	}
+}
+
+func quarterRound(x *[16]uint32, a, b, c, d int) {
+	x[a] += x[b]
+	x[d] = bits.RotateLeft32(x[d]^x[a], 16)
+	x[c] += x[d]
+	x[b] = bits.RotateLeft32(x[b]^x[c], 12)
+	x[a] += x[b]
+	x[d] = bits.RotateLeft32(x[d]^x[a], 8)
+	x[c] += x[d]
+	x[b] = bits.RotateLeft32(x[b]^x[c], 7)
+}
+
+// poly1305 returns the tag of the AEAD construction (RFC 8439,
+// Section 2.8): the Poly1305 MAC with one-time key k of
+// aad || pad16 || ciphertext || pad16 || len(aad) || len(ciphertext).
+func poly1305(k, aad, ciphertext []byte) [16]byte {
+	var p poly1305State
+	p.init(k)
+	p.write(aad)
+	p.write(ciphertext)
+	var lengths [16]byte
+	binary.LittleEndian.PutUint64(lengths[:], uint64(len(aad)))
+	binary.LittleEndian.PutUint64(lengths[8:], uint64(len(ciphertext)))
+	p.write(lengths[:])
+	return p.sum()
+}
+
+// poly1305State evaluates the Poly1305 polynomial modulo 2^130 - 5 in
+// five 26-bit limbs. Every write is padded with zeros to a multiple of 16
+// bytes, as the AEAD construction does.
+type poly1305State struct {
+	r, h [5]uint64
+	s    [4]uint32
+}
+
+func (p *poly1305State) init(k []byte) {
+	// Clamp r.
+	p.r[0] = uint64(binary.LittleEndian.Uint32(k[0:])) & 0x3ffffff
+	p.r[1] = uint64(binary.LittleEndian.Uint32(k[3:])>>2) & 0x3ffff03
+	p.r[2] = uint64(binary.LittleEndian.Uint32(k[6:])>>4) & 0x3ffc0ff
+	p.r[3] = uint64(binary.LittleEndian.Uint32(k[9:])>>6) & 0x3f03fff
+	p.r[4] = uint64(binary.LittleEndian.Uint32(k[12:])>>8) & 0x00fffff
+	for i := range p.s {
+		p.s[i] = binary.LittleEndian.Uint32(k[16+4*i:])
+	}
+}
+
+func (p *poly1305State) write(b []byte) {
+	for len(b) > 0 {
+		var m [16]byte
+		n := copy(m[:], b)
+		b = b[n:]
+		h := &p.h
+		h[0] += uint64(binary.LittleEndian.Uint32(m[0:])) & 0x3ffffff
+		h[1] += uint64(binary.LittleEndian.Uint32(m[3:])>>2) & 0x3ffffff
+		h[2] += uint64(binary.LittleEndian.Uint32(m[6:])>>4) & 0x3ffffff
+		h[3] += uint64(binary.LittleEndian.Uint32(m[9:])>>6) & 0x3ffffff
+		h[4] += uint64(binary.LittleEndian.Uint32(m[12:])>>8) | 1<<24
+		r := &p.r
+		// Limbs of r times 5, for the reduction 2^130 = 5.
+		s1, s2, s3, s4 := 5*r[1], 5*r[2], 5*r[3], 5*r[4]
+		d0 := h[0]*r[0] + h[1]*s4 + h[2]*s3 + h[3]*s2 + h[4]*s1
+		d1 := h[0]*r[1] + h[1]*r[0] + h[2]*s4 + h[3]*s3 + h[4]*s2
+		d2 := h[0]*r[2] + h[1]*r[1] + h[2]*r[0] + h[3]*s4 + h[4]*s3
+		d3 := h[0]*r[3] + h[1]*r[2] + h[2]*r[1] + h[3]*r[0] + h[4]*s4
+		d4 := h[0]*r[4] + h[1]*r[3] + h[2]*r[2] + h[3]*r[1] + h[4]*r[0]
+		d1 += d0 >> 26
+		d2 += d1 >> 26
+		d3 += d2 >> 26
+		d4 += d3 >> 26
+		h[0] = d0&0x3ffffff + 5*(d4>>26)
+		h[1] = d1 & 0x3ffffff
+		h[2] = d2 & 0x3ffffff
+		h[3] = d3 & 0x3ffffff
+		h[4] = d4 & 0x3ffffff
+		h[1] += h[0] >> 26
+		h[0] &= 0x3ffffff
+	}
+}
+
+func (p *poly1305State) sum() [16]byte {
+	h := p.h
+	// Carry fully, then subtract 2^130 - 5 if h is at least that, by
+	// selecting h + 5 - 2^130 with a mask.
+	for i := range 4 {
+		h[i+1] += h[i] >> 26
+		h[i] &= 0x3ffffff
+	}
+	h[0] += 5 * (h[4] >> 26)
+	h[4] &= 0x3ffffff
+	h[1] += h[0] >> 26
+	h[0] &= 0x3ffffff
+	var g [5]uint64
+	g[0] = h[0] + 5
+	for i := range 4 {
+		g[i+1] = h[i+1] + g[i]>>26
+		g[i] &= 0x3ffffff
+	}
+	mask := -(g[4] >> 26) // all ones if h + 5 >= 2^130
+	g[4] &= 0x3ffffff
+	for i := range h {
+		h[i] = h[i]&^mask | g[i]&mask
+	}
+	// h mod 2^128 + s.
+	w0 := h[0] | h[1]<<26
+	w1 := h[1]>>6 | h[2]<<20
+	w2 := h[2]>>12 | h[3]<<14
+	w3 := h[3]>>18 | h[4]<<8
+	var out [16]byte
+	var carry uint64
+	for i, w := range []uint64{w0, w1, w2, w3} {
+		carry += w&0xffffffff + uint64(p.s[i])
+		binary.LittleEndian.PutUint32(out[4*i:], uint32(carry))
+		carry >>= 32
+	}
+	return out
+}
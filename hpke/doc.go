@@ -0,0 +1,28 @@
+// Package hpke implements Hybrid Public Key Encryption (RFC 9180) with the
+// lattice KEMs of this module: a KEM to agree on a shared secret, HKDF to
+// derive keys from it, and an AEAD to encrypt under them, the KEM-DEM
+// construction behind encrypting files and messages to a public key.
+//
+// The KEMs are ML-KEM at the three levels and X-Wing, the hybrid of
+// ML-KEM-768 and X25519, with the identifiers of draft-ietf-hpke-pq; any
+// other kem.KEM can be given an identifier with a KEM value. The KDFs are
+// HKDF with SHA-256, SHA-384 or SHA-512, and the AEADs AES-128-GCM,
+// AES-256-GCM, ChaCha20-Poly1305 (implemented here, as the standard
+// library lacks it before Go 1.26) and the export-only AEAD.
+//
+// Two modes are supported: base, and PSK, in which sender and recipient
+// also share a secret key, so that the recipient knows the sender holds
+// it. The authenticated modes need a Diffie–Hellman style AuthEncap that
+// lattice KEMs lack. A Sender seals a sequence of messages and a
+// Recipient opens them in the same order; both can Export secrets bound
+// to the context, for uses other than the AEAD. Seal and Open are the
+// single-shot form for one message:
+//
+//	enc, ct, err := hpke.Seal(suite, pk, info, aad, plaintext)
+//	plaintext, err := hpke.Open(suite, sk, enc, info, aad, ct)
+//
+// Every suite of ML-KEM-768, ML-KEM-1024 or X-Wing interoperates with Go's
+// crypto/hpke in both directions, including Export; crypto/hpke has no
+// ML-KEM-512 and no PSK mode, so those are only checked against this
+// package itself.
+package hpke
@@ -0,0 +1,306 @@
+package hpke
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+
+	"github.com/haopining/Learn-Lattice-Based-Cryptography/kem"
+	"github.com/haopining/Learn-Lattice-Based-Cryptography/mlkem"
+)
+
+// A KEM is a key encapsulation mechanism of package kem with its HPKE
+// identifier. Its shared keys are used as the HPKE shared secret as they
+// are, which is right for ML-KEM and X-Wing, whose shared keys are already
+// uniform and bound to the ciphertext.
+type KEM struct {
+	ID  uint16
+	KEM kem.KEM
+}
+
+// The KEMs, with the identifiers of draft-ietf-hpke-pq.
+var (
+	MLKEM512  = &KEM{0x0040, kem.MLKEM(mlkem.MLKEM512)}
+	MLKEM768  = &KEM{0x0041, kem.MLKEM(mlkem.MLKEM768)}
+	MLKEM1024 = &KEM{0x0042, kem.MLKEM(mlkem.MLKEM1024)}
+	// XWing is MLKEM768-X25519.
+	XWing = &KEM{0x647a, kem.XWing()}
+)
+
+func (k *KEM) String() string { return k.KEM.Name() }
+
+// A KDF is an HKDF instance.
+type KDF struct {
+	ID   uint16
+	Name string
+	hash func() hash.Hash
+	size int // Nh
+}
+
+// The KDFs of RFC 9180, Section 7.2.
+var (
+	HKDFSHA256 = &KDF{0x0001, "HKDF-SHA256", sha256.New, 32}
+	HKDFSHA384 = &KDF{0x0002, "HKDF-SHA384", sha512.New384, 48}
+	HKDFSHA512 = &KDF{0x0003, "HKDF-SHA512", sha512.New, 64}
+)
+
+func (k *KDF) String() string { return k.Name }
+
+// An AEAD is an authenticated cipher; KeySize and NonceSize are Nk and Nn.
+type AEAD struct {
+	ID                 uint16
+	Name               string
+	KeySize, NonceSize int
+	new                func(key []byte) (cipher.AEAD, error)
+}
+
+// The AEADs of RFC 9180, Section 7.3. ExportOnly allows no encryption,
+// only Export.
+var (
+	AES128GCM        = &AEAD{0x0001, "AES-128-GCM", 16, 12, newGCM}
+	AES256GCM        = &AEAD{0x0002, "AES-256-GCM", 32, 12, newGCM}
+	ChaCha20Poly1305 = &AEAD{0x0003, "ChaCha20-Poly1305", 32, 12, newChaCha20Poly1305}
+	ExportOnly       = &AEAD{0xffff, "Export-only", 0, 0, nil}
+)
+
+func (a *AEAD) String() string { return a.Name }
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	b, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(b)
+}
+
+// A Suite is a choice of KEM, KDF and AEAD.
+type Suite struct {
+	KEM  *KEM
+	KDF  *KDF
+	AEAD *AEAD
+}
+
+func (s Suite) String() string {
+	return fmt.Sprintf("%v, %v, %v", s.KEM, s.KDF, s.AEAD)
+}
+
+// id is suite_id = "HPKE" || kem_id || kdf_id || aead_id.
+func (s Suite) id() []byte {
+	b := []byte("HPKE")
+	b = binary.BigEndian.AppendUint16(b, s.KEM.ID)
+	b = binary.BigEndian.AppendUint16(b, s.KDF.ID)
+	return binary.BigEndian.AppendUint16(b, s.AEAD.ID)
+}
+
+// labeledExtract is LabeledExtract(salt, label, ikm) of RFC 9180,
+// Section 4.
+func (s Suite) labeledExtract(salt []byte, label string, ikm []byte) []byte {
+	in := append([]byte("HPKE-v1"), s.id()...)
+	in = append(in, label...)
+	in = append(in, ikm...)
+	prk, err := hkdf.Extract(s.KDF.hash, in, salt)
+	if err != nil {
+		panic("hpke: " + err.Error())
+	}
+	return prk
+}
+
+// labeledExpand is LabeledExpand(prk, label, info, L).
+func (s Suite) labeledExpand(prk []byte, label string, info []byte, length int) ([]byte, error) {
+	if length > 0xffff {
+		return nil, errors.New("hpke: expansion too long")
+	}
+	in := binary.BigEndian.AppendUint16(nil, uint16(length))
+	in = append(in, "HPKE-v1"...)
+	in = append(in, s.id()...)
+	in = append(in, label...)
+	in = append(in, info...)
+	return hkdf.Expand(s.KDF.hash, prk, string(in), length)
+}
+
+// The modes of RFC 9180, Section 5. The authenticated modes need a KEM
+// with AuthEncap, which the lattice KEMs do not have.
+const (
+	modeBase = 0x00
+	modePSK  = 0x01
+)
+
+var (
+	errPSK     = errors.New("hpke: the PSK and its identifier must both be set, or both be empty")
+	errSeq     = errors.New("hpke: message limit reached")
+	errNoAEAD  = errors.New("hpke: suite is export-only")
+	errEncSize = errors.New("hpke: invalid encapsulated key size")
+)
+
+// context is the state shared by Sender and Recipient.
+type context struct {
+	suite     Suite
+	aead      cipher.AEAD
+	baseNonce []byte
+	seq       uint64
+	exporter  []byte
+}
+
+// keySchedule is KeySchedule of RFC 9180, Section 5.1.
+func keySchedule(s Suite, mode byte, sharedSecret, info, psk, pskID []byte) (*context, error) {
+	if (len(psk) == 0) != (len(pskID) == 0) || (mode == modePSK) != (len(psk) > 0) {
+		return nil, errPSK
+	}
+	pskIDHash := s.labeledExtract(nil, "psk_id_hash", pskID)
+	infoHash := s.labeledExtract(nil, "info_hash", info)
+	ksContext := append(append([]byte{mode}, pskIDHash...), infoHash...)
+	secret := s.labeledExtract(sharedSecret, "secret", psk)
+	c := &context{suite: s}
+	var err error
+	if c.exporter, err = s.labeledExpand(secret, "exp", ksContext, s.KDF.size); err != nil {
+		return nil, err
+	}
+	if s.AEAD.new == nil {
+		return c, nil
+	}
+	key, err := s.labeledExpand(secret, "key", ksContext, s.AEAD.KeySize)
+	if err != nil {
+		return nil, err
+	}
+	if c.baseNonce, err = s.labeledExpand(secret, "base_nonce", ksContext, s.AEAD.NonceSize); err != nil {
+		return nil, err
+	}
+	if c.aead, err = s.AEAD.new(key); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// nonce returns base_nonce XOR I2OSP(seq, Nn). The caller increments seq
+// once the message is sealed or opened.
+func (c *context) nonce() ([]byte, error) {
+	if c.aead == nil {
+		return nil, errNoAEAD
+	}
+	if c.seq == 1<<64-1 {
+		return nil, errSeq
+	}
+	n := append([]byte(nil), c.baseNonce...)
+	for i := range 8 {
+		n[len(n)-1-i] ^= byte(c.seq >> (8 * i))
+	}
+	return n, nil
+}
+
+// Export returns length bytes of secret derived from the context and
+// exporterContext (RFC 9180, Section 5.3). Sender and recipient get the
+// same bytes.
+func (c *context) Export(exporterContext []byte, length int) ([]byte, error) {
+	return c.suite.labeledExpand(c.exporter, "sec", exporterContext, length)
+}
+
+// A Sender encrypts a sequence of messages to one public key.
+type Sender struct {
+	context
+}
+
+// A Recipient decrypts the messages of a Sender, in order.
+type Recipient struct {
+	context
+}
+
+// NewSender sets up a sender in base mode and returns it with the
+// encapsulated key, enc, which the recipient needs.
+func NewSender(s Suite, pk kem.EncapsulationKey, info []byte) (enc []byte, _ *Sender, err error) {
+	return newSender(s, pk, modeBase, info, nil, nil)
+}
+
+// NewSenderPSK sets up a sender in PSK mode: the recipient must also know
+// psk, which should hold at least 32 bytes of entropy, to decrypt. pskID
+// names the PSK for the recipient.
+func NewSenderPSK(s Suite, pk kem.EncapsulationKey, info, psk, pskID []byte) (enc []byte, _ *Sender, err error) {
+	return newSender(s, pk, modePSK, info, psk, pskID)
+}
+
+func newSender(s Suite, pk kem.EncapsulationKey, mode byte, info, psk, pskID []byte) ([]byte, *Sender, error) {
+	sharedSecret, enc := pk.Encapsulate()
+	c, err := keySchedule(s, mode, sharedSecret, info, psk, pskID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return enc, &Sender{*c}, nil
+}
+
+// NewRecipient sets up the recipient of a base-mode sender.
+func NewRecipient(s Suite, sk kem.DecapsulationKey, enc, info []byte) (*Recipient, error) {
+	return newRecipient(s, sk, modeBase, enc, info, nil, nil)
+}
+
+// NewRecipientPSK sets up the recipient of a PSK-mode sender.
+func NewRecipientPSK(s Suite, sk kem.DecapsulationKey, enc, info, psk, pskID []byte) (*Recipient, error) {
+	return newRecipient(s, sk, modePSK, enc, info, psk, pskID)
+}
+
+func newRecipient(s Suite, sk kem.DecapsulationKey, mode byte, enc, info, psk, pskID []byte) (*Recipient, error) {
+	if len(enc) != s.KEM.KEM.CiphertextSize() {
+		return nil, errEncSize
+	}
+	sharedSecret, err := sk.Decapsulate(enc)
+	if err != nil {
+		return nil, err
+	}
+	c, err := keySchedule(s, mode, sharedSecret, info, psk, pskID)
+	if err != nil {
+		return nil, err
+	}
+	return &Recipient{*c}, nil
+}
+
+// Seal encrypts and authenticates the next message.
+func (s *Sender) Seal(aad, plaintext []byte) ([]byte, error) {
+	nonce, err := s.nonce()
+	if err != nil {
+		return nil, err
+	}
+	s.seq++
+	return s.aead.Seal(nil, nonce, plaintext, aad), nil
+}
+
+// Open decrypts the next message. A message that fails to authenticate
+// does not use up its sequence number, so the genuine message can follow.
+func (r *Recipient) Open(aad, ciphertext []byte) ([]byte, error) {
+	nonce, err := r.nonce()
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := r.aead.Open(nil, nonce, ciphertext, aad)
+	if err != nil {
+		return nil, err
+	}
+	r.seq++
+	return plaintext, nil
+}
+
+// Seal encrypts a single message to pk in base mode: the single-shot API
+// of RFC 9180, Section 6.1. It returns enc and the ciphertext.
+func Seal(s Suite, pk kem.EncapsulationKey, info, aad, plaintext []byte) (enc, ciphertext []byte, err error) {
+	enc, sender, err := NewSender(s, pk, info)
+	if err != nil {
+		return nil, nil, err
+	}
+	ciphertext, err = sender.Seal(aad, plaintext)
+	if err != nil {
+		return nil, nil, err
+	}
+	return enc, ciphertext, nil
+}
+
+// Open decrypts a message of Seal.
+func Open(s Suite, sk kem.DecapsulationKey, enc, info, aad, ciphertext []byte) ([]byte, error) {
+	r, err := NewRecipient(s, sk, enc, info)
+	if err != nil {
+		return nil, err
+	}
+	return r.Open(aad, ciphertext)
+}
@@ -0,0 +1,306 @@
+package hpke
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func fromHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// TestChaCha20Poly1305 checks the AEAD test vector of RFC 8439, Section
+// 2.8.2, and that any modified byte fails to open.
+func TestChaCha20Poly1305(t *testing.T) {
+	key := fromHex(t, "808182838485868788898a8b8c8d8e8f909192939495969798999a9b9c9d9e9f")
+	nonce := fromHex(t, "070000004041424344454647")
+	aad := fromHex(t, "50515253c0c1c2c3c4c5c6c7")
+	plaintext := []byte("Ladies and Gentlemen of the class of '99: If I could offer you only one tip for the future, sunscreen would be it.")
+	want := fromHex(t, "d31a8d34648e60db7b86afbc53ef7ec2a4aded51296e08fea9e2b5a736ee62d6"+
+		"3dbea45e8ca9671282fafb69da92728b1a71de0a9e060b2905d6a5b67ecd3b36"+
+		"92ddbd7f2d778b8c9803aee328091b58fab324e4fad675945585808b4831d7bc"+
+		"3ff4def08e4b7a9de576d26586cec64b6116"+
+		"1ae10b594f09e26a7e902ecbd0600691")
+	c, err := newChaCha20Poly1305(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prefix := []byte("dst")
+	got := c.Seal(bytes.Clone(prefix), nonce, plaintext, aad)
+	if !bytes.Equal(got, append(bytes.Clone(prefix), want...)) {
+		t.Fatalf("Seal = %x\nwant %x", got[len(prefix):], want)
+	}
+	opened, err := c.Open(nil, nonce, want, aad)
+	if err != nil || !bytes.Equal(opened, plaintext) {
+		t.Fatalf("Open = %q, %v", opened, err)
+	}
+	for _, i := range []int{0, len(plaintext) - 1, len(plaintext), len(want) - 1} {
+		bad := bytes.Clone(want)
+		bad[i] ^= 0x80
+		if _, err := c.Open(nil, nonce, bad, aad); err == nil {
+			t.Errorf("Open accepted a ciphertext modified at byte %d", i)
+		}
+	}
+	if _, err := c.Open(nil, nonce, want, aad[1:]); err == nil {
+		t.Error("Open accepted modified additional data")
+	}
+	if _, err := c.Open(nil, nonce, want[:15], aad); err == nil {
+		t.Error("Open accepted a ciphertext shorter than the tag")
+	}
+	if _, err := newChaCha20Poly1305(key[1:]); err == nil {
+		t.Error("newChaCha20Poly1305 accepted a 31-byte key")
+	}
+}
+
+// TestPoly1305 checks the vectors of RFC 8439, Appendix A.3, that are
+// whole 16-byte blocks, where the zero padding of poly1305State makes no
+// difference. Vectors 5 to 11 exercise the carries and the final
+// reduction modulo 2^130 - 5.
+func TestPoly1305(t *testing.T) {
+	zero := "00000000000000000000000000000000"
+	for i, tc := range []struct{ r, s, msg, tag string }{
+		{zero, zero, zero + zero + zero + zero, zero},
+		{"02" + zero[2:], zero, "ffffffffffffffffffffffffffffffff", "03" + zero[2:]},
+		{"02" + zero[2:], "ffffffffffffffffffffffffffffffff", "02" + zero[2:], "03" + zero[2:]},
+		{"01" + zero[2:], zero,
+			"ffffffffffffffffffffffffffffffff" + "f0ffffffffffffffffffffffffffffff" + "11" + zero[2:],
+			"05" + zero[2:]},
+		{"01" + zero[2:], zero,
+			"ffffffffffffffffffffffffffffffff" + "fbfefefefefefefefefefefefefefefe" + "01010101010101010101010101010101",
+			zero},
+		{"02" + zero[2:], zero, "fdffffffffffffffffffffffffffffff", "faffffffffffffffffffffffffffffff"},
+		{"01000000000000000400000000000000", zero,
+			"e33594d7505e43b900000000000000003394d7505e4379cd01000000000000000000000000000000000000000000000001" + zero[2:],
+			"14000000000000005500000000000000"},
+		{"01000000000000000400000000000000", zero,
+			"e33594d7505e43b900000000000000003394d7505e4379cd010000000000000000000000000000000000000000000000",
+			"13" + zero[2:]},
+	} {
+		var p poly1305State
+		p.init(fromHex(t, tc.r+tc.s))
+		p.write(fromHex(t, tc.msg))
+		if got := p.sum(); hex.EncodeToString(got[:]) != tc.tag {
+			t.Errorf("vector %d: tag %x, want %s", i, got, tc.tag)
+		}
+	}
+}
+
+var suites = func() []Suite {
+	var s []Suite
+	for _, k := range []*KEM{MLKEM512, MLKEM768, MLKEM1024, XWing} {
+		for _, kdf := range []*KDF{HKDFSHA256, HKDFSHA384, HKDFSHA512} {
+			for _, aead := range []*AEAD{AES128GCM, AES256GCM, ChaCha20Poly1305, ExportOnly} {
+				s = append(s, Suite{k, kdf, aead})
+			}
+		}
+	}
+	return s
+}()
+
+// TestRoundTrip seals three messages per suite, in base and PSK mode, and
+// checks that the recipient opens them and exports the sender's secrets.
+// Export-only suites must refuse to seal and open.
+func TestRoundTrip(t *testing.T) {
+	info, psk, pskID := []byte("info"), bytes.Repeat([]byte{0x42}, 32), []byte("psk id")
+	for _, s := range suites {
+		dk, err := s.KEM.KEM.GenerateKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, mode := range []string{"base", "psk"} {
+			t.Run(fmt.Sprintf("%v/%s", s, mode), func(t *testing.T) {
+				var enc []byte
+				var sender *Sender
+				var recipient *Recipient
+				var err error
+				if mode == "base" {
+					enc, sender, err = NewSender(s, dk.EncapsulationKey(), info)
+				} else {
+					enc, sender, err = NewSenderPSK(s, dk.EncapsulationKey(), info, psk, pskID)
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				if mode == "base" {
+					recipient, err = NewRecipient(s, dk, enc, info)
+				} else {
+					recipient, err = NewRecipientPSK(s, dk, enc, info, psk, pskID)
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				for i := range 3 {
+					aad, msg := []byte{byte(i)}, []byte(fmt.Sprintf("message %d", i))
+					ct, err := sender.Seal(aad, msg)
+					if s.AEAD == ExportOnly {
+						if !errors.Is(err, errNoAEAD) {
+							t.Fatalf("Seal on an export-only suite: %v", err)
+						}
+						if _, err := recipient.Open(aad, msg); !errors.Is(err, errNoAEAD) {
+							t.Fatalf("Open on an export-only suite: %v", err)
+						}
+						break
+					}
+					if err != nil {
+						t.Fatal(err)
+					}
+					got, err := recipient.Open(aad, ct)
+					if err != nil || !bytes.Equal(got, msg) {
+						t.Fatalf("message %d: Open = %q, %v", i, got, err)
+					}
+				}
+				a, err := sender.Export([]byte("exporter"), 45)
+				if err != nil {
+					t.Fatal(err)
+				}
+				b, err := recipient.Export([]byte("exporter"), 45)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(a) != 45 || !bytes.Equal(a, b) {
+					t.Errorf("exported %x and %x", a, b)
+				}
+			})
+		}
+	}
+}
+
+// TestPSK checks that the recipient needs the sender's PSK and PSK
+// identifier, and that a PSK without an identifier, or the reverse, is
+// rejected on both sides.
+func TestPSK(t *testing.T) {
+	s := Suite{MLKEM768, HKDFSHA256, AES128GCM}
+	dk, err := s.KEM.KEM.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, psk, pskID := []byte("info"), bytes.Repeat([]byte{0x42}, 32), []byte("psk id")
+	enc, sender, err := NewSenderPSK(s, dk.EncapsulationKey(), info, psk, pskID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ct, err := sender.Seal(nil, []byte("message"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherPSK := bytes.Clone(psk)
+	otherPSK[0] ^= 1
+	for name, c := range map[string]struct{ psk, pskID []byte }{
+		"other psk":    {otherPSK, pskID},
+		"other psk id": {psk, []byte("psk id 2")},
+	} {
+		r, err := NewRecipientPSK(s, dk, enc, info, c.psk, c.pskID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := r.Open(nil, ct); err == nil {
+			t.Errorf("%s: Open succeeded", name)
+		}
+	}
+	if r, err := NewRecipient(s, dk, enc, info); err != nil {
+		t.Fatal(err)
+	} else if _, err := r.Open(nil, ct); err == nil {
+		t.Error("a base-mode recipient opened a PSK-mode message")
+	}
+
+	for name, c := range map[string]struct{ psk, pskID []byte }{
+		"psk only":    {psk, nil},
+		"psk id only": {nil, pskID},
+		"neither":     {nil, nil},
+	} {
+		if _, _, err := NewSenderPSK(s, dk.EncapsulationKey(), info, c.psk, c.pskID); !errors.Is(err, errPSK) {
+			t.Errorf("NewSenderPSK with %s: got %v, want errPSK", name, err)
+		}
+		if _, err := NewRecipientPSK(s, dk, enc, info, c.psk, c.pskID); !errors.Is(err, errPSK) {
+			t.Errorf("NewRecipientPSK with %s: got %v, want errPSK", name, err)
+		}
+	}
+}
+
+// TestSequence checks that Open keeps its sequence number when a message
+// fails to authenticate, so that the genuine message still opens, and
+// that messages must be opened in order.
+func TestSequence(t *testing.T) {
+	s := Suite{XWing, HKDFSHA256, ChaCha20Poly1305}
+	dk, err := s.KEM.KEM.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc, sender, err := NewSender(s, dk.EncapsulationKey(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := NewRecipient(s, dk, enc, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cts [][]byte
+	for i := range 3 {
+		ct, err := sender.Seal(nil, []byte("same"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, prev := range cts {
+			if bytes.Equal(ct, prev) {
+				t.Fatal("two messages were sealed under the same nonce")
+			}
+		}
+		cts = append(cts, ct)
+		if r.seq != 0 || sender.seq != uint64(i+1) {
+			t.Fatalf("sequence numbers %d and %d", sender.seq, r.seq)
+		}
+	}
+	bad := bytes.Clone(cts[0])
+	bad[0] ^= 1
+	for _, ct := range [][]byte{bad, cts[1], cts[2]} {
+		if _, err := r.Open(nil, ct); err == nil {
+			t.Fatal("Open accepted a modified or out-of-order message")
+		}
+	}
+	if r.seq != 0 {
+		t.Fatalf("failed opens moved the sequence number to %d", r.seq)
+	}
+	for i, ct := range cts {
+		if got, err := r.Open(nil, ct); err != nil || string(got) != "same" {
+			t.Fatalf("message %d: Open = %q, %v", i, got, err)
+		}
+	}
+	if _, err := r.Open(nil, cts[2]); err == nil {
+		t.Error("Open accepted a replayed message")
+	}
+
+	sender.seq = 1<<64 - 1
+	if _, err := sender.Seal(nil, nil); !errors.Is(err, errSeq) {
+		t.Errorf("Seal at the last sequence number: got %v, want errSeq", err)
+	}
+}
+
+// TestSingleShot checks Seal and Open and the size check on enc.
+func TestSingleShot(t *testing.T) {
+	s := Suite{MLKEM512, HKDFSHA512, AES256GCM}
+	dk, err := s.KEM.KEM.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc, ct, err := Seal(s, dk.EncapsulationKey(), []byte("info"), []byte("aad"), []byte("message"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := Open(s, dk, enc, []byte("info"), []byte("aad"), ct); err != nil || string(got) != "message" {
+		t.Fatalf("Open = %q, %v", got, err)
+	}
+	if _, err := Open(s, dk, enc, []byte("other"), []byte("aad"), ct); err == nil {
+		t.Error("Open succeeded with other info")
+	}
+	if _, err := Open(s, dk, enc[1:], []byte("info"), []byte("aad"), ct); !errors.Is(err, errEncSize) {
+		t.Errorf("Open with a short enc: got %v, want errEncSize", err)
+	}
+}
@@ -0,0 +1,113 @@
+//go:build go1.26
+
+package hpke
+
+import (
+	"bytes"
+	"crypto/hpke"
+	"fmt"
+	"testing"
+)
+
+// TestStandardLibrary checks every suite of ML-KEM-768, ML-KEM-1024 and
+// X-Wing against crypto/hpke (Go 1.26) in both directions: keys are
+// exchanged in their serialized forms, each side opens the other's
+// messages, and both export the same secrets.
+func TestStandardLibrary(t *testing.T) {
+	info, aad := []byte("info"), []byte("aad")
+	for _, s := range suites {
+		if s.KEM == MLKEM512 {
+			continue
+		}
+		t.Run(s.String(), func(t *testing.T) {
+			stdKEM, err := hpke.NewKEM(s.KEM.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			kdf, err := hpke.NewKDF(s.KDF.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			aead, err := hpke.NewAEAD(s.AEAD.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			dk, err := s.KEM.KEM.GenerateKey()
+			if err != nil {
+				t.Fatal(err)
+			}
+			stdSK, err := stdKEM.NewPrivateKey(dk.Bytes())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := stdSK.PublicKey().Bytes(); !bytes.Equal(got, dk.EncapsulationKey().Bytes()) {
+				t.Fatal("crypto/hpke derives another public key from the private key")
+			}
+			stdPK, err := stdKEM.NewPublicKey(dk.EncapsulationKey().Bytes())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// This package sends, crypto/hpke receives.
+			enc, sender, err := NewSender(s, dk.EncapsulationKey(), info)
+			if err != nil {
+				t.Fatal(err)
+			}
+			stdRecipient, err := hpke.NewRecipient(enc, stdSK, kdf, aead, info)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// crypto/hpke sends, this package receives.
+			stdEnc, stdSender, err := hpke.NewSender(stdPK, kdf, aead, info)
+			if err != nil {
+				t.Fatal(err)
+			}
+			recipient, err := NewRecipient(s, dk, stdEnc, info)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if s.AEAD != ExportOnly {
+				for i := range 3 {
+					msg := []byte(fmt.Sprintf("message %d", i))
+					ct, err := sender.Seal(aad, msg)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if got, err := stdRecipient.Open(aad, ct); err != nil || !bytes.Equal(got, msg) {
+						t.Fatalf("crypto/hpke Open = %q, %v", got, err)
+					}
+					ct, err = stdSender.Seal(aad, msg)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if got, err := recipient.Open(aad, ct); err != nil || !bytes.Equal(got, msg) {
+						t.Fatalf("Open of a crypto/hpke message = %q, %v", got, err)
+					}
+				}
+			}
+
+			for _, pair := range []struct {
+				what string
+				ours interface {
+					Export([]byte, int) ([]byte, error)
+				}
+				std interface {
+					Export(string, int) ([]byte, error)
+				}
+			}{{"our sender", sender, stdRecipient}, {"our recipient", recipient, stdSender}} {
+				a, err := pair.ours.Export([]byte("exporter"), 32)
+				if err != nil {
+					t.Fatal(err)
+				}
+				b, err := pair.std.Export("exporter", 32)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(a, b) {
+					t.Errorf("%s exports %x, crypto/hpke %x", pair.what, a, b)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,27 @@
+// Package hybrid implements X25519MLKEM768, the hybrid key exchange of
+// TLS 1.3 (NamedGroup 0x11ec, draft-ietf-tls-ecdhe-mlkem) that combines
+// ML-KEM-768 from package mlkem with X25519 from crypto/ecdh. An attacker
+// must break both to learn the shared secret: X25519 alone falls to a
+// quantum computer, and ML-KEM is much younger.
+//
+// The byte layouts are those of TLS, with ML-KEM first in every field:
+//
+//	client key share  ML-KEM-768 encapsulation key (1184) || X25519 public key (32)
+//	server key share  ML-KEM-768 ciphertext (1088)        || X25519 public key (32)
+//	shared secret     ML-KEM shared key (32)              || X25519 shared secret (32)
+//
+// The client key share is an EncapsulationKey and the server key share
+// its ciphertext, so the scheme is a KEM like the others of this module,
+// and package kem offers it under the name X25519MLKEM768. The 64-byte
+// shared secret is used as it is, as the input to the TLS key schedule;
+// the concatenation is safe there because the key schedule hashes it
+// together with the transcript, which contains both key shares.
+//
+// X-Wing, the other ML-KEM-768 and X25519 hybrid, has the same key share
+// layout but hashes the two secrets with the X25519 ciphertext and public
+// key, so that it is a secure KEM on its own; it is the hybrid KEM of
+// package hpke.
+//
+// ClientExchange and ServerExchange run the exchange over a connection,
+// such as the two ends of a net.Pipe.
+package hybrid
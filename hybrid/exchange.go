@@ -0,0 +1,75 @@
+package hybrid
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// ClientExchange and ServerExchange run the key exchange over a
+// connection, each side sending one KeyShareEntry of TLS 1.3: the group
+// CodePoint and the length of the key share, as big-endian 16-bit
+// integers, then the share. They return the shared secret. This is the
+// key exchange of a TLS handshake without the rest of the handshake: it
+// authenticates nobody.
+
+// ClientExchange sends a fresh client key share on conn and decapsulates
+// the server's answer.
+func ClientExchange(conn io.ReadWriter) ([]byte, error) {
+	dk, err := GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+	if err := writeKeyShare(conn, dk.EncapsulationKey().Bytes()); err != nil {
+		return nil, err
+	}
+	ciphertext, err := readKeyShare(conn, CiphertextSize)
+	if err != nil {
+		return nil, err
+	}
+	return dk.Decapsulate(ciphertext)
+}
+
+// ServerExchange reads the client's key share from conn, encapsulates to
+// it and sends the server's key share.
+func ServerExchange(conn io.ReadWriter) ([]byte, error) {
+	share, err := readKeyShare(conn, EncapsulationKeySize)
+	if err != nil {
+		return nil, err
+	}
+	ek, err := NewEncapsulationKey(share)
+	if err != nil {
+		return nil, err
+	}
+	key, ciphertext := ek.Encapsulate()
+	if err := writeKeyShare(conn, ciphertext); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+func writeKeyShare(w io.Writer, share []byte) error {
+	b := binary.BigEndian.AppendUint16(nil, CodePoint)
+	b = binary.BigEndian.AppendUint16(b, uint16(len(share)))
+	_, err := w.Write(append(b, share...))
+	return err
+}
+
+// readKeyShare reads a KeyShareEntry and checks its group and length.
+func readKeyShare(r io.Reader, size int) ([]byte, error) {
+	var hdr [4]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return nil, err
+	}
+	if g := binary.BigEndian.Uint16(hdr[:2]); g != CodePoint {
+		return nil, fmt.Errorf("hybrid: key share for group %#04x, want %#04x", g, CodePoint)
+	}
+	if n := int(binary.BigEndian.Uint16(hdr[2:])); n != size {
+		return nil, fmt.Errorf("hybrid: key share of %d bytes, want %d", n, size)
+	}
+	share := make([]byte, size)
+	if _, err := io.ReadFull(r, share); err != nil {
+		return nil, err
+	}
+	return share, nil
+}
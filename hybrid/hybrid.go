@@ -0,0 +1,199 @@
+package hybrid
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"errors"
+	"io"
+
+	"github.com/haopining/Learn-Lattice-Based-Cryptography/mlkem"
+)
+
+// Name is the name of the group in TLS, and CodePoint its NamedGroup
+// value.
+const (
+	Name      = "X25519MLKEM768"
+	CodePoint = 0x11ec
+)
+
+// The sizes of the encodings: the client's key share (the encapsulation
+// key), the server's key share (the ciphertext), the shared secret and the
+// decapsulation key, in bytes.
+const (
+	EncapsulationKeySize = mlkemEKSize + x25519Size
+	CiphertextSize       = mlkemCTSize + x25519Size
+	SharedKeySize        = mlkem.SharedKeySize + x25519Size
+	DecapsulationKeySize = mlkem.SeedSize + x25519Size
+)
+
+// The sizes of the ML-KEM-768 encapsulation key and ciphertext, and of
+// X25519 keys.
+const (
+	mlkemEKSize = 1184
+	mlkemCTSize = 1088
+	x25519Size  = 32
+)
+
+var (
+	errKeySize        = errors.New("hybrid: invalid key size")
+	errCiphertextSize = errors.New("hybrid: invalid ciphertext size")
+	errLowOrder       = errors.New("hybrid: X25519 share is a low-order point")
+)
+
+// EncapsulationKey is the client's key share: an ML-KEM-768
+// encapsulation key and an X25519 public key.
+type EncapsulationKey struct {
+	pq *mlkem.EncapsulationKey
+	x  *ecdh.PublicKey
+}
+
+// DecapsulationKey is the client's private key.
+type DecapsulationKey struct {
+	pq *mlkem.DecapsulationKey
+	x  *ecdh.PrivateKey
+	ek *EncapsulationKey
+}
+
+// GenerateKey returns a fresh key pair.
+func GenerateKey() (*DecapsulationKey, error) {
+	b := make([]byte, DecapsulationKeySize)
+	if _, err := io.ReadFull(rand.Reader, b); err != nil {
+		return nil, err
+	}
+	return NewDecapsulationKey(b)
+}
+
+// NewDecapsulationKey parses a decapsulation key: the 64-byte ML-KEM seed
+// d || z followed by the 32-byte X25519 scalar.
+func NewDecapsulationKey(b []byte) (*DecapsulationKey, error) {
+	if len(b) != DecapsulationKeySize {
+		return nil, errKeySize
+	}
+	pq, err := mlkem.NewDecapsulationKey(mlkem.MLKEM768, b[:mlkem.SeedSize])
+	if err != nil {
+		return nil, err
+	}
+	x, err := ecdh.X25519().NewPrivateKey(b[mlkem.SeedSize:])
+	if err != nil {
+		return nil, err
+	}
+	ek := &EncapsulationKey{pq: pq.EncapsulationKey(), x: x.PublicKey()}
+	return &DecapsulationKey{pq: pq, x: x, ek: ek}, nil
+}
+
+// NewEncapsulationKey parses a client key share. Besides the ML-KEM
+// modulus check, it rejects X25519 points of small order, with which the
+// X25519 shared secret would be all zeros whatever the server's key.
+func NewEncapsulationKey(b []byte) (*EncapsulationKey, error) {
+	if len(b) != EncapsulationKeySize {
+		return nil, errKeySize
+	}
+	pq, err := mlkem.NewEncapsulationKey(mlkem.MLKEM768, b[:mlkemEKSize])
+	if err != nil {
+		return nil, err
+	}
+	x, err := parseX25519(b[mlkemEKSize:])
+	if err != nil {
+		return nil, err
+	}
+	return &EncapsulationKey{pq: pq, x: x}, nil
+}
+
+// lowOrderProbe is a fixed X25519 key for parseX25519. Its clamped scalar
+// is a multiple of the cofactor 8 and not of the group order, so the
+// shared secret with a point is zero exactly when the point has small
+// order.
+var lowOrderProbe, _ = ecdh.X25519().NewPrivateKey(make([]byte, x25519Size))
+
+// parseX25519 parses an X25519 public key and rejects the points of order
+// dividing 8, for which crypto/ecdh fails the key agreement.
+func parseX25519(b []byte) (*ecdh.PublicKey, error) {
+	x, err := ecdh.X25519().NewPublicKey(b)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := lowOrderProbe.ECDH(x); err != nil {
+		return nil, errLowOrder
+	}
+	return x, nil
+}
+
+// Bytes returns the key share as sent in the ClientHello:
+// ML-KEM-768 encapsulation key || X25519 public key.
+func (ek *EncapsulationKey) Bytes() []byte {
+	return append(ek.pq.Bytes(), ek.x.Bytes()...)
+}
+
+// Equal reports whether ek and x are the same key.
+func (ek *EncapsulationKey) Equal(x *EncapsulationKey) bool {
+	return ek.pq.Equal(x.pq) && ek.x.Equal(x.x)
+}
+
+// Encapsulate returns a fresh shared secret and the server's key share
+// that carries it.
+func (ek *EncapsulationKey) Encapsulate() (sharedKey, ciphertext []byte) {
+	m := make([]byte, 32)
+	x := make([]byte, x25519Size)
+	if _, err := io.ReadFull(rand.Reader, m); err != nil {
+		panic("hybrid: " + err.Error())
+	}
+	if _, err := io.ReadFull(rand.Reader, x); err != nil {
+		panic("hybrid: " + err.Error())
+	}
+	return ek.encapsulate(m, x)
+}
+
+// encapsulate is Encapsulate with the ML-KEM message m and the server's
+// X25519 scalar given. The ciphertext is ML-KEM-768 ciphertext || X25519
+// public key, and the shared secret the ML-KEM shared key || the X25519
+// shared secret, in the order of the TLS key schedule.
+func (ek *EncapsulationKey) encapsulate(m, scalar []byte) (sharedKey, ciphertext []byte) {
+	pqKey, pqCiphertext := ek.pq.EncapsulateInternal(m)
+	x, err := ecdh.X25519().NewPrivateKey(scalar)
+	if err != nil {
+		panic("hybrid: " + err.Error())
+	}
+	// The client's point was checked by NewEncapsulationKey, or comes from
+	// a private key, so the key agreement cannot fail.
+	xKey, err := x.ECDH(ek.x)
+	if err != nil {
+		panic("hybrid: " + err.Error())
+	}
+	return append(pqKey, xKey...), append(pqCiphertext, x.PublicKey().Bytes()...)
+}
+
+// Bytes returns the ML-KEM seed d || z followed by the X25519 scalar.
+func (dk *DecapsulationKey) Bytes() []byte {
+	return append(dk.pq.Bytes(), dk.x.Bytes()...)
+}
+
+// EncapsulationKey returns the client's key share.
+func (dk *DecapsulationKey) EncapsulationKey() *EncapsulationKey { return dk.ek }
+
+// Equal reports whether dk and x are the same key.
+func (dk *DecapsulationKey) Equal(x *DecapsulationKey) bool {
+	return dk.pq.Equal(x.pq) && dk.x.Equal(x.x)
+}
+
+// Decapsulate returns the shared secret of the server's key share. Like
+// ML-KEM it answers a corrupted ML-KEM ciphertext with an unrelated key,
+// but it fails for a share of the wrong size and for a low-order X25519
+// point, which TLS treats as a fatal error.
+func (dk *DecapsulationKey) Decapsulate(ciphertext []byte) ([]byte, error) {
+	if len(ciphertext) != CiphertextSize {
+		return nil, errCiphertextSize
+	}
+	pqKey, err := dk.pq.Decapsulate(ciphertext[:mlkemCTSize])
+	if err != nil {
+		return nil, err
+	}
+	x, err := ecdh.X25519().NewPublicKey(ciphertext[mlkemCTSize:])
+	if err != nil {
+		return nil, err
+	}
+	xKey, err := dk.x.ECDH(x)
+	if err != nil {
+		return nil, errLowOrder
+	}
+	return append(pqKey, xKey...), nil
+}
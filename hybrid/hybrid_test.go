@@ -0,0 +1,222 @@
+//go:build go1.26
+
+package hybrid
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/mlkem"
+	"crypto/mlkem/mlkemtest"
+	"crypto/sha3"
+	"encoding/hex"
+	"net"
+	"testing"
+)
+
+// exchange runs ClientExchange and ServerExchange on the two ends of a
+// net.Pipe and returns the secrets of both.
+func exchange(t *testing.T) (client, server []byte) {
+	c, s := net.Pipe()
+	type result struct {
+		key []byte
+		err error
+	}
+	done := make(chan result)
+	go func() {
+		defer s.Close()
+		key, err := ServerExchange(s)
+		done <- result{key, err}
+	}()
+	client, err := ClientExchange(c)
+	c.Close()
+	r := <-done
+	if err != nil {
+		t.Fatalf("client: %v", err)
+	}
+	if r.err != nil {
+		t.Fatalf("server: %v", r.err)
+	}
+	return client, r.key
+}
+
+func TestExchange(t *testing.T) {
+	for range 20 {
+		client, server := exchange(t)
+		if len(client) != SharedKeySize || !bytes.Equal(client, server) {
+			t.Fatalf("client secret %x, server secret %x", client, server)
+		}
+	}
+}
+
+// TestExchangeRejects checks the KeyShareEntry framing: a key share for
+// another group or of the wrong length ends the exchange.
+func TestExchangeRejects(t *testing.T) {
+	dk, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	share := dk.EncapsulationKey().Bytes()
+	for _, tc := range []struct {
+		name string
+		hdr  []byte
+	}{
+		{"X25519 group", []byte{0x00, 0x1d, byte(len(share) >> 8), byte(len(share))}},
+		{"short length", []byte{0x11, 0xec, byte((len(share) - 1) >> 8), byte(len(share) - 1)}},
+	} {
+		c, s := net.Pipe()
+		go func() {
+			c.Write(append(tc.hdr, share...))
+			c.Close()
+		}()
+		if _, err := ServerExchange(s); err == nil {
+			t.Errorf("%s: the server accepted the key share", tc.name)
+		}
+		s.Close()
+	}
+}
+
+// The X25519 key pairs and shared secret of RFC 7748, Section 6.1.
+const (
+	alicePrivate = "77076d0a7318a57d3c16c17251b26645df4c2f87ebc0992ab177fba51db92c2a"
+	alicePublic  = "8520f0098930a754748b7ddcb43ef75a0dbf3a0d26381af4eba4a98eaa9b4e6a"
+	bobPrivate   = "5dab087e624a8a4b79e17f8b83800ee66f3bb1292618b6fd1c2f8b27ff88e0eb"
+	bobPublic    = "de9edb7d7b7dc1b4d35b61c2ece435373f8343c85b78674dadfc7e146f882b4f"
+	x25519Secret = "4a5d9d5ba4ce2de1728e3bf480350f25e07e21c947d19e3376f09b3c1e161742"
+)
+
+func unhex(t *testing.T, s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// TestRFC7748 runs an exchange with Alice as the client and Bob as the
+// server. Only the X25519 halves are published, in RFC 7748; there are no
+// published X25519MLKEM768 vectors, so the ML-KEM-768 halves are left to
+// TestStandardLibrary.
+func TestRFC7748(t *testing.T) {
+	seed := make([]byte, 64)
+	dk, err := NewDecapsulationKey(append(seed, unhex(t, alicePrivate)...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	share := dk.EncapsulationKey().Bytes()
+	if got := hex.EncodeToString(share[mlkemEKSize:]); got != alicePublic {
+		t.Errorf("client X25519 share %s, want %s", got, alicePublic)
+	}
+	ek, err := NewEncapsulationKey(share)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, ciphertext := ek.encapsulate(make([]byte, 32), unhex(t, bobPrivate))
+	if got := hex.EncodeToString(ciphertext[mlkemCTSize:]); got != bobPublic {
+		t.Errorf("server X25519 share %s, want %s", got, bobPublic)
+	}
+	if got := hex.EncodeToString(key[32:]); got != x25519Secret {
+		t.Errorf("server X25519 secret %s, want %s", got, x25519Secret)
+	}
+	got, err := dk.Decapsulate(ciphertext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, key) {
+		t.Errorf("client secret %x, server secret %x", got, key)
+	}
+}
+
+// TestStandardLibrary compares both halves of every output with Go's
+// crypto/mlkem, using crypto/mlkem/mlkemtest (Go 1.26, hence the build
+// constraint of the file) for the fixed ML-KEM message, and crypto/ecdh,
+// on inputs expanded from a counter with SHAKE128. These are the
+// primitives crypto/tls combines into X25519MLKEM768.
+func TestStandardLibrary(t *testing.T) {
+	for i := range 10 {
+		in := sha3.SumSHAKE128([]byte{byte(i)}, DecapsulationKeySize+32+x25519Size)
+		dkBytes := in[:DecapsulationKeySize]
+		m, scalar := in[DecapsulationKeySize:DecapsulationKeySize+32], in[DecapsulationKeySize+32:]
+
+		dk, err := NewDecapsulationKey(dkBytes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(dk.Bytes(), dkBytes) {
+			t.Fatalf("input %d: decapsulation key does not round-trip", i)
+		}
+		refDK, err := mlkem.NewDecapsulationKey768(dkBytes[:64])
+		if err != nil {
+			t.Fatal(err)
+		}
+		alice, err := ecdh.X25519().NewPrivateKey(dkBytes[64:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		bob, err := ecdh.X25519().NewPrivateKey(scalar)
+		if err != nil {
+			t.Fatal(err)
+		}
+		refShare := append(refDK.EncapsulationKey().Bytes(), alice.PublicKey().Bytes()...)
+		share := dk.EncapsulationKey().Bytes()
+		if !bytes.Equal(share, refShare) {
+			t.Fatalf("input %d: client key shares differ", i)
+		}
+
+		pqKey, pqCiphertext, err := mlkemtest.Encapsulate768(refDK.EncapsulationKey(), m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		xKey, err := bob.ECDH(alice.PublicKey())
+		if err != nil {
+			t.Fatal(err)
+		}
+		refKey := append(pqKey, xKey...)
+		refCiphertext := append(pqCiphertext, bob.PublicKey().Bytes()...)
+
+		ek, err := NewEncapsulationKey(share)
+		if err != nil {
+			t.Fatal(err)
+		}
+		key, ciphertext := ek.encapsulate(m, scalar)
+		if !bytes.Equal(ciphertext, refCiphertext) {
+			t.Fatalf("input %d: server key shares differ", i)
+		}
+		if !bytes.Equal(key, refKey) {
+			t.Fatalf("input %d: server secret %x, want %x", i, key, refKey)
+		}
+		if key, err = dk.Decapsulate(ciphertext); err != nil || !bytes.Equal(key, refKey) {
+			t.Fatalf("input %d: client secret %x, want %x: %v", i, key, refKey, err)
+		}
+	}
+}
+
+// TestRejects checks implicit rejection of a tampered ML-KEM ciphertext
+// and the refusal of low-order X25519 points on both sides.
+func TestRejects(t *testing.T) {
+	dk, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, ciphertext := dk.EncapsulationKey().Encapsulate()
+	ciphertext[0] ^= 1
+	got, err := dk.Decapsulate(ciphertext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(got[:32], key[:32]) || !bytes.Equal(got[32:], key[32:]) {
+		t.Error("a tampered ML-KEM ciphertext changed the wrong half of the secret")
+	}
+
+	share := dk.EncapsulationKey().Bytes()
+	copy(share[mlkemEKSize:], make([]byte, x25519Size))
+	if _, err := NewEncapsulationKey(share); err == nil {
+		t.Error("accepted a low-order X25519 point in a client key share")
+	}
+	copy(ciphertext[mlkemCTSize:], make([]byte, x25519Size))
+	if _, err := dk.Decapsulate(ciphertext); err == nil {
+		t.Error("accepted a low-order X25519 point in a server key share")
+	}
+	if _, err := dk.Decapsulate(ciphertext[1:]); err == nil {
+		t.Error("accepted a short server key share")
+	}
+}
@@ -0,0 +1,141 @@
+package hybrid
+
+import (
+	"crypto/rand"
+	"crypto/sha3"
+	"crypto/subtle"
+	"io"
+)
+
+// X-Wing (draft-connolly-cfrg-xwing-kem) is the other common way to pair
+// ML-KEM-768 with X25519. Its key shares have the same layout as
+// X25519MLKEM768, but the shared secret is not the concatenation of the
+// two: it is hashed with the X25519 ciphertext and public key,
+//
+//	ss = SHA3-256(ss_M || ss_X || ct_X || pk_X || XWingLabel),
+//
+// which binds it to the X25519 half of the exchange on its own, so it can
+// be used as a KEM anywhere, without a transcript hash around it. HPKE
+// uses it as the KEM MLKEM768-X25519. Its private key is a 32-byte seed,
+// expanded with SHAKE256.
+
+// XWingName is the name of X-Wing in package kem, and XWingSeedSize the
+// size of its decapsulation keys.
+const (
+	XWingName     = "X-Wing"
+	XWingSeedSize = 32
+)
+
+// xwingLabel is the ASCII art \.//^\ that closes the combiner input.
+var xwingLabel = []byte(`\.//^\`)
+
+// XWingEncapsulationKey is an X-Wing public key, pk_M || pk_X.
+type XWingEncapsulationKey struct {
+	ek *EncapsulationKey
+}
+
+// XWingDecapsulationKey is an X-Wing private key, kept with its seed.
+type XWingDecapsulationKey struct {
+	seed [XWingSeedSize]byte
+	dk   *DecapsulationKey
+	ek   *XWingEncapsulationKey
+}
+
+// GenerateXWingKey returns an X-Wing key from a fresh seed.
+func GenerateXWingKey() (*XWingDecapsulationKey, error) {
+	seed := make([]byte, XWingSeedSize)
+	if _, err := io.ReadFull(rand.Reader, seed); err != nil {
+		return nil, err
+	}
+	return NewXWingDecapsulationKey(seed)
+}
+
+// NewXWingDecapsulationKey expands a 32-byte seed into a key: the first
+// 64 bytes of SHAKE256(seed) are the ML-KEM-768 seed d || z and the next
+// 32 the X25519 scalar.
+func NewXWingDecapsulationKey(seed []byte) (*XWingDecapsulationKey, error) {
+	if len(seed) != XWingSeedSize {
+		return nil, errKeySize
+	}
+	h := sha3.NewSHAKE256()
+	h.Write(seed)
+	expanded := make([]byte, DecapsulationKeySize)
+	h.Read(expanded)
+	dk, err := NewDecapsulationKey(expanded)
+	if err != nil {
+		return nil, err
+	}
+	xdk := &XWingDecapsulationKey{dk: dk, ek: &XWingEncapsulationKey{dk.ek}}
+	copy(xdk.seed[:], seed)
+	return xdk, nil
+}
+
+// NewXWingEncapsulationKey parses an X-Wing public key, with the checks of
+// NewEncapsulationKey.
+func NewXWingEncapsulationKey(b []byte) (*XWingEncapsulationKey, error) {
+	ek, err := NewEncapsulationKey(b)
+	if err != nil {
+		return nil, err
+	}
+	return &XWingEncapsulationKey{ek}, nil
+}
+
+// Bytes returns pk_M || pk_X.
+func (ek *XWingEncapsulationKey) Bytes() []byte { return ek.ek.Bytes() }
+
+// Equal reports whether ek and x are the same key.
+func (ek *XWingEncapsulationKey) Equal(x *XWingEncapsulationKey) bool { return ek.ek.Equal(x.ek) }
+
+// Encapsulate returns a fresh shared key and its ciphertext ct_M || ct_X.
+func (ek *XWingEncapsulationKey) Encapsulate() (sharedKey, ciphertext []byte) {
+	m := make([]byte, 32)
+	x := make([]byte, x25519Size)
+	if _, err := io.ReadFull(rand.Reader, m); err != nil {
+		panic("hybrid: " + err.Error())
+	}
+	if _, err := io.ReadFull(rand.Reader, x); err != nil {
+		panic("hybrid: " + err.Error())
+	}
+	return ek.encapsulate(m, x)
+}
+
+// encapsulate is Encapsulate with the ML-KEM message m and the ephemeral
+// X25519 scalar given.
+func (ek *XWingEncapsulationKey) encapsulate(m, scalar []byte) (sharedKey, ciphertext []byte) {
+	keys, ciphertext := ek.ek.encapsulate(m, scalar)
+	return xwingCombine(keys, ciphertext, ek.ek.x.Bytes()), ciphertext
+}
+
+// Bytes returns the 32-byte seed.
+func (dk *XWingDecapsulationKey) Bytes() []byte { return append([]byte(nil), dk.seed[:]...) }
+
+// EncapsulationKey returns the public key.
+func (dk *XWingDecapsulationKey) EncapsulationKey() *XWingEncapsulationKey { return dk.ek }
+
+// Equal reports whether dk and x are the same key, derived from the same
+// seed.
+func (dk *XWingDecapsulationKey) Equal(x *XWingDecapsulationKey) bool {
+	return subtle.ConstantTimeCompare(dk.seed[:], x.seed[:]) == 1
+}
+
+// Decapsulate returns the shared key of a ciphertext. It fails for a
+// ciphertext of the wrong size or whose X25519 half is a low-order point,
+// and answers a corrupted ML-KEM half with an unrelated key.
+func (dk *XWingDecapsulationKey) Decapsulate(ciphertext []byte) ([]byte, error) {
+	keys, err := dk.dk.Decapsulate(ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	return xwingCombine(keys, ciphertext, dk.dk.x.PublicKey().Bytes()), nil
+}
+
+// xwingCombine is the X-Wing combiner on ss_M || ss_X, as returned by the
+// X25519MLKEM768 functions, the ciphertext ct_M || ct_X and pk_X.
+func xwingCombine(keys, ciphertext, pkX []byte) []byte {
+	h := sha3.New256()
+	h.Write(keys)
+	h.Write(ciphertext[mlkemCTSize:])
+	h.Write(pkX)
+	h.Write(xwingLabel)
+	return h.Sum(nil)
+}
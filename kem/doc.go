@@ -0,0 +1,20 @@
+// Package kem puts the lattice key encapsulation mechanisms of this module
+// behind one interface, so that application code can choose a scheme by
+// name and swap it without other changes:
+//
+//	k, err := kem.ByName("ML-KEM-768")
+//	dk, err := k.GenerateKey()
+//	shared, ciphertext := dk.EncapsulationKey().Encapsulate()
+//	shared2, err := dk.Decapsulate(ciphertext)
+//
+// The adapters cover ML-KEM (package mlkem) at all its parameter sets and
+// the hybrids X25519MLKEM768 of TLS and X-Wing (package hybrid).
+// Keys travel as bytes in each scheme's own format, whose sizes the KEM
+// reports. The concrete key types of the scheme packages satisfy
+// EncapsulationKey directly, and Unwrap recovers the concrete decapsulation
+// key.
+//
+// Signatures need no such package: mldsa.PrivateKey and
+// falcon.PrivateKey implement crypto.Signer, and their public keys the
+// Equal method of crypto.PublicKey.
+package kem
@@ -0,0 +1,170 @@
+package kem
+
+import (
+	"fmt"
+
+	"github.com/haopining/Learn-Lattice-Based-Cryptography/hybrid"
+	"github.com/haopining/Learn-Lattice-Based-Cryptography/mlkem"
+)
+
+// A KEM is a key encapsulation mechanism at one parameter set.
+type KEM interface {
+	// Name is the name of the parameter set, as accepted by ByName.
+	Name() string
+	// GenerateKey returns a fresh key pair.
+	GenerateKey() (DecapsulationKey, error)
+	// NewEncapsulationKey and NewDecapsulationKey parse keys in the
+	// format of their Bytes methods.
+	NewEncapsulationKey(b []byte) (EncapsulationKey, error)
+	NewDecapsulationKey(b []byte) (DecapsulationKey, error)
+	// The sizes of the encodings, in bytes.
+	EncapsulationKeySize() int
+	DecapsulationKeySize() int
+	CiphertextSize() int
+	SharedKeySize() int
+}
+
+// An EncapsulationKey is a public key.
+type EncapsulationKey interface {
+	Bytes() []byte
+	// Encapsulate returns a fresh shared key and the ciphertext that
+	// carries it.
+	Encapsulate() (sharedKey, ciphertext []byte)
+}
+
+// A DecapsulationKey is a private key.
+type DecapsulationKey interface {
+	Bytes() []byte
+	// Decapsulate returns the shared key of a ciphertext. It fails only
+	// for a ciphertext of the wrong size; a corrupted ciphertext yields
+	// an unrelated pseudorandom key.
+	Decapsulate(ciphertext []byte) (sharedKey []byte, err error)
+	EncapsulationKey() EncapsulationKey
+}
+
+// decapsulationKey is the method set the scheme packages share, with
+// their own encapsulation key type E.
+type decapsulationKey[E EncapsulationKey] interface {
+	Bytes() []byte
+	Decapsulate(ciphertext []byte) ([]byte, error)
+	EncapsulationKey() E
+}
+
+// adapter turns the functions of a scheme package into a KEM.
+type adapter[E EncapsulationKey, D decapsulationKey[E]] struct {
+	name                   string
+	generate               func() (D, error)
+	newEK                  func([]byte) (E, error)
+	newDK                  func([]byte) (D, error)
+	ekSize, dkSize, ctSize int
+	sharedKeySize          int
+}
+
+func (a *adapter[E, D]) Name() string              { return a.name }
+func (a *adapter[E, D]) EncapsulationKeySize() int { return a.ekSize }
+func (a *adapter[E, D]) DecapsulationKeySize() int { return a.dkSize }
+func (a *adapter[E, D]) CiphertextSize() int       { return a.ctSize }
+func (a *adapter[E, D]) SharedKeySize() int        { return a.sharedKeySize }
+
+func (a *adapter[E, D]) GenerateKey() (DecapsulationKey, error) {
+	dk, err := a.generate()
+	if err != nil {
+		return nil, err
+	}
+	return wrapped[E, D]{dk}, nil
+}
+
+func (a *adapter[E, D]) NewEncapsulationKey(b []byte) (EncapsulationKey, error) {
+	return a.newEK(b)
+}
+
+func (a *adapter[E, D]) NewDecapsulationKey(b []byte) (DecapsulationKey, error) {
+	dk, err := a.newDK(b)
+	if err != nil {
+		return nil, err
+	}
+	return wrapped[E, D]{dk}, nil
+}
+
+// wrapped adapts a scheme's decapsulation key, whose EncapsulationKey
+// method returns the scheme's own type, to DecapsulationKey.
+type wrapped[E EncapsulationKey, D decapsulationKey[E]] struct{ dk D }
+
+func (w wrapped[E, D]) Bytes() []byte { return w.dk.Bytes() }
+
+func (w wrapped[E, D]) Decapsulate(c []byte) ([]byte, error) { return w.dk.Decapsulate(c) }
+
+func (w wrapped[E, D]) EncapsulationKey() EncapsulationKey { return w.dk.EncapsulationKey() }
+
+func (w wrapped[E, D]) unwrap() any { return w.dk }
+
+// Unwrap returns the decapsulation key of the scheme package, such as a
+// *mlkem.DecapsulationKey, for code that needs more than the interface.
+func Unwrap(dk DecapsulationKey) any {
+	if u, ok := dk.(interface{ unwrap() any }); ok {
+		return u.unwrap()
+	}
+	return dk
+}
+
+// MLKEM returns the KEM of an ML-KEM parameter set. Decapsulation keys are
+// the 64-byte seeds.
+func MLKEM(p *mlkem.Params) KEM {
+	return &adapter[*mlkem.EncapsulationKey, *mlkem.DecapsulationKey]{
+		name:          p.Name,
+		generate:      func() (*mlkem.DecapsulationKey, error) { return mlkem.GenerateKey(p) },
+		newEK:         func(b []byte) (*mlkem.EncapsulationKey, error) { return mlkem.NewEncapsulationKey(p, b) },
+		newDK:         func(b []byte) (*mlkem.DecapsulationKey, error) { return mlkem.NewDecapsulationKey(p, b) },
+		ekSize:        p.EncapsulationKeySize(),
+		dkSize:        mlkem.SeedSize,
+		ctSize:        p.CiphertextSize(),
+		sharedKeySize: mlkem.SharedKeySize,
+	}
+}
+
+// X25519MLKEM768 returns the hybrid KEM of TLS, whose encapsulation keys
+// and ciphertexts are the client and server key shares.
+func X25519MLKEM768() KEM {
+	return &adapter[*hybrid.EncapsulationKey, *hybrid.DecapsulationKey]{
+		name:          hybrid.Name,
+		generate:      hybrid.GenerateKey,
+		newEK:         hybrid.NewEncapsulationKey,
+		newDK:         hybrid.NewDecapsulationKey,
+		ekSize:        hybrid.EncapsulationKeySize,
+		dkSize:        hybrid.DecapsulationKeySize,
+		ctSize:        hybrid.CiphertextSize,
+		sharedKeySize: hybrid.SharedKeySize,
+	}
+}
+
+// XWing returns the X-Wing KEM, ML-KEM-768 and X25519 behind the X-Wing
+// combiner. Decapsulation keys are 32-byte seeds.
+func XWing() KEM {
+	return &adapter[*hybrid.XWingEncapsulationKey, *hybrid.XWingDecapsulationKey]{
+		name:          hybrid.XWingName,
+		generate:      hybrid.GenerateXWingKey,
+		newEK:         hybrid.NewXWingEncapsulationKey,
+		newDK:         hybrid.NewXWingDecapsulationKey,
+		ekSize:        hybrid.EncapsulationKeySize,
+		dkSize:        hybrid.XWingSeedSize,
+		ctSize:        hybrid.CiphertextSize,
+		sharedKeySize: 32,
+	}
+}
+
+// All lists every parameter set of every scheme.
+var All = []KEM{
+	MLKEM(mlkem.MLKEM512), MLKEM(mlkem.MLKEM768), MLKEM(mlkem.MLKEM1024),
+	X25519MLKEM768(), XWing(),
+}
+
+// ByName returns the KEM of the named parameter set, such as "ML-KEM-768"
+// or "X-Wing".
+func ByName(name string) (KEM, error) {
+	for _, k := range All {
+		if k.Name() == name {
+			return k, nil
+		}
+	}
+	return nil, fmt.Errorf("kem: unknown scheme %q", name)
+}
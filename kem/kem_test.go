@@ -0,0 +1,122 @@
+package kem
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/haopining/Learn-Lattice-Based-Cryptography/hybrid"
+	"github.com/haopining/Learn-Lattice-Based-Cryptography/mlkem"
+)
+
+// TestAll runs every KEM through key generation, encoding, encapsulation
+// and decapsulation, and checks the encoded sizes against the ones it
+// reports.
+func TestAll(t *testing.T) {
+	for _, k := range All {
+		t.Run(k.Name(), func(t *testing.T) {
+			dk, err := k.GenerateKey()
+			if err != nil {
+				t.Fatal(err)
+			}
+			ek := dk.EncapsulationKey()
+			for _, c := range []struct {
+				what      string
+				got, want int
+			}{
+				{"encapsulation key", len(ek.Bytes()), k.EncapsulationKeySize()},
+				{"decapsulation key", len(dk.Bytes()), k.DecapsulationKeySize()},
+			} {
+				if c.got != c.want {
+					t.Errorf("%s is %d bytes, want %d", c.what, c.got, c.want)
+				}
+			}
+
+			ek2, err := k.NewEncapsulationKey(ek.Bytes())
+			if err != nil {
+				t.Fatal(err)
+			}
+			dk2, err := k.NewDecapsulationKey(dk.Bytes())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(dk2.Bytes(), dk.Bytes()) || !bytes.Equal(dk2.EncapsulationKey().Bytes(), ek.Bytes()) {
+				t.Error("parsed keys do not round-trip")
+			}
+
+			shared, ct := ek2.Encapsulate()
+			if len(shared) != k.SharedKeySize() || len(ct) != k.CiphertextSize() {
+				t.Errorf("shared key and ciphertext are %d and %d bytes, want %d and %d",
+					len(shared), len(ct), k.SharedKeySize(), k.CiphertextSize())
+			}
+			for _, d := range []DecapsulationKey{dk, dk2} {
+				got, err := d.Decapsulate(ct)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(got, shared) {
+					t.Errorf("Decapsulate = %x, want %x", got, shared)
+				}
+			}
+
+			// A flipped bit is not an error but gives an unrelated key.
+			bad := bytes.Clone(ct)
+			bad[0] ^= 1
+			got, err := dk.Decapsulate(bad)
+			if err != nil {
+				t.Fatalf("Decapsulate of a flipped ciphertext: %v", err)
+			}
+			if len(got) != k.SharedKeySize() || bytes.Equal(got, shared) {
+				t.Errorf("Decapsulate of a flipped ciphertext = %x", got)
+			}
+			if again, _ := dk.Decapsulate(bad); !bytes.Equal(again, got) {
+				t.Error("implicit rejection is not deterministic")
+			}
+
+			if _, err := dk.Decapsulate(ct[1:]); err == nil {
+				t.Error("Decapsulate accepted a short ciphertext")
+			}
+			if _, err := k.NewEncapsulationKey(ek.Bytes()[1:]); err == nil {
+				t.Error("NewEncapsulationKey accepted a short key")
+			}
+			if _, err := k.NewDecapsulationKey(dk.Bytes()[1:]); err == nil {
+				t.Error("NewDecapsulationKey accepted a short key")
+			}
+		})
+	}
+}
+
+// TestByName checks that every KEM is found under its name and unknown
+// names are rejected.
+func TestByName(t *testing.T) {
+	for _, k := range All {
+		if got, err := ByName(k.Name()); err != nil || got != k {
+			t.Errorf("ByName(%q) = %v, %v", k.Name(), got, err)
+		}
+	}
+	for _, name := range []string{"", "ML-KEM-256", "ml-kem-768"} {
+		if _, err := ByName(name); err == nil {
+			t.Errorf("ByName(%q) succeeded", name)
+		}
+	}
+}
+
+// TestUnwrap checks that Unwrap returns the scheme's own key type.
+func TestUnwrap(t *testing.T) {
+	for name, want := range map[string]func(any) bool{
+		"ML-KEM-768":     func(x any) bool { _, ok := x.(*mlkem.DecapsulationKey); return ok },
+		hybrid.Name:      func(x any) bool { _, ok := x.(*hybrid.DecapsulationKey); return ok },
+		hybrid.XWingName: func(x any) bool { _, ok := x.(*hybrid.XWingDecapsulationKey); return ok },
+	} {
+		k, err := ByName(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dk, err := k.GenerateKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if u := Unwrap(dk); !want(u) {
+			t.Errorf("%s: Unwrap returned %T", name, u)
+		}
+	}
+}
@@ -0,0 +1,374 @@
+package lattice
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+)
+
+// ErrNotSquare is returned by operations that are only defined for square
+// bases, such as the determinant.
+var ErrNotSquare = errors.New("lattice: basis is not square")
+
+// ErrDependent is returned when the rows of a basis are linearly dependent.
+var ErrDependent = errors.New("lattice: basis vectors are linearly dependent")
+
+// ErrEmpty is returned by measures that are undefined for a basis of rank 0.
+var ErrEmpty = errors.New("lattice: empty basis")
+
+// Vector is an integer vector.
+type Vector []*big.Int
+
+// NewVector returns the vector with the given int64 entries.
+func NewVector(v ...int64) Vector {
+	out := make(Vector, len(v))
+	for i, x := range v {
+		out[i] = big.NewInt(x)
+	}
+	return out
+}
+
+// ZeroVector returns the all-zero vector of length n.
+func ZeroVector(n int) Vector {
+	out := make(Vector, n)
+	for i := range out {
+		out[i] = new(big.Int)
+	}
+	return out
+}
+
+// Clone returns a deep copy of v.
+func (v Vector) Clone() Vector {
+	out := make(Vector, len(v))
+	for i, x := range v {
+		out[i] = new(big.Int).Set(x)
+	}
+	return out
+}
+
+// Dot returns the inner product <v, w>.
+func (v Vector) Dot(w Vector) *big.Int {
+	sum, t := new(big.Int), new(big.Int)
+	for i := range v {
+		sum.Add(sum, t.Mul(v[i], w[i]))
+	}
+	return sum
+}
+
+// Norm2 returns the squared Euclidean length <v, v>.
+func (v Vector) Norm2() *big.Int { return v.Dot(v) }
+
+// Norm returns the Euclidean length of v as a float64.
+func (v Vector) Norm() float64 { return math.Sqrt(bigToFloat(v.Norm2())) }
+
+// Add sets v = v + w and returns v.
+func (v Vector) Add(w Vector) Vector {
+	for i := range v {
+		v[i].Add(v[i], w[i])
+	}
+	return v
+}
+
+// Sub sets v = v - w and returns v.
+func (v Vector) Sub(w Vector) Vector {
+	for i := range v {
+		v[i].Sub(v[i], w[i])
+	}
+	return v
+}
+
+// AddMul sets v = v + c*w and returns v.
+func (v Vector) AddMul(c *big.Int, w Vector) Vector {
+	t := new(big.Int)
+	for i := range v {
+		v[i].Add(v[i], t.Mul(c, w[i]))
+	}
+	return v
+}
+
+// IsZero reports whether every entry of v is zero.
+func (v Vector) IsZero() bool {
+	for _, x := range v {
+		if x.Sign() != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Equal reports whether v and w have the same entries.
+func (v Vector) Equal(w Vector) bool {
+	if len(v) != len(w) {
+		return false
+	}
+	for i := range v {
+		if v[i].Cmp(w[i]) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Int64s returns the entries of v as int64s. Entries that do not fit are
+// truncated, so it is only meant for printing small examples.
+func (v Vector) Int64s() []int64 {
+	out := make([]int64, len(v))
+	for i, x := range v {
+		out[i] = x.Int64()
+	}
+	return out
+}
+
+// Floats returns the entries of v as float64s.
+func (v Vector) Floats() []float64 {
+	out := make([]float64, len(v))
+	for i, x := range v {
+		out[i] = bigToFloat(x)
+	}
+	return out
+}
+
+func (v Vector) String() string {
+	parts := make([]string, len(v))
+	for i, x := range v {
+		parts[i] = x.String()
+	}
+	return "[" + strings.Join(parts, " ") + "]"
+}
+
+// Basis is an ordered list of integer row vectors b_0, ..., b_{k-1} in Z^n.
+//
+// Nothing forces the rows to be linearly independent; functions that need
+// it say so and return ErrDependent.
+type Basis []Vector
+
+// NewBasis builds a basis from int64 rows. All rows must have the same
+// length.
+func NewBasis(rows [][]int64) Basis {
+	b := make(Basis, len(rows))
+	for i, r := range rows {
+		if len(r) != len(rows[0]) {
+			panic(fmt.Sprintf("lattice: row %d has length %d, want %d", i, len(r), len(rows[0])))
+		}
+		b[i] = NewVector(r...)
+	}
+	return b
+}
+
+// Identity returns the standard basis of Z^n.
+func Identity(n int) Basis {
+	b := make(Basis, n)
+	for i := range b {
+		b[i] = ZeroVector(n)
+		b[i][i].SetInt64(1)
+	}
+	return b
+}
+
+// Rank returns the number of basis vectors k.
+func (b Basis) Rank() int { return len(b) }
+
+// Dim returns the dimension n of the ambient space Z^n.
+func (b Basis) Dim() int {
+	if len(b) == 0 {
+		return 0
+	}
+	return len(b[0])
+}
+
+// Clone returns a deep copy of b.
+func (b Basis) Clone() Basis {
+	out := make(Basis, len(b))
+	for i, v := range b {
+		out[i] = v.Clone()
+	}
+	return out
+}
+
+// Equal reports whether b and c have identical rows in the same order.
+func (b Basis) Equal(c Basis) bool {
+	if len(b) != len(c) {
+		return false
+	}
+	for i := range b {
+		if !b[i].Equal(c[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Gram returns the Gram matrix G with G[i][j] = <b_i, b_j>.
+func (b Basis) Gram() [][]*big.Int {
+	k := len(b)
+	g := make([][]*big.Int, k)
+	for i := range g {
+		g[i] = make([]*big.Int, k)
+	}
+	for i := 0; i < k; i++ {
+		for j := 0; j <= i; j++ {
+			g[i][j] = b[i].Dot(b[j])
+			g[j][i] = g[i][j]
+		}
+	}
+	return g
+}
+
+// Combination returns sum_i x[i] * b_i.
+func (b Basis) Combination(x []*big.Int) Vector {
+	v := ZeroVector(b.Dim())
+	for i := range b {
+		v.AddMul(x[i], b[i])
+	}
+	return v
+}
+
+// Floats returns the basis as a matrix of float64s.
+func (b Basis) Floats() [][]float64 {
+	out := make([][]float64, len(b))
+	for i, v := range b {
+		out[i] = v.Floats()
+	}
+	return out
+}
+
+func (b Basis) String() string {
+	var sb strings.Builder
+	for i, v := range b {
+		if i > 0 {
+			sb.WriteByte('\n')
+		}
+		sb.WriteString(v.String())
+	}
+	return sb.String()
+}
+
+// Determinant returns det(B) for a square basis, computed exactly with
+// Bareiss' fraction-free elimination so that every intermediate value stays
+// an integer.
+func (b Basis) Determinant() (*big.Int, error) {
+	n := len(b)
+	if n != b.Dim() {
+		return nil, ErrNotSquare
+	}
+	return bareiss(b.Clone()), nil
+}
+
+// bareiss destroys m and returns its determinant.
+func bareiss(m Basis) *big.Int {
+	n := len(m)
+	if n == 0 {
+		return big.NewInt(1)
+	}
+	sign := 1
+	prev := big.NewInt(1)
+	t := new(big.Int)
+	for k := 0; k < n-1; k++ {
+		if m[k][k].Sign() == 0 {
+			swap := -1
+			for i := k + 1; i < n; i++ {
+				if m[i][k].Sign() != 0 {
+					swap = i
+					break
+				}
+			}
+			if swap < 0 {
+				return new(big.Int)
+			}
+			m[k], m[swap] = m[swap], m[k]
+			sign = -sign
+		}
+		for i := k + 1; i < n; i++ {
+			for j := k + 1; j < n; j++ {
+				// m[i][j] = (m[i][j]*m[k][k] - m[i][k]*m[k][j]) / prev
+				m[i][j].Mul(m[i][j], m[k][k])
+				m[i][j].Sub(m[i][j], t.Mul(m[i][k], m[k][j]))
+				m[i][j].Quo(m[i][j], prev)
+			}
+		}
+		prev = m[k][k]
+	}
+	det := new(big.Int).Set(m[n-1][n-1])
+	if sign < 0 {
+		det.Neg(det)
+	}
+	return det
+}
+
+// Volume2 returns the squared covolume vol(L)^2 = det(B B^T). It is always
+// a non-negative integer, and zero exactly when the rows are dependent.
+func (b Basis) Volume2() *big.Int {
+	g := b.Gram()
+	m := make(Basis, len(g))
+	for i, row := range g {
+		m[i] = Vector(row).Clone()
+	}
+	return bareiss(m)
+}
+
+// Covolume returns vol(L) = sqrt(det(B B^T)) as a float64.
+func (b Basis) Covolume() float64 { return math.Exp(b.LogCovolume()) }
+
+// LogCovolume returns the natural logarithm of the covolume. It stays finite
+// where Covolume would overflow.
+func (b Basis) LogCovolume() float64 { return bigLog(b.Volume2()) / 2 }
+
+// OrthogonalityDefect returns prod ||b_i|| / vol(L). It is at least 1, with
+// equality for an orthogonal basis.
+func (b Basis) OrthogonalityDefect() (float64, error) {
+	logVol := b.LogCovolume()
+	if math.IsInf(logVol, -1) {
+		return 0, ErrDependent
+	}
+	sum := 0.0
+	for _, v := range b {
+		sum += bigLog(v.Norm2()) / 2
+	}
+	return math.Exp(sum - logVol), nil
+}
+
+// RootHermiteFactor returns delta = (||b_0|| / vol(L)^(1/k))^(1/k) for a
+// basis of rank k. LLL typically reaches about 1.0219 and BKZ-20 about
+// 1.0128 on random lattices.
+func (b Basis) RootHermiteFactor() (float64, error) {
+	if len(b) == 0 {
+		return 0, ErrEmpty
+	}
+	k := float64(len(b))
+	logVol := b.LogCovolume()
+	if math.IsInf(logVol, -1) {
+		return 0, ErrDependent
+	}
+	logB0 := bigLog(b[0].Norm2()) / 2
+	return math.Exp((logB0 - logVol/k) / k), nil
+}
+
+// bigToFloat converts x to the nearest float64.
+func bigToFloat(x *big.Int) float64 {
+	if x.IsInt64() {
+		return float64(x.Int64())
+	}
+	f, _ := new(big.Float).SetInt(x).Float64()
+	return f
+}
+
+// bigLog returns ln(x) for x > 0 and -Inf for x == 0, without overflowing
+// for huge x.
+func bigLog(x *big.Int) float64 {
+	if x.Sign() == 0 {
+		return math.Inf(-1)
+	}
+	shift := x.BitLen() - 53
+	if shift <= 0 {
+		return math.Log(bigToFloat(x))
+	}
+	top := new(big.Int).Rsh(x, uint(shift))
+	return math.Log(bigToFloat(top)) + float64(shift)*math.Ln2
+}
+
+// ratLog returns ln(x) for a positive rational.
+func ratLog(x *big.Rat) float64 {
+	return bigLog(x.Num()) - bigLog(x.Denom())
+}
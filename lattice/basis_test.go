@@ -0,0 +1,64 @@
+package lattice
+
+import (
+	"math"
+	"testing"
+)
+
+func TestQualityErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		b    Basis
+		want error
+	}{
+		{"empty", Basis{}, ErrEmpty},
+		{"repeated row", NewBasis([][]int64{{1, 2, 3}, {1, 2, 3}}), ErrDependent},
+		{"multiple", NewBasis([][]int64{{3, 1}, {6, 2}}), ErrDependent},
+		{"zero row", NewBasis([][]int64{{1, 0, 0}, {0, 0, 0}, {0, 0, 1}}), ErrDependent},
+		{"sum of rows", NewBasis([][]int64{{1, 2, 0}, {0, 1, 5}, {1, 3, 5}}), ErrDependent},
+	} {
+		if _, err := tc.b.Quality(); err != tc.want {
+			t.Errorf("%s: Quality returned %v, want %v", tc.name, err, tc.want)
+		}
+		if _, err := tc.b.QualityFloat(); err != tc.want {
+			t.Errorf("%s: QualityFloat returned %v, want %v", tc.name, err, tc.want)
+		}
+	}
+}
+
+// TestQuality compares Quality and QualityFloat on bases of full and
+// lower rank.
+func TestQuality(t *testing.T) {
+	for _, b := range []Basis{
+		NewBasis([][]int64{{2, 0}, {0, 3}}),
+		NewBasis([][]int64{{1, 1, 0}, {0, 1, 1}}),
+		NewBasis([][]int64{{5, -3, 2, 7}, {1, 8, -4, 0}, {-6, 2, 9, 3}, {4, 4, -1, -5}}),
+	} {
+		q, err := b.Quality()
+		if err != nil {
+			t.Fatal(err)
+		}
+		qf, err := b.QualityFloat()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if q.Rank != len(b) || qf.Rank != len(b) {
+			t.Errorf("rank %d and %d, want %d", q.Rank, qf.Rank, len(b))
+		}
+		if q.OrthogonalityDefect < 1-1e-12 {
+			t.Errorf("orthogonality defect %v below 1", q.OrthogonalityDefect)
+		}
+		for _, d := range [][2]float64{
+			{q.LogCovolume, qf.LogCovolume},
+			{q.OrthogonalityDefect, qf.OrthogonalityDefect},
+			{q.RootHermiteFactor, qf.RootHermiteFactor},
+		} {
+			if math.Abs(d[0]-d[1]) > 1e-9*math.Abs(d[0]) {
+				t.Errorf("%v: exact %v, float %v", b, d[0], d[1])
+			}
+		}
+	}
+	if q, _ := NewBasis([][]int64{{2, 0}, {0, 3}}).Quality(); q.OrthogonalityDefect != 1 {
+		t.Errorf("orthogonal basis has defect %v", q.OrthogonalityDefect)
+	}
+}
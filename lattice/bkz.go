@@ -0,0 +1,340 @@
+package lattice
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"math/rand"
+)
+
+// BKZOptions configures BKZ reduction.
+type BKZOptions struct {
+	// BlockSize is the BKZ block size beta (at least 2). BKZ-2 is LLL.
+	BlockSize int
+	// Delta is the LLL parameter used between enumerations. Default 0.99.
+	Delta float64
+	// MaxTours stops the reduction after this many tours even if it has
+	// not converged. 0 means no limit.
+	MaxTours int
+	// AutoAbort stops the reduction once the slope of the Gram–Schmidt
+	// log-profile has not improved by at least 0.1% for five tours, the
+	// early-abort rule of BKZ 2.0. Most of the quality is reached in the
+	// first few tours.
+	AutoAbort bool
+	// Pruning, if not nil, returns the pruning coefficients for a block
+	// of the given size, for instance LinearPruning.
+	Pruning func(d int) []float64
+	// Trials is the number of rerandomized enumerations per block when
+	// Pruning is set (extreme pruning). Default 1.
+	Trials int
+	// GHFactor bounds the enumeration radius by GHFactor times the
+	// Gaussian heuristic of the block, as in BKZ 2.0. 0 disables it.
+	GHFactor float64
+	// Rand drives the rerandomization. Default: a source seeded with 1.
+	Rand *rand.Rand
+	// OnTour, if not nil, is called with the statistics of every tour.
+	OnTour func(TourStats)
+}
+
+// TourStats describes the basis at the end of a BKZ tour.
+type TourStats struct {
+	Tour      int
+	BlockSize int
+	// Profile holds ln ||b*_i|| for every i.
+	Profile []float64
+	// Slope is the least-squares slope of Profile; under the geometric
+	// series assumption it is -2 ln(delta) * n/(n-1).
+	Slope             float64
+	RootHermiteFactor float64
+	Insertions        int
+	Nodes             int64
+}
+
+func (s TourStats) String() string {
+	return fmt.Sprintf("tour %d beta=%d slope=%.5f rhf=%.5f insertions=%d nodes=%d",
+		s.Tour, s.BlockSize, s.Slope, s.RootHermiteFactor, s.Insertions, s.Nodes)
+}
+
+// BKZ reduces b with the Block Korkine–Zolotarev algorithm in the style of
+// BKZ 2.0 (Chen–Nguyen).
+//
+// A tour walks a window of BlockSize vectors over the basis. For every
+// start index kappa it finds a short vector of the projected block
+// pi_kappa(b_kappa, ..., b_{kappa+beta-1}) by Schnorr–Euchner enumeration,
+// optionally pruned, and if it is shorter than b*_kappa inserts it at
+// position kappa and LLL-reduces away the resulting linear dependency.
+// Tours repeat until no insertion happens or an early-abort rule fires.
+// Every tour ends with an LLL reduction of the whole basis, so the output
+// is LLL-reduced.
+func BKZ(b Basis, opts *BKZOptions) (Basis, []TourStats, error) {
+	if opts == nil || opts.BlockSize < 2 {
+		return nil, nil, fmt.Errorf("lattice: BKZ block size must be at least 2")
+	}
+	if len(b) == 0 {
+		return nil, nil, ErrEmpty
+	}
+	lll := &LLLOptions{Delta: opts.Delta}
+	b, err := LLL(b, lll)
+	if err != nil {
+		return nil, nil, err
+	}
+	rng := opts.Rand
+	if rng == nil {
+		rng = rand.New(rand.NewSource(1))
+	}
+	trials := max(opts.Trials, 1)
+	if opts.Pruning == nil {
+		trials = 1
+	}
+
+	var stats []TourStats
+	bestSlope, stale := math.Inf(-1), 0
+	for tour := 1; opts.MaxTours == 0 || tour <= opts.MaxTours; tour++ {
+		st := TourStats{Tour: tour, BlockSize: opts.BlockSize}
+		for kappa := 0; kappa < len(b)-1; kappa++ {
+			end := min(kappa+opts.BlockSize, len(b))
+			inserted, nodes, err := bkzBlock(b, kappa, end, opts, trials, rng, lll)
+			if err != nil {
+				return nil, stats, err
+			}
+			st.Nodes += nodes
+			if inserted != nil {
+				b = inserted
+				st.Insertions++
+			}
+		}
+		// An insertion only reduces the prefix up to the end of its
+		// block, which can leave the rows after it unreduced.
+		if st.Insertions > 0 {
+			if b, err = LLL(b, lll); err != nil {
+				return nil, stats, err
+			}
+		}
+		g := gramSchmidtFloat(b.Floats())
+		st.Profile = g.Profile()
+		st.Slope = Slope(st.Profile)
+		st.RootHermiteFactor = rootHermite(st.Profile)
+		stats = append(stats, st)
+		if opts.OnTour != nil {
+			opts.OnTour(st)
+		}
+		if st.Insertions == 0 {
+			break
+		}
+		if opts.AutoAbort {
+			// The slope is negative and approaches 0 as the basis
+			// improves.
+			if st.Slope > bestSlope*(1-0.001) {
+				bestSlope, stale = st.Slope, 0
+			} else if stale++; stale >= 5 {
+				break
+			}
+		}
+	}
+	return b, stats, nil
+}
+
+// ProgressiveBKZ runs BKZ with increasing block sizes step, 2*step, ...
+// up to opts.BlockSize. Each stage starts from a basis already reduced by
+// the previous, smaller block size, which is far cheaper than starting
+// with the full block size on an LLL-reduced basis.
+func ProgressiveBKZ(b Basis, opts *BKZOptions, step int) (Basis, []TourStats, error) {
+	if opts == nil || opts.BlockSize < 2 {
+		return nil, nil, fmt.Errorf("lattice: BKZ block size must be at least 2")
+	}
+	if step < 1 {
+		step = 1
+	}
+	var all []TourStats
+	for beta := min(step+1, opts.BlockSize); ; beta = min(beta+step, opts.BlockSize) {
+		o := *opts
+		o.BlockSize = beta
+		var stats []TourStats
+		var err error
+		b, stats, err = BKZ(b, &o)
+		all = append(all, stats...)
+		if err != nil {
+			return nil, all, err
+		}
+		if beta == opts.BlockSize {
+			return b, all, nil
+		}
+	}
+}
+
+// bkzBlock processes the block [kappa, end). It returns the new basis if a
+// vector was inserted and nil otherwise.
+func bkzBlock(b Basis, kappa, end int, opts *BKZOptions, trials int, rng *rand.Rand, lll *LLLOptions) (Basis, int64, error) {
+	var nodes int64
+	work := b
+	for trial := 0; trial < trials; trial++ {
+		if trial > 0 {
+			var err error
+			work, err = rerandomize(work, kappa, end, rng, lll)
+			if err != nil {
+				return nil, nodes, err
+			}
+		}
+		g := gramSchmidtFloat(work[:end].Floats())
+		mu, r := localBlock(g, kappa, end)
+		// Only accept vectors that are clearly shorter than b*_kappa of
+		// the original basis.
+		radius2 := 0.99 * g.Norm2[kappa]
+		if trial > 0 {
+			radius2 = 0.99 * gramSchmidtFloat(b[:kappa+1].Floats()).Norm2[kappa]
+		}
+		if opts.GHFactor > 0 {
+			logVol := 0.0
+			for _, x := range r {
+				logVol += math.Log(x) / 2
+			}
+			gh := opts.GHFactor * GaussianHeuristic(logVol, len(r))
+			radius2 = math.Min(radius2, gh*gh)
+		}
+		var prune []float64
+		if opts.Pruning != nil {
+			prune = opts.Pruning(len(r))
+		}
+		res := enumerate(mu, r, radius2, prune, nil)
+		if res != nil {
+			nodes += res.Nodes
+			out, err := insert(work, kappa, end, res.Coeffs, lll)
+			return out, nodes, err
+		}
+	}
+	return nil, nodes, nil
+}
+
+// insert replaces the block [kappa, end) of b by a basis of the same
+// sublattice whose first vector is v = sum_i x_i b_{kappa+i}, then
+// LLL-reduces the prefix b_0..b_{end-1}. The new block basis is obtained
+// with unimodular operations (a Euclidean algorithm on the coefficients),
+// so no linear dependency ever appears.
+func insert(b Basis, kappa, end int, x []int64, lll *LLLOptions) (Basis, error) {
+	b = b.Clone()
+	blk := b[kappa:end]
+	c := int64sToBig(x)
+	q, t := new(big.Int), new(big.Int)
+	// Invariant: v = sum_i c_i * blk_i.
+	for {
+		// i: non-zero coefficient of smallest magnitude, j: any other
+		// non-zero coefficient.
+		i, j := -1, -1
+		for k := range c {
+			if c[k].Sign() != 0 && (i < 0 || c[k].CmpAbs(c[i]) < 0) {
+				i = k
+			}
+		}
+		for k := range c {
+			if k != i && c[k].Sign() != 0 {
+				j = k
+				break
+			}
+		}
+		if j < 0 {
+			// Only c_i is non-zero; since the enumerated vector is
+			// primitive it is ±1 and blk_i = ±v.
+			if c[i].Sign() < 0 {
+				for _, e := range blk[i] {
+					e.Neg(e)
+				}
+			}
+			v := blk[i]
+			copy(blk[1:i+1], blk[:i])
+			blk[0] = v
+			break
+		}
+		// c_i has the smallest magnitude: c_j -= q c_i and
+		// blk_i += q blk_j keep the invariant.
+		q.Quo(c[j], c[i])
+		c[j].Sub(c[j], t.Mul(q, c[i]))
+		blk[i].AddMul(q, blk[j])
+	}
+	red, err := LLL(b[:end], lll)
+	if err != nil {
+		return nil, err
+	}
+	copy(b, red)
+	return b, nil
+}
+
+// rerandomize applies a random unimodular transformation to the vectors
+// b_{kappa+1}..b_{end-1} and LLL-reduces the prefix again, giving a
+// different basis of the same projected block for another pruned
+// enumeration.
+func rerandomize(b Basis, kappa, end int, rng *rand.Rand, lll *LLLOptions) (Basis, error) {
+	b = b.Clone()
+	for i := kappa + 1; i < end; i++ {
+		for j := i + 1; j < end; j++ {
+			if rng.Intn(3) == 0 {
+				b[i].AddMul(big.NewInt(int64(rng.Intn(3)-1)), b[j])
+			}
+		}
+	}
+	for i := end - 1; i > kappa+1; i-- {
+		j := kappa + 1 + rng.Intn(i-kappa)
+		b[i], b[j] = b[j], b[i]
+	}
+	red, err := LLL(b[:end], lll)
+	if err != nil {
+		return nil, err
+	}
+	copy(b, red)
+	return b, nil
+}
+
+// Slope returns the least-squares slope of the points (i, profile[i]).
+func Slope(profile []float64) float64 {
+	n := float64(len(profile))
+	var sx, sy, sxx, sxy float64
+	for i, y := range profile {
+		x := float64(i)
+		sx += x
+		sy += y
+		sxx += x * x
+		sxy += x * y
+	}
+	return (n*sxy - sx*sy) / (n*sxx - sx*sx)
+}
+
+// rootHermite returns the root-Hermite factor of a basis from its
+// log-profile, or NaN for an empty profile.
+func rootHermite(profile []float64) float64 {
+	if len(profile) == 0 {
+		return math.NaN()
+	}
+	n := float64(len(profile))
+	logVol := 0.0
+	for _, p := range profile {
+		logVol += p
+	}
+	return math.Exp((profile[0] - logVol/n) / n)
+}
+
+// GSA returns the Gram–Schmidt log-profile predicted by the geometric
+// series assumption for a rank-n lattice of covolume exp(logVol) reduced
+// to root-Hermite factor delta:
+//
+//	ln ||b*_i|| = n ln(delta) + logVol/n - 2n/(n-1) * i * ln(delta)
+//
+// The lengths decay geometrically and multiply to the covolume.
+func GSA(n int, logVol, delta float64) []float64 {
+	p := make([]float64, n)
+	ld := math.Log(delta)
+	for i := range p {
+		p[i] = float64(n)*ld + logVol/float64(n) - 2*float64(n)/float64(n-1)*float64(i)*ld
+	}
+	return p
+}
+
+// BKZDelta returns the root-Hermite factor that BKZ-beta is expected to
+// reach in high dimension (Chen's limit formula):
+//
+//	delta = ( beta/(2 pi e) * (pi beta)^(1/beta) )^(1/(2(beta-1)))
+//
+// It is only meaningful for beta above about 40; smaller block sizes are
+// better described by experimental values such as 1.0219 for LLL.
+func BKZDelta(beta int) float64 {
+	k := float64(beta)
+	return math.Pow(k/(2*math.Pi*math.E)*math.Pow(math.Pi*k, 1/k), 1/(2*(k-1)))
+}
@@ -0,0 +1,177 @@
+package lattice
+
+import (
+	"math"
+	"math/big"
+	"math/rand"
+	"testing"
+)
+
+// bruteShortest returns the squared length of the shortest non-zero vector
+// of b by trying every coefficient vector in a box: the coefficients of a
+// vector v are <v, d_i> for the dual basis vectors d_i, so a vector no
+// longer than b_0 has |x_i| <= ||b_0|| ||d_i||.
+func bruteShortest(t *testing.T, b Basis) *big.Int {
+	d, err := Dual(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bound := make([]int64, len(b))
+	for i, row := range d {
+		bound[i] = int64(b[0].Norm() * math.Sqrt(ratFloat(ratDot(row, row))))
+	}
+	best := b[0].Norm2()
+	x := make([]*big.Int, len(b))
+	var walk func(i int)
+	walk = func(i int) {
+		if i == len(b) {
+			if v := b.Combination(x); !v.IsZero() && v.Norm2().Cmp(best) < 0 {
+				best = v.Norm2()
+			}
+			return
+		}
+		for c := -bound[i]; c <= bound[i]; c++ {
+			x[i] = big.NewInt(c)
+			walk(i + 1)
+		}
+	}
+	walk(0)
+	return best
+}
+
+func TestEnumerate(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+	for n := 2; n <= 6; n++ {
+		for range 3 {
+			b, err := LLL(randomBasis(r, n, 30), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := bruteShortest(t, b)
+			res, err := Enumerate(b, 0, n, 0, nil)
+			got := b[0].Norm2()
+			if err == nil {
+				got = b.Combination(int64sToBig(res.Coeffs)).Norm2()
+			}
+			if got.Cmp(want) != 0 {
+				t.Errorf("rank %d: enumeration found %v, brute force %v", n, got, want)
+			}
+			sv, err := ShortestVector(b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if sv.Norm2().Cmp(want) != 0 {
+				t.Errorf("rank %d: ShortestVector found %v, brute force %v", n, sv.Norm2(), want)
+			}
+		}
+	}
+	b := NewBasis([][]int64{{1, 0}, {0, 1}})
+	if _, err := Enumerate(b, 0, 2, 0.5, nil); err != ErrNotFound {
+		t.Errorf("radius below lambda_1: %v, want ErrNotFound", err)
+	}
+}
+
+func TestBKZ(t *testing.T) {
+	r := rand.New(rand.NewSource(4))
+	var bases []Basis
+	for n := 8; n <= 16; n += 4 {
+		bases = append(bases, randomBasis(r, n, 100), knapsackBasis(r, n, 24))
+	}
+	for _, b := range bases {
+		want, err := b.Determinant()
+		if err != nil {
+			t.Fatal(err)
+		}
+		lll, err := LLL(b, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, run := range []struct {
+			name string
+			bkz  func() (Basis, []TourStats, error)
+		}{
+			{"BKZ-4", func() (Basis, []TourStats, error) { return BKZ(b, &BKZOptions{BlockSize: 4}) }},
+			{"BKZ-6 pruned", func() (Basis, []TourStats, error) {
+				return BKZ(b, &BKZOptions{BlockSize: 6, Pruning: LinearPruning, Trials: 3})
+			}},
+			{"progressive BKZ-8", func() (Basis, []TourStats, error) {
+				return ProgressiveBKZ(b, &BKZOptions{BlockSize: 8, AutoAbort: true}, 2)
+			}},
+		} {
+			out, stats, err := run.bkz()
+			if err != nil {
+				t.Fatalf("%s rank %d: %v", run.name, len(b), err)
+			}
+			got, err := out.Determinant()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if new(big.Int).Abs(got).Cmp(new(big.Int).Abs(want)) != 0 {
+				t.Errorf("%s rank %d: |det| changed from %v to %v", run.name, len(b), want, got)
+			}
+			if !IsLLLReduced(out, 0.99, 0.51) {
+				t.Errorf("%s rank %d: output not LLL reduced", run.name, len(b))
+			}
+			if out[0].Norm2().Cmp(lll[0].Norm2()) > 0 {
+				t.Errorf("%s rank %d: b_1 of length %v, LLL reaches %v", run.name, len(b), out[0].Norm(), lll[0].Norm())
+			}
+			if len(stats) == 0 {
+				t.Errorf("%s rank %d: no tour statistics", run.name, len(b))
+			}
+		}
+	}
+}
+
+// TestBKZTours checks that MaxTours and AutoAbort stop the tours, and
+// that ProgressiveBKZ raises the block size up to the one asked for.
+func TestBKZTours(t *testing.T) {
+	b := knapsackBasis(rand.New(rand.NewSource(5)), 24, 40)
+	full, err := LLL(b, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, all, err := BKZ(full, &BKZOptions{BlockSize: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, limit := range []int{1, 2} {
+		out, stats, err := BKZ(b, &BKZOptions{BlockSize: 10, MaxTours: limit})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(stats) > limit {
+			t.Errorf("MaxTours %d: %d tours", limit, len(stats))
+		}
+		if !IsLLLReduced(out, 0.99, 0.51) {
+			t.Errorf("MaxTours %d: output not LLL reduced", limit)
+		}
+	}
+	_, stats, err := BKZ(b, &BKZOptions{BlockSize: 10, AutoAbort: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats) > len(all) {
+		t.Errorf("AutoAbort: %d tours, %d without it", len(stats), len(all))
+	}
+	if last := stats[len(stats)-1]; last.Insertions > 0 && len(stats) < 6 {
+		t.Errorf("AutoAbort stopped after %d tours with insertions in the last", len(stats))
+	}
+
+	_, stats, err = ProgressiveBKZ(b, &BKZOptions{BlockSize: 7, MaxTours: 3}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	beta := 0
+	for _, s := range stats {
+		if s.BlockSize < beta || s.Tour > 3 {
+			t.Errorf("progressive tour %d with block size %d after %d", s.Tour, s.BlockSize, beta)
+		}
+		beta = s.BlockSize
+	}
+	if beta != 7 {
+		t.Errorf("progressive BKZ ended at block size %d, want 7", beta)
+	}
+	if _, _, err := BKZ(b, &BKZOptions{BlockSize: 1}); err == nil {
+		t.Error("BKZ accepted block size 1")
+	}
+}
@@ -0,0 +1,216 @@
+package lattice
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+)
+
+// RatVector converts an integer vector into a rational target.
+func RatVector(v Vector) []*big.Rat {
+	out := make([]*big.Rat, len(v))
+	for i, x := range v {
+		out[i] = new(big.Rat).SetInt(x)
+	}
+	return out
+}
+
+// FloatTarget builds a rational target from float64 coordinates. The
+// conversion is exact.
+func FloatTarget(x ...float64) []*big.Rat {
+	out := make([]*big.Rat, len(x))
+	for i, f := range x {
+		out[i] = new(big.Rat).SetFloat64(f)
+	}
+	return out
+}
+
+// Coordinates returns the real coordinates x of the orthogonal projection of
+// t onto the span of b, that is the solution of x * B = t when t lies in
+// the span. It solves the normal equations (B B^T) x = B t exactly.
+func (b Basis) Coordinates(t []*big.Rat) ([]*big.Rat, error) {
+	if len(t) != b.Dim() {
+		return nil, fmt.Errorf("lattice: target has dimension %d, want %d", len(t), b.Dim())
+	}
+	k := len(b)
+	// Augmented system [G | B t].
+	m := make([][]*big.Rat, k)
+	g := b.Gram()
+	tmp := new(big.Rat)
+	for i := 0; i < k; i++ {
+		m[i] = make([]*big.Rat, k+1)
+		for j := 0; j < k; j++ {
+			m[i][j] = new(big.Rat).SetInt(g[i][j])
+		}
+		s := new(big.Rat)
+		for c, x := range b[i] {
+			s.Add(s, tmp.Mul(tmp.SetInt(x), t[c]))
+		}
+		m[i][k] = s
+	}
+	for col := 0; col < k; col++ {
+		p := -1
+		for r := col; r < k; r++ {
+			if m[r][col].Sign() != 0 {
+				p = r
+				break
+			}
+		}
+		if p < 0 {
+			return nil, ErrDependent
+		}
+		m[col], m[p] = m[p], m[col]
+		for r := 0; r < k; r++ {
+			if r == col || m[r][col].Sign() == 0 {
+				continue
+			}
+			f := new(big.Rat).Quo(m[r][col], m[col][col])
+			for c := col; c <= k; c++ {
+				m[r][c].Sub(m[r][c], tmp.Mul(f, m[col][c]))
+			}
+		}
+	}
+	x := make([]*big.Rat, k)
+	for i := range x {
+		x[i] = new(big.Rat).Quo(m[i][k], m[i][i])
+	}
+	return x, nil
+}
+
+// BabaiRound solves CVP approximately with Babai's rounding technique:
+// write t in the basis, round every coordinate to the nearest integer and
+// map back. The answer is the lattice point of the translated fundamental
+// parallelepiped B*[-1/2, 1/2)^k that contains t, so it is only good when
+// the basis is reduced and close to orthogonal.
+func BabaiRound(b Basis, t []*big.Rat) (Vector, error) {
+	x, err := b.Coordinates(t)
+	if err != nil {
+		return nil, err
+	}
+	c := make([]*big.Int, len(x))
+	for i, xi := range x {
+		c[i] = roundRat(xi)
+	}
+	return b.Combination(c), nil
+}
+
+// BabaiNearestPlane solves CVP approximately with Babai's nearest plane
+// algorithm. Going from the last basis vector to the first it picks the
+// hyperplane c*b*_i + span(b_0..b_{i-1}) closest to the current target,
+// subtracts c*b_i and recurses in one dimension less. The error t - v lies
+// in the box sum_i [-1/2, 1/2) b*_i, which makes it the natural decoder for
+// bounded-distance decoding with an LLL- or BKZ-reduced basis.
+func BabaiNearestPlane(b Basis, t []*big.Rat) (Vector, error) {
+	if len(t) != b.Dim() {
+		return nil, fmt.Errorf("lattice: target has dimension %d, want %d", len(t), b.Dim())
+	}
+	gso, err := b.GramSchmidt()
+	if err != nil {
+		return nil, err
+	}
+	_, c := nearestPlane(b, gso, t)
+	return b.Combination(c), nil
+}
+
+// nearestPlane returns the residual t - v and the coefficients of v.
+func nearestPlane(b Basis, gso *GSO, t []*big.Rat) ([]*big.Rat, []*big.Int) {
+	res := make([]*big.Rat, len(t))
+	for i, x := range t {
+		res[i] = new(big.Rat).Set(x)
+	}
+	c := make([]*big.Int, len(b))
+	tmp := new(big.Rat)
+	for i := len(b) - 1; i >= 0; i-- {
+		proj := ratDot(res, gso.BStar[i])
+		proj.Quo(proj, gso.Norm2[i])
+		c[i] = roundRat(proj)
+		if c[i].Sign() == 0 {
+			continue
+		}
+		ci := new(big.Rat).SetInt(c[i])
+		for j, x := range b[i] {
+			res[j].Sub(res[j], tmp.Mul(ci, tmp.SetInt(x)))
+		}
+	}
+	return res, c
+}
+
+// ClosestVector solves CVP exactly: it returns the lattice vector closest to
+// t among those at distance less than radius, found by Schnorr–Euchner
+// enumeration centered on t. A radius of 0 uses the distance of the nearest
+// plane answer (slightly enlarged), which always contains a solution.
+//
+// The enumeration tree is much smaller for a reduced basis, so callers
+// should LLL- or BKZ-reduce b first. When the target is given relative to
+// the span of b, only its projection onto the span matters.
+func ClosestVector(b Basis, t []*big.Rat, radius float64) (Vector, error) {
+	if len(t) != b.Dim() {
+		return nil, fmt.Errorf("lattice: target has dimension %d, want %d", len(t), b.Dim())
+	}
+	gso, err := b.GramSchmidt()
+	if err != nil {
+		return nil, err
+	}
+	radius2 := radius * radius
+	if radius == 0 {
+		res, _ := nearestPlane(b, gso, t)
+		// Only the component inside the span is enumerated.
+		d2 := 0.0
+		for i := range b {
+			c := ratDot(res, gso.BStar[i])
+			c.Quo(c, gso.Norm2[i])
+			f, _ := c.Float64()
+			r, _ := gso.Norm2[i].Float64()
+			d2 += f * f * r
+		}
+		radius2 = d2*(1+1e-9) + 1e-9
+	}
+	g := gso.Float()
+	tc := make([]float64, len(b))
+	for i := range b {
+		c := ratDot(t, gso.BStar[i])
+		c.Quo(c, gso.Norm2[i])
+		tc[i], _ = c.Float64()
+	}
+	mu, r := localBlock(g, 0, len(b))
+	res := enumerate(mu, r, radius2, nil, tc)
+	if res == nil {
+		return nil, ErrNotFound
+	}
+	return b.Combination(int64sToBig(res.Coeffs)), nil
+}
+
+// NearestPlaneRadius returns min_i ||b*_i|| / 2. Babai's nearest plane
+// algorithm is guaranteed to return the closest lattice vector whenever the
+// target is closer than this to the lattice, so it is the decoding radius
+// of the basis for bounded-distance decoding. Reduction makes the b*_i more
+// balanced and therefore increases it.
+func NearestPlaneRadius(b Basis) (float64, error) {
+	gso, err := b.GramSchmidt()
+	if err != nil {
+		return 0, err
+	}
+	m := math.Inf(1)
+	for _, r := range gso.Norm2 {
+		f, _ := r.Float64()
+		m = math.Min(m, f)
+	}
+	return math.Sqrt(m) / 2, nil
+}
+
+// DecodeBDD solves bounded-distance decoding: given t = v + e with v in the
+// lattice and e short, it returns v and e. The basis is LLL-reduced first
+// and the nearest plane algorithm does the decoding, which succeeds
+// whenever ||e|| < NearestPlaneRadius of the reduced basis, and usually
+// well beyond that.
+func DecodeBDD(b Basis, t Vector) (v, e Vector, err error) {
+	red, err := LLL(b, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	v, err = BabaiNearestPlane(red, RatVector(t))
+	if err != nil {
+		return nil, nil, err
+	}
+	return v, t.Clone().Sub(v), nil
+}
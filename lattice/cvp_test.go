@@ -0,0 +1,180 @@
+package lattice
+
+import (
+	"math"
+	"math/big"
+	"math/rand"
+	"testing"
+)
+
+// dist2 returns ||t - v||^2.
+func dist2(v Vector, t []*big.Rat) float64 {
+	s := 0.0
+	for i, x := range v {
+		f, _ := new(big.Rat).Sub(t[i], new(big.Rat).SetInt(x)).Float64()
+		s += f * f
+	}
+	return s
+}
+
+// plant returns a random lattice vector v = c B with coefficients in
+// [-20, 20], and the target v + e for a random e of norm radius.
+func plant(r *rand.Rand, b Basis, radius float64) (Vector, []*big.Rat) {
+	c := make([]*big.Int, len(b))
+	for i := range c {
+		c[i] = big.NewInt(r.Int63n(41) - 20)
+	}
+	v := b.Combination(c)
+	e := make([]float64, b.Dim())
+	norm := 0.0
+	for i := range e {
+		e[i] = r.NormFloat64()
+		norm += e[i] * e[i]
+	}
+	t := make([]float64, len(e))
+	for i := range e {
+		f, _ := new(big.Float).SetInt(v[i]).Float64()
+		t[i] = f + e[i]*radius/math.Sqrt(norm)
+	}
+	return v, FloatTarget(t...)
+}
+
+// roundRadius returns 1 / (2 max ||d_i||) over the dual basis vectors d_i:
+// within it every coordinate <e, d_i> of the error is below 1/2, which is
+// where Babai's rounding is exact.
+func roundRadius(t *testing.T, b Basis) float64 {
+	d, err := Dual(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := 0.0
+	for _, row := range d {
+		m = math.Max(m, math.Sqrt(ratFloat(ratDot(row, row))))
+	}
+	return 1 / (2 * m)
+}
+
+func ratFloat(x *big.Rat) float64 {
+	f, _ := x.Float64()
+	return f
+}
+
+func TestPlantedCloseVectors(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for n := 2; n <= 6; n++ {
+		b, err := LLL(randomBasis(r, n, 30), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		np, err := NearestPlaneRadius(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		radius := 0.99 * math.Min(np, roundRadius(t, b))
+		for range 20 {
+			v, target := plant(r, b, radius)
+			solvers := []struct {
+				name  string
+				solve func() (Vector, error)
+			}{
+				{"BabaiRound", func() (Vector, error) { return BabaiRound(b, target) }},
+				{"BabaiNearestPlane", func() (Vector, error) { return BabaiNearestPlane(b, target) }},
+				{"ClosestVector", func() (Vector, error) { return ClosestVector(b, target, 0) }},
+			}
+			for _, s := range solvers {
+				got, err := s.solve()
+				if err != nil {
+					t.Fatalf("%s rank %d: %v", s.name, n, err)
+				}
+				if !got.Equal(v) {
+					t.Errorf("%s rank %d: got %v, planted %v", s.name, n, got, v)
+				}
+			}
+		}
+	}
+}
+
+func TestDecodeBDD(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	for n := 2; n <= 6; n++ {
+		b := randomBasis(r, n, 30)
+		red, err := LLL(b, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		np, err := NearestPlaneRadius(red)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for range 20 {
+			// An integer error strictly inside the radius.
+			e := ZeroVector(n)
+			k := int64(np / math.Sqrt(float64(n)))
+			for i := range e {
+				e[i].SetInt64(r.Int63n(2*k+1) - k)
+			}
+			if e.Norm() >= np {
+				continue
+			}
+			c := make([]*big.Int, n)
+			for i := range c {
+				c[i] = big.NewInt(r.Int63n(41) - 20)
+			}
+			v := b.Combination(c)
+			gotV, gotE, err := DecodeBDD(b, v.Clone().Add(e))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !gotV.Equal(v) || !gotE.Equal(e) {
+				t.Errorf("rank %d: decoded %v + %v, planted %v + %v", n, gotV, gotE, v, e)
+			}
+		}
+	}
+}
+
+// TestClosestVectorFar checks ClosestVector against brute force for
+// targets beyond NearestPlaneRadius, where Babai gives no guarantee.
+func TestClosestVectorFar(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+	for n := 2; n <= 4; n++ {
+		b, err := LLL(randomBasis(r, n, 20), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		np, err := NearestPlaneRadius(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for range 20 {
+			_, target := plant(r, b, 3*np)
+			got, err := ClosestVector(b, target, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Search every coefficient vector within 4 of the rounded
+			// coordinates of the target.
+			x, err := b.Coordinates(target)
+			if err != nil {
+				t.Fatal(err)
+			}
+			best := math.Inf(1)
+			c := make([]*big.Int, n)
+			var search func(i int)
+			search = func(i int) {
+				if i == n {
+					best = math.Min(best, dist2(b.Combination(c), target))
+					return
+				}
+				mid := roundRat(x[i]).Int64()
+				for d := int64(-4); d <= 4; d++ {
+					c[i] = big.NewInt(mid + d)
+					search(i + 1)
+				}
+			}
+			search(0)
+			if d := dist2(got, target); math.Abs(d-best) > 1e-6*best {
+				t.Errorf("rank %d: ClosestVector at distance^2 %v, brute force %v", n, d, best)
+			}
+		}
+	}
+}
@@ -0,0 +1,29 @@
+// Package lattice models integer lattices and the basic quantities used to
+// reason about them.
+//
+// A lattice L is the set of all integer combinations of k linearly
+// independent vectors b_0, ..., b_{k-1} in Z^n:
+//
+//	L(B) = { x_0*b_0 + ... + x_{k-1}*b_{k-1} : x_i in Z }
+//
+// The vectors are stored as the rows of a Basis. Most of the geometry of a
+// lattice is read off its Gram–Schmidt orthogonalization
+//
+//	b*_i = b_i - sum_{j<i} mu_ij * b*_j,   mu_ij = <b_i, b*_j> / <b*_j, b*_j>
+//
+// whose lengths ||b*_i|| describe how "orthogonal" the basis is. From them we
+// get the covolume (the volume of the fundamental parallelepiped),
+//
+//	vol(L) = ||b*_0|| * ... * ||b*_{k-1}||,
+//
+// the orthogonality defect prod ||b_i|| / vol(L) (equal to 1 exactly when the
+// basis is orthogonal) and the root-Hermite factor
+//
+//	delta = ( ||b_0|| / vol(L)^(1/k) )^(1/k),
+//
+// the usual yardstick for the quality of a reduced basis.
+//
+// Every computation comes in two flavours: an exact one over big.Rat, which
+// is slow but is what the textbook proofs talk about, and a float64 one that
+// is fast enough for experiments in dimension a few hundred.
+package lattice
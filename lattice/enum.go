@@ -0,0 +1,220 @@
+package lattice
+
+import (
+	"errors"
+	"math"
+	"math/big"
+)
+
+// ErrNotFound is returned when an enumeration finds no non-zero lattice
+// vector within the requested radius.
+var ErrNotFound = errors.New("lattice: no vector found within the radius")
+
+// EnumResult is the outcome of an enumeration.
+type EnumResult struct {
+	// Coeffs are the coordinates of the vector found with respect to the
+	// enumerated block of basis vectors.
+	Coeffs []int64
+	// Norm2 is the squared length of the projection of the vector onto
+	// the span of the block, as computed by the enumeration.
+	Norm2 float64
+	// Nodes is the number of enumeration tree nodes visited.
+	Nodes int64
+}
+
+// enumerate runs Schnorr–Euchner enumeration on the projected block with
+// Gram–Schmidt coefficients mu (mu[i][j] for j < i) and squared lengths r,
+// both indexed from 0 to d-1 locally.
+//
+// With a nil target it solves SVP: it returns the shortest non-zero vector
+// whose projected squared length is below radius2, or nil. With a target,
+// given by its Gram–Schmidt coordinates target[i] = <t, b*_i> / r[i], it
+// solves CVP instead and returns the lattice vector v minimizing the
+// squared length of the projection of t - v, again only if it is below
+// radius2.
+//
+// If prune is not nil, it holds d pruning coefficients in (0, 1]: a node at
+// depth j+1 (with j+1 coordinates fixed, counting from the top) is only
+// expanded while its partial squared length stays below prune[j]*radius2.
+// Pruning trades a lower success probability for a much smaller tree.
+//
+// Every time a solution is found the radius shrinks to its length, so the
+// result is the best vector in the (pruned) tree.
+func enumerate(mu [][]float64, r []float64, radius2 float64, prune []float64, target []float64) *EnumResult {
+	d := len(r)
+	bound := func(k int) float64 {
+		if prune == nil {
+			return radius2
+		}
+		return prune[d-1-k] * radius2
+	}
+	x := make([]float64, d)
+	c := make([]float64, d)
+	dx := make([]float64, d)
+	ddx := make([]float64, d)
+	l := make([]float64, d+1)
+	var best []float64
+	bestNorm := 0.0
+	var nodes int64
+
+	center := func(k int) {
+		s := 0.0
+		if target != nil {
+			s = target[k]
+		}
+		for j := k + 1; j < d; j++ {
+			s -= x[j] * mu[j][k]
+		}
+		c[k] = s
+		x[k] = math.Round(s)
+		if s >= x[k] {
+			dx[k], ddx[k] = 1, 1
+		} else {
+			dx[k], ddx[k] = -1, -1
+		}
+	}
+	// next moves x[k] to the next candidate in zig-zag order around the
+	// center. For SVP, while every coordinate above k is zero only
+	// positive values are tried, so v and -v are not both enumerated.
+	next := func(k int) {
+		if target != nil || l[k+1] != 0 {
+			x[k] += dx[k]
+			ddx[k] = -ddx[k]
+			dx[k] = ddx[k] - dx[k]
+		} else {
+			x[k]++
+		}
+	}
+
+	k := d - 1
+	center(k)
+	for {
+		diff := x[k] - c[k]
+		lk := l[k+1] + diff*diff*r[k]
+		nodes++
+		if lk < bound(k) {
+			if k == 0 {
+				if lk > 0 || target != nil {
+					best = append(best[:0], x...)
+					bestNorm = lk
+					radius2 = lk
+				}
+				next(0)
+				continue
+			}
+			l[k] = lk
+			k--
+			center(k)
+			continue
+		}
+		k++
+		if k == d {
+			break
+		}
+		next(k)
+	}
+	if best == nil {
+		return nil
+	}
+	coeffs := make([]int64, d)
+	for i, v := range best {
+		coeffs[i] = int64(v)
+	}
+	return &EnumResult{Coeffs: coeffs, Norm2: bestNorm, Nodes: nodes}
+}
+
+// Enumerate searches the projected block b_start..b_{end-1} of b for the
+// shortest non-zero vector whose projection orthogonally to b_0..b_{start-1}
+// has squared length below radius2. A radius2 of 0 means ||b*_start||^2,
+// which is always reachable by b_start itself. prune may be nil; see
+// LinearPruning for its meaning.
+func Enumerate(b Basis, start, end int, radius2 float64, prune []float64) (*EnumResult, error) {
+	g := gramSchmidtFloat(b[:end].Floats())
+	mu, r := localBlock(g, start, end)
+	if radius2 == 0 {
+		radius2 = r[0] * (1 + 1e-9)
+	}
+	res := enumerate(mu, r, radius2, prune, nil)
+	if res == nil {
+		return nil, ErrNotFound
+	}
+	return res, nil
+}
+
+// ShortestVector solves SVP exactly by LLL reduction followed by full
+// (unpruned) Schnorr–Euchner enumeration over the whole basis. The running
+// time grows like 2^(O(n^2)) in the worst case and 2^(O(n log n)) on
+// LLL-reduced input, so it is meant for n up to about 50.
+func ShortestVector(b Basis) (Vector, error) {
+	red, err := LLL(b, nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := Enumerate(red, 0, len(red), 0, nil)
+	if err != nil {
+		// b_0 of the reduced basis is already the shortest.
+		return red[0].Clone(), nil
+	}
+	return red.Combination(int64sToBig(res.Coeffs)), nil
+}
+
+// localBlock extracts the Gram–Schmidt data of the block [start, end).
+func localBlock(g *GSOFloat, start, end int) ([][]float64, []float64) {
+	d := end - start
+	mu := make([][]float64, d)
+	r := make([]float64, d)
+	for i := 0; i < d; i++ {
+		mu[i] = make([]float64, i+1)
+		for j := 0; j < i; j++ {
+			mu[i][j] = g.Mu[start+i][start+j]
+		}
+		mu[i][i] = 1
+		r[i] = g.Norm2[start+i]
+	}
+	return mu, r
+}
+
+// GaussianHeuristic returns the expected length of the shortest vector of a
+// random lattice of rank n and covolume exp(logVol):
+//
+//	gh = vol^(1/n) * Gamma(n/2 + 1)^(1/n) / sqrt(pi)
+//
+// that is, the radius of the ball whose volume equals the covolume.
+func GaussianHeuristic(logVol float64, n int) float64 {
+	lg, _ := math.Lgamma(float64(n)/2 + 1)
+	return math.Exp((lg+logVol)/float64(n)) / math.Sqrt(math.Pi)
+}
+
+// LinearPruning returns the linear pruning coefficients (j+1)/d of Gama,
+// Nguyen and Regev for a block of size d. A single pruned enumeration then
+// succeeds with probability about 1/d but visits roughly 2^(d/2) times
+// fewer nodes.
+func LinearPruning(d int) []float64 {
+	p := make([]float64, d)
+	for j := range p {
+		p[j] = float64(j+1) / float64(d)
+	}
+	return p
+}
+
+// ExtremePruning returns pruning coefficients min(1, ((j+1)/d)^e * s)
+// that are more aggressive than linear pruning for e > 1. Such bounds are
+// only worth using together with rerandomization (BKZOptions.Trials), the
+// extreme pruning strategy of Gama–Nguyen–Regev: many cheap enumerations of
+// randomized bases instead of one expensive one.
+func ExtremePruning(d int, e, s float64) []float64 {
+	p := make([]float64, d)
+	for j := range p {
+		p[j] = math.Min(1, math.Pow(float64(j+1)/float64(d), e)*s)
+	}
+	p[d-1] = 1
+	return p
+}
+
+func int64sToBig(x []int64) []*big.Int {
+	out := make([]*big.Int, len(x))
+	for i, v := range x {
+		out[i] = big.NewInt(v)
+	}
+	return out
+}
@@ -0,0 +1,70 @@
+package lattice
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// GaussReduce reduces a rank-2 basis with the Lagrange–Gauss algorithm, the
+// two-dimensional ancestor of LLL:
+//
+//	order the vectors so that ||b_0|| <= ||b_1||
+//	repeat:
+//	    q   = round(<b_0, b_1> / <b_0, b_0>)
+//	    b_1 = b_1 - q*b_0
+//	    if ||b_1|| >= ||b_0||: stop
+//	    swap b_0, b_1
+//
+// On return b_0 is a shortest non-zero vector of the lattice and b_1 is a
+// shortest vector independent of it, so the output is both LLL reduced
+// with delta = 1 and HKZ reduced. If tr is not nil every swap and
+// reduction is recorded, which is enough to replay the run step by step.
+func GaussReduce(b Basis, tr *Trace) (Basis, error) {
+	if len(b) != 2 {
+		return nil, fmt.Errorf("lattice: Gauss reduction needs 2 vectors, got %d", len(b))
+	}
+	b = b.Clone()
+	n0, n1 := b[0].Norm2(), b[1].Norm2()
+	if n0.Sign() == 0 || n1.Sign() == 0 {
+		return nil, ErrDependent
+	}
+	if n1.Cmp(n0) < 0 {
+		b[0], b[1] = b[1], b[0]
+		n0, n1 = n1, n0
+		tr.swap(1)
+	}
+	for {
+		q := roundRat(new(big.Rat).SetFrac(b[0].Dot(b[1]), n0))
+		if q.Sign() != 0 {
+			b[1].AddMul(new(big.Int).Neg(q), b[0])
+			tr.sizeReduce(1, 0, q)
+			n1 = b[1].Norm2()
+		}
+		if n1.Sign() == 0 {
+			return nil, ErrDependent
+		}
+		if n1.Cmp(n0) >= 0 {
+			return b, nil
+		}
+		b[0], b[1] = b[1], b[0]
+		n0, n1 = n1, n0
+		tr.swap(1)
+	}
+}
+
+// Replay applies the steps of a trace to a copy of b and returns the basis
+// before the first step and after every step.
+func Replay(b Basis, steps []Step) []Basis {
+	b = b.Clone()
+	out := []Basis{b.Clone()}
+	for _, s := range steps {
+		switch s.Kind {
+		case SizeReduce:
+			b[s.I].AddMul(new(big.Int).Neg(s.Q), b[s.J])
+		case Swap:
+			b[s.I], b[s.J] = b[s.J], b[s.I]
+		}
+		out = append(out, b.Clone())
+	}
+	return out
+}
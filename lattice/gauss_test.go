@@ -0,0 +1,57 @@
+package lattice
+
+import (
+	"math/big"
+	"math/rand"
+	"testing"
+)
+
+func TestGaussReduce(t *testing.T) {
+	r := rand.New(rand.NewSource(6))
+	for i := range 200 {
+		dim, bound := 2+i%2, int64(1000)
+		if i%4 < 2 {
+			bound = 30
+		}
+		var b Basis
+		for {
+			b = NewBasis([][]int64{make([]int64, dim), make([]int64, dim)})
+			for _, v := range b {
+				for _, x := range v {
+					x.SetInt64(r.Int63n(2*bound+1) - bound)
+				}
+			}
+			if b.Volume2().Sign() != 0 {
+				break
+			}
+		}
+		tr := &Trace{}
+		out, err := GaussReduce(b, tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		n0, n1, dot := out[0].Norm2(), out[1].Norm2(), out[0].Dot(out[1])
+		if n0.Cmp(n1) > 0 || new(big.Int).Lsh(new(big.Int).Abs(dot), 1).Cmp(n0) > 0 {
+			t.Errorf("%v: output %v is not Lagrange reduced", b, out)
+		}
+		if out.Volume2().Cmp(b.Volume2()) != 0 {
+			t.Errorf("%v: covolume changed", b)
+		}
+		if want := bruteShortest(t, b); n0.Cmp(want) != 0 {
+			t.Errorf("%v: ||b_1||^2 = %v, lambda_1^2 = %v", b, n0, want)
+		}
+		steps := Replay(b, tr.Steps)
+		if len(steps) != len(tr.Steps)+1 || !steps[0].Equal(b) || !steps[len(steps)-1].Equal(out) {
+			t.Errorf("%v: replay of %d steps does not end at the output", b, len(tr.Steps))
+		}
+	}
+	for _, b := range []Basis{
+		NewBasis([][]int64{{1, 2}, {2, 4}}),
+		NewBasis([][]int64{{0, 0}, {1, 1}}),
+		NewBasis([][]int64{{1, 0}, {0, 1}, {1, 1}}),
+	} {
+		if _, err := GaussReduce(b, nil); err == nil {
+			t.Errorf("%v: reduced", b)
+		}
+	}
+}
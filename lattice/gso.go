@@ -0,0 +1,241 @@
+package lattice
+
+import (
+	"math"
+	"math/big"
+)
+
+// GSO is the exact Gram–Schmidt orthogonalization of a basis.
+//
+// For a basis b_0..b_{k-1} it holds
+//
+//	BStar[i] = b*_i
+//	Mu[i][j] = <b_i, b*_j> / <b*_j, b*_j>   for j < i (and Mu[i][i] = 1)
+//	Norm2[i] = <b*_i, b*_i>
+//
+// so that b_i = b*_i + sum_{j<i} Mu[i][j] * b*_j.
+type GSO struct {
+	BStar [][]*big.Rat
+	Mu    [][]*big.Rat
+	Norm2 []*big.Rat
+}
+
+// GramSchmidt computes the exact Gram–Schmidt orthogonalization of b over
+// the rationals. Dependent rows produce a zero b*_i, which is reported as
+// ErrDependent.
+func (b Basis) GramSchmidt() (*GSO, error) {
+	k, n := len(b), b.Dim()
+	g := &GSO{
+		BStar: make([][]*big.Rat, k),
+		Mu:    make([][]*big.Rat, k),
+		Norm2: make([]*big.Rat, k),
+	}
+	t := new(big.Rat)
+	for i := 0; i < k; i++ {
+		bi := make([]*big.Rat, n)
+		for c := range bi {
+			bi[c] = new(big.Rat).SetInt(b[i][c])
+		}
+		g.Mu[i] = make([]*big.Rat, i+1)
+		g.Mu[i][i] = big.NewRat(1, 1)
+		g.BStar[i] = make([]*big.Rat, n)
+		for c := range bi {
+			g.BStar[i][c] = new(big.Rat).Set(bi[c])
+		}
+		for j := 0; j < i; j++ {
+			mu := ratDot(bi, g.BStar[j])
+			mu.Quo(mu, g.Norm2[j])
+			g.Mu[i][j] = mu
+			for c := range g.BStar[i] {
+				g.BStar[i][c].Sub(g.BStar[i][c], t.Mul(mu, g.BStar[j][c]))
+			}
+		}
+		g.Norm2[i] = ratDot(g.BStar[i], g.BStar[i])
+		if g.Norm2[i].Sign() == 0 {
+			return nil, ErrDependent
+		}
+	}
+	return g, nil
+}
+
+// LogCovolume returns ln vol(L) = sum_i ln ||b*_i||.
+func (g *GSO) LogCovolume() float64 {
+	sum := 0.0
+	for _, r := range g.Norm2 {
+		sum += ratLog(r) / 2
+	}
+	return sum
+}
+
+// Profile returns the Gram–Schmidt log-profile ln ||b*_i||, the curve that
+// reduction algorithms try to flatten.
+func (g *GSO) Profile() []float64 {
+	p := make([]float64, len(g.Norm2))
+	for i, r := range g.Norm2 {
+		p[i] = ratLog(r) / 2
+	}
+	return p
+}
+
+// Float returns the float64 approximation of g.
+func (g *GSO) Float() *GSOFloat {
+	f := &GSOFloat{
+		BStar: make([][]float64, len(g.BStar)),
+		Mu:    make([][]float64, len(g.Mu)),
+		Norm2: make([]float64, len(g.Norm2)),
+	}
+	for i := range g.BStar {
+		f.BStar[i] = make([]float64, len(g.BStar[i]))
+		for c, x := range g.BStar[i] {
+			f.BStar[i][c], _ = x.Float64()
+		}
+		f.Mu[i] = make([]float64, len(g.Mu[i]))
+		for j, x := range g.Mu[i] {
+			f.Mu[i][j], _ = x.Float64()
+		}
+		f.Norm2[i], _ = g.Norm2[i].Float64()
+	}
+	return f
+}
+
+// GSOFloat is the float64 counterpart of GSO. The fields have the same
+// meaning.
+type GSOFloat struct {
+	BStar [][]float64
+	Mu    [][]float64
+	Norm2 []float64
+}
+
+// GramSchmidtFloat computes the Gram–Schmidt orthogonalization in float64
+// using the modified Gram–Schmidt process, which loses far less precision
+// than the classical formula. It does not detect dependent rows; a
+// vanishing Norm2[i] is left for the caller to interpret.
+func (b Basis) GramSchmidtFloat() *GSOFloat {
+	return gramSchmidtFloat(b.Floats())
+}
+
+func gramSchmidtFloat(rows [][]float64) *GSOFloat {
+	k := len(rows)
+	g := &GSOFloat{
+		BStar: make([][]float64, k),
+		Mu:    make([][]float64, k),
+		Norm2: make([]float64, k),
+	}
+	for i := 0; i < k; i++ {
+		v := append([]float64(nil), rows[i]...)
+		g.Mu[i] = make([]float64, i+1)
+		g.Mu[i][i] = 1
+		for j := 0; j < i; j++ {
+			if g.Norm2[j] == 0 {
+				continue
+			}
+			// Modified Gram–Schmidt projects the running vector, not
+			// b_i, which keeps the b*_j numerically orthogonal. In exact
+			// arithmetic both give the same mu.
+			mu := floatDot(v, g.BStar[j]) / g.Norm2[j]
+			g.Mu[i][j] = mu
+			for c := range v {
+				v[c] -= mu * g.BStar[j][c]
+			}
+		}
+		g.BStar[i] = v
+		g.Norm2[i] = floatDot(v, v)
+	}
+	return g
+}
+
+// LogCovolume returns ln vol(L) = sum_i ln ||b*_i||.
+func (g *GSOFloat) LogCovolume() float64 {
+	sum := 0.0
+	for _, r := range g.Norm2 {
+		sum += math.Log(r) / 2
+	}
+	return sum
+}
+
+// Profile returns ln ||b*_i|| for every i.
+func (g *GSOFloat) Profile() []float64 {
+	p := make([]float64, len(g.Norm2))
+	for i, r := range g.Norm2 {
+		p[i] = math.Log(r) / 2
+	}
+	return p
+}
+
+// Quality collects the standard measures of a basis.
+type Quality struct {
+	Rank                int
+	LogCovolume         float64
+	OrthogonalityDefect float64
+	RootHermiteFactor   float64
+}
+
+// Quality computes the standard measures of b exactly: the covolume comes
+// from the integer Gram determinant and only the final logarithms are taken
+// in floating point.
+func (b Basis) Quality() (Quality, error) {
+	od, err := b.OrthogonalityDefect()
+	if err != nil {
+		return Quality{}, err
+	}
+	rhf, err := b.RootHermiteFactor()
+	if err != nil {
+		return Quality{}, err
+	}
+	return Quality{
+		Rank:                len(b),
+		LogCovolume:         b.LogCovolume(),
+		OrthogonalityDefect: od,
+		RootHermiteFactor:   rhf,
+	}, nil
+}
+
+// QualityFloat computes the same measures as Quality from a float64
+// Gram–Schmidt orthogonalization. It is much faster in high dimension and
+// accurate to a few ulps for well-conditioned bases. A row whose
+// Gram–Schmidt vector is shorter than dim·ε times the row, which is zero
+// up to rounding, makes the basis dependent.
+func (b Basis) QualityFloat() (Quality, error) {
+	if len(b) == 0 {
+		return Quality{}, ErrEmpty
+	}
+	rows := b.Floats()
+	g := gramSchmidtFloat(rows)
+	k := float64(len(b))
+	logVol := g.LogCovolume()
+	if math.IsInf(logVol, -1) {
+		return Quality{}, ErrDependent
+	}
+	eps := float64(len(rows[0])) * 0x1p-52
+	sum := 0.0
+	for i, r := range rows {
+		n2 := floatDot(r, r)
+		if g.Norm2[i] <= eps*eps*n2 {
+			return Quality{}, ErrDependent
+		}
+		sum += math.Log(n2) / 2
+	}
+	logB0 := math.Log(floatDot(rows[0], rows[0])) / 2
+	return Quality{
+		Rank:                len(b),
+		LogCovolume:         logVol,
+		OrthogonalityDefect: math.Exp(sum - logVol),
+		RootHermiteFactor:   math.Exp((logB0 - logVol/k) / k),
+	}, nil
+}
+
+func ratDot(a, b []*big.Rat) *big.Rat {
+	sum, t := new(big.Rat), new(big.Rat)
+	for i := range a {
+		sum.Add(sum, t.Mul(a[i], b[i]))
+	}
+	return sum
+}
+
+func floatDot(a, b []float64) float64 {
+	s := 0.0
+	for i := range a {
+		s += a[i] * b[i]
+	}
+	return s
+}
@@ -0,0 +1,308 @@
+package lattice
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// The Hermite normal form is the canonical basis of a lattice. For a basis
+// with linearly independent rows it is the unique matrix H = U*B, U
+// unimodular, in row echelon form whose pivots are positive and whose
+// entries above each pivot are reduced modulo it:
+//
+//	[ 3  1  4  0 ]
+//	[ 0  5  2  1 ]      0 <= H[r][p_i] < H[i][p_i] for r < i,
+//	[ 0  0  0  7 ]      where p_i is the pivot column of row i.
+//
+// Two bases span the same lattice exactly when their HNFs are equal, which
+// makes the HNF the tool of choice for comparing and canonicalizing bases.
+
+// HNF returns the Hermite normal form of the lattice spanned by the rows of
+// b, which must be linearly independent.
+//
+// For a square basis it uses the modular method of Domich, Kannan and
+// Trotter: D = |det B| times every unit vector lies in the lattice, so all
+// arithmetic can be done modulo D, with a modulus that shrinks after every
+// pivot, and no entry ever exceeds D. The plain elimination used for other
+// shapes can produce intermediate entries with exponentially many digits.
+func HNF(b Basis) (Basis, error) {
+	if len(b) > 0 && len(b) == b.Dim() {
+		d, _ := b.Determinant()
+		if d.Sign() == 0 {
+			return nil, ErrDependent
+		}
+		return hnfModular(b, len(b), d.Abs(d), true), nil
+	}
+	h, _, err := HNFWithTransform(b)
+	return h, err
+}
+
+// HNFModular returns the Hermite normal form of the lattice generated by
+// the rows of gens together with d*Z^n, for a positive integer d. The rows
+// of gens need not be independent and there may be any number of them.
+// This is the natural description of q-ary lattices, and of any full-rank
+// lattice whose determinant divides d.
+func HNFModular(gens Basis, d *big.Int) Basis {
+	return hnfModular(gens, gens.Dim(), d, false)
+}
+
+// hnfModular computes the HNF of the lattice generated by the rows of b and
+// d*Z^n.
+//
+// The invariant is that the remaining rows together with R*e_j, for every
+// column j not yet processed, generate the part of the lattice that is zero
+// on the processed columns. For column c the rows are combined with
+// extended gcds until a single one, p, has a non-zero entry a in that
+// column. Combining p with R*e_c gives the pivot row u*p with pivot
+// g = gcd(a, R), and the other combination (a/g)*R*e_c - (R/g)*p, which is
+// zero in column c, stays behind for the next columns.
+//
+// If d is a multiple of the determinant (shrink), the sublattice left for
+// the next columns has determinant det/g, so R can shrink to R/g and the
+// leftover combination, a multiple of R/g, can be dropped.
+func hnfModular(b Basis, n int, d *big.Int, shrink bool) Basis {
+	rows := b.Clone()
+	for _, r := range rows {
+		modVec(r, d)
+	}
+	h := make(Basis, n)
+	R := new(big.Int).Set(d)
+	for c := 0; c < n; c++ {
+		eliminateColumn(rows, c, R)
+		var w Vector
+		if len(rows) > 0 && rows[0][c].Sign() != 0 {
+			p := rows[0]
+			rows = rows[1:]
+			g, u := new(big.Int), new(big.Int)
+			g.GCD(u, nil, p[c], R)
+			w = p.Clone()
+			for j := range w {
+				w[j].Mul(w[j], u)
+			}
+			w[c].Set(g)
+			if shrink {
+				R.Quo(R, g)
+			} else if g.Cmp(R) != 0 {
+				z := p.Clone()
+				f := new(big.Int).Quo(R, g)
+				for j := range z {
+					z[j].Mul(z[j], f)
+				}
+				z[c].SetInt64(0)
+				rows = append(rows, z)
+			}
+		} else {
+			// Only R*e_c has a non-zero entry here.
+			w = ZeroVector(n)
+			w[c].Set(R)
+			if shrink {
+				R.SetInt64(1)
+			}
+		}
+		for j := c + 1; j < n; j++ {
+			w[j].Mod(w[j], R)
+		}
+		for _, r := range rows {
+			r[c].SetInt64(0)
+			for j := c + 1; j < n; j++ {
+				r[j].Mod(r[j], R)
+			}
+		}
+		h[c] = w
+	}
+	reduceAbovePivots(h)
+	return h
+}
+
+// eliminateColumn combines rows with extended gcds so that at most rows[0]
+// has a non-zero entry in column c, reducing every entry modulo m (if m is
+// not nil).
+func eliminateColumn(rows Basis, c int, m *big.Int) {
+	if len(rows) == 0 {
+		return
+	}
+	for i := 1; i < len(rows); i++ {
+		if rows[i][c].Sign() == 0 {
+			continue
+		}
+		combineRows(rows[0], rows[i], c, nil, nil)
+		if m != nil {
+			modVec(rows[0], m)
+			modVec(rows[i], m)
+		}
+	}
+	if rows[0][c].Sign() == 0 {
+		// Move a row with a non-zero entry to the front, if any.
+		for i := 1; i < len(rows); i++ {
+			if rows[i][c].Sign() != 0 {
+				rows[0], rows[i] = rows[i], rows[0]
+				break
+			}
+		}
+	}
+}
+
+// combineRows applies the unimodular transformation
+//
+//	[ x    y  ]   [ r ]
+//	[ -b/g a/g] * [ s ]
+//
+// with a = r[c], b = s[c] and x*a + y*b = g = gcd(a, b), so that afterwards
+// r[c] = g and s[c] = 0. The same operation is applied to ur and us if they
+// are not nil, to keep track of the transformation matrix.
+func combineRows(r, s Vector, c int, ur, us Vector) {
+	a, b := new(big.Int).Set(r[c]), new(big.Int).Set(s[c])
+	x, y, g := new(big.Int), new(big.Int), new(big.Int)
+	g.GCD(x, y, a, b)
+	a.Quo(a, g)
+	b.Quo(b, g)
+	apply := func(r, s Vector) {
+		t1, t2 := new(big.Int), new(big.Int)
+		for j := range r {
+			nr := new(big.Int).Add(t1.Mul(x, r[j]), t2.Mul(y, s[j]))
+			ns := new(big.Int).Sub(t1.Mul(a, s[j]), t2.Mul(b, r[j]))
+			r[j], s[j] = nr, ns
+		}
+	}
+	apply(r, s)
+	if ur != nil {
+		apply(ur, us)
+	}
+}
+
+// HNFWithTransform returns the Hermite normal form H of the rows of b
+// together with the unimodular matrix U such that U*B = H. It uses plain
+// gcd elimination, reducing entries above the pivots as it goes, and works
+// for any number of linearly independent rows.
+func HNFWithTransform(b Basis) (h, u Basis, err error) {
+	k, n := len(b), b.Dim()
+	h = b.Clone()
+	u = Identity(k)
+	row := 0
+	for c := 0; c < n && row < k; c++ {
+		for i := row + 1; i < k; i++ {
+			if h[i][c].Sign() != 0 {
+				combineRows(h[row], h[i], c, u[row], u[i])
+			}
+		}
+		if h[row][c].Sign() == 0 {
+			// No pivot in this column; try a row below.
+			found := false
+			for i := row + 1; i < k; i++ {
+				if h[i][c].Sign() != 0 {
+					h[row], h[i] = h[i], h[row]
+					u[row], u[i] = u[i], u[row]
+					found = true
+					break
+				}
+			}
+			if !found {
+				continue
+			}
+		}
+		if h[row][c].Sign() < 0 {
+			negVec(h[row])
+			negVec(u[row])
+		}
+		q := new(big.Int)
+		for r := 0; r < row; r++ {
+			q.Div(h[r][c], h[row][c])
+			if q.Sign() != 0 {
+				negQ := new(big.Int).Neg(q)
+				h[r].AddMul(negQ, h[row])
+				u[r].AddMul(negQ, u[row])
+			}
+		}
+		row++
+	}
+	if row < k {
+		return nil, nil, ErrDependent
+	}
+	return h, u, nil
+}
+
+// reduceAbovePivots reduces the entries above every pivot of an echelon
+// form into [0, pivot).
+func reduceAbovePivots(h Basis) {
+	q := new(big.Int)
+	for i, row := range h {
+		p := pivotColumn(row)
+		if p < 0 {
+			continue
+		}
+		for r := 0; r < i; r++ {
+			q.Div(h[r][p], row[p])
+			if q.Sign() != 0 {
+				h[r].AddMul(new(big.Int).Neg(q), row)
+			}
+		}
+	}
+}
+
+// pivotColumn returns the index of the first non-zero entry of v, or -1.
+func pivotColumn(v Vector) int {
+	for j, x := range v {
+		if x.Sign() != 0 {
+			return j
+		}
+	}
+	return -1
+}
+
+// SameLattice reports whether b and c span the same lattice, by comparing
+// their Hermite normal forms.
+func SameLattice(b, c Basis) (bool, error) {
+	hb, err := HNF(b)
+	if err != nil {
+		return false, err
+	}
+	hc, err := HNF(c)
+	if err != nil {
+		return false, err
+	}
+	return hb.Equal(hc), nil
+}
+
+// Contains reports whether v lies in the lattice spanned by b, and if so
+// returns its integer coordinates x with respect to the Hermite normal
+// form H = HNF(b) of that lattice, so that v = x*H. With H in echelon form
+// the coordinates are found one pivot at a time, and v is in the lattice
+// exactly when every division by a pivot is exact and nothing is left
+// over.
+func Contains(b Basis, v Vector) (x []*big.Int, ok bool, err error) {
+	if len(v) != b.Dim() {
+		return nil, false, fmt.Errorf("lattice: vector has dimension %d, want %d", len(v), b.Dim())
+	}
+	h, err := HNF(b)
+	if err != nil {
+		return nil, false, err
+	}
+	rest := v.Clone()
+	x = make([]*big.Int, len(h))
+	m := new(big.Int)
+	for i, row := range h {
+		p := pivotColumn(row)
+		x[i] = new(big.Int)
+		x[i].QuoRem(rest[p], row[p], m)
+		if m.Sign() != 0 {
+			return nil, false, nil
+		}
+		rest.AddMul(new(big.Int).Neg(x[i]), row)
+	}
+	if !rest.IsZero() {
+		return nil, false, nil
+	}
+	return x, true, nil
+}
+
+func modVec(v Vector, m *big.Int) {
+	for _, x := range v {
+		x.Mod(x, m)
+	}
+}
+
+func negVec(v Vector) {
+	for _, x := range v {
+		x.Neg(x)
+	}
+}
@@ -0,0 +1,242 @@
+package lattice
+
+import (
+	"math/big"
+	"math/rand"
+	"testing"
+)
+
+func mul(a, b Basis) Basis {
+	out := make(Basis, len(a))
+	for i, row := range a {
+		out[i] = b.Combination(row)
+	}
+	return out
+}
+
+func unimodular(t *testing.T, u Basis) bool {
+	d, err := u.Determinant()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d.CmpAbs(big.NewInt(1)) == 0
+}
+
+// isHNF reports whether h is in Hermite normal form: no zero rows, pivots
+// positive and moving right, and the entries above each pivot in
+// [0, pivot).
+func isHNF(h Basis) bool {
+	last := -1
+	for i, row := range h {
+		p := pivotColumn(row)
+		if p <= last || row[p].Sign() <= 0 {
+			return false
+		}
+		for r := range i {
+			if h[r][p].Sign() < 0 || h[r][p].Cmp(row[p]) >= 0 {
+				return false
+			}
+		}
+		last = p
+	}
+	return true
+}
+
+// randomRows returns k random independent rows of length n.
+func randomRows(r *rand.Rand, k, n int, bound int64) Basis {
+	for {
+		rows := make([][]int64, k)
+		for i := range rows {
+			rows[i] = make([]int64, n)
+			for j := range rows[i] {
+				rows[i][j] = r.Int63n(2*bound+1) - bound
+			}
+		}
+		if b := NewBasis(rows); b.Volume2().Sign() != 0 {
+			return b
+		}
+	}
+}
+
+func TestHNF(t *testing.T) {
+	r := rand.New(rand.NewSource(10))
+	for i := range 60 {
+		k := 2 + i%5
+		n := k + i%3
+		b := randomRows(r, k, n, 20)
+		h, u, err := HNFWithTransform(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !unimodular(t, u) {
+			t.Errorf("%v: transform %v is not unimodular", b, u)
+		}
+		if !mul(u, b).Equal(h) {
+			t.Errorf("%v: U*B differs from H", b)
+		}
+		if !isHNF(h) {
+			t.Errorf("%v: %v is not in Hermite normal form", b, h)
+		}
+		hm, err := HNF(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !hm.Equal(h) {
+			t.Errorf("%v: HNF %v, HNFWithTransform %v", b, hm, h)
+		}
+	}
+	if _, err := HNF(NewBasis([][]int64{{1, 2}, {2, 4}})); err != ErrDependent {
+		t.Errorf("dependent square rows: %v", err)
+	}
+	if _, _, err := HNFWithTransform(NewBasis([][]int64{{1, 2, 3}, {2, 4, 6}})); err != ErrDependent {
+		t.Errorf("dependent rows: %v", err)
+	}
+}
+
+// TestHNFModular checks that q Z^n is absorbed: the HNF of a q-ary
+// lattice is the same as that of its generators stacked on q I.
+func TestHNFModular(t *testing.T) {
+	r := rand.New(rand.NewSource(11))
+	q := big.NewInt(97)
+	gens := randomRows(r, 2, 4, 50)
+	stacked := append(gens.Clone(), Identity(4)...)
+	for i := 2; i < len(stacked); i++ {
+		for _, x := range stacked[i] {
+			x.Mul(x, q)
+		}
+	}
+	h := HNFModular(gens, q)
+	if !isHNF(h) {
+		t.Fatalf("%v is not in Hermite normal form", h)
+	}
+	if same, err := SameLattice(h, HNFModular(stacked, q)); err != nil || !same {
+		t.Errorf("HNFModular depends on the generators of q Z^n: %v", err)
+	}
+	for _, g := range stacked {
+		if _, ok, err := Contains(h, g); err != nil || !ok {
+			t.Errorf("%v is not in the lattice: %v", g, err)
+		}
+	}
+}
+
+func TestSNF(t *testing.T) {
+	r := rand.New(rand.NewSource(12))
+	one := big.NewInt(1)
+	for i := range 60 {
+		k, n := 2+i%4, 2+(i/4)%4
+		b := randomRows(r, min(k, n), n, 12)
+		if k > n {
+			// Rank-deficient: the extra rows are combinations.
+			for range k - n {
+				c := ZeroVector(len(b))
+				c[0].SetInt64(r.Int63n(5) - 2)
+				c[1].SetInt64(r.Int63n(5) - 2)
+				b = append(b, b.Combination(c))
+			}
+		}
+		d, u, v := SNF(b)
+		if !unimodular(t, u) || !unimodular(t, v) {
+			t.Errorf("%v: U or V is not unimodular", b)
+		}
+		a := mul(mul(u, b), v)
+		for i, row := range a {
+			for j, x := range row {
+				want := new(big.Int)
+				if i == j {
+					want = d[i]
+				}
+				if x.Cmp(want) != 0 {
+					t.Fatalf("%v: U*B*V = %v is not diag(%v)", b, a, d)
+				}
+			}
+		}
+		for i := 0; i+1 < len(d); i++ {
+			if d[i].Sign() < 0 || d[i].Sign() == 0 && d[i+1].Sign() != 0 ||
+				d[i].Sign() > 0 && new(big.Int).Rem(d[i+1], d[i]).Sign() != 0 {
+				t.Errorf("%v: invariant factors %v do not divide each other", b, d)
+			}
+		}
+		if k != n {
+			continue
+		}
+		det, err := b.Determinant()
+		if err != nil {
+			t.Fatal(err)
+		}
+		f, err := InvariantFactors(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		prod := big.NewInt(1)
+		for i, x := range f {
+			prod.Mul(prod, x)
+			if x.Cmp(d[i]) != 0 {
+				t.Errorf("%v: InvariantFactors %v, SNF %v", b, f, d)
+				break
+			}
+		}
+		if prod.CmpAbs(det) != 0 {
+			t.Errorf("%v: invariant factors %v multiply to %v, |det| = %v", b, f, prod, det)
+		}
+		g, err := QuotientGroup(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, x := range g {
+			if x.Cmp(one) == 0 {
+				t.Errorf("%v: quotient group %v has a trivial factor", b, g)
+			}
+		}
+	}
+}
+
+func TestContains(t *testing.T) {
+	r := rand.New(rand.NewSource(13))
+	for range 30 {
+		b := randomBasis(r, 5, 20)
+		red, err := LLL(b, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if same, err := SameLattice(b, red); err != nil || !same {
+			t.Errorf("%v: LLL changed the lattice: %v", b, err)
+		}
+		double := b.Clone()
+		for _, x := range double[0] {
+			x.Lsh(x, 1)
+		}
+		if same, _ := SameLattice(b, double); same {
+			t.Errorf("%v: the same lattice with b_0 doubled", b)
+		}
+
+		c := make([]*big.Int, len(b))
+		for i := range c {
+			c[i] = big.NewInt(r.Int63n(41) - 20)
+		}
+		v := b.Combination(c)
+		x, ok, err := Contains(b, v)
+		if err != nil || !ok {
+			t.Fatalf("%v: planted vector %v not found: %v", b, v, err)
+		}
+		h, err := HNF(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !h.Combination(x).Equal(v) {
+			t.Errorf("%v: coordinates %v do not give %v", b, x, v)
+		}
+		// e_p is outside the lattice for a pivot h[i][p] > 1.
+		for _, row := range h {
+			if p := pivotColumn(row); row[p].Cmp(big.NewInt(1)) > 0 {
+				v[p].Add(v[p], big.NewInt(1))
+				if _, ok, err := Contains(b, v); err != nil || ok {
+					t.Errorf("%v: %v reported in the lattice: %v", b, v, err)
+				}
+				break
+			}
+		}
+	}
+	if _, _, err := Contains(Identity(3), NewVector(1, 2)); err == nil {
+		t.Error("accepted a vector of the wrong dimension")
+	}
+}
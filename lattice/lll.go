@@ -0,0 +1,365 @@
+package lattice
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+)
+
+// ErrPrecision is returned by the floating-point reductions when float64 is
+// not precise enough for the input and the algorithm stops making progress.
+var ErrPrecision = errors.New("lattice: float64 precision exhausted")
+
+// LLLOptions configures LLL reduction. The zero value, or a nil pointer,
+// selects the defaults.
+type LLLOptions struct {
+	// Delta is the Lovász parameter in (1/4, 1]. Larger values give
+	// shorter vectors at the cost of more swaps. Default 0.99.
+	Delta float64
+	// Eta is the size-reduction bound used by the floating-point
+	// variant, in [1/2, sqrt(Delta)). The exact variant always reduces to
+	// |mu| <= 1/2. Default 0.51.
+	Eta float64
+	// Trace, if not nil, receives every size reduction and swap.
+	Trace *Trace
+}
+
+func (o *LLLOptions) delta() float64 {
+	if o == nil || o.Delta == 0 {
+		return 0.99
+	}
+	return o.Delta
+}
+
+func (o *LLLOptions) eta() float64 {
+	if o == nil || o.Eta == 0 {
+		return 0.51
+	}
+	return o.Eta
+}
+
+func (o *LLLOptions) trace() *Trace {
+	if o == nil {
+		return nil
+	}
+	return o.Trace
+}
+
+// checkDelta validates Delta, the only option of LLLExact; checkEta also
+// validates Eta, which only the floating-point variant uses.
+func (o *LLLOptions) checkDelta() error {
+	if d := o.delta(); d <= 0.25 || d > 1 {
+		return fmt.Errorf("lattice: LLL delta %v not in (1/4, 1]", d)
+	}
+	return nil
+}
+
+func (o *LLLOptions) checkEta() error {
+	if err := o.checkDelta(); err != nil {
+		return err
+	}
+	if e := o.eta(); e < 0.5 || e*e >= o.delta() {
+		return fmt.Errorf("lattice: LLL eta %v not in [1/2, sqrt(delta))", e)
+	}
+	return nil
+}
+
+// StepKind tells what a traced reduction step did.
+type StepKind int
+
+const (
+	// SizeReduce is b_I -= Q * b_J.
+	SizeReduce StepKind = iota
+	// Swap exchanges b_I and b_J = b_{I-1} after a failed Lovász test.
+	Swap
+)
+
+func (k StepKind) String() string {
+	switch k {
+	case SizeReduce:
+		return "size-reduce"
+	case Swap:
+		return "swap"
+	}
+	return fmt.Sprintf("StepKind(%d)", int(k))
+}
+
+// Step is one elementary operation on the basis.
+type Step struct {
+	Kind StepKind
+	I, J int
+	Q    *big.Int // multiplier of a SizeReduce step, nil for Swap
+}
+
+func (s Step) String() string {
+	if s.Kind == Swap {
+		return fmt.Sprintf("swap b%d <-> b%d", s.J, s.I)
+	}
+	return fmt.Sprintf("b%d -= %s*b%d", s.I, s.Q, s.J)
+}
+
+// Trace records the steps taken by a reduction algorithm.
+type Trace struct {
+	Steps          []Step
+	Swaps          int
+	SizeReductions int
+}
+
+func (t *Trace) sizeReduce(i, j int, q *big.Int) {
+	if t == nil {
+		return
+	}
+	t.SizeReductions++
+	t.Steps = append(t.Steps, Step{Kind: SizeReduce, I: i, J: j, Q: new(big.Int).Set(q)})
+}
+
+func (t *Trace) swap(i int) {
+	if t == nil {
+		return
+	}
+	t.Swaps++
+	t.Steps = append(t.Steps, Step{Kind: Swap, I: i, J: i - 1})
+}
+
+// LLL is the default LLL reduction: the floating-point L² variant. It
+// returns a reduced copy of b and leaves b untouched.
+func LLL(b Basis, opts *LLLOptions) (Basis, error) {
+	return LLLFloat(b, opts)
+}
+
+// LLLExact reduces b with the textbook Lenstra–Lenstra–Lovász algorithm,
+// keeping the Gram–Schmidt coefficients as exact rationals. It is the
+// version to read alongside the proofs: the output satisfies
+//
+//	|mu_ij| <= 1/2                                      (size reduced)
+//	delta * ||b*_{k-1}||^2 <= ||b*_k||^2 + mu_{k,k-1}^2 ||b*_{k-1}||^2  (Lovász)
+//
+// exactly, but the rationals grow quickly so it is only practical in small
+// dimension. The rows of b must be linearly independent.
+func LLLExact(b Basis, opts *LLLOptions) (Basis, error) {
+	if err := opts.checkDelta(); err != nil {
+		return nil, err
+	}
+	gso, err := b.GramSchmidt()
+	if err != nil {
+		return nil, err
+	}
+	b = b.Clone()
+	n := len(b)
+	mu, bn := gso.Mu, gso.Norm2
+	delta := new(big.Rat).SetFloat64(opts.delta())
+	half := big.NewRat(1, 2)
+	tr := opts.trace()
+	t, q := new(big.Rat), new(big.Rat)
+
+	sizeReduce := func(k, j int) {
+		if new(big.Rat).Abs(mu[k][j]).Cmp(half) <= 0 {
+			return
+		}
+		r := roundRat(mu[k][j])
+		b[k].AddMul(new(big.Int).Neg(r), b[j])
+		q.SetInt(r)
+		mu[k][j].Sub(mu[k][j], q)
+		for l := 0; l < j; l++ {
+			mu[k][l].Sub(mu[k][l], t.Mul(q, mu[j][l]))
+		}
+		tr.sizeReduce(k, j, r)
+	}
+
+	for k := 1; k < n; {
+		sizeReduce(k, k-1)
+		// Lovász condition: B_k >= (delta - mu_{k,k-1}^2) B_{k-1}.
+		rhs := new(big.Rat).Mul(mu[k][k-1], mu[k][k-1])
+		rhs.Sub(delta, rhs)
+		rhs.Mul(rhs, bn[k-1])
+		if bn[k].Cmp(rhs) >= 0 {
+			for j := k - 2; j >= 0; j-- {
+				sizeReduce(k, j)
+			}
+			k++
+			continue
+		}
+		// Swap b_{k-1} and b_k and update the GSO data in place.
+		b[k-1], b[k] = b[k], b[k-1]
+		tr.swap(k)
+		m := new(big.Rat).Set(mu[k][k-1])
+		newB := new(big.Rat).Mul(m, m)
+		newB.Mul(newB, bn[k-1])
+		newB.Add(newB, bn[k])
+		mu[k][k-1] = new(big.Rat).Quo(t.Mul(m, bn[k-1]), newB)
+		bn[k] = new(big.Rat).Quo(t.Mul(bn[k-1], bn[k]), newB)
+		bn[k-1] = newB
+		for j := 0; j < k-1; j++ {
+			mu[k-1][j], mu[k][j] = mu[k][j], mu[k-1][j]
+		}
+		for i := k + 1; i < n; i++ {
+			old := new(big.Rat).Set(mu[i][k])
+			mu[i][k] = new(big.Rat).Sub(mu[i][k-1], t.Mul(m, old))
+			mu[i][k-1] = new(big.Rat).Add(old, t.Mul(mu[k][k-1], mu[i][k]))
+		}
+		k = max(k-1, 1)
+	}
+	return b, nil
+}
+
+// LLLFloat reduces b with an L²-style floating-point LLL (Nguyen–Stehlé).
+//
+// The basis vectors and their Gram matrix are kept exactly as big integers;
+// only the Gram–Schmidt data r_ij = <b_i, b*_j> and mu_ij = r_ij / r_jj are
+// recomputed in float64 from the exact Gram matrix with a Cholesky-style
+// recurrence. Size reduction is "lazy": it is repeated until every
+// |mu_kj| <= Eta, which absorbs the rounding errors of a single pass. The
+// rows of b must be linearly independent.
+func LLLFloat(b Basis, opts *LLLOptions) (Basis, error) {
+	if err := opts.checkEta(); err != nil {
+		return nil, err
+	}
+	b = b.Clone()
+	n := len(b)
+	if n == 0 {
+		return b, nil
+	}
+	delta, eta := opts.delta(), opts.eta()
+	tr := opts.trace()
+	g := b.Gram()
+	if g[0][0].Sign() == 0 {
+		return nil, ErrDependent
+	}
+	r := make([][]float64, n)
+	mu := make([][]float64, n)
+	for i := range r {
+		r[i] = make([]float64, n)
+		mu[i] = make([]float64, n)
+	}
+	r[0][0] = bigToFloat(g[0][0])
+
+	// cholesky recomputes row k of r and mu from the exact Gram matrix,
+	// assuming rows 0..k-1 are up to date.
+	cholesky := func(k int) {
+		for j := 0; j <= k; j++ {
+			s := bigToFloat(g[k][j])
+			for l := 0; l < j; l++ {
+				s -= mu[j][l] * r[k][l]
+			}
+			r[k][j] = s
+			if j < k {
+				mu[k][j] = s / r[j][j]
+			}
+		}
+	}
+
+	x, t := new(big.Int), new(big.Int)
+	for k := 1; k < n; {
+		// Lazy size reduction of b_k.
+		for iter := 0; ; iter++ {
+			if iter > 100 {
+				return nil, ErrPrecision
+			}
+			cholesky(k)
+			reduced := true
+			for j := 0; j < k; j++ {
+				if math.Abs(mu[k][j]) > eta {
+					reduced = false
+					break
+				}
+			}
+			if reduced {
+				break
+			}
+			for j := k - 1; j >= 0; j-- {
+				xf := math.Round(mu[k][j])
+				if xf == 0 {
+					continue
+				}
+				floatToBig(x, xf)
+				b[k].AddMul(t.Neg(x), b[j])
+				for l := 0; l < j; l++ {
+					mu[k][l] -= xf * mu[j][l]
+				}
+				// Update the Gram matrix exactly:
+				// G_kk <- G_kk - 2x G_kj + x^2 G_jj,  G_ki <- G_ki - x G_ji.
+				gkj := new(big.Int).Set(g[k][j])
+				g[k][k].Sub(g[k][k], t.Mul(t.Lsh(x, 1), gkj))
+				g[k][k].Add(g[k][k], t.Mul(t.Mul(x, x), g[j][j]))
+				for i := 0; i < n; i++ {
+					if i == k {
+						continue
+					}
+					v := new(big.Int).Sub(g[k][i], t.Mul(x, g[j][i]))
+					g[k][i], g[i][k] = v, v
+				}
+				tr.sizeReduce(k, j, x)
+			}
+		}
+		if g[k][k].Sign() == 0 {
+			return nil, ErrDependent
+		}
+		// Lovász condition on the float Gram–Schmidt data.
+		m := mu[k][k-1]
+		if delta*r[k-1][k-1] <= r[k][k]+m*m*r[k-1][k-1] {
+			k++
+			continue
+		}
+		b[k-1], b[k] = b[k], b[k-1]
+		g[k-1], g[k] = g[k], g[k-1]
+		for i := range g {
+			g[i][k-1], g[i][k] = g[i][k], g[i][k-1]
+		}
+		tr.swap(k)
+		k = max(k-1, 1)
+		if k == 1 {
+			r[0][0] = bigToFloat(g[0][0])
+		}
+	}
+	return b, nil
+}
+
+// IsLLLReduced reports whether b is (delta, eta)-LLL reduced, checking both
+// conditions exactly over the rationals: |mu_ij| <= eta for all j < i and
+// delta * ||b*_{k-1}||^2 <= ||b*_k||^2 + mu_{k,k-1}^2 ||b*_{k-1}||^2.
+func IsLLLReduced(b Basis, delta, eta float64) bool {
+	gso, err := b.GramSchmidt()
+	if err != nil {
+		return false
+	}
+	d := new(big.Rat).SetFloat64(delta)
+	e := new(big.Rat).SetFloat64(eta)
+	abs := new(big.Rat)
+	for i := range b {
+		for j := 0; j < i; j++ {
+			if abs.Abs(gso.Mu[i][j]).Cmp(e) > 0 {
+				return false
+			}
+		}
+	}
+	for k := 1; k < len(b); k++ {
+		lhs := new(big.Rat).Mul(d, gso.Norm2[k-1])
+		rhs := new(big.Rat).Mul(gso.Mu[k][k-1], gso.Mu[k][k-1])
+		rhs.Mul(rhs, gso.Norm2[k-1])
+		rhs.Add(rhs, gso.Norm2[k])
+		if lhs.Cmp(rhs) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// roundRat returns the integer nearest to x, rounding halves up.
+func roundRat(x *big.Rat) *big.Int {
+	num := new(big.Int).Lsh(x.Num(), 1)
+	num.Add(num, x.Denom())
+	den := new(big.Int).Lsh(x.Denom(), 1)
+	// floor((2a + b) / 2b) = floor(a/b + 1/2); Div rounds towards -inf
+	// for a positive divisor.
+	return num.Div(num, den)
+}
+
+// floatToBig sets z to the integer-valued float f.
+func floatToBig(z *big.Int, f float64) *big.Int {
+	if math.Abs(f) < 1<<62 {
+		return z.SetInt64(int64(f))
+	}
+	new(big.Float).SetFloat64(f).Int(z)
+	return z
+}
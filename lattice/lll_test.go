@@ -0,0 +1,118 @@
+package lattice
+
+import (
+	"math/big"
+	"math/rand"
+	"testing"
+)
+
+func randomBasis(r *rand.Rand, n int, bound int64) Basis {
+	for {
+		rows := make([][]int64, n)
+		for i := range rows {
+			rows[i] = make([]int64, n)
+			for j := range rows[i] {
+				rows[i][j] = r.Int63n(2*bound+1) - bound
+			}
+		}
+		b := NewBasis(rows)
+		if d, _ := b.Determinant(); d.Sign() != 0 {
+			return b
+		}
+	}
+}
+
+// knapsackBasis is the Lagarias–Odlyzko basis of a subset-sum instance
+// with n weights below 2^bits: the rows (e_i, N a_i) and (0, N s).
+func knapsackBasis(r *rand.Rand, n, bits int) Basis {
+	const scale = 1 << 10
+	rows := make([][]int64, n+1)
+	var s int64
+	for i := range n {
+		a := r.Int63n(1 << bits)
+		rows[i] = make([]int64, n+1)
+		rows[i][i] = 1
+		rows[i][n] = scale * a
+		// The first weight is always in, so that s != 0 and the rows
+		// are independent.
+		if i == 0 || r.Intn(2) == 1 {
+			s += a
+		}
+	}
+	rows[n] = make([]int64, n+1)
+	rows[n][n] = scale * s
+	return NewBasis(rows)
+}
+
+func TestLLL(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	var bases []Basis
+	for n := 2; n <= 10; n += 2 {
+		bases = append(bases, randomBasis(r, n, 50), knapsackBasis(r, n, 20))
+	}
+	variants := []struct {
+		name string
+		lll  func(Basis, *LLLOptions) (Basis, error)
+		eta  float64
+	}{
+		{"exact", LLLExact, 0.5},
+		{"float", LLLFloat, 0.51},
+	}
+	for _, b := range bases {
+		want, err := b.Determinant()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, v := range variants {
+			for _, delta := range []float64{0.75, 0.99} {
+				tr := &Trace{}
+				out, err := v.lll(b, &LLLOptions{Delta: delta, Trace: tr})
+				if err != nil {
+					t.Fatalf("%s rank %d: %v", v.name, len(b), err)
+				}
+				if !IsLLLReduced(out, delta, v.eta) {
+					t.Errorf("%s rank %d delta %v: output not LLL reduced", v.name, len(b), delta)
+				}
+				got, err := out.Determinant()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if new(big.Int).Abs(got).Cmp(new(big.Int).Abs(want)) != 0 {
+					t.Errorf("%s rank %d: |det| changed from %v to %v", v.name, len(b), want, got)
+				}
+				var swaps, reductions int
+				for _, s := range tr.Steps {
+					switch s.Kind {
+					case Swap:
+						swaps++
+					case SizeReduce:
+						reductions++
+					}
+				}
+				if swaps != tr.Swaps || reductions != tr.SizeReductions || swaps+reductions != len(tr.Steps) {
+					t.Errorf("%s rank %d: trace counts %d swaps and %d reductions, steps hold %d and %d of %d",
+						v.name, len(b), tr.Swaps, tr.SizeReductions, swaps, reductions, len(tr.Steps))
+				}
+			}
+		}
+	}
+}
+
+func TestLLLExactIgnoresEta(t *testing.T) {
+	b := randomBasis(rand.New(rand.NewSource(2)), 4, 20)
+	out, err := LLLExact(b, &LLLOptions{Delta: 0.26})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsLLLReduced(out, 0.26, 0.5) {
+		t.Error("output not LLL reduced")
+	}
+	if _, err := LLLFloat(b, &LLLOptions{Delta: 0.26}); err == nil {
+		t.Error("LLLFloat accepted eta 0.51 >= sqrt(0.26)")
+	}
+	for _, delta := range []float64{0.25, 1.01} {
+		if _, err := LLLExact(b, &LLLOptions{Delta: delta}); err == nil {
+			t.Errorf("LLLExact accepted delta %v", delta)
+		}
+	}
+}
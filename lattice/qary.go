@@ -0,0 +1,250 @@
+package lattice
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// q-ary lattices are the lattices behind SIS and LWE. For A in Z_q^(n x m)
+//
+//	Lambda_q(A)      = { y in Z^m : y = A^T s mod q for some s in Z^n }
+//	Lambda_q^perp(A) = { y in Z^m : A y = 0 mod q }
+//
+// Both contain q*Z^m, so they have full rank m, and they are dual to each
+// other up to scaling: Lambda_q^perp(A) = q * Lambda_q(A)^*. LWE asks to
+// decode a point close to Lambda_q(A); SIS asks for a short vector in
+// Lambda_q^perp(A).
+
+// QaryImage returns the HNF basis of Lambda_q(A), the lattice generated by
+// the rows of A and q*Z^m. A is given as n rows of length m.
+func QaryImage(a [][]int64, q int64) Basis {
+	return HNFModular(NewBasis(a), big.NewInt(q))
+}
+
+// QaryKernel returns the HNF basis of Lambda_q^perp(A) = { y : A y = 0 mod q },
+// computed as q times the dual of Lambda_q(A).
+func QaryKernel(a [][]int64, q int64) (Basis, error) {
+	img := QaryImage(a, q)
+	k, err := ScaledDual(img, big.NewInt(q))
+	if err != nil {
+		return nil, err
+	}
+	return HNF(k)
+}
+
+// Dual returns a basis of the dual lattice L^* = { x in span(L) : <x, v> in Z
+// for all v in L }. Its rows are D = (B B^T)^(-1) B, so that D B^T = I; for
+// a square basis this is just B^(-T). The entries are rational in general.
+func Dual(b Basis) ([][]*big.Rat, error) {
+	k, n := len(b), b.Dim()
+	g := b.Gram()
+	// Invert the Gram matrix by Gauss–Jordan elimination on [G | I].
+	m := make([][]*big.Rat, k)
+	for i := range m {
+		m[i] = make([]*big.Rat, 2*k)
+		for j := 0; j < k; j++ {
+			m[i][j] = new(big.Rat).SetInt(g[i][j])
+			m[i][k+j] = new(big.Rat)
+		}
+		m[i][k+i].SetInt64(1)
+	}
+	t := new(big.Rat)
+	for c := 0; c < k; c++ {
+		p := -1
+		for r := c; r < k; r++ {
+			if m[r][c].Sign() != 0 {
+				p = r
+				break
+			}
+		}
+		if p < 0 {
+			return nil, ErrDependent
+		}
+		m[c], m[p] = m[p], m[c]
+		inv := new(big.Rat).Inv(m[c][c])
+		for j := range m[c] {
+			m[c][j].Mul(m[c][j], inv)
+		}
+		for r := 0; r < k; r++ {
+			if r == c || m[r][c].Sign() == 0 {
+				continue
+			}
+			f := new(big.Rat).Set(m[r][c])
+			for j := range m[r] {
+				m[r][j].Sub(m[r][j], t.Mul(f, m[c][j]))
+			}
+		}
+	}
+	d := make([][]*big.Rat, k)
+	for i := range d {
+		d[i] = make([]*big.Rat, n)
+		for c := 0; c < n; c++ {
+			s := new(big.Rat)
+			for j := 0; j < k; j++ {
+				s.Add(s, t.Mul(m[i][k+j], t.SetInt(b[j][c])))
+			}
+			d[i][c] = s
+		}
+	}
+	return d, nil
+}
+
+// ScaledDual returns the integer basis s * D of the scaled dual lattice
+// s * L^*. It fails if s * L^* is not integral; s = |det B| always works for
+// a square basis, and s = q works for every q-ary lattice.
+func ScaledDual(b Basis, s *big.Int) (Basis, error) {
+	d, err := Dual(b)
+	if err != nil {
+		return nil, err
+	}
+	out := make(Basis, len(d))
+	sr := new(big.Rat).SetInt(s)
+	for i, row := range d {
+		out[i] = make(Vector, len(row))
+		for j, x := range row {
+			y := new(big.Rat).Mul(x, sr)
+			if !y.IsInt() {
+				return nil, fmt.Errorf("lattice: %v times the dual lattice is not integral", s)
+			}
+			out[i][j] = new(big.Int).Set(y.Num())
+		}
+	}
+	return out, nil
+}
+
+// RotationBasis returns the n x n matrix whose row i is the coefficient
+// vector of x^i * a in Z[x]/(x^n + 1) when negacyclic is true, and in
+// Z[x]/(x^n - 1) otherwise. With row vectors, the product of two ring
+// elements becomes a vector-matrix product:
+//
+//	coeffs(s * a) = coeffs(s) * RotationBasis(a)
+//
+// For x^n + 1 every wrap-around flips the sign, which gives the
+// "anticirculant" matrix
+//
+//	[  a0   a1   a2 ]
+//	[ -a2   a0   a1 ]
+//	[ -a1  -a2   a0 ]
+func RotationBasis(a []int64, negacyclic bool) Basis {
+	n := len(a)
+	b := make(Basis, n)
+	row := append([]int64(nil), a...)
+	for i := 0; i < n; i++ {
+		b[i] = NewVector(row...)
+		// Multiply by x: shift right and wrap the top coefficient.
+		top := row[n-1]
+		copy(row[1:], row[:n-1])
+		row[0] = top
+		if negacyclic {
+			row[0] = -top
+		}
+	}
+	return b
+}
+
+// RingQary returns a basis of the q-ary lattice { (s*a mod q) } of a ring
+// element a in Z_q[x]/(x^n ± 1), that is Lambda_q(RotationBasis(a)).
+func RingQary(a []int64, q int64, negacyclic bool) Basis {
+	return HNFModular(RotationBasis(a, negacyclic), big.NewInt(q))
+}
+
+// NTRULattice returns the NTRU lattice of a public key h = g/f in
+// Z_q[x]/(x^n ± 1):
+//
+//	[ I_n  Rot(h) ]
+//	[  0   q*I_n  ]
+//
+// It contains the secret (f, g) because f*h = g mod q, and its covolume is
+// q^n, so (f, g) is unusually short and lattice reduction can find it.
+func NTRULattice(h []int64, q int64, negacyclic bool) Basis {
+	n := len(h)
+	rot := RotationBasis(modSlice(h, q), negacyclic)
+	b := make(Basis, 2*n)
+	for i := 0; i < n; i++ {
+		b[i] = ZeroVector(2 * n)
+		b[i][i].SetInt64(1)
+		for j := 0; j < n; j++ {
+			b[i][n+j].Set(rot[i][j])
+		}
+		b[n+i] = ZeroVector(2 * n)
+		b[n+i][n+i].SetInt64(q)
+	}
+	return b
+}
+
+// RingLWEEmbedding returns Kannan's embedding of a Ring-LWE sample
+// (a, b = a*s + e) in Z_q[x]/(x^n ± 1):
+//
+//	[ q*I_n    0    0 ]
+//	[ Rot(a)  I_n   0 ]
+//	[   b      0    M ]
+//
+// The combination (k, -s, 1) is the short vector (e, -s, M), so the secret
+// is recovered by solving unique-SVP in dimension 2n+1. M is usually
+// chosen close to the size of the error, for instance 1.
+func RingLWEEmbedding(a, b []int64, q int64, negacyclic bool, m int64) Basis {
+	return embedding(RotationBasis(modSlice(a, q), negacyclic), b, q, m)
+}
+
+// LWEEmbedding returns Kannan's embedding of a plain LWE instance
+// b = A*s + e mod q with A given as m rows of length n:
+//
+//	[ q*I_m    0    0 ]
+//	[  A^T    I_n   0 ]
+//	[  b^T     0    M ]
+//
+// The combination (k, -s, 1) is the short vector (e, -s, M).
+func LWEEmbedding(a [][]int64, b []int64, q int64, m int64) Basis {
+	rows, cols := len(a), len(a[0])
+	at := make(Basis, cols)
+	for j := 0; j < cols; j++ {
+		at[j] = ZeroVector(rows)
+		for i := 0; i < rows; i++ {
+			at[j][i].SetInt64(mod(a[i][j], q))
+		}
+	}
+	return embedding(at, b, q, m)
+}
+
+// embedding builds [[q I, 0, 0], [R, I, 0], [b, 0, M]] for an n x m
+// matrix R and a target b of length m.
+func embedding(r Basis, b []int64, q int64, m int64) Basis {
+	n, dim := len(r), r.Dim()
+	size := dim + n + 1
+	out := make(Basis, size)
+	for i := 0; i < dim; i++ {
+		out[i] = ZeroVector(size)
+		out[i][i].SetInt64(q)
+	}
+	for i := 0; i < n; i++ {
+		row := ZeroVector(size)
+		for j := 0; j < dim; j++ {
+			row[j].Mod(r[i][j], big.NewInt(q))
+		}
+		row[dim+i].SetInt64(1)
+		out[dim+i] = row
+	}
+	last := ZeroVector(size)
+	for j := 0; j < dim; j++ {
+		last[j].SetInt64(mod(b[j], q))
+	}
+	last[size-1].SetInt64(m)
+	out[size-1] = last
+	return out
+}
+
+func mod(x, q int64) int64 {
+	x %= q
+	if x < 0 {
+		x += q
+	}
+	return x
+}
+
+func modSlice(a []int64, q int64) []int64 {
+	out := make([]int64, len(a))
+	for i, x := range a {
+		out[i] = mod(x, q)
+	}
+	return out
+}
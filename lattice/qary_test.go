@@ -0,0 +1,195 @@
+package lattice
+
+import (
+	"math/big"
+	"math/rand"
+	"testing"
+)
+
+func randomMatrix(r *rand.Rand, n, m int, q int64) [][]int64 {
+	a := make([][]int64, n)
+	for i := range a {
+		a[i] = make([]int64, m)
+		for j := range a[i] {
+			a[i][j] = r.Int63n(q)
+		}
+	}
+	return a
+}
+
+func TestQary(t *testing.T) {
+	r := rand.New(rand.NewSource(14))
+	for _, tc := range []struct {
+		n, m int
+		q    int64
+	}{{1, 3, 7}, {2, 4, 17}, {2, 6, 97}, {3, 6, 12}} {
+		a := randomMatrix(r, tc.n, tc.m, tc.q)
+		img := QaryImage(a, tc.q)
+		ker, err := QaryKernel(a, tc.q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, b := range []Basis{img, ker} {
+			if len(b) != tc.m || !isHNF(b) {
+				t.Errorf("%v mod %d: %v is not a full-rank HNF basis", a, tc.q, b)
+			}
+		}
+		for _, y := range ker {
+			if !inKernel(a, y, tc.q) {
+				t.Errorf("%v mod %d: A y != 0 for the kernel vector %v", a, tc.q, y)
+			}
+		}
+		gens := append(Basis{}, NewBasis(a)...)
+		for j := range tc.m {
+			v := ZeroVector(tc.m)
+			v[j].SetInt64(tc.q)
+			gens = append(gens, v)
+		}
+		for _, g := range gens {
+			if _, ok, err := Contains(img, g); err != nil || !ok {
+				t.Errorf("%v mod %d: image lattice misses %v: %v", a, tc.q, g, err)
+			}
+			if _, ok, err := Contains(ker, g); err != nil || ok != inKernel(a, g, tc.q) {
+				t.Errorf("%v mod %d: kernel lattice reports %v for %v: %v", a, tc.q, ok, g, err)
+			}
+		}
+	}
+}
+
+// inKernel reports whether A y = 0 mod q.
+func inKernel(a [][]int64, y Vector, q int64) bool {
+	for _, row := range a {
+		if s := NewVector(row...).Dot(y); s.Mod(s, big.NewInt(q)).Sign() != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func TestDual(t *testing.T) {
+	r := rand.New(rand.NewSource(15))
+	for _, b := range []Basis{randomBasis(r, 4, 20), randomRows(r, 2, 4, 20), randomRows(r, 3, 5, 10)} {
+		d, err := Dual(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, di := range d {
+			for j, bj := range b {
+				want := int64(0)
+				if i == j {
+					want = 1
+				}
+				if got := ratDot(di, RatVector(bj)); got.Cmp(big.NewRat(want, 1)) != 0 {
+					t.Fatalf("%v: <d_%d, b_%d> = %v", b, i, j, got)
+				}
+			}
+		}
+		// The Gram determinant clears every denominator of D, so s*D
+		// is integral and its dual is B/s.
+		s := b.Volume2()
+		sd, err := ScaledDual(b, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dd, err := Dual(sd)
+		if err != nil {
+			t.Fatal(err)
+		}
+		back := make(Basis, len(dd))
+		for i, row := range dd {
+			back[i] = make(Vector, len(row))
+			for j, x := range row {
+				y := new(big.Rat).Mul(x, new(big.Rat).SetInt(s))
+				if !y.IsInt() {
+					t.Fatalf("%v: dual of the dual is not integral", b)
+				}
+				back[i][j] = y.Num()
+			}
+		}
+		if !back.Equal(b) {
+			t.Errorf("%v: dual of the dual is %v", b, back)
+		}
+	}
+	if _, err := ScaledDual(NewBasis([][]int64{{2, 0}, {0, 3}}), big.NewInt(2)); err == nil {
+		t.Error("2 times the dual of diag(2, 3) reported integral")
+	}
+}
+
+// mulRing returns a*s in Z[x]/(x^n + 1) or Z[x]/(x^n - 1), schoolbook.
+func mulRing(a, s []int64, negacyclic bool) []int64 {
+	n := len(a)
+	out := make([]int64, n)
+	for i := range s {
+		for j := range a {
+			k, c := i+j, s[i]*a[j]
+			if k >= n {
+				k -= n
+				if negacyclic {
+					c = -c
+				}
+			}
+			out[k] += c
+		}
+	}
+	return out
+}
+
+func TestRotationBasis(t *testing.T) {
+	r := rand.New(rand.NewSource(16))
+	for _, n := range []int{1, 3, 8} {
+		for _, negacyclic := range []bool{true, false} {
+			a, s := make([]int64, n), make([]int64, n)
+			for i := range a {
+				a[i], s[i] = r.Int63n(201)-100, r.Int63n(201)-100
+			}
+			rot := RotationBasis(a, negacyclic)
+			for i := range n {
+				xi := make([]int64, n)
+				xi[i] = 1
+				if !rot[i].Equal(NewVector(mulRing(a, xi, negacyclic)...)) {
+					t.Errorf("n=%d negacyclic=%v: row %d is not x^%d * a", n, negacyclic, i, i)
+				}
+			}
+			if got, want := rot.Combination(NewVector(s...)), NewVector(mulRing(a, s, negacyclic)...); !got.Equal(want) {
+				t.Errorf("n=%d negacyclic=%v: s * Rot(a) = %v, s*a = %v", n, negacyclic, got, want)
+			}
+		}
+	}
+}
+
+// TestEmbedding checks that the secrets are in the NTRU lattice and that
+// (e, -s, M) is in the Kannan embedding of an LWE sample.
+func TestEmbedding(t *testing.T) {
+	r := rand.New(rand.NewSource(17))
+	const n, q = 8, 257
+	small := func() []int64 {
+		v := make([]int64, n)
+		for i := range v {
+			v[i] = r.Int63n(3) - 1
+		}
+		return v
+	}
+	a, s, e := randomMatrix(r, 1, n, q)[0], small(), small()
+	b := mulRing(a, s, true)
+	for i := range b {
+		b[i] = mod(b[i]+e[i], q)
+	}
+	short := append(append(append([]int64{}, e...), make([]int64, n)...), 1)
+	for i := range s {
+		short[n+i] = -s[i]
+	}
+	if _, ok, err := Contains(RingLWEEmbedding(a, b, q, true, 1), NewVector(short...)); err != nil || !ok {
+		t.Errorf("(e, -s, 1) is not in the Ring-LWE embedding: %v", err)
+	}
+
+	// (f, f*h mod q) is in the NTRU lattice of h for every f; for a key
+	// h = g/f it is the short vector (f, g).
+	h, f := randomMatrix(r, 1, n, q)[0], small()
+	fg := append(append([]int64{}, f...), mulRing(h, f, true)...)
+	for i := n; i < len(fg); i++ {
+		fg[i] = mod(fg[i], q)
+	}
+	if _, ok, err := Contains(NTRULattice(h, q, true), NewVector(fg...)); err != nil || !ok {
+		t.Errorf("(f, f*h) is not in the NTRU lattice: %v", err)
+	}
+}
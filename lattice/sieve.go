@@ -0,0 +1,394 @@
+package lattice
+
+import (
+	"math"
+	"math/big"
+	"math/rand"
+	"runtime"
+	"sync"
+)
+
+// SieveOptions configures the sieving algorithms. A nil pointer selects the
+// defaults.
+type SieveOptions struct {
+	// MaxCollisions stops the Gauss sieve after this many collisions
+	// (vectors reduced to zero). Default: 200 + list size / 10, the rule
+	// of Micciancio–Voulgaris.
+	MaxCollisions int
+	// Gamma is the shrinking factor of the Nguyen–Vidick sieve, in (2/3, 1).
+	// Default 0.97.
+	Gamma float64
+	// Samples is the number of vectors the Nguyen–Vidick sieve starts
+	// with. Default: a multiple of the heuristic list size (4/3)^(n/2).
+	Samples int
+	// Target, if positive, stops either sieve as soon as a vector of at
+	// most this length is found, for instance 1.05 times the Gaussian
+	// heuristic.
+	Target float64
+	// Workers is the number of goroutines used for list reduction.
+	// Default runtime.GOMAXPROCS(0).
+	Workers int
+	// Rand drives the sampler. Default: a source seeded with 1.
+	Rand *rand.Rand
+}
+
+func (o *SieveOptions) workers() int {
+	if o == nil || o.Workers <= 0 {
+		return runtime.GOMAXPROCS(0)
+	}
+	return o.Workers
+}
+
+func (o *SieveOptions) rand() *rand.Rand {
+	if o == nil || o.Rand == nil {
+		return rand.New(rand.NewSource(1))
+	}
+	return o.Rand
+}
+
+// SieveStats reports how a sieve ran, to compare its cost with enumeration.
+type SieveStats struct {
+	// Samples is the number of fresh lattice vectors drawn.
+	Samples int
+	// Collisions counts vectors that were reduced to zero; each one is a
+	// wasted sample.
+	Collisions int
+	// MaxListSize is the largest size the list of vectors reached, which
+	// is the memory cost of the sieve.
+	MaxListSize int
+	// ListSizes records the list size after every iteration (Gauss sieve)
+	// or round (NV sieve).
+	ListSizes []int
+	// Reductions counts pairwise reductions v -= ±w.
+	Reductions int
+}
+
+// sieveVec is a lattice vector in float64 coordinates. Lattice vectors of
+// an integer basis have integer coordinates, which float64 represents
+// exactly as long as they stay below 2^53.
+type sieveVec struct {
+	x  []float64
+	n2 float64
+}
+
+func newSieveVec(x []float64) *sieveVec { return &sieveVec{x: x, n2: floatDot(x, x)} }
+
+// reduceBy returns v - s*w for the sign s in {+1, -1} that makes it shortest,
+// and whether that is shorter than v.
+func (v *sieveVec) reduceBy(w *sieveVec) (*sieveVec, bool) {
+	d := floatDot(v.x, w.x)
+	// ||v - s w||^2 = ||v||^2 - 2 s <v,w> + ||w||^2
+	if 2*math.Abs(d) <= w.n2 {
+		return nil, false
+	}
+	s := 1.0
+	if d < 0 {
+		s = -1
+	}
+	out := make([]float64, len(v.x))
+	for i := range out {
+		out[i] = v.x[i] - s*w.x[i]
+	}
+	return newSieveVec(out), true
+}
+
+// sampler draws lattice vectors with Klein's randomized nearest plane
+// around the origin, whose output is short but far from deterministic.
+type sampler struct {
+	b     [][]float64
+	g     *GSOFloat
+	sigma float64
+	rng   *rand.Rand
+}
+
+func newSampler(b Basis, rng *rand.Rand) *sampler {
+	rows := b.Floats()
+	g := gramSchmidtFloat(rows)
+	// A width a little above ||b_0|| gives vectors of length about
+	// sqrt(n) ||b_0||, short enough for the sieve to start quickly.
+	sigma := math.Sqrt(g.Norm2[0])
+	return &sampler{b: rows, g: g, sigma: sigma, rng: rng}
+}
+
+func (s *sampler) sample() *sieveVec {
+	n := len(s.b)
+	z := make([]float64, n)
+	for i := n - 1; i >= 0; i-- {
+		c := 0.0
+		for j := i + 1; j < n; j++ {
+			c -= z[j] * s.g.Mu[j][i]
+		}
+		si := s.sigma / math.Sqrt(s.g.Norm2[i])
+		z[i] = math.Round(c + si*s.rng.NormFloat64())
+	}
+	x := make([]float64, len(s.b[0]))
+	for i, zi := range z {
+		if zi == 0 {
+			continue
+		}
+		for c := range x {
+			x[c] += zi * s.b[i][c]
+		}
+	}
+	return newSieveVec(x)
+}
+
+// parallelScan splits [0, n) into chunks and runs f on each in its own
+// goroutine. Small ranges are scanned directly, where goroutines would
+// cost more than they save.
+func parallelScan(n, workers int, f func(lo, hi int)) {
+	if workers <= 1 || n < 1024 {
+		f(0, n)
+		return
+	}
+	var wg sync.WaitGroup
+	chunk := (n + workers - 1) / workers
+	for lo := 0; lo < n; lo += chunk {
+		hi := min(lo+chunk, n)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			f(lo, hi)
+		}()
+	}
+	wg.Wait()
+}
+
+// GaussSieve finds a shortest vector with the Gauss sieve of Micciancio and
+// Voulgaris. It keeps a list L of vectors that are pairwise Gauss reduced,
+// |<v, w>| <= ||w||^2 / 2 for ||w|| <= ||v||. Each new vector is first
+// reduced against L; if it survives it joins L, and the longer list
+// vectors it can now reduce are taken out and queued to be reduced again.
+// The list grows to about 2^(0.21 n) vectors; the run stops once enough
+// new vectors collapse to zero, the sign that L already contains the
+// shortest vectors.
+func GaussSieve(b Basis, opts *SieveOptions) (Vector, SieveStats, error) {
+	var st SieveStats
+	red, err := LLL(b, nil)
+	if err != nil {
+		return nil, st, err
+	}
+	workers := opts.workers()
+	smp := newSampler(red, opts.rand())
+	maxColl := -1
+	target := 0.0
+	if opts != nil {
+		if opts.MaxCollisions > 0 {
+			maxColl = opts.MaxCollisions
+		}
+		target = opts.Target * opts.Target
+	}
+	best := newSieveVec(red[0].Floats())
+	var list, stack []*sieveVec
+	for {
+		limit := maxColl
+		if limit < 0 {
+			limit = 200 + len(list)/10
+		}
+		if st.Collisions >= limit || (target > 0 && best.n2 <= target) {
+			break
+		}
+		var v *sieveVec
+		if n := len(stack); n > 0 {
+			v, stack = stack[n-1], stack[:n-1]
+		} else {
+			v = smp.sample()
+			st.Samples++
+		}
+		// Reduce v against the list until it no longer changes.
+		for changed := true; changed && v.n2 > 0; {
+			changed = false
+			if w := bestReducer(v, list, workers); w != nil {
+				v, _ = v.reduceBy(w)
+				st.Reductions++
+				changed = true
+			}
+		}
+		if v.n2 < 0.5 {
+			st.Collisions++
+			continue
+		}
+		if v.n2 < best.n2 {
+			best = v
+		}
+		// Move the list vectors that v reduces onto the stack.
+		reducible := make([]bool, len(list))
+		parallelScan(len(list), workers, func(lo, hi int) {
+			for i := lo; i < hi; i++ {
+				w := list[i]
+				if w.n2 > v.n2 && 2*math.Abs(floatDot(v.x, w.x)) > v.n2 {
+					reducible[i] = true
+				}
+			}
+		})
+		kept := list[:0]
+		for i, w := range list {
+			if !reducible[i] {
+				kept = append(kept, w)
+				continue
+			}
+			w, _ = w.reduceBy(v)
+			st.Reductions++
+			if w.n2 < 0.5 {
+				st.Collisions++
+				continue
+			}
+			stack = append(stack, w)
+		}
+		list = append(kept, v)
+		st.MaxListSize = max(st.MaxListSize, len(list))
+		st.ListSizes = append(st.ListSizes, len(list))
+	}
+	return floatsToVector(best.x), st, nil
+}
+
+// bestReducer returns the list vector w that makes v - ±w shortest, or nil
+// if no list vector shortens v. The list is scanned in parallel; of equal
+// gains the first in the list wins, so the result does not depend on the
+// scheduling.
+func bestReducer(v *sieveVec, list []*sieveVec, workers int) *sieveVec {
+	type cand struct {
+		i    int
+		gain float64
+	}
+	var mu sync.Mutex
+	best := cand{i: -1}
+	parallelScan(len(list), workers, func(lo, hi int) {
+		local := cand{i: -1}
+		for i := lo; i < hi; i++ {
+			// ||v||^2 - ||v - s w||^2 = 2|<v,w>| - ||w||^2
+			w := list[i]
+			if gain := 2*math.Abs(floatDot(v.x, w.x)) - w.n2; gain > local.gain {
+				local = cand{i, gain}
+			}
+		}
+		mu.Lock()
+		if local.i >= 0 && (local.gain > best.gain || local.gain == best.gain && local.i < best.i) {
+			best = local
+		}
+		mu.Unlock()
+	})
+	if best.i < 0 {
+		return nil
+	}
+	return list[best.i]
+}
+
+// NVSieve finds a short vector with the Nguyen–Vidick sieve. It starts
+// from a large set S of sampled vectors of length at most R and repeatedly
+// replaces it by shorter ones: a set of centers C is chosen greedily from S
+// and every other vector v is replaced by v - c for a center c with
+// ||v - c|| <= Gamma*R. Each round shrinks the radius by Gamma at the cost
+// of the vectors used as centers, and the shortest non-zero vector seen is
+// returned. About (4/3)^(n/2) centers are needed, which bounds the usable
+// dimension by memory rather than time.
+func NVSieve(b Basis, opts *SieveOptions) (Vector, SieveStats, error) {
+	var st SieveStats
+	red, err := LLL(b, nil)
+	if err != nil {
+		return nil, st, err
+	}
+	n := len(red)
+	gamma, samples, target := 0.97, 0, 0.0
+	if opts != nil {
+		if opts.Gamma > 0 {
+			gamma = opts.Gamma
+		}
+		samples = opts.Samples
+		target = opts.Target * opts.Target
+	}
+	if samples == 0 {
+		samples = 20*int(math.Pow(4.0/3, float64(n)/2)) + 500
+	}
+	workers := opts.workers()
+	smp := newSampler(red, opts.rand())
+
+	best := newSieveVec(red[0].Floats())
+	set := make([]*sieveVec, samples)
+	for i := range set {
+		set[i] = smp.sample()
+		if set[i].n2 > 0 && set[i].n2 < best.n2 {
+			best = set[i]
+		}
+	}
+	st.Samples = samples
+	for len(set) > 0 && (target == 0 || best.n2 > target) {
+		r2 := 0.0
+		for _, v := range set {
+			r2 = math.Max(r2, v.n2)
+		}
+		g2 := gamma * gamma * r2
+		var centers, next []*sieveVec
+		for _, v := range set {
+			if v.n2 <= g2 {
+				next = append(next, v)
+				continue
+			}
+			c := findCenter(v, centers, g2, workers)
+			if c == nil {
+				centers = append(centers, v)
+				continue
+			}
+			w, _ := v.reduceBy(c)
+			st.Reductions++
+			if w == nil || w.n2 > g2 {
+				// -c was not the right sign either; keep v as a
+				// center instead.
+				centers = append(centers, v)
+				continue
+			}
+			if w.n2 < 0.5 {
+				st.Collisions++
+				continue
+			}
+			if w.n2 < best.n2 {
+				best = w
+			}
+			next = append(next, w)
+		}
+		st.MaxListSize = max(st.MaxListSize, len(centers))
+		st.ListSizes = append(st.ListSizes, len(next))
+		if len(next) == len(set) {
+			// No progress is possible at this radius.
+			break
+		}
+		set = next
+	}
+	return floatsToVector(best.x), st, nil
+}
+
+// findCenter returns the first center c, in list order, with
+// min(||v - c||, ||v + c||)^2 <= g2, or nil. The centers are scanned in
+// parallel: each chunk stops at its first match and the lowest index
+// across the chunks wins, so the result does not depend on the
+// scheduling.
+func findCenter(v *sieveVec, centers []*sieveVec, g2 float64, workers int) *sieveVec {
+	var mu sync.Mutex
+	found := -1
+	parallelScan(len(centers), workers, func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			c := centers[i]
+			if d := v.n2 + c.n2 - 2*math.Abs(floatDot(v.x, c.x)); d <= g2 {
+				mu.Lock()
+				if found < 0 || i < found {
+					found = i
+				}
+				mu.Unlock()
+				return
+			}
+		}
+	})
+	if found < 0 {
+		return nil
+	}
+	return centers[found]
+}
+
+func floatsToVector(x []float64) Vector {
+	v := make(Vector, len(x))
+	for i, f := range x {
+		v[i] = new(big.Int)
+		floatToBig(v[i], math.Round(f))
+	}
+	return v
+}
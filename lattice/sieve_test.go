@@ -0,0 +1,88 @@
+package lattice
+
+import (
+	"math"
+	"math/big"
+	"math/rand"
+	"testing"
+)
+
+// inLattice reports whether v is a non-zero vector of the lattice of b.
+func inLattice(t *testing.T, b Basis, v Vector) bool {
+	x, err := b.Coordinates(RatVector(v))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := make([]*big.Int, len(x))
+	for i, xi := range x {
+		if !xi.IsInt() {
+			return false
+		}
+		c[i] = xi.Num()
+	}
+	return !v.IsZero() && b.Combination(c).Equal(v)
+}
+
+func TestSieves(t *testing.T) {
+	r := rand.New(rand.NewSource(7))
+	for _, n := range []int{6, 10, 14, 18, 20} {
+		for _, b := range []Basis{randomBasis(r, n, 100), knapsackBasis(r, n-1, 3*n)} {
+			sv, err := ShortestVector(b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			lambda := sv.Norm()
+			for _, s := range []struct {
+				name  string
+				sieve func(Basis, *SieveOptions) (Vector, SieveStats, error)
+				slack float64
+			}{
+				{"Gauss sieve", GaussSieve, 1},
+				{"NV sieve", NVSieve, 1.2},
+			} {
+				v, st, err := s.sieve(b, &SieveOptions{Rand: rand.New(rand.NewSource(int64(n)))})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !inLattice(t, b, v) {
+					t.Errorf("%s rank %d: %v is not a non-zero lattice vector", s.name, n, v)
+				}
+				if got := v.Norm(); got > s.slack*lambda+1e-9 {
+					t.Errorf("%s rank %d: length %.3f, lambda_1 %.3f", s.name, n, got, lambda)
+				}
+				if st.Samples == 0 || len(st.ListSizes) == 0 {
+					t.Errorf("%s rank %d: empty statistics %+v", s.name, n, st)
+				}
+			}
+		}
+	}
+}
+
+// TestScanOrder checks that the parallel scans of the sieves pick the
+// first match in list order, whatever chunk finishes first.
+func TestScanOrder(t *testing.T) {
+	r := rand.New(rand.NewSource(8))
+	list := make([]*sieveVec, 8000)
+	for i := range list {
+		list[i] = newSieveVec([]float64{float64(r.Intn(9) - 4), float64(r.Intn(9) - 4), 40})
+	}
+	v := newSieveVec([]float64{1, -2, 40})
+	wantCenter, wantReducer := -1, -1
+	bestGain := 0.0
+	for i, c := range list {
+		if wantCenter < 0 && v.n2+c.n2-2*math.Abs(floatDot(v.x, c.x)) <= 2 {
+			wantCenter = i
+		}
+		if gain := 2*math.Abs(floatDot(v.x, c.x)) - c.n2; gain > bestGain {
+			wantReducer, bestGain = i, gain
+		}
+	}
+	for range 50 {
+		if c := findCenter(v, list, 2, 8); c != list[wantCenter] {
+			t.Fatalf("findCenter did not return center %d", wantCenter)
+		}
+		if w := bestReducer(v, list, 8); w != list[wantReducer] {
+			t.Fatalf("bestReducer did not return vector %d", wantReducer)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package lattice
+
+import (
+	"math/big"
+)
+
+// SNF returns the Smith normal form of the integer matrix b (rows of any
+// number and length): unimodular U and V and the diagonal d with
+//
+//	U * B * V = diag(d_0, d_1, ...),   d_0 | d_1 | ...
+//
+// The d_i are the invariant factors of B. For a full-rank square basis the
+// quotient group Z^n / L is isomorphic to Z/d_0 x ... x Z/d_{n-1}; a rank
+// deficiency shows up as trailing zeros.
+func SNF(b Basis) (d []*big.Int, u, v Basis) {
+	k, n := len(b), b.Dim()
+	a := b.Clone()
+	u, v = Identity(k), Identity(n)
+	for t := 0; t < min(k, n); t++ {
+		for {
+			// Bring a non-zero entry of least magnitude to (t, t).
+			pi, pj := -1, -1
+			for i := t; i < k; i++ {
+				for j := t; j < n; j++ {
+					if a[i][j].Sign() != 0 && (pi < 0 || a[i][j].CmpAbs(a[pi][pj]) < 0) {
+						pi, pj = i, j
+					}
+				}
+			}
+			if pi < 0 {
+				// The remaining block is zero.
+				return diagonal(a), u, v
+			}
+			a[t], a[pi] = a[pi], a[t]
+			u[t], u[pi] = u[pi], u[t]
+			swapColumns(a, t, pj)
+			swapColumns(v, t, pj)
+
+			// Divide the rest of row t and column t by the pivot; any
+			// non-zero remainder is smaller than the pivot and becomes
+			// the next pivot.
+			done := true
+			q := new(big.Int)
+			for i := t + 1; i < k; i++ {
+				if a[i][t].Sign() == 0 {
+					continue
+				}
+				q.Quo(a[i][t], a[t][t])
+				negQ := new(big.Int).Neg(q)
+				a[i].AddMul(negQ, a[t])
+				u[i].AddMul(negQ, u[t])
+				if a[i][t].Sign() != 0 {
+					done = false
+				}
+			}
+			for j := t + 1; j < n; j++ {
+				if a[t][j].Sign() == 0 {
+					continue
+				}
+				q.Quo(a[t][j], a[t][t])
+				negQ := new(big.Int).Neg(q)
+				addColumn(a, j, t, negQ)
+				addColumn(v, j, t, negQ)
+				if a[t][j].Sign() != 0 {
+					done = false
+				}
+			}
+			if !done {
+				continue
+			}
+			// The pivot must divide every remaining entry; if one does
+			// not, adding its row to row t brings it into play.
+			bad := -1
+			m := new(big.Int)
+			for i := t + 1; i < k && bad < 0; i++ {
+				for j := t + 1; j < n; j++ {
+					if m.Rem(a[i][j], a[t][t]).Sign() != 0 {
+						bad = i
+						break
+					}
+				}
+			}
+			if bad < 0 {
+				break
+			}
+			a[t].Add(a[bad])
+			u[t].Add(u[bad])
+		}
+		if a[t][t].Sign() < 0 {
+			negVec(a[t])
+			negVec(u[t])
+		}
+	}
+	return diagonal(a), u, v
+}
+
+// InvariantFactors returns the invariant factors d_0 | d_1 | ... | d_{n-1}
+// of a full-rank square basis, so that
+//
+//	Z^n / L  =  Z/d_0 x Z/d_1 x ... x Z/d_{n-1},   d_0 * ... * d_{n-1} = |det B|.
+//
+// Since D = |det B| kills the quotient group, the elimination is done
+// modulo D on the Hermite normal form and no entry ever exceeds D.
+func InvariantFactors(b Basis) ([]*big.Int, error) {
+	h, err := HNF(b)
+	if err != nil {
+		return nil, err
+	}
+	n := len(h)
+	det := big.NewInt(1)
+	for i := range h {
+		det.Mul(det, h[i][i])
+	}
+	// Reducing modulo D is allowed because D*e_j lies in the lattice for
+	// every j: it amounts to adding such vectors to a row.
+	a := h.Clone()
+	for t := 0; t < n; t++ {
+		for {
+			pi, pj := -1, -1
+			for i := t; i < n; i++ {
+				for j := t; j < n; j++ {
+					if a[i][j].Sign() != 0 && (pi < 0 || a[i][j].Cmp(a[pi][pj]) < 0) {
+						pi, pj = i, j
+					}
+				}
+			}
+			if pi < 0 {
+				break
+			}
+			a[t], a[pi] = a[pi], a[t]
+			swapColumns(a, t, pj)
+			done := true
+			q := new(big.Int)
+			for i := t + 1; i < n; i++ {
+				if a[i][t].Sign() == 0 {
+					continue
+				}
+				q.Quo(a[i][t], a[t][t])
+				a[i].AddMul(q.Neg(q), a[t])
+				modVec(a[i], det)
+				if a[i][t].Sign() != 0 {
+					done = false
+				}
+			}
+			for j := t + 1; j < n; j++ {
+				if a[t][j].Sign() == 0 {
+					continue
+				}
+				q.Quo(a[t][j], a[t][t])
+				addColumn(a, j, t, q.Neg(q))
+				for i := range a {
+					a[i][j].Mod(a[i][j], det)
+				}
+				if a[t][j].Sign() != 0 {
+					done = false
+				}
+			}
+			if !done {
+				continue
+			}
+			bad := -1
+			m := new(big.Int)
+			for i := t + 1; i < n && bad < 0; i++ {
+				for j := t + 1; j < n; j++ {
+					if m.Rem(a[i][j], a[t][t]).Sign() != 0 {
+						bad = i
+						break
+					}
+				}
+			}
+			if bad < 0 {
+				break
+			}
+			a[t].Add(a[bad])
+			modVec(a[t], det)
+		}
+	}
+	d := diagonal(a)
+	for _, x := range d {
+		// A zero modulo D stands for D itself.
+		x.GCD(nil, nil, x, det)
+	}
+	return d, nil
+}
+
+// QuotientGroup returns the invariant factors of Z^n / L that are larger
+// than 1, i.e. the cyclic factors of the finite abelian group Z^n / L.
+func QuotientGroup(b Basis) ([]*big.Int, error) {
+	d, err := InvariantFactors(b)
+	if err != nil {
+		return nil, err
+	}
+	var out []*big.Int
+	for _, x := range d {
+		if x.Cmp(big.NewInt(1)) != 0 {
+			out = append(out, x)
+		}
+	}
+	return out, nil
+}
+
+func diagonal(a Basis) []*big.Int {
+	d := make([]*big.Int, min(len(a), a.Dim()))
+	for i := range d {
+		d[i] = new(big.Int).Set(a[i][i])
+	}
+	return d
+}
+
+func swapColumns(a Basis, i, j int) {
+	if i == j {
+		return
+	}
+	for _, row := range a {
+		row[i], row[j] = row[j], row[i]
+	}
+}
+
+// addColumn adds c times column src to column dst.
+func addColumn(a Basis, dst, src int, c *big.Int) {
+	t := new(big.Int)
+	for _, row := range a {
+		row[dst].Add(row[dst], t.Mul(c, row[src]))
+	}
+}
@@ -0,0 +1,48 @@
+// Package lwe generates and verifies LWE, Ring-LWE, Module-LWE and SIS
+// challenge instances.
+//
+// All four problems are described over the same ring R_q = Z_q[x]/(x^d + 1)
+// as the NTT notes, with plain LWE and SIS as the degenerate case d = 1
+// (where R_q is just Z_q):
+//
+//	LWE         d = 1,    A in Z_q^(m x n),     b = A s + e
+//	Ring-LWE    d = 2^k,  A in R_q^(m x 1),     b = A s + e
+//	Module-LWE  d = 2^k,  A in R_q^(m x k),     b = A s + e
+//	SIS         d = 1,    A in Z_q^(n x m),     find z != 0, A z = 0, ||z|| <= beta
+//
+// Secrets and errors are rounded Gaussians of width sigma (normal-form LWE).
+// Every random choice is drawn from a SHAKE128 stream keyed by the seed, so
+// the same parameters and seed always give the same instance.
+//
+// # Challenge format
+//
+// A challenge is a JSON document:
+//
+//	{
+//	  "format":  "lattice-challenge/v1",
+//	  "kind":    "lwe" | "ring-lwe" | "module-lwe" | "sis",
+//	  "degree":  d,          // ring degree, 1 for LWE and SIS
+//	  "rank":    k,          // columns of A (n for LWE, 1 for Ring-LWE)
+//	  "samples": m,          // rows of A (n for SIS)
+//	  "q":       q,
+//	  "sigma":   sigma,      // LWE kinds only
+//	  "bound":   beta,       // accepted Euclidean norm of e (LWE) or z (SIS)
+//	  "seed":    "...",
+//	  "a":       [[[...]]],  // samples x rank x degree coefficients in [0, q)
+//	  "b":       [[...]]     // samples x degree, LWE kinds only
+//	}
+//
+// Ring elements are lists of d coefficients, constant term first. The
+// hidden solution is written to a separate sidecar document:
+//
+//	{
+//	  "format": "lattice-challenge-solution/v1",
+//	  "kind":   ...,
+//	  "s":      [[...]],     // rank x degree, centered coefficients (LWE kinds)
+//	  "e":      [[...]],     // samples x degree (LWE kinds)
+//	  "z":      [[...]]      // rank x degree (SIS)
+//	}
+//
+// A candidate answer uses the same solution format; only "s" (LWE kinds)
+// or "z" (SIS) needs to be filled in.
+package lwe
@@ -0,0 +1,374 @@
+package lwe
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// Format and SolutionFormat identify the JSON documents of this package.
+const (
+	Format         = "lattice-challenge/v1"
+	SolutionFormat = "lattice-challenge-solution/v1"
+)
+
+// Kind names a lattice problem.
+type Kind string
+
+const (
+	LWE       Kind = "lwe"
+	RingLWE   Kind = "ring-lwe"
+	ModuleLWE Kind = "module-lwe"
+	SIS       Kind = "sis"
+)
+
+// Params selects an instance. Degree is 1 for LWE and SIS and Rank is 1
+// for Ring-LWE, so they may be left zero for those kinds, and a zero Bound
+// takes the default below; every other field must be set.
+type Params struct {
+	Kind Kind
+	// Degree is the ring degree d of Z_q[x]/(x^d + 1); it is 1 for LWE
+	// and SIS and a power of two for the ring kinds.
+	Degree int
+	// Rank is the number of columns of A: the secret dimension n of LWE,
+	// the module rank k of Module-LWE, 1 for Ring-LWE and m for SIS.
+	Rank int
+	// Samples is the number of rows of A: m for the LWE kinds, n for SIS.
+	Samples int
+	Q       int64
+	// Sigma is the width of the rounded Gaussian secrets and errors, and
+	// of the planted SIS solution.
+	Sigma float64
+	// Bound is the largest accepted Euclidean norm of e (LWE kinds) or z
+	// (SIS). The default is 2*Sigma*sqrt(number of coefficients) + 1,
+	// about twice the expected norm of the planted solution, raised to
+	// the smallest bound Generate accepts where that is larger.
+	Bound float64
+	Seed  string
+}
+
+func (p *Params) normalize() error {
+	switch p.Kind {
+	case LWE, SIS:
+		if p.Degree > 1 {
+			return fmt.Errorf("lwe: %s has degree 1, got %d", p.Kind, p.Degree)
+		}
+		p.Degree = 1
+	case RingLWE:
+		if p.Rank > 1 {
+			return fmt.Errorf("lwe: ring-lwe has rank 1, got %d", p.Rank)
+		}
+		p.Rank = 1
+	case ModuleLWE:
+	default:
+		return fmt.Errorf("lwe: unknown kind %q", p.Kind)
+	}
+	if p.Degree < 1 || p.Degree&(p.Degree-1) != 0 {
+		return fmt.Errorf("lwe: degree %d is not a power of two", p.Degree)
+	}
+	if p.Rank < 1 || p.Samples < 1 {
+		return errors.New("lwe: rank and samples must be positive")
+	}
+	if p.Q < 2 || p.Q > 1<<31 {
+		return fmt.Errorf("lwe: modulus %d out of range [2, 2^31]", p.Q)
+	}
+	if p.Sigma <= 0 {
+		return errors.New("lwe: sigma must be positive")
+	}
+	if p.Bound < 0 {
+		return fmt.Errorf("lwe: negative bound %v", p.Bound)
+	}
+	if p.Bound == 0 {
+		coeffs := p.Samples * p.Degree
+		if p.Kind == SIS {
+			coeffs = p.Rank
+		}
+		p.Bound = math.Max(math.Ceil(2*p.Sigma*math.Sqrt(float64(coeffs)))+1, math.Ceil(p.minBound()))
+	}
+	return nil
+}
+
+// minBound returns the smallest bound Generate accepts, one that the
+// planted solution fits under with a fair chance. A rounded Gaussian
+// coefficient has variance v = Sigma^2 + 1/12, so the norm of the error,
+// or of the SIS solution with its fixed z_{m-1} = 1, concentrates at
+// sqrt(v*drawn + fixed) with a spread of about sqrt(v/2); two sqrt(v)
+// above that, a draw fails well under one time in a hundred.
+func (p *Params) minBound() float64 {
+	v := p.Sigma*p.Sigma + 1.0/12
+	drawn, fixed := float64(p.Samples*p.Degree), 0.0
+	if p.Kind == SIS {
+		drawn, fixed = float64(p.Rank-1), 1
+	}
+	if drawn == 0 {
+		return fixed
+	}
+	return math.Sqrt(v*drawn+fixed) + 2*math.Sqrt(v)
+}
+
+// maxDraws caps the resampling of the planted solution, which minBound
+// makes likely to succeed at the first draw.
+const maxDraws = 1000
+
+var errDraws = errors.New("lwe: no planted solution within the bound after 1000 draws")
+
+// Instance is a public challenge.
+type Instance struct {
+	Format  string      `json:"format"`
+	Kind    Kind        `json:"kind"`
+	Degree  int         `json:"degree"`
+	Rank    int         `json:"rank"`
+	Samples int         `json:"samples"`
+	Q       int64       `json:"q"`
+	Sigma   float64     `json:"sigma,omitempty"`
+	Bound   float64     `json:"bound"`
+	Seed    string      `json:"seed"`
+	A       [][][]int64 `json:"a"`
+	B       [][]int64   `json:"b,omitempty"`
+}
+
+// Solution is the hidden solution of an instance, or a candidate answer.
+type Solution struct {
+	Format string    `json:"format"`
+	Kind   Kind      `json:"kind"`
+	S      [][]int64 `json:"s,omitempty"`
+	E      [][]int64 `json:"e,omitempty"`
+	Z      [][]int64 `json:"z,omitempty"`
+}
+
+// Generate builds the instance selected by p together with its hidden
+// solution.
+func Generate(p Params) (*Instance, *Solution, error) {
+	if err := p.normalize(); err != nil {
+		return nil, nil, err
+	}
+	if lo := p.minBound(); p.Bound < lo {
+		return nil, nil, fmt.Errorf("lwe: bound %v below %.4g, the norm of the planted solution with a margin", p.Bound, lo)
+	}
+	inst := &Instance{
+		Format: Format, Kind: p.Kind, Degree: p.Degree, Rank: p.Rank,
+		Samples: p.Samples, Q: p.Q, Bound: p.Bound, Seed: p.Seed,
+	}
+	sol := &Solution{Format: SolutionFormat, Kind: p.Kind}
+	if p.Kind == SIS {
+		if err := generateSIS(p, inst, sol); err != nil {
+			return nil, nil, err
+		}
+		return inst, sol, nil
+	}
+	inst.Sigma = p.Sigma
+
+	xa := newStream("a", p.Seed)
+	inst.A = make([][][]int64, p.Samples)
+	for i := range inst.A {
+		inst.A[i] = make([][]int64, p.Rank)
+		for j := range inst.A[i] {
+			inst.A[i][j] = make([]int64, p.Degree)
+			for c := range inst.A[i][j] {
+				inst.A[i][j][c] = xa.uniform(p.Q)
+			}
+		}
+	}
+	xs := newStream("secret", p.Seed)
+	sol.S = make([][]int64, p.Rank)
+	for j := range sol.S {
+		sol.S[j] = gaussianPoly(xs, p.Degree, p.Sigma)
+	}
+	// Resample the error in the (very unlikely) case that it exceeds the
+	// published bound, so the hidden solution always verifies.
+	xe := newStream("error", p.Seed)
+	for draw := 0; ; draw++ {
+		if draw == maxDraws {
+			return nil, nil, errDraws
+		}
+		sol.E = make([][]int64, p.Samples)
+		for i := range sol.E {
+			sol.E[i] = gaussianPoly(xe, p.Degree, p.Sigma)
+		}
+		if norm(sol.E) <= p.Bound {
+			break
+		}
+	}
+	inst.B = make([][]int64, p.Samples)
+	for i := range inst.B {
+		acc := make([]int64, p.Degree)
+		for j := 0; j < p.Rank; j++ {
+			addPoly(acc, mulPoly(inst.A[i][j], sol.S[j], p.Q), p.Q)
+		}
+		addPoly(acc, sol.E[i], p.Q)
+		inst.B[i] = acc
+	}
+	return inst, sol, nil
+}
+
+// generateSIS plants a short solution z with z_{m-1} = 1: all but the last
+// column of A are uniform and the last one is set to -sum_j A_j z_j, which
+// is again uniform because the other columns are.
+func generateSIS(p Params, inst *Instance, sol *Solution) error {
+	n, m := p.Samples, p.Rank
+	xz := newStream("solution", p.Seed)
+	z := make([]int64, m)
+	for draw := 0; ; draw++ {
+		if draw == maxDraws {
+			return errDraws
+		}
+		for j := 0; j < m-1; j++ {
+			z[j] = xz.gaussian(p.Sigma)
+		}
+		z[m-1] = 1
+		if norm([][]int64{z}) <= p.Bound {
+			break
+		}
+	}
+	xa := newStream("a", p.Seed)
+	inst.A = make([][][]int64, n)
+	for i := range inst.A {
+		inst.A[i] = make([][]int64, m)
+		last := int64(0)
+		for j := 0; j < m-1; j++ {
+			a := xa.uniform(p.Q)
+			inst.A[i][j] = []int64{a}
+			last = mod(last-a*z[j], p.Q)
+		}
+		inst.A[i][m-1] = []int64{last}
+	}
+	sol.Z = make([][]int64, m)
+	for j := range sol.Z {
+		sol.Z[j] = []int64{z[j]}
+	}
+	return nil
+}
+
+// WriteJSON writes v as indented JSON.
+func WriteJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// ReadInstance parses and validates a challenge document.
+func ReadInstance(r io.Reader) (*Instance, error) {
+	var inst Instance
+	if err := json.NewDecoder(r).Decode(&inst); err != nil {
+		return nil, err
+	}
+	if inst.Format != Format {
+		return nil, fmt.Errorf("lwe: unsupported challenge format %q", inst.Format)
+	}
+	if err := inst.check(); err != nil {
+		return nil, err
+	}
+	return &inst, nil
+}
+
+// ReadSolution parses a solution document.
+func ReadSolution(r io.Reader) (*Solution, error) {
+	var sol Solution
+	if err := json.NewDecoder(r).Decode(&sol); err != nil {
+		return nil, err
+	}
+	if sol.Format != SolutionFormat {
+		return nil, fmt.Errorf("lwe: unsupported solution format %q", sol.Format)
+	}
+	return &sol, nil
+}
+
+// check validates the shape of a parsed instance.
+func (inst *Instance) check() error {
+	p := Params{Kind: inst.Kind, Degree: inst.Degree, Rank: inst.Rank,
+		Samples: inst.Samples, Q: inst.Q, Sigma: inst.Sigma, Bound: inst.Bound}
+	if inst.Kind == SIS {
+		p.Sigma = 1
+	}
+	if err := p.normalize(); err != nil {
+		return err
+	}
+	if err := checkShape("a", inst.A, inst.Samples, inst.Rank, inst.Degree); err != nil {
+		return err
+	}
+	if inst.Kind != SIS {
+		return checkShape("b", [][][]int64{inst.B}, 1, inst.Samples, inst.Degree)
+	}
+	return nil
+}
+
+func checkShape(name string, x [][][]int64, rows, cols, deg int) error {
+	if len(x) != rows {
+		return fmt.Errorf("lwe: %s has %d rows, want %d", name, len(x), rows)
+	}
+	for _, r := range x {
+		if len(r) != cols {
+			return fmt.Errorf("lwe: %s has a row of length %d, want %d", name, len(r), cols)
+		}
+		for _, p := range r {
+			if len(p) != deg {
+				return fmt.Errorf("lwe: %s has a ring element of degree %d, want %d", name, len(p), deg)
+			}
+		}
+	}
+	return nil
+}
+
+func gaussianPoly(x *stream, d int, sigma float64) []int64 {
+	p := make([]int64, d)
+	for i := range p {
+		p[i] = x.gaussian(sigma)
+	}
+	return p
+}
+
+// mulPoly multiplies in Z_q[x]/(x^d + 1) with the schoolbook method: a
+// product term x^(i+j) with i+j >= d wraps around to -x^(i+j-d).
+func mulPoly(a, b []int64, q int64) []int64 {
+	d := len(a)
+	c := make([]int64, d)
+	for i, ai := range a {
+		if ai == 0 {
+			continue
+		}
+		for j, bj := range b {
+			t := mod(ai*mod(bj, q), q)
+			if k := i + j; k < d {
+				c[k] = mod(c[k]+t, q)
+			} else {
+				c[k-d] = mod(c[k-d]-t, q)
+			}
+		}
+	}
+	return c
+}
+
+func addPoly(acc, b []int64, q int64) {
+	for i := range acc {
+		acc[i] = mod(acc[i]+b[i], q)
+	}
+}
+
+func mod(x, q int64) int64 {
+	x %= q
+	if x < 0 {
+		x += q
+	}
+	return x
+}
+
+// center maps x in [0, q) to the representative in (-q/2, q/2].
+func center(x, q int64) int64 {
+	x = mod(x, q)
+	if x > q/2 {
+		x -= q
+	}
+	return x
+}
+
+func norm(v [][]int64) float64 {
+	s := 0.0
+	for _, p := range v {
+		for _, x := range p {
+			s += float64(x) * float64(x)
+		}
+	}
+	return math.Sqrt(s)
+}
@@ -0,0 +1,161 @@
+package lwe
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+)
+
+var params = []Params{
+	{Kind: LWE, Rank: 16, Samples: 32, Q: 3329, Sigma: 2, Seed: "lwe"},
+	{Kind: RingLWE, Degree: 16, Samples: 2, Q: 3329, Sigma: 1.5, Seed: "ring"},
+	{Kind: ModuleLWE, Degree: 8, Rank: 3, Samples: 3, Q: 7681, Sigma: 1, Seed: "module"},
+	{Kind: SIS, Rank: 40, Samples: 10, Q: 97, Sigma: 1, Seed: "sis"},
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, p := range params {
+		inst, sol, err := Generate(p)
+		if err != nil {
+			t.Fatalf("%s: %v", p.Kind, err)
+		}
+		if n, err := Verify(inst, sol); err != nil || n > inst.Bound {
+			t.Errorf("%s: hidden solution of norm %.2f rejected: %v", p.Kind, n, err)
+		}
+
+		var b bytes.Buffer
+		if err := WriteJSON(&b, inst); err != nil {
+			t.Fatal(err)
+		}
+		parsed, err := ReadInstance(&b)
+		if err != nil {
+			t.Fatalf("%s: %v", p.Kind, err)
+		}
+		if !reflect.DeepEqual(parsed, inst) {
+			t.Errorf("%s: instance changed in a JSON round trip", p.Kind)
+		}
+		b.Reset()
+		if err := WriteJSON(&b, sol); err != nil {
+			t.Fatal(err)
+		}
+		parsedSol, err := ReadSolution(&b)
+		if err != nil {
+			t.Fatalf("%s: %v", p.Kind, err)
+		}
+		if !reflect.DeepEqual(parsedSol, sol) {
+			t.Errorf("%s: solution changed in a JSON round trip", p.Kind)
+		}
+		if _, err := Verify(parsed, parsedSol); err != nil {
+			t.Errorf("%s: parsed solution rejected: %v", p.Kind, err)
+		}
+	}
+}
+
+// TestRejects changes one coefficient of the hidden solution, which moves
+// the LWE error by a uniform column of A and takes z out of the kernel.
+func TestRejects(t *testing.T) {
+	for _, p := range params {
+		inst, sol, err := Generate(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		bad := &Solution{Kind: p.Kind}
+		if p.Kind == SIS {
+			bad.Z = clone(sol.Z)
+			bad.Z[0][0]++
+		} else {
+			bad.S = clone(sol.S)
+			bad.S[0][0] += p.Q / 3
+		}
+		if _, err := Verify(inst, bad); !errors.Is(err, ErrRejected) {
+			t.Errorf("%s: wrong solution: %v", p.Kind, err)
+		}
+		if p.Kind == SIS {
+			bad.Z = clone(sol.Z)
+			for _, z := range bad.Z {
+				z[0] = 0
+			}
+			if _, err := Verify(inst, bad); !errors.Is(err, ErrRejected) {
+				t.Errorf("sis: zero solution: %v", err)
+			}
+		}
+		if _, err := Verify(inst, &Solution{Kind: p.Kind}); err == nil {
+			t.Errorf("%s: empty solution accepted", p.Kind)
+		}
+	}
+	inst, sol, err := Generate(params[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	sol.Kind = SIS
+	if _, err := Verify(inst, sol); err == nil {
+		t.Error("accepted a solution of another kind")
+	}
+}
+
+func clone(x [][]int64) [][]int64 {
+	out := make([][]int64, len(x))
+	for i, v := range x {
+		out[i] = append([]int64(nil), v...)
+	}
+	return out
+}
+
+func TestDeterministic(t *testing.T) {
+	for _, p := range params {
+		encode := func(p Params) []byte {
+			inst, sol, err := Generate(p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var b bytes.Buffer
+			WriteJSON(&b, inst)
+			WriteJSON(&b, sol)
+			return b.Bytes()
+		}
+		first := encode(p)
+		if !bytes.Equal(encode(p), first) {
+			t.Errorf("%s: the same seed gave another instance", p.Kind)
+		}
+		p.Seed += "'"
+		if bytes.Equal(encode(p), first) {
+			t.Errorf("%s: another seed gave the same instance", p.Kind)
+		}
+	}
+}
+
+// TestBound generates large instances at the smallest accepted bound,
+// where the planted solution still fits, and checks that a bound at
+// sigma*sqrt(coefficients), which nearly no draw fits, is refused.
+func TestBound(t *testing.T) {
+	for _, p := range []Params{
+		{Kind: LWE, Degree: 1, Rank: 256, Samples: 2048, Q: 3329, Sigma: 3},
+		{Kind: ModuleLWE, Degree: 256, Rank: 2, Samples: 8, Q: 3329, Sigma: 0.5},
+		{Kind: SIS, Degree: 1, Rank: 4096, Samples: 16, Q: 3329, Sigma: 1},
+		{Kind: SIS, Degree: 1, Rank: 1, Samples: 4, Q: 3329, Sigma: 1},
+	} {
+		coeffs := float64(p.Samples * p.Degree)
+		if p.Kind == SIS {
+			coeffs = float64(p.Rank)
+		}
+		p.Bound = math.Ceil(p.minBound())
+		for _, seed := range []string{"a", "b", "c"} {
+			p.Seed = seed
+			if _, _, err := Generate(p); err != nil {
+				t.Errorf("%s, %v coefficients, bound %v: %v", p.Kind, coeffs, p.Bound, err)
+			}
+		}
+		if p.Rank == 1 {
+			continue
+		}
+		p.Bound = p.Sigma * math.Sqrt(coeffs)
+		if _, _, err := Generate(p); err == nil || err == errDraws {
+			t.Errorf("%s: bound sigma*sqrt(%v) gave %v, want a parameter error", p.Kind, coeffs, err)
+		}
+	}
+	if _, _, err := Generate(Params{Kind: LWE, Rank: 4, Samples: 4, Q: 17, Sigma: 1, Bound: -1}); err == nil {
+		t.Error("accepted a negative bound")
+	}
+}
@@ -0,0 +1,77 @@
+package lwe
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrRejected is wrapped by every error Verify returns for a wrong answer,
+// as opposed to a malformed one.
+var ErrRejected = errors.New("lwe: candidate rejected")
+
+// Verify checks a candidate answer and returns the norm that was compared
+// with the bound.
+//
+// For the LWE kinds the candidate is a secret s; it is accepted when the
+// implied error e = b - A s, with centered coefficients, has norm at most
+// inst.Bound. Any such s is a valid answer, not only the hidden one. For
+// SIS the candidate is a vector z; it is accepted when z != 0, A z = 0
+// mod q and ||z|| <= inst.Bound.
+func Verify(inst *Instance, cand *Solution) (float64, error) {
+	if cand.Kind != "" && cand.Kind != inst.Kind {
+		return 0, fmt.Errorf("lwe: solution is for %s, challenge is %s", cand.Kind, inst.Kind)
+	}
+	if inst.Kind == SIS {
+		return verifySIS(inst, cand.Z)
+	}
+	if err := checkShape("s", [][][]int64{cand.S}, 1, inst.Rank, inst.Degree); err != nil {
+		return 0, err
+	}
+	e := Residual(inst, cand.S)
+	n := norm(e)
+	if n > inst.Bound {
+		return n, fmt.Errorf("%w: error norm %.2f exceeds bound %g", ErrRejected, n, inst.Bound)
+	}
+	return n, nil
+}
+
+// Residual returns b - A s with coefficients centered in (-q/2, q/2], the
+// error implied by a candidate secret s.
+func Residual(inst *Instance, s [][]int64) [][]int64 {
+	q := inst.Q
+	e := make([][]int64, inst.Samples)
+	for i := range e {
+		acc := make([]int64, inst.Degree)
+		for j := 0; j < inst.Rank; j++ {
+			addPoly(acc, mulPoly(inst.A[i][j], s[j], q), q)
+		}
+		e[i] = make([]int64, inst.Degree)
+		for c := range e[i] {
+			e[i][c] = center(inst.B[i][c]-acc[c], q)
+		}
+	}
+	return e
+}
+
+func verifySIS(inst *Instance, z [][]int64) (float64, error) {
+	if err := checkShape("z", [][][]int64{z}, 1, inst.Rank, 1); err != nil {
+		return 0, err
+	}
+	n := norm(z)
+	if n == 0 {
+		return 0, fmt.Errorf("%w: z is zero", ErrRejected)
+	}
+	for i, row := range inst.A {
+		acc := int64(0)
+		for j, a := range row {
+			acc = mod(acc+a[0]*mod(z[j][0], inst.Q), inst.Q)
+		}
+		if acc != 0 {
+			return n, fmt.Errorf("%w: row %d of A z is %d, not 0 mod q", ErrRejected, i, acc)
+		}
+	}
+	if n > inst.Bound {
+		return n, fmt.Errorf("%w: norm %.2f exceeds bound %g", ErrRejected, n, inst.Bound)
+	}
+	return n, nil
+}
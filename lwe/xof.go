@@ -0,0 +1,49 @@
+package lwe
+
+import (
+	"crypto/sha3"
+	"encoding/binary"
+	"math"
+)
+
+// stream turns a seed into reproducible random values.
+type stream struct {
+	xof *sha3.SHAKE
+}
+
+// newStream keys a SHAKE128 stream with a domain label and the seed, so
+// that different uses of the same seed are independent.
+func newStream(label, seed string) *stream {
+	h := sha3.NewSHAKE128()
+	h.Write([]byte("lattice-challenge/v1\x00" + label + "\x00"))
+	h.Write([]byte(seed))
+	return &stream{xof: h}
+}
+
+func (s *stream) uint64() uint64 {
+	var buf [8]byte
+	s.xof.Read(buf[:])
+	return binary.LittleEndian.Uint64(buf[:])
+}
+
+// uniform returns a uniform value in [0, q) by rejection sampling, which
+// avoids the bias of a plain reduction modulo q.
+func (s *stream) uniform(q int64) int64 {
+	limit := math.MaxUint64 - math.MaxUint64%uint64(q)
+	for {
+		if x := s.uint64(); x < limit {
+			return int64(x % uint64(q))
+		}
+	}
+}
+
+// float returns a uniform float64 in (0, 1).
+func (s *stream) float() float64 {
+	return (float64(s.uint64()>>11) + 0.5) / (1 << 53)
+}
+
+// gaussian returns round(sigma * N(0, 1)), using the Box–Muller transform.
+func (s *stream) gaussian(sigma float64) int64 {
+	r := math.Sqrt(-2 * math.Log(s.float()))
+	return int64(math.Round(sigma * r * math.Cos(2*math.Pi*s.float())))
+}
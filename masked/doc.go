@@ -0,0 +1,32 @@
+// Package masked is a first-order masked ML-KEM decapsulation, with a
+// simulated leakage model to test it.
+//
+// Every secret is split into two shares whose combination is the value:
+// s-hat in arithmetic shares modulo q, z, the message and the hash
+// states in Boolean shares, x = x0 ^ x1. The shares are refreshed before
+// every decapsulation. Linear steps work share by share: the NTT and its
+// inverse, the products with the public u-hat, A-hat and t-hat, and the
+// additions of the noise. The rest needs gadgets that never combine the
+// two shares of a value:
+//
+//   - Compression takes arithmetic shares to Boolean shares of the top d
+//     bits, by scaling each share to 2^32 / q and Goubin's A2B
+//     conversion, as in Bos et al., "Masking Kyber" (TCHES 2021).
+//   - Keccak is masked in the Boolean domain, its only nonlinear step,
+//     chi, through the ISW AND gadget; it gives G, J and the PRF.
+//   - The centered binomial sampler converts the PRF bits to arithmetic
+//     shares one by one, with a B2A conversion of single bits, and so
+//     does the decoding of the message for the re-encryption.
+//   - The comparison of the re-encryption with the ciphertext ORs the
+//     Boolean shares of the differences together and unmasks a single
+//     bit, which is public for a valid ciphertext.
+//
+// The leakage model is that of package sca: every intermediate value
+// that depends on a secret leaks its Hamming weight plus Gaussian noise,
+// one sample each. Assess runs the fixed-versus-random t-test of the
+// TVLA methodology on this leakage, for the masked code or for the same
+// code with every mask zero, which leaks the message at once. Masking of
+// order one only defeats first-order analysis: the product of the
+// samples of two shares still depends on the secret, and higher orders
+// need more shares.
+package masked
@@ -0,0 +1,131 @@
+package masked
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// The masked Keccak-f[1600] keeps two Boolean shares of the state. Theta,
+// rho, pi and iota are linear and apply to each share; chi is the only
+// nonlinear step, a ^ (^b & c) on lanes, whose AND is computed by and.
+
+var roundConstants = [24]uint64{
+	0x0000000000000001, 0x0000000000008082, 0x800000000000808a, 0x8000000080008000,
+	0x000000000000808b, 0x0000000080000001, 0x8000000080008081, 0x8000000000008009,
+	0x000000000000008a, 0x0000000000000088, 0x0000000080008009, 0x000000008000000a,
+	0x000000008000808b, 0x800000000000008b, 0x8000000000008089, 0x8000000000008003,
+	0x8000000000008002, 0x8000000000000080, 0x000000000000800a, 0x800000008000000a,
+	0x8000000080008081, 0x8000000000008080, 0x0000000080000001, 0x8000000080008008,
+}
+
+// rotations[x+5y] is the rho offset of lane (x, y).
+var rotations = [25]int{
+	0, 1, 62, 28, 27,
+	36, 44, 6, 55, 20,
+	3, 10, 43, 25, 39,
+	41, 45, 15, 21, 8,
+	18, 2, 61, 56, 14,
+}
+
+type state [2][25]uint64
+
+func (s *state) permute(m masker, l *leaker) {
+	for _, rc := range roundConstants {
+		var b [2][25]uint64
+		for k := range s {
+			a := &s[k]
+			// theta
+			var c, d [5]uint64
+			for x := range 5 {
+				c[x] = a[x] ^ a[x+5] ^ a[x+10] ^ a[x+15] ^ a[x+20]
+				l.leak(c[x])
+			}
+			for x := range 5 {
+				d[x] = c[(x+4)%5] ^ bits.RotateLeft64(c[(x+1)%5], 1)
+				l.leak(d[x])
+			}
+			for i := range a {
+				a[i] ^= d[i%5]
+				l.leak(a[i])
+			}
+			// rho and pi: (x, y) moves to (y, 2x + 3y)
+			for x := range 5 {
+				for y := range 5 {
+					b[k][y+5*((2*x+3*y)%5)] = bits.RotateLeft64(a[x+5*y], rotations[x+5*y])
+				}
+			}
+		}
+		// chi
+		for y := 0; y < 25; y += 5 {
+			for x := range 5 {
+				i, j := y+(x+1)%5, y+(x+2)%5
+				n0, n1 := and(m, l, ^b[0][i], b[1][i], b[0][j], b[1][j])
+				s[0][y+x] = b[0][y+x] ^ n0
+				s[1][y+x] = b[1][y+x] ^ n1
+				l.leak(s[0][y+x])
+				l.leak(s[1][y+x])
+			}
+		}
+		// iota
+		s[0][0] ^= rc
+	}
+}
+
+// refresh adds a fresh random mask to both shares of every lane.
+func (s *state) refresh(m masker, l *leaker) {
+	for i := range s[0] {
+		r := m.u64()
+		s[0][i] ^= r
+		s[1][i] ^= r
+		l.leak(s[0][i])
+		l.leak(s[1][i])
+	}
+}
+
+// sponge returns the shares of the Keccak sponge of the shared input in,
+// with the given rate and domain separation byte: 0x06 for SHA3 and 0x1f
+// for SHAKE. Public input goes in share 0, with zeros in share 1. The
+// state is refreshed after every absorbed block, since a block of public
+// bytes alone leaves a lane unmasked.
+func sponge(m masker, l *leaker, rate int, ds byte, in [2][]byte, n int) [2][]byte {
+	var s state
+	var padded [2][]byte
+	for k := range in {
+		padded[k] = append([]byte(nil), in[k]...)
+		pad := rate - len(in[k])%rate
+		padded[k] = append(padded[k], make([]byte, pad)...)
+	}
+	padded[0][len(in[0])] ^= ds
+	padded[0][len(padded[0])-1] ^= 0x80
+	for off := 0; off < len(padded[0]); off += rate {
+		for k := range s {
+			for i := 0; i < rate/8; i++ {
+				s[k][i] ^= binary.LittleEndian.Uint64(padded[k][off+8*i:])
+			}
+		}
+		s.refresh(m, l)
+		s.permute(m, l)
+	}
+	var out [2][]byte
+	for len(out[0]) < n {
+		for k := range s {
+			for i := 0; i < rate/8; i++ {
+				out[k] = binary.LittleEndian.AppendUint64(out[k], s[k][i])
+			}
+		}
+		if len(out[0]) < n {
+			s.permute(m, l)
+		}
+	}
+	out[0], out[1] = out[0][:n], out[1][:n]
+	return out
+}
+
+// sha3512 and shake256 are the masked G, PRF and J of ML-KEM.
+func sha3512(m masker, l *leaker, in [2][]byte) [2][]byte {
+	return sponge(m, l, 72, 0x06, in, 64)
+}
+
+func shake256(m masker, l *leaker, in [2][]byte, n int) [2][]byte {
+	return sponge(m, l, 136, 0x1f, in, n)
+}
@@ -0,0 +1,321 @@
+package masked
+
+import (
+	"crypto/sha3"
+	"crypto/subtle"
+	"errors"
+
+	"github.com/haopining/Learn-Lattice-Based-Cryptography/encoding"
+	"github.com/haopining/Learn-Lattice-Based-Cryptography/gauss"
+	"github.com/haopining/Learn-Lattice-Based-Cryptography/mlkem"
+)
+
+// DecapsulationKey is an ML-KEM private key kept in two shares: s-hat in
+// arithmetic shares modulo q and the rejection seed z in Boolean shares,
+// both refreshed before every decapsulation.
+type DecapsulationKey struct {
+	p *mlkem.Params
+	s [][2]mlkem.Poly
+	z [2][]byte
+	m masker
+	// The public key: t-hat, A-hat and H(ek).
+	ek *mlkem.EncapsulationKey
+	t  []mlkem.Poly
+	a  [][]mlkem.Poly
+	h  [32]byte
+}
+
+var (
+	errCiphertextSize = errors.New("masked: invalid ciphertext size")
+	errKey            = errors.New("masked: invalid decapsulation key")
+)
+
+// New splits dk into shares, with r as the source of every mask. A nil r
+// turns the masking off: every mask is zero and share 0 holds the secret
+// values themselves, the unprotected reference of the leakage assessment.
+func New(dk *mlkem.DecapsulationKey, r *gauss.Rand) (*DecapsulationKey, error) {
+	p := dk.Params()
+	ek := dk.EncapsulationKey()
+	b := dk.ExpandedBytes()
+	mk := &DecapsulationKey{p: p, m: masker{r}, ek: ek, s: make([][2]mlkem.Poly, p.K)}
+	for i := range mk.s {
+		f, err := encoding.ByteDecode(12, b[384*i:384*(i+1)])
+		if err != nil {
+			return nil, errKey
+		}
+		copy(mk.s[i][0][:], f)
+		refresh(mk.m, nil, &mk.s[i])
+	}
+	z := b[len(b)-32:]
+	mk.z = [2][]byte{make([]byte, 32), make([]byte, 32)}
+	for i := range z {
+		x := byte(mk.m.u64())
+		mk.z[0][i], mk.z[1][i] = z[i]^x, x
+	}
+	raw := ek.Bytes()
+	mk.t = make([]mlkem.Poly, p.K)
+	for i := range mk.t {
+		f, err := encoding.ByteDecode(12, raw[384*i:384*(i+1)])
+		if err != nil {
+			return nil, errKey
+		}
+		copy(mk.t[i][:], f)
+	}
+	mk.a = mlkem.ExpandA(raw[384*p.K:], p.K, false, nil)
+	mk.h = sha3.Sum256(raw)
+	return mk, nil
+}
+
+// EncapsulationKey returns the public key.
+func (dk *DecapsulationKey) EncapsulationKey() *mlkem.EncapsulationKey { return dk.ek }
+
+// Decapsulate returns the shared key of a ciphertext, as
+// mlkem.DecapsulationKey.Decapsulate does. Only the shared key is
+// unmasked, on output, and the result of the comparison, which is no
+// secret: a valid ciphertext is expected to re-encrypt to itself.
+func (dk *DecapsulationKey) Decapsulate(c []byte) ([]byte, error) {
+	return dk.decapsulate(c, nil)
+}
+
+func (dk *DecapsulationKey) decapsulate(c []byte, l *leaker) ([]byte, error) {
+	p, m := dk.p, dk.m
+	if len(c) != p.CiphertextSize() {
+		return nil, errCiphertextSize
+	}
+	for i := range dk.s {
+		refresh(m, l, &dk.s[i])
+	}
+	for i := range dk.z[0] {
+		x := byte(m.u64())
+		dk.z[0][i] ^= x
+		dk.z[1][i] ^= x
+	}
+
+	// Decryption: w = v - InvNTT(s-hat^T u-hat), share by share, since
+	// the NTT and the products with the public u-hat are linear.
+	n := 32 * p.Du
+	uHat := make([]mlkem.Poly, p.K)
+	for i := range uHat {
+		uHat[i] = mlkem.NTT(decompress(p.Du, c[n*i:n*(i+1)]))
+	}
+	v := decompress(p.Dv, c[n*p.K:])
+	var w [2]mlkem.Poly
+	for k := range w {
+		var acc mlkem.Poly
+		for i := range uHat {
+			t := basemul(l, &dk.s[i][k], &uHat[i])
+			acc = acc.Add(&t)
+		}
+		w[k] = invNTT(l, acc)
+	}
+	w[0] = v.Sub(&w[0])
+	w[1] = new(mlkem.Poly).Sub(&w[1])
+	msg := [2][]byte{make([]byte, 32), make([]byte, 32)}
+	for i := range w[0] {
+		b0, b1 := compress(m, l, 1, w[0][i], w[1][i])
+		msg[0][i/8] |= byte(b0) << (i % 8)
+		msg[1][i/8] |= byte(b1) << (i % 8)
+	}
+
+	// (K, r) = G(m || H(ek)), and the re-encryption of m with coins r.
+	g := sha3512(m, l, [2][]byte{append(msg[0], dk.h[:]...), append(msg[1], make([]byte, 32)...)})
+	key := [2][]byte{g[0][:32], g[1][:32]}
+	coins := [2][]byte{g[0][32:], g[1][32:]}
+	diff := dk.encrypt(l, msg, coins, c)
+
+	// J(z || c), the implicit rejection key.
+	j := shake256(m, l, [2][]byte{append(dk.z[0], c...), append(dk.z[1], make([]byte, len(c))...)}, 32)
+	equal := int(1 - diff)
+	for k := range key {
+		subtle.ConstantTimeCopy(1-equal, key[k], j[k])
+	}
+	out := make([]byte, 32)
+	for i := range out {
+		out[i] = key[0][i] ^ key[1][i]
+	}
+	return out, nil
+}
+
+// encrypt re-encrypts the shared message with the shared coins and
+// compares the result with the ciphertext c, in shares; it returns 0 if
+// they are equal and 1 otherwise.
+func (dk *DecapsulationKey) encrypt(l *leaker, msg, coins [2][]byte, c []byte) uint64 {
+	p, m := dk.p, dk.m
+	var nonce byte
+	prf := func(eta int) [2]mlkem.Poly {
+		buf := shake256(m, l, [2][]byte{append(append([]byte(nil), coins[0]...), nonce), append(append([]byte(nil), coins[1]...), 0)}, 64*eta)
+		nonce++
+		return cbd(m, l, eta, buf)
+	}
+	y := make([][2]mlkem.Poly, p.K)
+	for i := range y {
+		y[i] = prf(p.Eta1)
+		for k := range y[i] {
+			y[i][k] = ntt(l, y[i][k])
+		}
+	}
+
+	// The comparison ORs the differences of the shared compressed values
+	// from those of c, coefficient by coefficient, into acc.
+	var acc [2]uint64
+	cmp := func(d int, f *[2]mlkem.Poly, b []byte) {
+		want, err := encoding.ByteDecode(d, b)
+		if err != nil {
+			panic(err)
+		}
+		for i := range f[0] {
+			b0, b1 := compress(m, l, d, f[0][i], f[1][i])
+			acc[0], acc[1] = or(m, l, acc[0], acc[1], uint64(b0^want[i]), uint64(b1))
+		}
+	}
+	n := 32 * p.Du
+	for i := range p.K {
+		e1 := prf(p.Eta2)
+		var u [2]mlkem.Poly
+		for k := range u {
+			var sum mlkem.Poly
+			for j := range p.K {
+				t := basemul(l, &y[j][k], &dk.a[j][i])
+				sum = sum.Add(&t)
+			}
+			u[k] = invNTT(l, sum)
+			u[k] = u[k].Add(&e1[k])
+		}
+		cmp(p.Du, &u, c[n*i:n*(i+1)])
+	}
+	e2 := prf(p.Eta2)
+	var v [2]mlkem.Poly
+	for k := range v {
+		var sum mlkem.Poly
+		for j := range p.K {
+			t := basemul(l, &y[j][k], &dk.t[j])
+			sum = sum.Add(&t)
+		}
+		v[k] = invNTT(l, sum)
+		v[k] = v[k].Add(&e2[k])
+	}
+	// Decompress_1(m): each bit, converted to arithmetic shares, times
+	// round(q/2).
+	for i := range v[0] {
+		a0, a1 := bitB2A(m, l, uint16(msg[0][i/8]>>(i%8)&1), uint16(msg[1][i/8]>>(i%8)&1))
+		v[0][i] = addq(v[0][i], mulq(a0, (q+1)/2))
+		v[1][i] = addq(v[1][i], mulq(a1, (q+1)/2))
+		l.leak16(v[0][i])
+		l.leak16(v[1][i])
+	}
+	cmp(p.Dv, &v, c[n*p.K:])
+
+	// Fold the bits of acc into bit 0 and unmask it.
+	for s := 32; s > 0; s /= 2 {
+		acc[0], acc[1] = or(m, l, acc[0], acc[1], acc[0]>>s, acc[1]>>s)
+	}
+	return (acc[0] ^ acc[1]) & 1
+}
+
+// cbd samples shares of a polynomial of CBD_eta from Boolean shares of
+// the PRF output: every bit is converted to arithmetic shares, and the
+// shares are added and subtracted as the bits are in SamplePolyCBD.
+func cbd(m masker, l *leaker, eta int, buf [2][]byte) [2]mlkem.Poly {
+	bit := func(k, i int) uint16 { return uint16(buf[k][i/8] >> (i % 8) & 1) }
+	var f [2]mlkem.Poly
+	for i := range f[0] {
+		for j := range 2 * eta {
+			at := 2*i*eta + j
+			a0, a1 := bitB2A(m, l, bit(0, at), bit(1, at))
+			if j < eta {
+				f[0][i], f[1][i] = addq(f[0][i], a0), addq(f[1][i], a1)
+			} else {
+				f[0][i], f[1][i] = subq(f[0][i], a0), subq(f[1][i], a1)
+			}
+			l.leak16(f[0][i])
+			l.leak16(f[1][i])
+		}
+	}
+	return f
+}
+
+func decompress(d int, b []byte) mlkem.Poly {
+	f, err := encoding.ByteDecode(d, b)
+	if err != nil {
+		panic(err)
+	}
+	var g mlkem.Poly
+	copy(g[:], encoding.DecompressPoly(d, f))
+	return g
+}
+
+// zetas[i] = 17^bitrev7(i) and gammas[i] = 17^(2 bitrev7(i) + 1), as in
+// package mlkem.
+var zetas, gammas = func() (z, g [128]uint16) {
+	pow := func(e int) uint16 {
+		r := uint16(1)
+		for range e {
+			r = mulq(r, 17)
+		}
+		return r
+	}
+	for i := range z {
+		rev := 0
+		for b := range 7 {
+			rev |= (i >> b & 1) << (6 - b)
+		}
+		z[i] = pow(rev)
+		g[i] = pow(2*rev + 1)
+	}
+	return z, g
+}()
+
+// ntt, invNTT and basemul are those of package mlkem, applied to one
+// share, leaking the outputs of every butterfly and product.
+func ntt(l *leaker, f mlkem.Poly) mlkem.Poly {
+	k := 1
+	for n := 128; n >= 2; n /= 2 {
+		for start := 0; start < mlkem.N; start += 2 * n {
+			zeta := zetas[k]
+			k++
+			for j := start; j < start+n; j++ {
+				t := mulq(zeta, f[j+n])
+				f[j+n] = subq(f[j], t)
+				f[j] = addq(f[j], t)
+				l.leak16(f[j+n])
+				l.leak16(f[j])
+			}
+		}
+	}
+	return f
+}
+
+func invNTT(l *leaker, f mlkem.Poly) mlkem.Poly {
+	k := 127
+	for n := 2; n <= 128; n *= 2 {
+		for start := 0; start < mlkem.N; start += 2 * n {
+			zeta := zetas[k]
+			k--
+			for j := start; j < start+n; j++ {
+				t := f[j]
+				f[j] = addq(t, f[j+n])
+				f[j+n] = mulq(zeta, subq(f[j+n], t))
+				l.leak16(f[j])
+				l.leak16(f[j+n])
+			}
+		}
+	}
+	for i := range f {
+		f[i] = mulq(f[i], 3303)
+		l.leak16(f[i])
+	}
+	return f
+}
+
+// basemul multiplies a share f by the public g.
+func basemul(l *leaker, f, g *mlkem.Poly) mlkem.Poly {
+	var h mlkem.Poly
+	for i := range mlkem.N / 2 {
+		a0, a1, b0, b1 := f[2*i], f[2*i+1], g[2*i], g[2*i+1]
+		h[2*i] = addq(mulq(a0, b0), mulq(mulq(a1, b1), gammas[i]))
+		h[2*i+1] = addq(mulq(a0, b1), mulq(a1, b0))
+		l.leak16(h[2*i])
+		l.leak16(h[2*i+1])
+	}
+	return h
+}
@@ -0,0 +1,179 @@
+package masked
+
+import (
+	"bytes"
+	"crypto/sha3"
+	"testing"
+
+	"github.com/haopining/Learn-Lattice-Based-Cryptography/encoding"
+	"github.com/haopining/Learn-Lattice-Based-Cryptography/gauss"
+	"github.com/haopining/Learn-Lattice-Based-Cryptography/mlkem"
+)
+
+func randomBytes(r *gauss.Rand, n int) []byte {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = byte(r.Uint64())
+	}
+	return b
+}
+
+// TestDecapsulate compares the masked decapsulation, with and without
+// masks, with package mlkem for every parameter set, on valid ciphertexts
+// and on ciphertexts with a flipped bit, which must yield the implicit
+// rejection key.
+func TestDecapsulate(t *testing.T) {
+	r := gauss.NewSHAKE([]byte("masked"))
+	for _, p := range []*mlkem.Params{mlkem.MLKEM512, mlkem.MLKEM768, mlkem.MLKEM1024} {
+		dk, err := mlkem.NewDecapsulationKey(p, randomBytes(r, mlkem.SeedSize))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, mr := range []*gauss.Rand{nil, r} {
+			mk, err := New(dk, mr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(mk.EncapsulationKey().Bytes(), dk.EncapsulationKey().Bytes()) {
+				t.Errorf("%v: the masked key has another encapsulation key", p)
+			}
+			for i := range 4 {
+				tampered := i%2 == 1
+				k, c := dk.EncapsulationKey().EncapsulateInternal(randomBytes(r, 32))
+				if tampered {
+					c[r.Below(uint64(len(c)))] ^= 1 << r.Below(8)
+				}
+				want, err := dk.Decapsulate(c)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if tampered == bytes.Equal(want, k) {
+					t.Fatalf("%v: mlkem decapsulation is wrong (tampered %v)", p, tampered)
+				}
+				got, err := mk.Decapsulate(c)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(got, want) {
+					t.Errorf("%v: decapsulation differs from mlkem (masked %v, tampered %v)", p, mr != nil, tampered)
+				}
+			}
+			if _, err := mk.Decapsulate(make([]byte, p.CiphertextSize()-1)); err == nil {
+				t.Errorf("%v: Decapsulate accepted a short ciphertext", p)
+			}
+		}
+	}
+}
+
+// TestGadgets checks that the gadgets compute on the shared values: the
+// ISW AND and OR, Goubin's A2B, the bit B2A, the masked compression and
+// the refresh of arithmetic shares.
+func TestGadgets(t *testing.T) {
+	r := gauss.NewSHAKE([]byte("gadgets"))
+	m := masker{r}
+	for range 1000 {
+		a0, a1, b0, b1 := r.Uint64(), r.Uint64(), r.Uint64(), r.Uint64()
+		if c0, c1 := and(m, nil, a0, a1, b0, b1); c0^c1 != (a0^a1)&(b0^b1) {
+			t.Fatalf("and(%x ^ %x, %x ^ %x) is wrong", a0, a1, b0, b1)
+		}
+		if c0, c1 := or(m, nil, a0, a1, b0, b1); c0^c1 != (a0^a1)|(b0^b1) {
+			t.Fatalf("or(%x ^ %x, %x ^ %x) is wrong", a0, a1, b0, b1)
+		}
+		x, s := uint32(r.Uint64()), uint32(r.Uint64())
+		if got := a2b(m, nil, x, s) ^ s; got != x+s {
+			t.Fatalf("a2b(%d + %d) = %d", x, s, got)
+		}
+	}
+	for _, x0 := range []uint16{0, 1} {
+		for _, x1 := range []uint16{0, 1} {
+			for range 10 {
+				if a0, a1 := bitB2A(m, nil, x0, x1); addq(a0, a1) != x0^x1 {
+					t.Errorf("bitB2A(%d ^ %d) = %d + %d", x0, x1, a0, a1)
+				}
+			}
+		}
+	}
+	for _, d := range []int{1, 4, 5, 10, 11} {
+		for w := range uint16(q) {
+			w1 := m.modq()
+			b0, b1 := compress(m, nil, d, subq(w, w1), w1)
+			if got, want := b0^b1, encoding.Compress(d, w); got != want {
+				t.Fatalf("compress(%d, %d) = %d, want %d", d, w, got, want)
+			}
+		}
+	}
+	var f [2]mlkem.Poly
+	for i := range f[0] {
+		f[0][i] = m.modq()
+	}
+	want := f[0]
+	refresh(m, nil, &f)
+	if f[0] == want || f[0].Add(&f[1]) != want {
+		t.Error("refresh did not re-randomize the shares of the same value")
+	}
+}
+
+// TestKeccak checks the masked SHA3-512 and SHAKE256 against crypto/sha3
+// on shared inputs, with and without masks.
+func TestKeccak(t *testing.T) {
+	r := gauss.NewSHAKE([]byte("keccak"))
+	for _, mr := range []*gauss.Rand{nil, r} {
+		m := masker{mr}
+		for _, n := range []int{0, 1, 64, 71, 72, 135, 136, 137, 1100} {
+			in, mask := randomBytes(r, n), randomBytes(r, n)
+			shared := make([]byte, n)
+			for i := range in {
+				shared[i] = in[i] ^ mask[i]
+			}
+			h := sha3512(m, nil, [2][]byte{bytes.Clone(shared), bytes.Clone(mask)})
+			want := sha3.Sum512(in)
+			if got := xor(h[0], h[1]); !bytes.Equal(got, want[:]) {
+				t.Errorf("sha3512 of %d bytes = %x, want %x", n, got, want)
+			}
+			s := shake256(m, nil, [2][]byte{bytes.Clone(shared), bytes.Clone(mask)}, 200)
+			if got, want := xor(s[0], s[1]), sha3.SumSHAKE256(in, 200); !bytes.Equal(got, want) {
+				t.Errorf("shake256 of %d bytes = %x, want %x", n, got, want)
+			}
+		}
+	}
+}
+
+func xor(a, b []byte) []byte {
+	c := make([]byte, len(a))
+	for i := range c {
+		c[i] = a[i] ^ b[i]
+	}
+	return c
+}
+
+// TestAssess runs the fixed-versus-random t-tests on a reduced number of
+// traces: the unmasked decapsulation must show leaks that repeat in two
+// tests, and the masked one none.
+func TestAssess(t *testing.T) {
+	if testing.Short() {
+		t.Skip("the t-tests take a few seconds")
+	}
+	r := gauss.NewSHAKE([]byte("tvla"))
+	dk, err := mlkem.NewDecapsulationKey(mlkem.MLKEM512, randomBytes(r, mlkem.SeedSize))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range []bool{false, true} {
+		var tt [2]*TTest
+		for i := range tt {
+			if tt[i], err = Assess(dk, m, 40, 1, r); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if tt[0].Traces() != [2]int{40, 40} || tt[0].Samples() != tt[1].Samples() {
+			t.Fatalf("masked %v: %v traces of %d and %d samples", m, tt[0].Traces(), tt[0].Samples(), tt[1].Samples())
+		}
+		leaks := Leaks(tt[0], tt[1], Threshold)
+		if m && len(leaks) > 0 {
+			t.Errorf("masked: %d leaking samples, the first %d", len(leaks), leaks[0])
+		}
+		if !m && len(leaks) == 0 {
+			t.Error("unmasked: no leaking samples")
+		}
+	}
+}
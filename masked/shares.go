@@ -0,0 +1,222 @@
+package masked
+
+import (
+	"math"
+	"math/bits"
+
+	"github.com/haopining/Learn-Lattice-Based-Cryptography/gauss"
+	"github.com/haopining/Learn-Lattice-Based-Cryptography/mlkem"
+)
+
+const q = mlkem.Q
+
+// A masker draws the fresh randomness of the gadgets. A masker without a
+// source draws zeros, which turns the masking off: share 1 of every value
+// is then zero and share 0 is the value itself.
+type masker struct {
+	r *gauss.Rand
+}
+
+func (m masker) u64() uint64 {
+	if m.r == nil {
+		return 0
+	}
+	return m.r.Uint64()
+}
+
+func (m masker) modq() uint16 {
+	if m.r == nil {
+		return 0
+	}
+	return uint16(m.r.Below(q))
+}
+
+// A leaker receives the simulated leakage of the shares: the Hamming
+// weight of every intermediate value that depends on a secret, plus
+// Gaussian noise. Public values, such as the ciphertext and its NTT, do
+// not leak, so that leakage is attributed to the secrets alone. A nil
+// leaker records nothing.
+type leaker struct {
+	sigma  float64
+	r      *gauss.Rand
+	sample func(i int, x float64)
+	i      int
+}
+
+func (l *leaker) leak(v uint64) {
+	if l == nil {
+		return
+	}
+	x := float64(bits.OnesCount64(v))
+	if l.sigma > 0 {
+		x += l.sigma * l.r.Normal()
+	}
+	l.sample(l.i, x)
+	l.i++
+}
+
+func (l *leaker) leak16(v uint16) { l.leak(uint64(v)) }
+
+func addq(a, b uint16) uint16 { return uint16((uint32(a) + uint32(b)) % q) }
+func subq(a, b uint16) uint16 { return uint16((uint32(a) + q - uint32(b)) % q) }
+func mulq(a, b uint16) uint16 { return uint16(uint32(a) * uint32(b) % q) }
+
+// and is the ISW multiplication of Boolean shares, first order: the
+// cross products a0 b1 and a1 b0 are folded in after a fresh mask, so
+// that no intermediate depends on both shares of one input.
+func and(m masker, l *leaker, a0, a1, b0, b1 uint64) (c0, c1 uint64) {
+	r := m.u64()
+	c0 = a0&b0 ^ r
+	l.leak(c0)
+	t := r ^ a0&b1
+	l.leak(t)
+	t ^= a1 & b0
+	l.leak(t)
+	c1 = t ^ a1&b1
+	l.leak(c1)
+	return c0, c1
+}
+
+// or is a | b = ^(^a & ^b); negating a value negates its share 0.
+func or(m masker, l *leaker, a0, a1, b0, b1 uint64) (c0, c1 uint64) {
+	c0, c1 = and(m, l, ^a0, a1, ^b0, b1)
+	return ^c0, c1
+}
+
+// a2b converts arithmetic shares modulo 2^32, x = a + r, into Boolean
+// shares, x = x' ^ r, by Goubin's algorithm (CHES 2001). Every value it
+// computes is masked by the random gamma or by r.
+func a2b(m masker, l *leaker, a, r uint32) uint32 {
+	g := uint32(m.u64())
+	t := 2 * g
+	x := g ^ r
+	o := g & x
+	l.leak(uint64(o))
+	x = t ^ a
+	l.leak(uint64(x))
+	g ^= x
+	g &= r
+	o ^= g
+	g = t & a
+	o ^= g
+	l.leak(uint64(o))
+	for range 31 {
+		g = t & r
+		g ^= o
+		t &= a
+		g ^= t
+		t = 2 * g
+		l.leak(uint64(t))
+	}
+	x ^= t
+	l.leak(uint64(x))
+	return x
+}
+
+// bitB2A converts the bit x = x0 ^ x1 into arithmetic shares modulo q.
+// With c = 1 - 2 x0, x = x0 + c x1 = (x0 + c r) + c (x1 - r) for a fresh
+// r; each term depends on one share and r only.
+func bitB2A(m masker, l *leaker, x0, x1 uint16) (a0, a1 uint16) {
+	r := m.modq()
+	c := subq(1, 2*x0)
+	t := subq(x1, r)
+	l.leak16(t)
+	a1 = mulq(c, t)
+	l.leak16(a1)
+	a0 = addq(x0, mulq(c, r))
+	l.leak16(a0)
+	return a0, a1
+}
+
+// compress returns Boolean shares of Compress_d(w) for w = w0 + w1 mod q.
+// Each share is scaled to 2^32 w_i / q on its own; their sum modulo 2^32
+// is 2^32 w / q up to a rounding error of one unit, far from the rounding
+// boundaries of the top d bits, so an A2B conversion followed by a shift
+// of both shares gives the compressed value exactly (as in Bos et al.,
+// "Masking Kyber", TCHES 2021).
+func compress(m masker, l *leaker, d int, w0, w1 uint16) (b0, b1 uint16) {
+	a := uint32(uint64(w0)<<32/q) + 1<<(31-d)
+	r := uint32(uint64(w1) << 32 / q)
+	l.leak(uint64(a))
+	l.leak(uint64(r))
+	x := a2b(m, l, a, r)
+	// r takes only q values, so the shares are refreshed before use.
+	f := uint32(m.u64())
+	x ^= f
+	r ^= f
+	l.leak(uint64(x))
+	l.leak(uint64(r))
+	return uint16(x >> (32 - d)), uint16(r >> (32 - d))
+}
+
+// refresh re-randomizes the arithmetic shares of a polynomial.
+func refresh(m masker, l *leaker, f *[2]mlkem.Poly) {
+	for i := range f[0] {
+		r := m.modq()
+		f[0][i] = addq(f[0][i], r)
+		f[1][i] = subq(f[1][i], r)
+		l.leak16(f[0][i])
+		l.leak16(f[1][i])
+	}
+}
+
+// A TTest accumulates Welch's t-test, sample by sample, between two
+// classes of traces of the same layout, with Welford's algorithm.
+type TTest struct {
+	n           [2]float64
+	mean, m2    [2][]float64
+	Sigma       float64 // noise added to every sample
+	noise       *gauss.Rand
+	class, size int
+}
+
+// T returns the largest |t| over the samples, and its sample.
+func (t *TTest) T() (maxT float64, at int) {
+	for i := range t.size {
+		if x := math.Abs(t.At(i)); x > maxT {
+			maxT, at = x, i
+		}
+	}
+	return maxT, at
+}
+
+// At returns the t statistic of sample i, or 0 for a sample that is
+// constant in both classes.
+func (t *TTest) At(i int) float64 {
+	if t.n[0] < 2 || t.n[1] < 2 {
+		return 0
+	}
+	v0 := t.m2[0][i] / (t.n[0] - 1)
+	v1 := t.m2[1][i] / (t.n[1] - 1)
+	if v0+v1 == 0 {
+		return 0
+	}
+	return (t.mean[0][i] - t.mean[1][i]) / math.Sqrt(v0/t.n[0]+v1/t.n[1])
+}
+
+// Samples returns the number of samples per trace.
+func (t *TTest) Samples() int { return t.size }
+
+// Traces returns the number of traces of each class.
+func (t *TTest) Traces() [2]int { return [2]int{int(t.n[0]), int(t.n[1])} }
+
+// leaker returns a leaker that adds one trace of the class c.
+func (t *TTest) leaker(c int) *leaker {
+	t.n[c]++
+	t.class = c
+	return &leaker{sigma: t.Sigma, r: t.noise, sample: t.push}
+}
+
+func (t *TTest) push(i int, x float64) {
+	c := t.class
+	if i >= len(t.mean[c]) {
+		for k := range t.mean {
+			t.mean[k] = append(t.mean[k], make([]float64, i+1-len(t.mean[k]))...)
+			t.m2[k] = append(t.m2[k], make([]float64, i+1-len(t.m2[k]))...)
+		}
+		t.size = i + 1
+	}
+	d := x - t.mean[c][i]
+	t.mean[c][i] += d / t.n[c]
+	t.m2[c][i] += d * (x - t.mean[c][i])
+}
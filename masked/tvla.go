@@ -0,0 +1,69 @@
+package masked
+
+import (
+	"github.com/haopining/Learn-Lattice-Based-Cryptography/gauss"
+	"github.com/haopining/Learn-Lattice-Based-Cryptography/mlkem"
+)
+
+// Threshold is the usual bound on |t| of the TVLA methodology: beyond
+// it, the two classes are taken to differ.
+const Threshold = 4.5
+
+// Assess runs a fixed-versus-random test on the decapsulation of dk, in
+// the Hamming weight model with noise of deviation sigma: n traces of
+// one fixed ciphertext and n of fresh ones, in random order, all valid
+// and under the same key. With masked false the decapsulation runs with
+// all masks zero, as an unprotected reference. r supplies the messages,
+// the masks and the noise.
+func Assess(dk *mlkem.DecapsulationKey, masked bool, n int, sigma float64, r *gauss.Rand) (*TTest, error) {
+	var mr *gauss.Rand
+	if masked {
+		mr = r
+	}
+	mk, err := New(dk, mr)
+	if err != nil {
+		return nil, err
+	}
+	ek := dk.EncapsulationKey()
+	message := func() []byte {
+		m := make([]byte, 32)
+		for i := range m {
+			m[i] = byte(r.Uint64())
+		}
+		return m
+	}
+	_, fixed := ek.EncapsulateInternal(message())
+	t := &TTest{Sigma: sigma, noise: r}
+	var left [2]int
+	left[0], left[1] = n, n
+	for left[0]+left[1] > 0 {
+		c := int(r.Bit())
+		if left[c] == 0 {
+			c = 1 - c
+		}
+		left[c]--
+		ct := fixed
+		if c == 1 {
+			_, ct = ek.EncapsulateInternal(message())
+		}
+		if _, err := mk.decapsulate(ct, t.leaker(c)); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// Leaks returns the samples where two independent tests of the same
+// layout both exceed the threshold, with the same sign. With a hundred
+// thousand samples a single test crosses 4.5 somewhere by chance alone;
+// the TVLA methodology confirms a leak only if it repeats.
+func Leaks(a, b *TTest, threshold float64) []int {
+	var at []int
+	for i := range min(a.size, b.size) {
+		x, y := a.At(i), b.At(i)
+		if x*y > 0 && min(x*x, y*y) > threshold*threshold {
+			at = append(at, i)
+		}
+	}
+	return at
+}
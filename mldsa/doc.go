@@ -0,0 +1,20 @@
+// Package mldsa implements the ML-DSA signature scheme (FIPS 204,
+// formerly CRYSTALS-Dilithium) at the three standard security levels.
+//
+// ML-DSA is a Fiat–Shamir signature over Module-LWE and Module-SIS in
+// R_q = Z_q[x]/(x^256 + 1) with q = 8380417. The public key is
+// t = A s_1 + s_2, of which only the high bits t_1 are published. To sign,
+// the signer commits to the high bits of w = A y for a random mask y,
+// hashes them with the message into a sparse challenge c with tau
+// coefficients +-1, and reveals z = y + c s_1. Rejection sampling throws
+// away every z whose coefficients could reveal s_1 (||z|| >= gamma1 -
+// beta) and every attempt where the verifier, who can only compute
+// A z - c t_1 2^d = w - c s_2 + c t_0, would round differently; the hint h
+// tells the verifier how to correct the carries caused by c t_0.
+//
+// Keys are generated from a 32-byte seed, which is also their PKCS #8
+// form. Signing is hedged by default (FIPS 204 mixes 32 fresh random bytes
+// into the mask seed) or deterministic, and messages are domain-separated
+// by a context string of up to 255 bytes. All encodings go through package
+// encoding, whose decoders reject non-canonical signatures.
+package mldsa
@@ -0,0 +1,444 @@
+package mldsa
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"io"
+
+	"github.com/haopining/Learn-Lattice-Based-Cryptography/encoding"
+)
+
+// SeedSize is the size of the private key seed xi.
+const SeedSize = 32
+
+// Params is an ML-DSA parameter set (FIPS 204, Table 1).
+type Params struct {
+	Name string
+	// K and L are the dimensions of A, Eta bounds the secret
+	// coefficients, Tau is the weight of the challenge and Lambda the
+	// collision strength, which sets the challenge hash to Lambda/4 bytes.
+	K, L, Eta, Tau, Lambda int
+	// Gamma1 bounds the mask y, Gamma2 is the low-order rounding range,
+	// Beta = Tau Eta bounds c s_1 and c s_2, and Omega is the maximum
+	// number of ones in the hint.
+	Gamma1, Gamma2, Beta uint32
+	Omega                int
+}
+
+// The three standard parameter sets.
+var (
+	MLDSA44 = &Params{Name: "ML-DSA-44", K: 4, L: 4, Eta: 2, Tau: 39, Lambda: 128,
+		Gamma1: 1 << 17, Gamma2: (Q - 1) / 88, Beta: 78, Omega: 80}
+	MLDSA65 = &Params{Name: "ML-DSA-65", K: 6, L: 5, Eta: 4, Tau: 49, Lambda: 192,
+		Gamma1: 1 << 19, Gamma2: (Q - 1) / 32, Beta: 196, Omega: 55}
+	MLDSA87 = &Params{Name: "ML-DSA-87", K: 8, L: 7, Eta: 2, Tau: 60, Lambda: 256,
+		Gamma1: 1 << 19, Gamma2: (Q - 1) / 32, Beta: 120, Omega: 75}
+)
+
+func (p *Params) String() string { return p.Name }
+
+// etaBits is the size of a packed secret coefficient, bitlen(2 eta).
+func (p *Params) etaBits() int { return 3 + p.Eta/4 }
+
+// zBits is the size of a packed response coefficient, 1 + bitlen(gamma1 - 1).
+func (p *Params) zBits() int {
+	if p.Gamma1 == 1<<19 {
+		return 20
+	}
+	return 18
+}
+
+// PublicKeySize returns 32 + 320 k.
+func (p *Params) PublicKeySize() int { return 32 + 320*p.K }
+
+// PrivateKeySize returns the size of the expanded private key of FIPS 204;
+// the seed form is always SeedSize bytes.
+func (p *Params) PrivateKeySize() int {
+	return 128 + 32*((p.K+p.L)*p.etaBits()+d*p.K)
+}
+
+// SignatureSize returns lambda/4 + 32 l zBits + omega + k.
+func (p *Params) SignatureSize() int {
+	return p.Lambda/4 + 32*p.L*p.zBits() + p.Omega + p.K
+}
+
+var (
+	// ErrInvalidSignature is returned by Verify for any signature that
+	// does not verify, whatever the reason.
+	ErrInvalidSignature = errors.New("mldsa: invalid signature")
+	errKeySize          = errors.New("mldsa: invalid key size")
+	errContext          = errors.New("mldsa: context longer than 255 bytes")
+)
+
+// PublicKey is an ML-DSA public key.
+type PublicKey struct {
+	p   *Params
+	raw []byte
+	a   [][]poly
+	t1  []poly // t_1 2^d, NTT domain
+	tr  []byte // H(pk)
+}
+
+// PrivateKey is an ML-DSA private key, kept with its seed.
+type PrivateKey struct {
+	seed   [SeedSize]byte
+	key    []byte // K, the signing randomness key
+	s1, s2 []poly // NTT domain
+	t0     []poly // NTT domain
+	// Coefficient forms, only for the expanded encoding.
+	s1c, s2c, t0c []poly
+	pub           *PublicKey
+}
+
+// GenerateKey returns a key for p from a fresh seed.
+func GenerateKey(p *Params) (*PrivateKey, error) {
+	seed := make([]byte, SeedSize)
+	if _, err := io.ReadFull(rand.Reader, seed); err != nil {
+		return nil, err
+	}
+	return NewPrivateKey(p, seed)
+}
+
+// NewPrivateKey expands the 32-byte seed xi into a key (FIPS 204,
+// Algorithm 6).
+func NewPrivateKey(p *Params, seed []byte) (*PrivateKey, error) {
+	if len(seed) != SeedSize {
+		return nil, errKeySize
+	}
+	sk := &PrivateKey{}
+	copy(sk.seed[:], seed)
+	ex := h(128, seed, []byte{byte(p.K), byte(p.L)})
+	rho, rhoPrime := ex[:32], ex[32:96]
+	sk.key = ex[96:]
+	pk := &PublicKey{p: p, a: expandA(rho, p.K, p.L)}
+	sk.s1c, sk.s2c = expandS(rhoPrime, p.Eta, p.K, p.L)
+	sk.s1 = nttVec(sk.s1c)
+	sk.s2 = nttVec(sk.s2c)
+	pk.raw = append(pk.raw, rho...)
+	for i := range p.K {
+		t := invNTT(dot(pk.a[i], sk.s1))
+		t = t.add(&sk.s2c[i])
+		var t1, t0 poly
+		for j, x := range t {
+			hi, lo := power2Round(x)
+			t1[j], t0[j] = hi, fieldFromInt(lo)
+		}
+		pk.raw = append(pk.raw, packT1(&t1)...)
+		pk.t1 = append(pk.t1, ntt(shiftT1(&t1)))
+		sk.t0c = append(sk.t0c, t0)
+		sk.t0 = append(sk.t0, ntt(t0))
+	}
+	pk.tr = h(64, pk.raw)
+	sk.pub = pk
+	return sk, nil
+}
+
+// NewPublicKey parses an encoded public key rho || t_1.
+func NewPublicKey(p *Params, b []byte) (*PublicKey, error) {
+	if len(b) != p.PublicKeySize() {
+		return nil, errKeySize
+	}
+	pk := &PublicKey{p: p, raw: append([]byte(nil), b...), a: expandA(b[:32], p.K, p.L)}
+	for i := range p.K {
+		w, err := encoding.SimpleBitUnpack(b[32+320*i:32+320*(i+1)], 1<<10-1, N)
+		if err != nil {
+			return nil, err
+		}
+		var t1 poly
+		for j, x := range w {
+			t1[j] = uint32(x)
+		}
+		pk.t1 = append(pk.t1, ntt(shiftT1(&t1)))
+	}
+	pk.tr = h(64, pk.raw)
+	return pk, nil
+}
+
+// Params returns the parameter set of the key.
+func (pk *PublicKey) Params() *Params { return pk.p }
+
+// Bytes returns the encoded key.
+func (pk *PublicKey) Bytes() []byte { return append([]byte(nil), pk.raw...) }
+
+// Equal reports whether pk and x are the same key.
+func (pk *PublicKey) Equal(x crypto.PublicKey) bool {
+	xx, ok := x.(*PublicKey)
+	return ok && pk.p == xx.p && bytes.Equal(pk.raw, xx.raw)
+}
+
+// Params returns the parameter set of the key.
+func (sk *PrivateKey) Params() *Params { return sk.pub.p }
+
+// Bytes returns the seed xi, the private key format of the PKCS #8
+// encodings.
+func (sk *PrivateKey) Bytes() []byte { return append([]byte(nil), sk.seed[:]...) }
+
+// PublicKey returns the public key.
+func (sk *PrivateKey) PublicKey() *PublicKey { return sk.pub }
+
+// Public returns the public key, for crypto.Signer.
+func (sk *PrivateKey) Public() crypto.PublicKey { return sk.pub }
+
+// Equal reports whether sk and x are the same key, derived from the same
+// seed.
+func (sk *PrivateKey) Equal(x crypto.PrivateKey) bool {
+	xx, ok := x.(*PrivateKey)
+	return ok && sk.pub.p == xx.pub.p && subtle.ConstantTimeCompare(sk.seed[:], xx.seed[:]) == 1
+}
+
+// ExpandedBytes returns the private key in the format of FIPS 204
+// (Algorithm 24), rho || K || tr || s_1 || s_2 || t_0.
+func (sk *PrivateKey) ExpandedBytes() []byte {
+	p := sk.pub.p
+	b := append([]byte(nil), sk.pub.raw[:32]...)
+	b = append(b, sk.key...)
+	b = append(b, sk.pub.tr...)
+	eta := uint32(p.Eta)
+	for _, s := range append(append([]poly(nil), sk.s1c...), sk.s2c...) {
+		b = append(b, pack(&s, eta, eta)...)
+	}
+	for i := range sk.t0c {
+		b = append(b, pack(&sk.t0c[i], 1<<(d-1)-1, 1<<(d-1))...)
+	}
+	return b
+}
+
+// SignWithContext signs message under the context string, at most 255
+// bytes (FIPS 204, Algorithm 2). The signature is hedged with 32 bytes
+// from rand, or deterministic if rand is nil.
+func (sk *PrivateKey) SignWithContext(rand io.Reader, message, context []byte) ([]byte, error) {
+	if len(context) > 255 {
+		return nil, errContext
+	}
+	rnd := make([]byte, 32)
+	if rand != nil {
+		if _, err := io.ReadFull(rand, rnd); err != nil {
+			return nil, err
+		}
+	}
+	return sk.signInternal(formatMessage(message, context), rnd), nil
+}
+
+// Options are the signing options of Sign. The zero value, or nil, signs
+// with the empty context.
+type Options struct {
+	// Context is the context string, at most 255 bytes.
+	Context string
+}
+
+// HashFunc returns 0: ML-DSA signs the message itself, not a digest of
+// it. Pre-hashed HashML-DSA is not supported.
+func (o *Options) HashFunc() crypto.Hash { return 0 }
+
+// Sign implements crypto.Signer. Like Ed25519, ML-DSA hashes the message
+// itself, so message is the full message and opts must be nil, an
+// *Options, or have HashFunc() == 0. The signature is hedged with rand, or
+// deterministic if rand is nil.
+func (sk *PrivateKey) Sign(rand io.Reader, message []byte, opts crypto.SignerOpts) ([]byte, error) {
+	var context string
+	switch o := opts.(type) {
+	case *Options:
+		if o != nil {
+			context = o.Context
+		}
+	case nil:
+	default:
+		if o.HashFunc() != 0 {
+			return nil, errors.New("mldsa: cannot sign a pre-hashed message")
+		}
+	}
+	return sk.SignWithContext(rand, message, []byte(context))
+}
+
+// formatMessage returns M' = 0 || len(ctx) || ctx || M, the domain
+// separation of pure (not pre-hashed) ML-DSA.
+func formatMessage(message, context []byte) []byte {
+	m := append([]byte{0, byte(len(context))}, context...)
+	return append(m, message...)
+}
+
+// signInternal is ML-DSA.Sign_internal (FIPS 204, Algorithm 7): the
+// Fiat–Shamir with aborts loop. Each attempt commits to w = A y for a
+// fresh mask y, derives the challenge c from its high bits, and answers
+// z = y + c s_1; an answer that would leak s_1 or s_2 through its range,
+// or whose hint would be too large, is thrown away.
+func (sk *PrivateKey) signInternal(mprime, rnd []byte) []byte {
+	p, pk := sk.pub.p, sk.pub
+	mu := h(64, pk.tr, mprime)
+	rhoPP := h(64, sk.key, rnd, mu)
+	for kappa := 0; ; kappa += p.L {
+		y := p.expandMask(rhoPP, kappa)
+		yHat := nttVec(y)
+		w := make([]poly, p.K)
+		var w1 []byte
+		for i := range w {
+			w[i] = invNTT(dot(pk.a[i], yHat))
+			w1 = append(w1, p.packW1(&w[i])...)
+		}
+		cTilde := h(p.Lambda/4, mu, w1)
+		c := ntt(sampleInBall(cTilde, p.Tau))
+
+		z := make([]poly, p.L)
+		ok := true
+		for j := range z {
+			cs1 := invNTT(c.mulNTT(&sk.s1[j]))
+			z[j] = y[j].add(&cs1)
+			ok = ok && z[j].infNorm() < p.Gamma1-p.Beta
+		}
+		hint := make([][]int32, p.K)
+		ones := 0
+		for i := 0; ok && i < p.K; i++ {
+			cs2 := invNTT(c.mulNTT(&sk.s2[i]))
+			r := w[i].sub(&cs2)
+			ct0 := invNTT(c.mulNTT(&sk.t0[i]))
+			ok = ok && ct0.infNorm() < p.Gamma2
+			hint[i] = make([]int32, N)
+			for j := range r {
+				lo := lowBits(r[j], p.Gamma2)
+				if lo < 0 {
+					lo = -lo
+				}
+				ok = ok && uint32(lo) < p.Gamma2-p.Beta
+				// MakeHint(-ct0, w - cs2 + ct0).
+				hint[i][j] = makeHint(fieldSub(0, ct0[j]), fieldAdd(r[j], ct0[j]), p.Gamma2)
+				ones += int(hint[i][j])
+			}
+		}
+		if !ok || ones > p.Omega {
+			continue
+		}
+		sig := append([]byte(nil), cTilde...)
+		for j := range z {
+			sig = append(sig, pack(&z[j], p.Gamma1-1, p.Gamma1)...)
+		}
+		hb, err := encoding.HintBitPack(hint, p.Omega)
+		if err != nil {
+			panic(err)
+		}
+		return append(sig, hb...)
+	}
+}
+
+// Verify reports whether sig is a valid signature of message under the
+// context string (FIPS 204, Algorithms 3 and 8).
+func Verify(pk *PublicKey, message, sig, context []byte) error {
+	p := pk.p
+	if len(context) > 255 || len(sig) != p.SignatureSize() {
+		return ErrInvalidSignature
+	}
+	cTilde := sig[:p.Lambda/4]
+	zLen := 32 * p.zBits()
+	z := make([]poly, p.L)
+	for j := range z {
+		off := p.Lambda/4 + zLen*j
+		w, err := encoding.BitUnpack(sig[off:off+zLen], p.Gamma1-1, p.Gamma1, N)
+		if err != nil {
+			return ErrInvalidSignature
+		}
+		for i, x := range w {
+			z[j][i] = fieldFromInt(x)
+		}
+		if z[j].infNorm() >= p.Gamma1-p.Beta {
+			return ErrInvalidSignature
+		}
+	}
+	hint, err := encoding.HintBitUnpack(sig[p.Lambda/4+zLen*p.L:], p.K, p.Omega)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	mu := h(64, pk.tr, formatMessage(message, context))
+	c := ntt(sampleInBall(cTilde, p.Tau))
+	zHat := nttVec(z)
+	var w1 []byte
+	for i := range p.K {
+		ct1 := c.mulNTT(&pk.t1[i])
+		az := dot(pk.a[i], zHat)
+		approx := az.sub(&ct1)
+		wApprox := invNTT(approx)
+		var hi poly
+		for j := range hi {
+			hi[j] = useHint(hint[i][j], wApprox[j], p.Gamma2)
+		}
+		w1 = append(w1, p.packHigh(&hi)...)
+	}
+	if subtle.ConstantTimeCompare(cTilde, h(p.Lambda/4, mu, w1)) != 1 {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+func nttVec(v []poly) []poly {
+	out := make([]poly, len(v))
+	for i := range v {
+		out[i] = ntt(v[i])
+	}
+	return out
+}
+
+// dot returns sum_j a_j b_j in the NTT domain.
+func dot(a, b []poly) poly {
+	var acc poly
+	for j := range a {
+		t := a[j].mulNTT(&b[j])
+		acc = acc.add(&t)
+	}
+	return acc
+}
+
+// shiftT1 returns t_1 2^d.
+func shiftT1(t1 *poly) poly {
+	var f poly
+	for i, x := range t1 {
+		f[i] = x << d
+	}
+	return f
+}
+
+func packT1(t1 *poly) []byte {
+	w := make([]int32, N)
+	for i, x := range t1 {
+		w[i] = int32(x)
+	}
+	b, err := encoding.SimpleBitPack(w, 1<<10-1)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// pack returns BitPack of the centered coefficients of f in [-a, b].
+func pack(f *poly, a, b uint32) []byte {
+	w := make([]int32, N)
+	for i, x := range f {
+		w[i] = centered(x)
+	}
+	out, err := encoding.BitPack(w, a, b)
+	if err != nil {
+		panic(err)
+	}
+	return out
+}
+
+// packW1 returns w1Encode(HighBits(w)) (FIPS 204, Algorithm 28).
+func (p *Params) packW1(w *poly) []byte {
+	var hi poly
+	for i, x := range w {
+		hi[i] = highBits(x, p.Gamma2)
+	}
+	return p.packHigh(&hi)
+}
+
+func (p *Params) packHigh(hi *poly) []byte {
+	w := make([]int32, N)
+	for i, x := range hi {
+		w[i] = int32(x)
+	}
+	b, err := encoding.SimpleBitPack(w, (Q-1)/(2*p.Gamma2)-1)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
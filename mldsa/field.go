@@ -0,0 +1,134 @@
+package mldsa
+
+// N is the degree of the ring and Q its modulus, 2^23 - 2^13 + 1.
+const (
+	N = 256
+	Q = 8380417
+)
+
+// poly is an element of R_q = Z_q[x]/(x^256 + 1), in the coefficient or
+// the NTT domain, with coefficients in [0, q).
+type poly [N]uint32
+
+// Unlike ML-KEM, q = 1 mod 512, so x^256 + 1 splits completely and the NTT
+// runs all eight layers down to single coefficients: products in the NTT
+// domain are coefficient-wise.
+
+// The reductions are branch-free. A product of two values below q fits in
+// 46 bits, and % by the constant q compiles to a multiplication and a
+// shift, without a division instruction.
+
+func fieldAdd(a, b uint32) uint32 { return condSub(a + b) }
+
+func fieldSub(a, b uint32) uint32 { return condSub(a + Q - b) }
+
+func fieldMul(a, b uint32) uint32 { return uint32(uint64(a) * uint64(b) % Q) }
+
+// condSub returns a - q if a >= q and a otherwise, for a < 2q.
+func condSub(a uint32) uint32 {
+	a -= Q
+	return a + (Q & -(a >> 31))
+}
+
+// fieldFromInt maps a signed value in (-q, q) to [0, q).
+func fieldFromInt(a int32) uint32 {
+	return uint32(a) + (Q & -(uint32(a) >> 31))
+}
+
+// centered maps a in [0, q) to the representative in (-q/2, q/2],
+// written a mod+- q in FIPS 204.
+func centered(a uint32) int32 {
+	x := int32(a)
+	return x - int32(Q&-((uint32(Q/2)-a)>>31))
+}
+
+// infNorm returns the largest |a mod+- q| of f.
+func (f *poly) infNorm() uint32 {
+	var m uint32
+	for _, a := range f {
+		x := centered(a)
+		// |x| without branching.
+		s := x >> 31
+		v := uint32((x ^ s) - s)
+		m = max(m, v)
+	}
+	return m
+}
+
+func (f *poly) add(g *poly) poly {
+	var h poly
+	for i := range h {
+		h[i] = fieldAdd(f[i], g[i])
+	}
+	return h
+}
+
+func (f *poly) sub(g *poly) poly {
+	var h poly
+	for i := range h {
+		h[i] = fieldSub(f[i], g[i])
+	}
+	return h
+}
+
+// mulNTT returns the coefficient-wise product of two NTT-domain elements.
+func (f *poly) mulNTT(g *poly) poly {
+	var h poly
+	for i := range h {
+		h[i] = fieldMul(f[i], g[i])
+	}
+	return h
+}
+
+// zetas[k] = 1753^bitrev8(k), 1753 being a primitive 512-th root of unity.
+var zetas = func() (z [N]uint32) {
+	for k := range z {
+		var r uint32
+		for b := 0; b < 8; b++ {
+			r |= uint32(k>>b&1) << (7 - b)
+		}
+		z[k] = 1
+		for range r {
+			z[k] = fieldMul(z[k], 1753)
+		}
+	}
+	return z
+}()
+
+// ntt is FIPS 204, Algorithm 41.
+func ntt(f poly) poly {
+	m := 0
+	for l := 128; l >= 1; l /= 2 {
+		for start := 0; start < N; start += 2 * l {
+			m++
+			z := zetas[m]
+			for j := start; j < start+l; j++ {
+				t := fieldMul(z, f[j+l])
+				f[j+l] = fieldSub(f[j], t)
+				f[j] = fieldAdd(f[j], t)
+			}
+		}
+	}
+	return f
+}
+
+// invNTT is FIPS 204, Algorithm 42, ending with the scaling by
+// 256^-1 = 8347681.
+func invNTT(f poly) poly {
+	m := N
+	for l := 1; l < N; l *= 2 {
+		for start := 0; start < N; start += 2 * l {
+			m--
+			z := Q - zetas[m]
+			for j := start; j < start+l; j++ {
+				t := f[j]
+				f[j] = fieldAdd(t, f[j+l])
+				f[j+l] = fieldMul(z, fieldSub(t, f[j+l]))
+			}
+		}
+	}
+	for i := range f {
+		f[i] = fieldMul(f[i], 8347681)
+	}
+	return f
+}
@@ -0,0 +1,129 @@
+//go:build go1.27
+
+package mldsa
+
+import (
+	"bytes"
+	std "crypto/mldsa"
+	"crypto/rand"
+	"crypto/sha3"
+	"testing"
+)
+
+// TestStandardLibrary checks keys and deterministic signatures against
+// crypto/mldsa, which needs Go 1.27 (hence the build constraint of the
+// file), and that each side verifies the hedged signatures of the other.
+func TestStandardLibrary(t *testing.T) {
+	for _, tc := range []struct {
+		p   *Params
+		ref std.Parameters
+	}{
+		{MLDSA44, std.MLDSA44()},
+		{MLDSA65, std.MLDSA65()},
+		{MLDSA87, std.MLDSA87()},
+	} {
+		t.Run(tc.p.Name, func(t *testing.T) {
+			for i := range 10 {
+				buf := sha3.SumSHAKE128([]byte{tc.p.Name[6], byte(i)}, SeedSize+64)
+				seed, msg := buf[:SeedSize], buf[SeedSize:SeedSize+i]
+				ctx := string(buf[SeedSize+32 : SeedSize+32+i%4])
+				sk, err := NewPrivateKey(tc.p, seed)
+				if err != nil {
+					t.Fatal(err)
+				}
+				ref, err := std.NewPrivateKey(tc.ref, seed)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(sk.PublicKey().Bytes(), ref.PublicKey().Bytes()) {
+					t.Fatalf("seed %x: public keys differ", seed)
+				}
+				sig, err := sk.Sign(nil, msg, &Options{Context: ctx})
+				if err != nil {
+					t.Fatal(err)
+				}
+				refSig, err := ref.SignDeterministic(msg, &std.Options{Context: ctx})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(sig, refSig) {
+					t.Fatalf("seed %x, message %x: deterministic signatures differ", seed, msg)
+				}
+
+				sig, err = sk.Sign(rand.Reader, msg, &Options{Context: ctx})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := std.Verify(ref.PublicKey(), msg, sig, &std.Options{Context: ctx}); err != nil {
+					t.Fatalf("crypto/mldsa rejects a hedged signature: %v", err)
+				}
+				refSig, err = ref.Sign(nil, msg, &std.Options{Context: ctx})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := Verify(sk.PublicKey(), msg, refSig, []byte(ctx)); err != nil {
+					t.Fatalf("rejects a hedged signature of crypto/mldsa: %v", err)
+				}
+			}
+		})
+	}
+}
+
+// TestVerifyRejects checks that a signature fails under any change to the
+// message, the context, the key or the signature itself.
+func TestVerifyRejects(t *testing.T) {
+	for _, p := range []*Params{MLDSA44, MLDSA65, MLDSA87} {
+		sk, err := GenerateKey(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		other, err := GenerateKey(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		msg, ctx := []byte("message"), []byte("context")
+		sig, err := sk.SignWithContext(rand.Reader, msg, ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(sig) != p.SignatureSize() {
+			t.Errorf("%v: signature of %d bytes, want %d", p, len(sig), p.SignatureSize())
+		}
+		if err := Verify(sk.PublicKey(), msg, sig, ctx); err != nil {
+			t.Fatalf("%v: %v", p, err)
+		}
+		pk, err := NewPublicKey(p, sk.PublicKey().Bytes())
+		if err != nil || !pk.Equal(sk.PublicKey()) {
+			t.Errorf("%v: public key round trip failed: %v", p, err)
+		}
+		if n := len(sk.ExpandedBytes()); n != p.PrivateKeySize() {
+			t.Errorf("%v: expanded key of %d bytes, want %d", p, n, p.PrivateKeySize())
+		}
+		for _, bad := range []struct {
+			name          string
+			pk            *PublicKey
+			msg, sig, ctx []byte
+		}{
+			{"message", sk.PublicKey(), []byte("messagf"), sig, ctx},
+			{"context", sk.PublicKey(), msg, sig, nil},
+			{"key", other.PublicKey(), msg, sig, ctx},
+			{"challenge", sk.PublicKey(), msg, flip(sig, 0), ctx},
+			{"response", sk.PublicKey(), msg, flip(sig, p.Lambda/4+100), ctx},
+			{"hint", sk.PublicKey(), msg, flip(sig, len(sig)-1), ctx},
+			{"length", sk.PublicKey(), msg, sig[1:], ctx},
+		} {
+			if err := Verify(bad.pk, bad.msg, bad.sig, bad.ctx); err != ErrInvalidSignature {
+				t.Errorf("%v: changed %s: %v", p, bad.name, err)
+			}
+		}
+		if _, err := sk.SignWithContext(nil, msg, make([]byte, 256)); err == nil {
+			t.Errorf("%v: signed with a context of 256 bytes", p)
+		}
+	}
+}
+
+func flip(b []byte, i int) []byte {
+	b = bytes.Clone(b)
+	b[i] ^= 1
+	return b
+}
@@ -0,0 +1,69 @@
+package mldsa
+
+// d is the number of low bits dropped from t (FIPS 204, Table 1).
+const d = 13
+
+// power2Round splits r in [0, q) as r1 2^d + r0 with r0 in (-2^(d-1),
+// 2^(d-1)] (FIPS 204, Algorithm 35). t_1 goes in the public key and t_0
+// in the private key.
+func power2Round(r uint32) (r1 uint32, r0 int32) {
+	x := int32(r)
+	r0 = x & (1<<d - 1)
+	// Subtract 2^d when r0 > 2^(d-1).
+	r0 -= (1<<(d-1) - r0) >> 31 & (1 << d)
+	return uint32((x - r0) >> d), r0
+}
+
+// decompose splits r in [0, q) as r1 2 gamma2 + r0 with r0 in (-gamma2,
+// gamma2] (FIPS 204, Algorithm 36), except that the top value of r1,
+// (q - 1) / (2 gamma2), wraps to 0 with r0 decreased by one. The quotient
+// is computed by multiplication with a precomputed reciprocal for each of
+// the two values of gamma2, as in the reference implementation, instead of
+// a division whose time may depend on r.
+func decompose(r uint32, gamma2 uint32) (r1 uint32, r0 int32) {
+	a := int32(r)
+	a1 := (a + 127) >> 7
+	if gamma2 == (Q-1)/32 {
+		a1 = (a1*1025 + 1<<21) >> 22
+		a1 &= 15
+	} else {
+		a1 = (a1*11275 + 1<<23) >> 24
+		a1 ^= ((43 - a1) >> 31) & a1
+	}
+	r0 = a - a1*2*int32(gamma2)
+	r0 -= (((Q-1)/2 - r0) >> 31) & Q
+	return uint32(a1), r0
+}
+
+func highBits(r, gamma2 uint32) uint32 {
+	r1, _ := decompose(r, gamma2)
+	return r1
+}
+
+func lowBits(r, gamma2 uint32) int32 {
+	_, r0 := decompose(r, gamma2)
+	return r0
+}
+
+// makeHint returns 1 if adding z to r changes its high bits (FIPS 204,
+// Algorithm 39).
+func makeHint(z, r, gamma2 uint32) int32 {
+	if highBits(r, gamma2) != highBits(fieldAdd(r, z), gamma2) {
+		return 1
+	}
+	return 0
+}
+
+// useHint returns the high bits of r corrected by the hint h (FIPS 204,
+// Algorithm 40). It runs on public data only.
+func useHint(h int32, r, gamma2 uint32) uint32 {
+	m := (Q - 1) / (2 * gamma2)
+	r1, r0 := decompose(r, gamma2)
+	switch {
+	case h == 1 && r0 > 0:
+		return (r1 + 1) % m
+	case h == 1:
+		return (r1 + m - 1) % m
+	}
+	return r1
+}
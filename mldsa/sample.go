@@ -0,0 +1,156 @@
+package mldsa
+
+import (
+	"crypto/sha3"
+	"encoding/binary"
+
+	"github.com/haopining/Learn-Lattice-Based-Cryptography/encoding"
+)
+
+// h returns n bytes of SHAKE256 of the concatenated inputs, the function H
+// of FIPS 204.
+func h(n int, in ...[]byte) []byte {
+	s := sha3.NewSHAKE256()
+	for _, b := range in {
+		s.Write(b)
+	}
+	out := make([]byte, n)
+	s.Read(out)
+	return out
+}
+
+// rejNTTPoly samples a uniform NTT-domain polynomial from SHAKE128(seed),
+// with 23-bit candidates from three bytes (FIPS 204, Algorithm 30).
+func rejNTTPoly(seed []byte) poly {
+	s := sha3.NewSHAKE128()
+	s.Write(seed)
+	var f poly
+	var buf [168]byte
+	for j := 0; j < N; {
+		s.Read(buf[:])
+		for c := 0; c+3 <= len(buf) && j < N; c += 3 {
+			z := uint32(buf[c]) | uint32(buf[c+1])<<8 | uint32(buf[c+2]&0x7f)<<16
+			if z < Q {
+				f[j] = z
+				j++
+			}
+		}
+	}
+	return f
+}
+
+// rejBoundedPoly samples a polynomial with coefficients uniform in
+// [-eta, eta] from SHAKE256(seed), two half-byte candidates per byte
+// (FIPS 204, Algorithm 31). For eta = 2 a nibble z < 15 gives 2 - (z mod
+// 5); for eta = 4 a nibble z < 9 gives 4 - z.
+func rejBoundedPoly(seed []byte, eta int) poly {
+	s := sha3.NewSHAKE256()
+	s.Write(seed)
+	var f poly
+	var buf [136]byte
+	coeff := func(z uint32) (uint32, bool) {
+		if eta == 2 && z < 15 {
+			return fieldFromInt(2 - int32(z%5)), true
+		}
+		if eta == 4 && z < 9 {
+			return fieldFromInt(4 - int32(z)), true
+		}
+		return 0, false
+	}
+	for j := 0; j < N; {
+		s.Read(buf[:])
+		for _, b := range buf {
+			if c, ok := coeff(uint32(b) & 15); ok && j < N {
+				f[j] = c
+				j++
+			}
+			if c, ok := coeff(uint32(b) >> 4); ok && j < N {
+				f[j] = c
+				j++
+			}
+		}
+	}
+	return f
+}
+
+// expandA returns A-hat with A[r][s] = RejNTTPoly(rho || s || r) (FIPS
+// 204, Algorithm 32).
+func expandA(rho []byte, k, l int) [][]poly {
+	a := make([][]poly, k)
+	for r := range a {
+		a[r] = make([]poly, l)
+		for s := range a[r] {
+			a[r][s] = rejNTTPoly(append(append([]byte(nil), rho...), byte(s), byte(r)))
+		}
+	}
+	return a
+}
+
+// expandS returns the secret vectors s_1 and s_2 (FIPS 204, Algorithm 33),
+// each polynomial keyed by rho' and a 16-bit index.
+func expandS(rho []byte, eta, k, l int) (s1, s2 []poly) {
+	seed := make([]byte, len(rho)+2)
+	copy(seed, rho)
+	for r := range k + l {
+		binary.LittleEndian.PutUint16(seed[len(rho):], uint16(r))
+		p := rejBoundedPoly(seed, eta)
+		if r < l {
+			s1 = append(s1, p)
+		} else {
+			s2 = append(s2, p)
+		}
+	}
+	return s1, s2
+}
+
+// expandMask returns the masking vector y with coefficients in
+// (-gamma1, gamma1], the polynomials keyed by the mask seed and kappa + r
+// (FIPS 204, Algorithm 34).
+func (p *Params) expandMask(rho []byte, kappa int) []poly {
+	bits := 18
+	if p.Gamma1 == 1<<19 {
+		bits = 20
+	}
+	y := make([]poly, p.L)
+	for r := range y {
+		var n [2]byte
+		binary.LittleEndian.PutUint16(n[:], uint16(kappa+r))
+		w, err := encoding.BitUnpack(h(32*bits, rho, n[:]), p.Gamma1-1, p.Gamma1, N)
+		if err != nil {
+			panic(err) // every 18- or 20-bit pattern is in range
+		}
+		for i, x := range w {
+			y[r][i] = fieldFromInt(x)
+		}
+	}
+	return y
+}
+
+// sampleInBall returns the challenge c: tau coefficients +-1 at positions
+// chosen by a Fisher–Yates shuffle driven by SHAKE256(seed), the rest zero
+// (FIPS 204, Algorithm 29). It only depends on public data.
+func sampleInBall(seed []byte, tau int) poly {
+	s := sha3.NewSHAKE256()
+	s.Write(seed)
+	var signs [8]byte
+	s.Read(signs[:])
+	bits := binary.LittleEndian.Uint64(signs[:])
+	var c poly
+	var b [1]byte
+	for i := N - tau; i < N; i++ {
+		for {
+			s.Read(b[:])
+			if int(b[0]) <= i {
+				break
+			}
+		}
+		j := b[0]
+		c[i] = c[j]
+		c[j] = 1
+		if bits&1 == 1 {
+			c[j] = Q - 1
+		}
+		bits >>= 1
+	}
+	return c
+}
@@ -0,0 +1,31 @@
+// Package mlkem implements ML-KEM (FIPS 203, formerly Kyber): its ring
+// arithmetic and sampling, and the key encapsulation mechanism built on
+// them at the three standard security levels.
+//
+// ML-KEM works in R_q = Z_q[x]/(x^256 + 1) with q = 3329. Since q - 1 is
+// divisible by 256 but not by 512, x^256 + 1 splits only into 128
+// quadratic factors x^2 - zeta^(2 bitrev7(i) + 1) modulo q, with zeta = 17
+// a primitive 256-th root of unity. The NTT is therefore the incomplete
+// NTT of the notes, stopping one layer early, and products in the NTT
+// domain are 128 products of linear polynomials modulo those quadratics
+// (MultiplyNTTs).
+//
+// Polynomials enter the scheme in two ways, both driven by SHAKE:
+//
+//   - The public matrix A is sampled directly in the NTT domain by
+//     SampleNTT, which rejection-samples 12-bit candidates from a SHAKE128
+//     stream keyed by the seed rho and the matrix position.
+//   - Secrets and errors come from the centered binomial distribution
+//     CBD_eta by SamplePolyCBD, applied to 64 eta bytes of SHAKE256 output
+//     (the PRF), and are moved to the NTT domain afterwards if needed.
+//
+// SampleStats accounts for every byte squeezed from and consumed by the
+// XOFs, so the cost of rejection sampling can be read off exactly.
+//
+// The KEM is the Fujisaki–Okamoto transform of K-PKE: Encapsulate encrypts
+// a random message with coins derived from it, and Decapsulate decrypts,
+// re-encrypts and compares, answering a mismatch with a pseudorandom key
+// (implicit rejection). Keys are generated from a 64-byte seed d || z,
+// which is also their PKCS #8 form; the expanded key of FIPS 203 is
+// available through ExpandedBytes.
+package mlkem
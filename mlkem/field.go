@@ -0,0 +1,131 @@
+package mlkem
+
+// N is the degree of the ring and Q its modulus.
+const (
+	N = 256
+	Q = 3329
+)
+
+// Poly is an element of R_q, in the coefficient or the NTT domain. The
+// coefficients are kept in [0, q).
+type Poly [N]uint16
+
+// All reductions below are branch-free, so that they can process secret
+// values: a conditional subtraction of q is turned into a mask.
+
+// condSub returns a - q if a >= q and a otherwise, for a < 2q.
+func condSub(a uint16) uint16 {
+	a -= Q
+	return a + (Q & -(a >> 15))
+}
+
+// barrett returns a mod q for a < 2^24, using the Barrett constant
+// floor(2^24/q) = 5039. The estimated quotient is off by at most one, so
+// one conditional subtraction completes the reduction.
+func barrett(a uint32) uint16 {
+	quo := uint32((uint64(a) * 5039) >> 24)
+	return condSub(uint16(a - quo*Q))
+}
+
+func fieldAdd(a, b uint16) uint16 { return condSub(a + b) }
+
+func fieldSub(a, b uint16) uint16 { return condSub(a - b + Q) }
+
+func fieldMul(a, b uint16) uint16 { return barrett(uint32(a) * uint32(b)) }
+
+// Add returns f + g.
+func (f *Poly) Add(g *Poly) Poly {
+	var h Poly
+	for i := range h {
+		h[i] = fieldAdd(f[i], g[i])
+	}
+	return h
+}
+
+// Sub returns f - g.
+func (f *Poly) Sub(g *Poly) Poly {
+	var h Poly
+	for i := range h {
+		h[i] = fieldSub(f[i], g[i])
+	}
+	return h
+}
+
+// bitRev7 reverses the 7 low bits of i.
+func bitRev7(i uint8) uint8 {
+	var r uint8
+	for b := 0; b < 7; b++ {
+		r |= (i >> b & 1) << (6 - b)
+	}
+	return r
+}
+
+func pow(a, e uint16) uint16 {
+	r := uint16(1)
+	for ; e > 0; e-- {
+		r = fieldMul(r, a)
+	}
+	return r
+}
+
+// zetas[i] = 17^bitrev7(i) and gammas[i] = 17^(2 bitrev7(i) + 1), the
+// twiddle factors of the NTT and the moduli of the base multiplications.
+var zetas, gammas = func() (z, g [128]uint16) {
+	for i := range z {
+		z[i] = pow(17, uint16(bitRev7(uint8(i))))
+		g[i] = pow(17, 2*uint16(bitRev7(uint8(i)))+1)
+	}
+	return z, g
+}()
+
+// NTT returns the number-theoretic transform of f (FIPS 203, Algorithm 9):
+// seven layers of Cooley–Tukey butterflies, down to 128 polynomials of
+// degree one.
+func NTT(f Poly) Poly {
+	k := 1
+	for l := 128; l >= 2; l /= 2 {
+		for start := 0; start < N; start += 2 * l {
+			zeta := zetas[k]
+			k++
+			for j := start; j < start+l; j++ {
+				t := fieldMul(zeta, f[j+l])
+				f[j+l] = fieldSub(f[j], t)
+				f[j] = fieldAdd(f[j], t)
+			}
+		}
+	}
+	return f
+}
+
+// InvNTT returns the inverse transform (FIPS 203, Algorithm 10), with
+// Gentleman–Sande butterflies and a final scaling by 128^-1 = 3303.
+func InvNTT(f Poly) Poly {
+	k := 127
+	for l := 2; l <= 128; l *= 2 {
+		for start := 0; start < N; start += 2 * l {
+			zeta := zetas[k]
+			k--
+			for j := start; j < start+l; j++ {
+				t := f[j]
+				f[j] = fieldAdd(t, f[j+l])
+				f[j+l] = fieldMul(zeta, fieldSub(f[j+l], t))
+			}
+		}
+	}
+	for i := range f {
+		f[i] = fieldMul(f[i], 3303)
+	}
+	return f
+}
+
+// MultiplyNTTs returns the product of f and g in the NTT domain (FIPS 203,
+// Algorithm 11): for each i, (f0 + f1 x)(g0 + g1 x) mod x^2 - gamma_i.
+func MultiplyNTTs(f, g *Poly) Poly {
+	var h Poly
+	for i := 0; i < N/2; i++ {
+		a0, a1, b0, b1 := f[2*i], f[2*i+1], g[2*i], g[2*i+1]
+		h[2*i] = fieldAdd(fieldMul(a0, b0), fieldMul(fieldMul(a1, b1), gammas[i]))
+		h[2*i+1] = fieldAdd(fieldMul(a0, b1), fieldMul(a1, b0))
+	}
+	return h
+}
@@ -0,0 +1,340 @@
+package mlkem
+
+import (
+	"crypto/rand"
+	"crypto/sha3"
+	"crypto/subtle"
+	"errors"
+	"io"
+
+	"github.com/haopining/Learn-Lattice-Based-Cryptography/encoding"
+)
+
+// SeedSize is the size of a decapsulation key seed d || z, and
+// SharedKeySize the size of the shared secrets.
+const (
+	SeedSize      = 64
+	SharedKeySize = 32
+)
+
+// Params is an ML-KEM parameter set (FIPS 203, Table 2).
+type Params struct {
+	Name string
+	// K is the module rank; Eta1 and Eta2 the CBD parameters of the
+	// secrets and of the encryption noise; Du and Dv the compression of
+	// the two ciphertext parts.
+	K, Eta1, Eta2, Du, Dv int
+}
+
+// The three standard parameter sets.
+var (
+	MLKEM512  = &Params{Name: "ML-KEM-512", K: 2, Eta1: 3, Eta2: 2, Du: 10, Dv: 4}
+	MLKEM768  = &Params{Name: "ML-KEM-768", K: 3, Eta1: 2, Eta2: 2, Du: 10, Dv: 4}
+	MLKEM1024 = &Params{Name: "ML-KEM-1024", K: 4, Eta1: 2, Eta2: 2, Du: 11, Dv: 5}
+)
+
+func (p *Params) String() string { return p.Name }
+
+// EncapsulationKeySize returns 384 k + 32.
+func (p *Params) EncapsulationKeySize() int { return 384*p.K + 32 }
+
+// DecapsulationKeySize returns the size of the expanded decapsulation key
+// of FIPS 203, 768 k + 96; the seed form is always SeedSize bytes.
+func (p *Params) DecapsulationKeySize() int { return 768*p.K + 96 }
+
+// CiphertextSize returns 32 (du k + dv).
+func (p *Params) CiphertextSize() int { return 32 * (p.Du*p.K + p.Dv) }
+
+var (
+	errKeySize        = errors.New("mlkem: invalid key size")
+	errCiphertextSize = errors.New("mlkem: invalid ciphertext size")
+)
+
+// EncapsulationKey is an ML-KEM public key.
+type EncapsulationKey struct {
+	p   *Params
+	raw []byte
+	t   []Poly   // t-hat, NTT domain
+	a   [][]Poly // A-hat
+	rho [32]byte
+	h   [32]byte // H(ek)
+}
+
+// DecapsulationKey is an ML-KEM private key, kept with its seed.
+type DecapsulationKey struct {
+	seed [SeedSize]byte //ct:secret
+	s    []Poly         //ct:secret s-hat, NTT domain
+	ek   *EncapsulationKey
+}
+
+// GenerateKey returns a key for p from a fresh seed.
+func GenerateKey(p *Params) (*DecapsulationKey, error) {
+	seed := make([]byte, SeedSize)
+	if _, err := io.ReadFull(rand.Reader, seed); err != nil {
+		return nil, err
+	}
+	return NewDecapsulationKey(p, seed)
+}
+
+// NewDecapsulationKey expands the 64-byte seed d || z into a key
+// (FIPS 203, Algorithms 13 and 16).
+//
+//ct:secret seed
+//ct:public rho ek
+func NewDecapsulationKey(p *Params, seed []byte) (*DecapsulationKey, error) {
+	if len(seed) != SeedSize {
+		return nil, errKeySize
+	}
+	dk := &DecapsulationKey{}
+	copy(dk.seed[:], seed)
+	// (rho, sigma) = G(d || k), the rank byte separating the parameter
+	// sets.
+	g := sha3.Sum512(append(append([]byte(nil), seed[:32]...), byte(p.K)))
+	rho, sigma := g[:32], g[32:]
+	ek := &EncapsulationKey{p: p}
+	copy(ek.rho[:], rho)
+	ek.a = ExpandA(rho, p.K, false, nil)
+	var nonce byte
+	dk.s = make([]Poly, p.K)
+	for i := range dk.s {
+		dk.s[i] = SampleCBD(p.Eta1, sigma, nonce, true, nil)
+		nonce++
+	}
+	ek.t = make([]Poly, p.K)
+	for i := range ek.t {
+		e := SampleCBD(p.Eta1, sigma, nonce, true, nil)
+		nonce++
+		ek.t[i] = dotNTT(ek.a[i], dk.s)
+		ek.t[i] = ek.t[i].Add(&e)
+	}
+	for i := range ek.t {
+		ek.raw = append(ek.raw, encode12(&ek.t[i])...)
+	}
+	ek.raw = append(ek.raw, rho...)
+	ek.h = sha3.Sum256(ek.raw)
+	dk.ek = ek
+	return dk, nil
+}
+
+// NewEncapsulationKey parses an encapsulation key, rejecting coefficients
+// of t-hat that are not reduced modulo q (the FIPS 203 modulus check).
+func NewEncapsulationKey(p *Params, b []byte) (*EncapsulationKey, error) {
+	if len(b) != p.EncapsulationKeySize() {
+		return nil, errKeySize
+	}
+	ek := &EncapsulationKey{p: p, raw: append([]byte(nil), b...), t: make([]Poly, p.K)}
+	for i := range ek.t {
+		f, err := encoding.ByteDecode(12, b[384*i:384*(i+1)])
+		if err != nil {
+			return nil, errors.New("mlkem: encapsulation key coefficient out of range")
+		}
+		copy(ek.t[i][:], f)
+	}
+	copy(ek.rho[:], b[384*p.K:])
+	ek.a = ExpandA(ek.rho[:], p.K, false, nil)
+	ek.h = sha3.Sum256(ek.raw)
+	return ek, nil
+}
+
+// Params returns the parameter set of the key.
+func (ek *EncapsulationKey) Params() *Params { return ek.p }
+
+// Bytes returns the encoded key, ByteEncode_12(t-hat) || rho.
+func (ek *EncapsulationKey) Bytes() []byte { return append([]byte(nil), ek.raw...) }
+
+// Equal reports whether ek and x are the same key.
+func (ek *EncapsulationKey) Equal(x *EncapsulationKey) bool {
+	return ek.p == x.p && subtle.ConstantTimeCompare(ek.raw, x.raw) == 1
+}
+
+// Encapsulate returns a fresh shared key and its ciphertext.
+func (ek *EncapsulationKey) Encapsulate() (sharedKey, ciphertext []byte) {
+	m := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, m); err != nil {
+		panic("mlkem: " + err.Error())
+	}
+	return ek.EncapsulateInternal(m)
+}
+
+// EncapsulateInternal is Encapsulate with the message m fixed (FIPS 203,
+// Algorithm 17), for test vectors: (K, r) = G(m || H(ek)) and the
+// ciphertext is the encryption of m with coins r.
+//
+//ct:secret m
+func (ek *EncapsulationKey) EncapsulateInternal(m []byte) (sharedKey, ciphertext []byte) {
+	g := sha3.Sum512(append(append([]byte(nil), m...), ek.h[:]...))
+	return g[:32], ek.encrypt(m, g[32:])
+}
+
+// encrypt is K-PKE.Encrypt (FIPS 203, Algorithm 14).
+func (ek *EncapsulationKey) encrypt(m, coins []byte) []byte {
+	p := ek.p
+	var nonce byte
+	y := make([]Poly, p.K)
+	for i := range y {
+		y[i] = SampleCBD(p.Eta1, coins, nonce, true, nil)
+		nonce++
+	}
+	var c []byte
+	for i := range p.K {
+		// u_i = InvNTT(sum_j A-hat[j][i] y-hat_j) + e1_i, with A transposed.
+		var acc Poly
+		for j := range p.K {
+			t := MultiplyNTTs(&ek.a[j][i], &y[j])
+			acc = acc.Add(&t)
+		}
+		u := InvNTT(acc)
+		e1 := SampleCBD(p.Eta2, coins, nonce, false, nil)
+		nonce++
+		u = u.Add(&e1)
+		c = append(c, compressEncode(p.Du, &u)...)
+	}
+	e2 := SampleCBD(p.Eta2, coins, nonce, false, nil)
+	v := InvNTT(dotNTT(ek.t, y))
+	v = v.Add(&e2)
+	mu := decodeMessage(m)
+	v = v.Add(&mu)
+	return append(c, compressEncode(p.Dv, &v)...)
+}
+
+// Bytes returns the seed d || z, the private key format of the PKCS #8
+// encodings.
+func (dk *DecapsulationKey) Bytes() []byte { return append([]byte(nil), dk.seed[:]...) }
+
+// ExpandedBytes returns the decapsulation key in the format of FIPS 203,
+// ByteEncode_12(s-hat) || ek || H(ek) || z.
+func (dk *DecapsulationKey) ExpandedBytes() []byte {
+	var b []byte
+	for i := range dk.s {
+		b = append(b, encode12(&dk.s[i])...)
+	}
+	b = append(b, dk.ek.raw...)
+	b = append(b, dk.ek.h[:]...)
+	return append(b, dk.seed[32:]...)
+}
+
+// EncapsulationKey returns the public key.
+func (dk *DecapsulationKey) EncapsulationKey() *EncapsulationKey { return dk.ek }
+
+// Params returns the parameter set of the key.
+func (dk *DecapsulationKey) Params() *Params { return dk.ek.p }
+
+// Equal reports whether dk and x are the same key, derived from the same
+// seed.
+func (dk *DecapsulationKey) Equal(x *DecapsulationKey) bool {
+	return dk.ek.p == x.ek.p && subtle.ConstantTimeCompare(dk.seed[:], x.seed[:]) == 1
+}
+
+// Decapsulate returns the shared key of a ciphertext (FIPS 203,
+// Algorithm 18). A ciphertext that does not re-encrypt to itself yields
+// the implicit rejection key J(z || c) instead, chosen without a branch,
+// so an attacker learns nothing from malformed ciphertexts.
+func (dk *DecapsulationKey) Decapsulate(ciphertext []byte) ([]byte, error) {
+	p := dk.ek.p
+	if len(ciphertext) != p.CiphertextSize() {
+		return nil, errCiphertextSize
+	}
+	m := dk.decrypt(ciphertext)
+	g := sha3.Sum512(append(m, dk.ek.h[:]...))
+	key, coins := g[:32], g[32:]
+	j := sha3.NewSHAKE256()
+	j.Write(dk.seed[32:])
+	j.Write(ciphertext)
+	reject := make([]byte, 32)
+	j.Read(reject)
+	c := dk.ek.encrypt(m, coins)
+	equal := subtle.ConstantTimeCompare(c, ciphertext)
+	out := make([]byte, 32)
+	subtle.ConstantTimeCopy(equal, out, key)
+	subtle.ConstantTimeCopy(1-equal, out, reject)
+	return out, nil
+}
+
+// decrypt is K-PKE.Decrypt (FIPS 203, Algorithm 15).
+func (dk *DecapsulationKey) decrypt(c []byte) []byte {
+	p := dk.ek.p
+	u := make([]Poly, p.K)
+	for i := range u {
+		n := 32 * p.Du
+		u[i] = NTT(decodeDecompress(p.Du, c[n*i:n*(i+1)]))
+	}
+	v := decodeDecompress(p.Dv, c[32*p.Du*p.K:])
+	su := InvNTT(dotNTT(dk.s, u))
+	w := v.Sub(&su)
+	return encodeMessage(&w)
+}
+
+// dotNTT returns sum_i a_i b_i in the NTT domain.
+func dotNTT(a, b []Poly) Poly {
+	var acc Poly
+	for i := range a {
+		t := MultiplyNTTs(&a[i], &b[i])
+		acc = acc.Add(&t)
+	}
+	return acc
+}
+
+func encode12(f *Poly) []byte { return byteEncode(12, f) }
+
+// compressEncode returns ByteEncode_d(Compress_d(f)).
+func compressEncode(d int, f *Poly) []byte {
+	var g Poly
+	copy(g[:], encoding.CompressPoly(d, f[:]))
+	return byteEncode(d, &g)
+}
+
+// decodeDecompress returns Decompress_d(ByteDecode_d(b)); every d-bit
+// pattern is valid for d < 12, so ciphertexts need no range check.
+func decodeDecompress(d int, b []byte) Poly {
+	f := byteDecode(d, b)
+	var g Poly
+	copy(g[:], encoding.DecompressPoly(d, f[:]))
+	return g
+}
+
+// byteEncode and byteDecode are ByteEncode_d and ByteDecode_d without the
+// range checks of package encoding, which would branch on the secret
+// s-hat, messages and ciphertext coefficients they see here. The
+// coefficients are reduced, or compressed to d bits, by construction, and
+// the only input that needs a check, the encapsulation key, goes through
+// encoding.ByteDecode in NewEncapsulationKey. Only d picks the branches.
+func byteEncode(d int, f *Poly) []byte {
+	out := make([]byte, 32*d)
+	var acc uint32
+	n, pos := 0, 0
+	for _, x := range f {
+		acc |= uint32(x) << n
+		for n += d; n >= 8; n -= 8 {
+			out[pos] = byte(acc)
+			pos++
+			acc >>= 8
+		}
+	}
+	return out
+}
+
+func byteDecode(d int, b []byte) Poly {
+	var f Poly
+	var acc uint32
+	n, pos := 0, 0
+	for i := range f {
+		for ; n < d; n += 8 {
+			acc |= uint32(b[pos]) << n
+			pos++
+		}
+		f[i] = uint16(acc & (1<<d - 1))
+		acc >>= d
+		n -= d
+	}
+	return f
+}
+
+// decodeMessage maps the 256 message bits to 0 or round(q/2).
+func decodeMessage(m []byte) Poly {
+	return decodeDecompress(1, m)
+}
+
+// encodeMessage rounds every coefficient to the nearer of 0 and q/2.
+func encodeMessage(w *Poly) []byte {
+	return compressEncode(1, w)
+}
@@ -0,0 +1,144 @@
+//go:build go1.26
+
+package mlkem
+
+import (
+	"bytes"
+	std "crypto/mlkem"
+	"crypto/mlkem/mlkemtest"
+	"crypto/sha3"
+	"testing"
+)
+
+// reference runs crypto/mlkem on the key of seed: it returns the
+// encapsulation key, the encapsulation with message m, and the
+// decapsulation of c. Fixing the message needs crypto/mlkem/mlkemtest,
+// hence the Go 1.26 build constraint of the file.
+type reference func(t *testing.T, seed, m, c []byte) (ek, key, ct, dec []byte)
+
+func std768(t *testing.T, seed, m, c []byte) (ek, key, ct, dec []byte) {
+	dk, err := std.NewDecapsulationKey768(seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, ct, err = mlkemtest.Encapsulate768(dk.EncapsulationKey(), m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dec, err = dk.Decapsulate(c); err != nil {
+		t.Fatal(err)
+	}
+	return dk.EncapsulationKey().Bytes(), key, ct, dec
+}
+
+func std1024(t *testing.T, seed, m, c []byte) (ek, key, ct, dec []byte) {
+	dk, err := std.NewDecapsulationKey1024(seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, ct, err = mlkemtest.Encapsulate1024(dk.EncapsulationKey(), m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dec, err = dk.Decapsulate(c); err != nil {
+		t.Fatal(err)
+	}
+	return dk.EncapsulationKey().Bytes(), key, ct, dec
+}
+
+// TestStandardLibrary checks keys, encapsulations and decapsulations,
+// including the implicit rejection of a corrupted ciphertext, against
+// crypto/mlkem, which has no ML-KEM-512.
+func TestStandardLibrary(t *testing.T) {
+	for _, tc := range []struct {
+		p   *Params
+		ref reference
+	}{
+		{MLKEM768, std768},
+		{MLKEM1024, std1024},
+	} {
+		t.Run(tc.p.Name, func(t *testing.T) {
+			for i := range 20 {
+				buf := sha3.SumSHAKE128([]byte{tc.p.Name[7], byte(i)}, SeedSize+32)
+				seed, m := buf[:SeedSize], buf[SeedSize:]
+				dk, err := NewDecapsulationKey(tc.p, seed)
+				if err != nil {
+					t.Fatal(err)
+				}
+				key, ct := dk.EncapsulationKey().EncapsulateInternal(m)
+				bad := bytes.Clone(ct)
+				bad[i] ^= 1
+				ek, refKey, refCT, refDec := tc.ref(t, seed, m, bad)
+				if !bytes.Equal(dk.EncapsulationKey().Bytes(), ek) {
+					t.Fatalf("seed %x: encapsulation keys differ", seed)
+				}
+				if !bytes.Equal(key, refKey) || !bytes.Equal(ct, refCT) {
+					t.Fatalf("seed %x, m %x: encapsulations differ", seed, m)
+				}
+				if got, err := dk.Decapsulate(ct); err != nil || !bytes.Equal(got, key) {
+					t.Fatalf("seed %x: decapsulation failed: %v", seed, err)
+				}
+				dec, err := dk.Decapsulate(bad)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(dec, refDec) || bytes.Equal(dec, key) {
+					t.Fatalf("seed %x: implicit rejection key %x, want %x", seed, dec, refDec)
+				}
+			}
+		})
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, p := range []*Params{MLKEM512, MLKEM768, MLKEM1024} {
+		dk, err := GenerateKey(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n := len(dk.ExpandedBytes()); n != p.DecapsulationKeySize() {
+			t.Errorf("%v: expanded key of %d bytes, want %d", p, n, p.DecapsulationKeySize())
+		}
+		ek, err := NewEncapsulationKey(p, dk.EncapsulationKey().Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ek.Equal(dk.EncapsulationKey()) {
+			t.Errorf("%v: parsed encapsulation key differs", p)
+		}
+		key, ct := ek.Encapsulate()
+		if len(ct) != p.CiphertextSize() {
+			t.Errorf("%v: ciphertext of %d bytes, want %d", p, len(ct), p.CiphertextSize())
+		}
+		if got, err := dk.Decapsulate(ct); err != nil || !bytes.Equal(got, key) {
+			t.Errorf("%v: decapsulation failed: %v", p, err)
+		}
+		if _, err := dk.Decapsulate(ct[1:]); err == nil {
+			t.Errorf("%v: accepted a short ciphertext", p)
+		}
+		if _, err := NewDecapsulationKey(p, dk.Bytes()[1:]); err == nil {
+			t.Errorf("%v: accepted a short seed", p)
+		}
+	}
+}
+
+// TestModulusCheck sets a coefficient of t-hat to q, which ByteDecode_12
+// would reduce to 0 and FIPS 203 requires to be rejected.
+func TestModulusCheck(t *testing.T) {
+	dk, err := NewDecapsulationKey(MLKEM768, make([]byte, SeedSize))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := dk.EncapsulationKey().Bytes()
+	// The first coefficient is the low 12 bits of the first 3 bytes.
+	b[0], b[1] = Q&0xff, b[1]&0xf0|Q>>8
+	if _, err := NewEncapsulationKey(MLKEM768, b); err == nil {
+		t.Error("accepted an encapsulation key coefficient equal to q")
+	}
+	if _, err := std.NewEncapsulationKey768(b); err == nil {
+		t.Error("crypto/mlkem accepted it, the test key is wrong")
+	}
+	if _, err := NewEncapsulationKey(MLKEM768, b[1:]); err == nil {
+		t.Error("accepted a short encapsulation key")
+	}
+}
@@ -0,0 +1,219 @@
+package mlkem
+
+import (
+	"crypto/sha3"
+	"fmt"
+)
+
+// xofRate is the SHAKE128 rate: the XOF is squeezed in blocks of this many
+// bytes, 56 three-byte groups.
+const xofRate = 168
+
+// SampleStats accounts for the bytes used by the samplers. It accumulates
+// over calls, so one value can follow a whole key generation.
+type SampleStats struct {
+	// Squeezed is the number of bytes squeezed from the XOFs, a whole
+	// number of blocks for SampleNTT.
+	Squeezed int
+	// Consumed is the number of those bytes the samplers actually parsed:
+	// 3 per candidate pair for SampleNTT, 64 eta per SamplePolyCBD.
+	Consumed int
+	// Candidates and Rejected count the 12-bit candidates SampleNTT looked
+	// at and the ones it rejected for being >= q; the expected rejection
+	// rate is 1 - q/4096, about 18.7%.
+	Candidates, Rejected int
+}
+
+func (s *SampleStats) String() string {
+	return fmt.Sprintf("squeezed %d bytes, consumed %d, candidates %d, rejected %d",
+		s.Squeezed, s.Consumed, s.Candidates, s.Rejected)
+}
+
+// XOF returns the SHAKE128 stream used for entry (i, j) of A:
+// SHAKE128(rho || j || i), with the column index first as in FIPS 203.
+func XOF(rho []byte, i, j byte) *sha3.SHAKE {
+	h := sha3.NewSHAKE128()
+	h.Write(rho)
+	h.Write([]byte{j, i})
+	return h
+}
+
+// nttParser turns a byte stream into NTT coefficients (FIPS 203,
+// Algorithm 7). It is fed one XOF block at a time so that several
+// parsers can share a batched XOF.
+type nttParser struct {
+	a *Poly
+	j int
+}
+
+func (p *nttParser) done() bool { return p.j == N }
+
+// feed parses 3-byte groups of block until the polynomial is full, and
+// records what it used.
+func (p *nttParser) feed(block []byte, st *SampleStats) {
+	for c := 0; c+3 <= len(block) && p.j < N; c += 3 {
+		d1 := uint16(block[c]) | uint16(block[c+1]&0x0f)<<8
+		d2 := uint16(block[c+1])>>4 | uint16(block[c+2])<<4
+		if st != nil {
+			st.Consumed += 3
+			st.Candidates++
+		}
+		if d1 < Q {
+			p.a[p.j] = d1
+			p.j++
+		} else if st != nil {
+			st.Rejected++
+		}
+		if p.j == N {
+			break
+		}
+		if st != nil {
+			st.Candidates++
+		}
+		if d2 < Q {
+			p.a[p.j] = d2
+			p.j++
+		} else if st != nil {
+			st.Rejected++
+		}
+	}
+}
+
+// SampleNTT samples a uniform polynomial in the NTT domain from xof by
+// rejection (FIPS 203, Algorithm 7). st may be nil. Rejection only
+// depends on public data, the seed rho, so it need not be constant time.
+func SampleNTT(xof *sha3.SHAKE, st *SampleStats) Poly {
+	var a Poly
+	p := nttParser{a: &a}
+	var block [xofRate]byte
+	for !p.done() {
+		xof.Read(block[:])
+		if st != nil {
+			st.Squeezed += xofRate
+		}
+		p.feed(block[:], st)
+	}
+	return a
+}
+
+// SampleNTTx4 fills out[l] from xofs[l] for four lanes at once, squeezing
+// one block from every lane per round until all four polynomials are
+// full, the way a four-way SIMD Keccak is used in optimized Kyber code.
+// Lanes with a nil XOF are skipped. The results are those of SampleNTT;
+// only the accounting differs, since a lane that finishes early still
+// squeezes blocks while the others catch up.
+func SampleNTTx4(xofs [4]*sha3.SHAKE, out [4]*Poly, st *SampleStats) {
+	var ps [4]nttParser
+	for l := range xofs {
+		if xofs[l] != nil {
+			ps[l] = nttParser{a: out[l]}
+		}
+	}
+	var block [xofRate]byte
+	for {
+		pending := false
+		for l := range ps {
+			if xofs[l] != nil && !ps[l].done() {
+				pending = true
+			}
+		}
+		if !pending {
+			return
+		}
+		for l := range xofs {
+			if xofs[l] == nil {
+				continue
+			}
+			xofs[l].Read(block[:])
+			if st != nil {
+				st.Squeezed += xofRate
+			}
+			ps[l].feed(block[:], st)
+		}
+	}
+}
+
+// ExpandA returns the k x k matrix A-hat with entries SampleNTT(XOF(rho,
+// i, j)), or its transpose, as used by K-PKE key generation and
+// encryption. The entries are generated four at a time with SampleNTTx4.
+func ExpandA(rho []byte, k int, transpose bool, st *SampleStats) [][]Poly {
+	a := make([][]Poly, k)
+	for i := range a {
+		a[i] = make([]Poly, k)
+	}
+	var xofs [4]*sha3.SHAKE
+	var out [4]*Poly
+	lane := 0
+	flush := func() {
+		SampleNTTx4(xofs, out, st)
+		xofs, out, lane = [4]*sha3.SHAKE{}, [4]*Poly{}, 0
+	}
+	for i := 0; i < k; i++ {
+		for j := 0; j < k; j++ {
+			if transpose {
+				xofs[lane] = XOF(rho, byte(j), byte(i))
+			} else {
+				xofs[lane] = XOF(rho, byte(i), byte(j))
+			}
+			out[lane] = &a[i][j]
+			if lane++; lane == 4 {
+				flush()
+			}
+		}
+	}
+	if lane > 0 {
+		flush()
+	}
+	return a
+}
+
+// PRF returns the 64 eta bytes SHAKE256(s || b) that SamplePolyCBD turns
+// into one polynomial.
+func PRF(eta int, s []byte, b byte, st *SampleStats) []byte {
+	h := sha3.NewSHAKE256()
+	h.Write(s)
+	h.Write([]byte{b})
+	out := make([]byte, 64*eta)
+	h.Read(out)
+	if st != nil {
+		st.Squeezed += len(out)
+	}
+	return out
+}
+
+// SamplePolyCBD samples a polynomial from the centered binomial
+// distribution CBD_eta (FIPS 203, Algorithm 8): each coefficient is the
+// difference of two sums of eta bits of buf, which must hold 64 eta bytes.
+// eta is 2 or 3 in ML-KEM. The coefficients follow a binomial law on
+// [-eta, eta] with variance eta/2. The bit sums involve no branches, since
+// the result is secret.
+func SamplePolyCBD(eta int, buf []byte, st *SampleStats) Poly {
+	if len(buf) != 64*eta {
+		panic("mlkem: SamplePolyCBD needs 64*eta bytes")
+	}
+	var f Poly
+	for i := range f {
+		var x, y uint16
+		for j := 0; j < eta; j++ {
+			x += bit(buf, 2*i*eta+j)
+			y += bit(buf, 2*i*eta+eta+j)
+		}
+		f[i] = fieldSub(x, y)
+	}
+	if st != nil {
+		st.Consumed += len(buf)
+	}
+	return f
+}
+
+func bit(b []byte, i int) uint16 { return uint16(b[i/8] >> (i % 8) & 1) }
+
+// SampleCBD returns SamplePolyCBD(eta, PRF(eta, s, nonce)), in the NTT
+// domain if ntt is set.
+func SampleCBD(eta int, s []byte, nonce byte, ntt bool, st *SampleStats) Poly {
+	f := SamplePolyCBD(eta, PRF(eta, s, nonce, st), st)
+	if ntt {
+		f = NTT(f)
+	}
+	return f
+}
@@ -0,0 +1,21 @@
+// Package poly multiplies polynomials with coefficients modulo q, in
+// Z_q[x] and in the negacyclic ring Z_q[x]/(x^n + 1) used by Ring- and
+// Module-LWE.
+//
+// The fast multipliers are the polynomial form of the integer algorithms in
+// the notes (python/karatsuba.py and python/toom3.py). An integer split in
+// base 10^m, x = a 10^m + b, becomes a polynomial split in x^m,
+// f = f_1 x^m + f_0, and the carries disappear, so the recursions carry
+// over unchanged:
+//
+//   - Karatsuba computes f g from the three half-size products f_0 g_0,
+//     f_1 g_1 and (f_0 + f_1)(g_0 + g_1), in O(n^1.585).
+//   - Toom-3 splits into thirds, evaluates at 0, 1, -1, 2 and infinity,
+//     multiplies the five values recursively and interpolates with exactly
+//     the steps of the notes, in O(n^1.465). Its divisions by 2 and 3
+//     become multiplications by inverses modulo q.
+//
+// Both fall back to the schoolbook method for short inputs, like the base
+// cases of the notes. Products in the ring are full products folded with
+// x^n = -1.
+package poly
@@ -0,0 +1,190 @@
+package poly
+
+// A Multiplier returns the product of a and b in Z_q[x], of length
+// len(a) + len(b) - 1, with coefficients in [0, q). The inputs must have
+// coefficients in [0, q), and q must be below 2^31 so that products of two
+// coefficients fit in an int64.
+type Multiplier func(a, b []int64, q int64) []int64
+
+// schoolbookMax is the length up to which the fast multipliers call
+// Schoolbook instead of recursing.
+const schoolbookMax = 16
+
+// Schoolbook multiplies with the quadratic method.
+func Schoolbook(a, b []int64, q int64) []int64 {
+	if len(a) == 0 || len(b) == 0 {
+		return nil
+	}
+	c := make([]int64, len(a)+len(b)-1)
+	for i, ai := range a {
+		if ai == 0 {
+			continue
+		}
+		for j, bj := range b {
+			c[i+j] = (c[i+j] + ai*bj) % q
+		}
+	}
+	return c
+}
+
+// Karatsuba multiplies with Karatsuba's method. With h = ceil(n/2),
+//
+//	f = f_1 x^h + f_0,   g = g_1 x^h + g_0,
+//	f g = z_2 x^2h + (z_1 - z_2 - z_0) x^h + z_0,
+//
+// where z_0 = f_0 g_0, z_2 = f_1 g_1 and z_1 = (f_0 + f_1)(g_0 + g_1).
+func Karatsuba(a, b []int64, q int64) []int64 {
+	a, b, n, size := pad(a, b)
+	if n == 0 {
+		return nil
+	}
+	return karatsuba(a, b, q)[:size]
+}
+
+func karatsuba(a, b []int64, q int64) []int64 {
+	n := len(a)
+	if n <= schoolbookMax {
+		return Schoolbook(a, b, q)
+	}
+	h := (n + 1) / 2
+	a0, a1 := split(a, h)
+	b0, b1 := split(b, h)
+	z0 := karatsuba(a0, b0, q)
+	z2 := karatsuba(a1, b1, q)
+	z1 := karatsuba(addMod(a0, a1, q), addMod(b0, b1, q), q)
+	c := make([]int64, 4*h-1)
+	for i := range z1 {
+		mid := z1[i] - z0[i] - z2[i]
+		c[i] = (c[i] + z0[i]) % q
+		c[i+h] = ((c[i+h]+mid)%q + 2*q) % q
+		c[i+2*h] = (c[i+2*h] + z2[i]) % q
+	}
+	return c[:2*n-1]
+}
+
+// Toom3 multiplies with the Toom–Cook method for three parts. With
+// h = ceil(n/3), f = f_2 x^2h + f_1 x^h + f_0 is evaluated as a polynomial
+// in y = x^h at y = 0, 1, -1, 2 and infinity (the leading coefficient),
+// the five values of f and g are multiplied recursively, and the five
+// parts c_0..c_4 of f g are interpolated:
+//
+//	c_0 = r(0),  c_4 = r(inf),  c_2 = (r(1) + r(-1))/2 - c_0 - c_4,
+//	S = (r(1) - r(-1))/2 = c_1 + c_3,
+//	D = (5 S - (r(2) - c_0 - 4 c_2 - 16 c_4)) / 3 = c_1 - c_3,
+//	c_1 = (S + D)/2,  c_3 = (S - D)/2.
+//
+// The divisions need 2 and 3 to be invertible modulo q. For other moduli,
+// such as the powers of two of Saber and NTRU, where Toom–Cook needs extra
+// precision bits, Toom3 returns Karatsuba(a, b, q).
+func Toom3(a, b []int64, q int64) []int64 {
+	if q%2 == 0 || q%3 == 0 {
+		return Karatsuba(a, b, q)
+	}
+	a, b, n, size := pad(a, b)
+	if n == 0 {
+		return nil
+	}
+	t := toom{q: q, inv2: inverse(2, q), inv3: inverse(3, q)}
+	return t.mul(a, b)[:size]
+}
+
+type toom struct {
+	q, inv2, inv3 int64
+}
+
+// eval returns f(0), f(1), f(-1), f(2) and f(inf) for f = f2 y^2 + f1 y + f0.
+func (t toom) eval(f0, f1, f2 []int64) [5][]int64 {
+	var v [5][]int64
+	for i := range v {
+		v[i] = make([]int64, len(f0))
+	}
+	q := t.q
+	for i := range f0 {
+		v[0][i] = f0[i]
+		v[1][i] = (f0[i] + f1[i] + f2[i]) % q
+		v[2][i] = ((f0[i]-f1[i]+f2[i])%q + q) % q
+		v[3][i] = (f0[i] + 2*f1[i] + 4*f2[i]) % q
+		v[4][i] = f2[i]
+	}
+	return v
+}
+
+func (t toom) mul(a, b []int64) []int64 {
+	n := len(a)
+	if n <= schoolbookMax {
+		return Schoolbook(a, b, t.q)
+	}
+	h := (n + 2) / 3
+	a0, a1, a2 := split3(a, h)
+	b0, b1, b2 := split3(b, h)
+	va, vb := t.eval(a0, a1, a2), t.eval(b0, b1, b2)
+	var r [5][]int64
+	for i := range r {
+		r[i] = t.mul(va[i], vb[i])
+	}
+	q, m := t.q, len(r[0])
+	c := make([]int64, 6*h-1)
+	for i := range m {
+		r0, r1, rm1, r2, rinf := r[0][i], r[1][i], r[2][i], r[3][i], r[4][i]
+		c0, c4 := r0, rinf
+		c2 := ((r1+rm1)%q*t.inv2%q - c0 - c4 + 2*q) % q
+		s := (r1 - rm1 + q) % q * t.inv2 % q
+		temp := ((r2-c0-4*c2-16*c4)%q + 21*q) % q
+		d := (5*s - temp + q) % q * t.inv3 % q
+		c1 := (s + d) % q * t.inv2 % q
+		c3 := (s - d + q) % q * t.inv2 % q
+		for j, cj := range [5]int64{c0, c1, c2, c3, c4} {
+			c[i+j*h] = (c[i+j*h] + cj) % q
+		}
+	}
+	return c[:2*n-1]
+}
+
+// pad extends a and b with zeros to a common length n and returns the
+// length of their product.
+func pad(a, b []int64) ([]int64, []int64, int, int) {
+	if len(a) == 0 || len(b) == 0 {
+		return nil, nil, 0, 0
+	}
+	size := len(a) + len(b) - 1
+	n := max(len(a), len(b))
+	return extend(a, n), extend(b, n), n, size
+}
+
+func extend(a []int64, n int) []int64 {
+	if len(a) == n {
+		return a
+	}
+	e := make([]int64, n)
+	copy(e, a)
+	return e
+}
+
+// split returns the parts a[:h] and a[h:], the second padded to length h.
+func split(a []int64, h int) ([]int64, []int64) {
+	return a[:h], extend(a[h:], h)
+}
+
+func split3(a []int64, h int) ([]int64, []int64, []int64) {
+	a = extend(a, 3*h)
+	return a[:h], a[h : 2*h], a[2*h:]
+}
+
+func addMod(a, b []int64, q int64) []int64 {
+	c := make([]int64, len(a))
+	for i := range c {
+		c[i] = (a[i] + b[i]) % q
+	}
+	return c
+}
+
+// inverse returns a^-1 mod q by the extended Euclidean algorithm.
+func inverse(a, q int64) int64 {
+	r0, r1, s0, s1 := q, a%q, int64(0), int64(1)
+	for r1 != 0 {
+		k := r0 / r1
+		r0, r1 = r1, r0-k*r1
+		s0, s1 = s1, s0-k*s1
+	}
+	return (s0%q + q) % q
+}
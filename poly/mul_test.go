@@ -0,0 +1,91 @@
+package poly
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+func randomPoly(r *rand.Rand, n int, q int64) []int64 {
+	a := make([]int64, n)
+	for i := range a {
+		a[i] = r.Int63n(q)
+	}
+	return a
+}
+
+// TestMultipliers checks Karatsuba and Toom3 against Schoolbook, with
+// lengths on both sides of schoolbookMax, unequal operands and moduli that
+// are a power of two (where Toom3 falls back to Karatsuba), a prime and
+// the largest supported.
+func TestMultipliers(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	lengths := [][2]int{{1, 1}, {1, 7}, {3, 3}, {15, 16}, {17, 17}, {17, 5}, {33, 31}, {51, 51}, {99, 64}, {255, 255}, {257, 1}}
+	for _, q := range []int64{1 << 13, 3329, 1<<31 - 1} {
+		for _, l := range lengths {
+			a, b := randomPoly(r, l[0], q), randomPoly(r, l[1], q)
+			want := Schoolbook(a, b, q)
+			if len(want) != l[0]+l[1]-1 {
+				t.Fatalf("Schoolbook returned %d coefficients for lengths %v", len(want), l)
+			}
+			for _, m := range []struct {
+				name string
+				mul  Multiplier
+			}{{"Karatsuba", Karatsuba}, {"Toom3", Toom3}} {
+				t.Run(fmt.Sprintf("%s/q=%d/%dx%d", m.name, q, l[0], l[1]), func(t *testing.T) {
+					if got := m.mul(a, b, q); !slices.Equal(got, want) {
+						t.Errorf("product differs from Schoolbook:\n got %v\nwant %v", got, want)
+					}
+				})
+			}
+		}
+	}
+	for _, m := range []Multiplier{Schoolbook, Karatsuba, Toom3} {
+		if got := m(nil, []int64{1}, 17); got != nil {
+			t.Errorf("empty operand: got %v, want nil", got)
+		}
+	}
+}
+
+// TestRing checks Mul against x^N = -1 applied term by term, and the
+// additive operations against each other.
+func TestRing(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	for _, n := range []int{1, 8, 64, 256} {
+		for _, q := range []int64{1 << 13, 3329} {
+			ring, err := NewRing(n, q, Toom3)
+			if err != nil {
+				t.Fatal(err)
+			}
+			a, b := randomPoly(r, n, q), randomPoly(r, n, q)
+			want := make([]int64, n)
+			for i := range a {
+				for j := range b {
+					k, c := i+j, a[i]*b[j]%q
+					if k >= n {
+						k, c = k-n, q-c
+					}
+					want[k] = (want[k] + c) % q
+				}
+			}
+			if got := ring.Mul(a, b); !slices.Equal(got, want) {
+				t.Errorf("n=%d q=%d: Mul differs from the negacyclic product", n, q)
+			}
+			if got := ring.Add(ring.Sub(a, b), b); !slices.Equal(got, a) {
+				t.Errorf("n=%d q=%d: (a - b) + b != a", n, q)
+			}
+			if got := ring.Add(a, ring.Neg(a)); !slices.Equal(got, ring.Zero()) {
+				t.Errorf("n=%d q=%d: a + (-a) != 0", n, q)
+			}
+		}
+	}
+	for _, tc := range []struct {
+		n int
+		q int64
+	}{{0, 17}, {8, 1}, {8, 1 << 31}} {
+		if _, err := NewRing(tc.n, tc.q, nil); err == nil {
+			t.Errorf("NewRing(%d, %d) succeeded", tc.n, tc.q)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package poly
+
+import "fmt"
+
+// Ring is Z_q[x]/(x^N + 1). Elements are slices of N coefficients in
+// [0, q).
+type Ring struct {
+	N int
+	Q int64
+	// Mult multiplies in Z_q[x]; nil means Karatsuba.
+	Mult Multiplier
+}
+
+// NewRing returns the ring of degree n modulo q with multiplier mul.
+func NewRing(n int, q int64, mul Multiplier) (Ring, error) {
+	if n < 1 || q < 2 || q >= 1<<31 {
+		return Ring{}, fmt.Errorf("poly: unsupported ring of degree %d modulo %d", n, q)
+	}
+	return Ring{N: n, Q: q, Mult: mul}, nil
+}
+
+// Zero returns the zero element.
+func (r Ring) Zero() []int64 { return make([]int64, r.N) }
+
+// Reduce returns a copy of a with every coefficient reduced into [0, q).
+func (r Ring) Reduce(a []int64) []int64 {
+	c := make([]int64, r.N)
+	for i, x := range a {
+		x %= r.Q
+		if x < 0 {
+			x += r.Q
+		}
+		c[i] = x
+	}
+	return c
+}
+
+// Add returns a + b.
+func (r Ring) Add(a, b []int64) []int64 {
+	c := make([]int64, r.N)
+	for i := range c {
+		c[i] = (a[i] + b[i]) % r.Q
+	}
+	return c
+}
+
+// Sub returns a - b.
+func (r Ring) Sub(a, b []int64) []int64 {
+	c := make([]int64, r.N)
+	for i := range c {
+		c[i] = (a[i] - b[i] + r.Q) % r.Q
+	}
+	return c
+}
+
+// Neg returns -a.
+func (r Ring) Neg(a []int64) []int64 {
+	c := make([]int64, r.N)
+	for i := range c {
+		c[i] = (r.Q - a[i]) % r.Q
+	}
+	return c
+}
+
+// Mul returns a b mod x^N + 1: the product in Z_q[x] with every term
+// x^(N+i) folded back to -x^i.
+func (r Ring) Mul(a, b []int64) []int64 {
+	mul := r.Mult
+	if mul == nil {
+		mul = Karatsuba
+	}
+	full := mul(a, b, r.Q)
+	c := make([]int64, r.N)
+	copy(c, full)
+	for i := r.N; i < len(full); i++ {
+		c[i-r.N] = (c[i-r.N] - full[i] + r.Q) % r.Q
+	}
+	return c
+}
@@ -0,0 +1,29 @@
+// Package pqkeys encodes ML-KEM and ML-DSA keys in the formats of X.509
+// and PKCS #8, so that they can be stored and exchanged with existing
+// tools.
+//
+// Public keys go in a SubjectPublicKeyInfo (RFC 5280) whose algorithm is
+// the NIST OID of the parameter set, with absent parameters, and whose
+// BIT STRING is the raw key of FIPS 203 or FIPS 204. Private keys go in a
+// PKCS #8 OneAsymmetricKey (RFC 5958) of version 0, whose privateKey OCTET
+// STRING holds a CHOICE between three forms (the IETF LAMPS profiles of
+// ML-KEM and ML-DSA):
+//
+//	seed          [0] IMPLICIT OCTET STRING      -- 64 or 32 bytes
+//	expandedKey   OCTET STRING                   -- the FIPS format
+//	both          SEQUENCE { seed, expandedKey }
+//
+// Marshaling always writes the seed form: it is the shortest, and every
+// other representation of the key can be recomputed from it. Parsing
+// accepts the seed form and the "both" form, whose expanded key must match
+// the one derived from the seed; an expanded key alone cannot be turned
+// back into a seed and is rejected.
+//
+// Parsing is strict: after decoding with encoding/asn1, the structure is
+// encoded again and must give back the input byte for byte, which rules
+// out trailing data, BER length forms, unexpected optional fields and any
+// other second encoding of the same key.
+//
+// The PEM helpers use the types "PUBLIC KEY" and "PRIVATE KEY", as
+// OpenSSL does, and refuse blocks with headers.
+package pqkeys
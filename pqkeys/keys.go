@@ -0,0 +1,264 @@
+package pqkeys
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+
+	"github.com/haopining/Learn-Lattice-Based-Cryptography/mldsa"
+	"github.com/haopining/Learn-Lattice-Based-Cryptography/mlkem"
+)
+
+// The NIST algorithm OIDs, under 2.16.840.1.101.3.4: kems (4) and sigAlgs
+// (3).
+var (
+	OIDMLKEM512  = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 4, 1}
+	OIDMLKEM768  = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 4, 2}
+	OIDMLKEM1024 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 4, 3}
+	OIDMLDSA44   = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 3, 17}
+	OIDMLDSA65   = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 3, 18}
+	OIDMLDSA87   = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 3, 19}
+)
+
+var (
+	// ErrNonCanonical is returned for input that decodes but is not the
+	// DER encoding this package would produce for the same key.
+	ErrNonCanonical = errors.New("pqkeys: non-canonical encoding")
+	// ErrUnknownAlgorithm is returned for an OID, or a key type, other
+	// than the six parameter sets.
+	ErrUnknownAlgorithm = errors.New("pqkeys: unknown algorithm")
+)
+
+// An algorithm ties an OID to one parameter set; exactly one of kem and
+// dsa is set.
+type algorithm struct {
+	oid asn1.ObjectIdentifier
+	kem *mlkem.Params
+	dsa *mldsa.Params
+}
+
+var algorithms = []algorithm{
+	{oid: OIDMLKEM512, kem: mlkem.MLKEM512},
+	{oid: OIDMLKEM768, kem: mlkem.MLKEM768},
+	{oid: OIDMLKEM1024, kem: mlkem.MLKEM1024},
+	{oid: OIDMLDSA44, dsa: mldsa.MLDSA44},
+	{oid: OIDMLDSA65, dsa: mldsa.MLDSA65},
+	{oid: OIDMLDSA87, dsa: mldsa.MLDSA87},
+}
+
+func byOID(oid asn1.ObjectIdentifier) (algorithm, error) {
+	for _, a := range algorithms {
+		if a.oid.Equal(oid) {
+			return a, nil
+		}
+	}
+	return algorithm{}, fmt.Errorf("%w %v", ErrUnknownAlgorithm, oid)
+}
+
+func byKEM(p *mlkem.Params) (algorithm, error) {
+	for _, a := range algorithms {
+		if a.kem == p {
+			return a, nil
+		}
+	}
+	return algorithm{}, ErrUnknownAlgorithm
+}
+
+func byDSA(p *mldsa.Params) (algorithm, error) {
+	for _, a := range algorithms {
+		if a.dsa == p {
+			return a, nil
+		}
+	}
+	return algorithm{}, ErrUnknownAlgorithm
+}
+
+// subjectPublicKeyInfo is the structure of RFC 5280, section 4.1.
+type subjectPublicKeyInfo struct {
+	Algorithm pkix.AlgorithmIdentifier
+	PublicKey asn1.BitString
+}
+
+// oneAsymmetricKey is the structure of RFC 5958, restricted to version 0
+// without attributes; anything more fails the canonicity check.
+type oneAsymmetricKey struct {
+	Version    int
+	Algorithm  pkix.AlgorithmIdentifier
+	PrivateKey []byte
+}
+
+// bothForm is the "both" choice of the private key.
+type bothForm struct {
+	Seed     []byte
+	Expanded []byte
+}
+
+// unmarshal decodes der into v and checks that encoding v gives der back.
+func unmarshal[T any](der []byte, v *T) error {
+	rest, err := asn1.Unmarshal(der, v)
+	if err != nil {
+		return fmt.Errorf("pqkeys: %v", err)
+	}
+	if len(rest) != 0 {
+		return fmt.Errorf("%w: trailing data", ErrNonCanonical)
+	}
+	back, err := asn1.Marshal(*v)
+	if err != nil || !bytes.Equal(back, der) {
+		return ErrNonCanonical
+	}
+	return nil
+}
+
+// MarshalPKIXPublicKey returns the DER SubjectPublicKeyInfo of a
+// *mlkem.EncapsulationKey or *mldsa.PublicKey.
+func MarshalPKIXPublicKey(pub any) ([]byte, error) {
+	var (
+		alg algorithm
+		raw []byte
+		err error
+	)
+	switch k := pub.(type) {
+	case *mlkem.EncapsulationKey:
+		alg, err = byKEM(k.Params())
+		raw = k.Bytes()
+	case *mldsa.PublicKey:
+		alg, err = byDSA(k.Params())
+		raw = k.Bytes()
+	default:
+		return nil, fmt.Errorf("%w: public key of type %T", ErrUnknownAlgorithm, pub)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(subjectPublicKeyInfo{
+		Algorithm: pkix.AlgorithmIdentifier{Algorithm: alg.oid},
+		PublicKey: asn1.BitString{Bytes: raw, BitLength: 8 * len(raw)},
+	})
+}
+
+// ParsePKIXPublicKey parses a DER SubjectPublicKeyInfo into a
+// *mlkem.EncapsulationKey or *mldsa.PublicKey. The parameters must be
+// absent and the key must pass the checks of its scheme, the modulus check
+// for ML-KEM.
+func ParsePKIXPublicKey(der []byte) (any, error) {
+	var spki subjectPublicKeyInfo
+	if err := unmarshal(der, &spki); err != nil {
+		return nil, err
+	}
+	alg, err := byOID(spki.Algorithm.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+	if len(spki.Algorithm.Parameters.FullBytes) != 0 {
+		return nil, errors.New("pqkeys: algorithm parameters must be absent")
+	}
+	if spki.PublicKey.BitLength%8 != 0 {
+		return nil, errors.New("pqkeys: public key is not a whole number of bytes")
+	}
+	if alg.kem != nil {
+		return mlkem.NewEncapsulationKey(alg.kem, spki.PublicKey.Bytes)
+	}
+	return mldsa.NewPublicKey(alg.dsa, spki.PublicKey.Bytes)
+}
+
+// MarshalPKCS8PrivateKey returns the DER PKCS #8 encoding of a
+// *mlkem.DecapsulationKey or *mldsa.PrivateKey, in the seed form.
+func MarshalPKCS8PrivateKey(key any) ([]byte, error) {
+	var (
+		alg  algorithm
+		seed []byte
+		err  error
+	)
+	switch k := key.(type) {
+	case *mlkem.DecapsulationKey:
+		alg, err = byKEM(k.Params())
+		seed = k.Bytes()
+	case *mldsa.PrivateKey:
+		alg, err = byDSA(k.Params())
+		seed = k.Bytes()
+	default:
+		return nil, fmt.Errorf("%w: private key of type %T", ErrUnknownAlgorithm, key)
+	}
+	if err != nil {
+		return nil, err
+	}
+	inner, err := asn1.Marshal(asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, Bytes: seed})
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(oneAsymmetricKey{
+		Algorithm:  pkix.AlgorithmIdentifier{Algorithm: alg.oid},
+		PrivateKey: inner,
+	})
+}
+
+// ParsePKCS8PrivateKey parses a DER PKCS #8 private key into a
+// *mlkem.DecapsulationKey or *mldsa.PrivateKey. It accepts the seed and
+// "both" forms of the key; in the latter, the expanded key must be the one
+// the seed expands to.
+func ParsePKCS8PrivateKey(der []byte) (any, error) {
+	var p8 oneAsymmetricKey
+	if err := unmarshal(der, &p8); err != nil {
+		return nil, err
+	}
+	if p8.Version != 0 {
+		return nil, fmt.Errorf("pqkeys: unsupported PKCS #8 version %d", p8.Version)
+	}
+	alg, err := byOID(p8.Algorithm.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+	if len(p8.Algorithm.Parameters.FullBytes) != 0 {
+		return nil, errors.New("pqkeys: algorithm parameters must be absent")
+	}
+	seed, expanded, err := parseChoice(p8.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		key any
+		exp []byte
+	)
+	if alg.kem != nil {
+		dk, err := mlkem.NewDecapsulationKey(alg.kem, seed)
+		if err != nil {
+			return nil, err
+		}
+		key, exp = dk, dk.ExpandedBytes()
+	} else {
+		sk, err := mldsa.NewPrivateKey(alg.dsa, seed)
+		if err != nil {
+			return nil, err
+		}
+		key, exp = sk, sk.ExpandedBytes()
+	}
+	if expanded != nil && subtle.ConstantTimeCompare(expanded, exp) != 1 {
+		return nil, errors.New("pqkeys: expanded key does not match the seed")
+	}
+	return key, nil
+}
+
+// parseChoice returns the seed, and the expanded key of the "both" form,
+// from the contents of the privateKey OCTET STRING.
+func parseChoice(b []byte) (seed, expanded []byte, err error) {
+	var raw asn1.RawValue
+	if err := unmarshal(b, &raw); err != nil {
+		return nil, nil, err
+	}
+	switch {
+	case raw.Class == asn1.ClassContextSpecific && raw.Tag == 0 && !raw.IsCompound:
+		return raw.Bytes, nil, nil
+	case raw.Class == asn1.ClassUniversal && raw.Tag == asn1.TagSequence:
+		var both bothForm
+		if err := unmarshal(b, &both); err != nil {
+			return nil, nil, err
+		}
+		return both.Seed, both.Expanded, nil
+	case raw.Class == asn1.ClassUniversal && raw.Tag == asn1.TagOctetString:
+		return nil, nil, errors.New("pqkeys: expanded-only private keys are not supported")
+	}
+	return nil, nil, errors.New("pqkeys: malformed private key")
+}
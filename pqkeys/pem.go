@@ -0,0 +1,60 @@
+package pqkeys
+
+import (
+	"encoding/pem"
+	"errors"
+	"fmt"
+
+	"github.com/haopining/Learn-Lattice-Based-Cryptography/mldsa"
+	"github.com/haopining/Learn-Lattice-Based-Cryptography/mlkem"
+)
+
+// The PEM block types of SubjectPublicKeyInfo and PKCS #8.
+const (
+	PublicKeyPEM  = "PUBLIC KEY"
+	PrivateKeyPEM = "PRIVATE KEY"
+)
+
+// MarshalPEM returns the PEM encoding of a public or private key, a
+// PUBLIC KEY or PRIVATE KEY block around the DER of MarshalPKIXPublicKey or
+// MarshalPKCS8PrivateKey.
+func MarshalPEM(key any) ([]byte, error) {
+	typ := PublicKeyPEM
+	var (
+		der []byte
+		err error
+	)
+	switch key.(type) {
+	case *mlkem.DecapsulationKey, *mldsa.PrivateKey:
+		typ = PrivateKeyPEM
+		der, err = MarshalPKCS8PrivateKey(key)
+	default:
+		der, err = MarshalPKIXPublicKey(key)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der}), nil
+}
+
+// ParsePEM parses the first PEM block of data, which must be a PUBLIC KEY
+// or PRIVATE KEY block without headers, and returns the key and the data
+// after the block.
+func ParsePEM(data []byte) (key any, rest []byte, err error) {
+	block, rest := pem.Decode(data)
+	if block == nil {
+		return nil, data, errors.New("pqkeys: no PEM block found")
+	}
+	if len(block.Headers) != 0 {
+		return nil, rest, errors.New("pqkeys: unexpected PEM headers")
+	}
+	switch block.Type {
+	case PublicKeyPEM:
+		key, err = ParsePKIXPublicKey(block.Bytes)
+	case PrivateKeyPEM:
+		key, err = ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		err = fmt.Errorf("pqkeys: unexpected PEM block type %q", block.Type)
+	}
+	return key, rest, err
+}
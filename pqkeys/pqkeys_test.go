@@ -0,0 +1,288 @@
+//go:build go1.27
+
+package pqkeys
+
+import (
+	"bytes"
+	std "crypto/mldsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"testing"
+
+	"github.com/haopining/Learn-Lattice-Based-Cryptography/mldsa"
+	"github.com/haopining/Learn-Lattice-Based-Cryptography/mlkem"
+)
+
+// keys returns a private key of every parameter set, from fixed seeds.
+func keys(t *testing.T) []any {
+	var out []any
+	for i, p := range []*mlkem.Params{mlkem.MLKEM512, mlkem.MLKEM768, mlkem.MLKEM1024} {
+		dk, err := mlkem.NewDecapsulationKey(p, bytes.Repeat([]byte{byte(i)}, mlkem.SeedSize))
+		if err != nil {
+			t.Fatal(err)
+		}
+		out = append(out, dk)
+	}
+	for i, p := range []*mldsa.Params{mldsa.MLDSA44, mldsa.MLDSA65, mldsa.MLDSA87} {
+		sk, err := mldsa.NewPrivateKey(p, bytes.Repeat([]byte{byte(i)}, mldsa.SeedSize))
+		if err != nil {
+			t.Fatal(err)
+		}
+		out = append(out, sk)
+	}
+	return out
+}
+
+func public(key any) any {
+	if dk, ok := key.(*mlkem.DecapsulationKey); ok {
+		return dk.EncapsulationKey()
+	}
+	return key.(*mldsa.PrivateKey).PublicKey()
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, key := range keys(t) {
+		der, err := MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := ParsePKCS8PrivateKey(der)
+		if err != nil {
+			t.Fatalf("%T: %v", key, err)
+		}
+		switch k := key.(type) {
+		case *mlkem.DecapsulationKey:
+			if !k.Equal(got.(*mlkem.DecapsulationKey)) {
+				t.Errorf("%v: private key changed", k.Params())
+			}
+		case *mldsa.PrivateKey:
+			if !k.Equal(got) {
+				t.Errorf("%v: private key changed", k.Params())
+			}
+		}
+
+		pub := public(key)
+		der, err = MarshalPKIXPublicKey(pub)
+		if err != nil {
+			t.Fatal(err)
+		}
+		gotPub, err := ParsePKIXPublicKey(der)
+		if err != nil {
+			t.Fatalf("%T: %v", pub, err)
+		}
+		switch k := pub.(type) {
+		case *mlkem.EncapsulationKey:
+			if !k.Equal(gotPub.(*mlkem.EncapsulationKey)) {
+				t.Errorf("%v: public key changed", k.Params())
+			}
+		case *mldsa.PublicKey:
+			if !k.Equal(gotPub) {
+				t.Errorf("%v: public key changed", k.Params())
+			}
+		}
+
+		for _, k := range []any{key, pub} {
+			b, err := MarshalPEM(k)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, rest, err := ParsePEM(append(b, "tail"...)); err != nil || string(rest) != "tail" {
+				t.Errorf("%T: PEM round trip failed: %v", k, err)
+			}
+		}
+	}
+}
+
+// TestX509 checks that crypto/x509, which supports ML-DSA but not ML-KEM,
+// produces exactly the same encodings and reads the ones of this package.
+func TestX509(t *testing.T) {
+	for i, params := range []std.Parameters{std.MLDSA44(), std.MLDSA65(), std.MLDSA87()} {
+		sk := keys(t)[3+i].(*mldsa.PrivateKey)
+		ref, err := std.NewPrivateKey(params, sk.Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		der, err := MarshalPKCS8PrivateKey(sk)
+		if err != nil {
+			t.Fatal(err)
+		}
+		refDER, err := x509.MarshalPKCS8PrivateKey(ref)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(der, refDER) {
+			t.Errorf("%v: PKCS #8 encodings differ:\n%x\n%x", params, der, refDER)
+		}
+		parsed, err := x509.ParsePKCS8PrivateKey(der)
+		if err != nil {
+			t.Fatalf("%v: crypto/x509: %v", params, err)
+		}
+		if !ref.Equal(parsed) {
+			t.Errorf("%v: crypto/x509 parsed another private key", params)
+		}
+		if got, err := ParsePKCS8PrivateKey(refDER); err != nil || !sk.Equal(got) {
+			t.Errorf("%v: cannot parse the PKCS #8 key of crypto/x509: %v", params, err)
+		}
+
+		der, err = MarshalPKIXPublicKey(sk.PublicKey())
+		if err != nil {
+			t.Fatal(err)
+		}
+		refDER, err = x509.MarshalPKIXPublicKey(ref.PublicKey())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(der, refDER) {
+			t.Errorf("%v: SubjectPublicKeyInfo encodings differ", params)
+		}
+		parsedPub, err := x509.ParsePKIXPublicKey(der)
+		if err != nil {
+			t.Fatalf("%v: crypto/x509: %v", params, err)
+		}
+		if pk, ok := parsedPub.(*std.PublicKey); !ok || !bytes.Equal(pk.Bytes(), ref.PublicKey().Bytes()) {
+			t.Errorf("%v: crypto/x509 parsed another public key", params)
+		}
+		if got, err := ParsePKIXPublicKey(refDER); err != nil || !sk.PublicKey().Equal(got) {
+			t.Errorf("%v: cannot parse the public key of crypto/x509: %v", params, err)
+		}
+	}
+}
+
+func TestStrict(t *testing.T) {
+	dk := keys(t)[1].(*mlkem.DecapsulationKey)
+	sk := keys(t)[3].(*mldsa.PrivateKey)
+	p8, err := MarshalPKCS8PrivateKey(sk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spki, err := MarshalPKIXPublicKey(sk.PublicKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// privateKey builds a PKCS #8 key around the given privateKey
+	// contents.
+	privateKey := func(oid asn1.ObjectIdentifier, inner any) []byte {
+		b, err := asn1.Marshal(inner)
+		if err != nil {
+			t.Fatal(err)
+		}
+		der, err := asn1.Marshal(oneAsymmetricKey{Algorithm: pkix.AlgorithmIdentifier{Algorithm: oid}, PrivateKey: b})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return der
+	}
+	seed := asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, Bytes: sk.Bytes()}
+	if _, err := ParsePKCS8PrivateKey(privateKey(OIDMLDSA44, seed)); err != nil {
+		t.Fatalf("the seed form built by the test is rejected: %v", err)
+	}
+	if key, err := ParsePKCS8PrivateKey(privateKey(OIDMLDSA44, bothForm{sk.Bytes(), sk.ExpandedBytes()})); err != nil || !sk.Equal(key) {
+		t.Errorf("rejected the both form: %v", err)
+	}
+
+	// Re-encode the public key with a long-form length, valid BER but
+	// not DER.
+	var info subjectPublicKeyInfo
+	if _, err := asn1.Unmarshal(spki, &info); err != nil {
+		t.Fatal(err)
+	}
+	alg, err := asn1.Marshal(info.Algorithm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bits, err := asn1.Marshal(info.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := len(alg) + len(bits)
+	ber := append([]byte{0x30, 0x84, byte(n >> 24), byte(n >> 16), byte(n >> 8), byte(n)}, alg...)
+	ber = append(ber, bits...)
+	null, err := asn1.Marshal(subjectPublicKeyInfo{
+		Algorithm: pkix.AlgorithmIdentifier{Algorithm: OIDMLDSA44, Parameters: asn1.NullRawValue},
+		PublicKey: info.PublicKey,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ed25519, err := asn1.Marshal(subjectPublicKeyInfo{
+		Algorithm: pkix.AlgorithmIdentifier{Algorithm: asn1.ObjectIdentifier{1, 3, 101, 112}},
+		PublicKey: info.PublicKey,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wrongSet, err := asn1.Marshal(subjectPublicKeyInfo{
+		Algorithm: pkix.AlgorithmIdentifier{Algorithm: OIDMLDSA65},
+		PublicKey: info.PublicKey,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name  string
+		parse func([]byte) (any, error)
+		der   []byte
+		want  error
+	}{
+		{"private key with trailing data", ParsePKCS8PrivateKey, append(bytes.Clone(p8), 0), ErrNonCanonical},
+		{"public key with trailing data", ParsePKIXPublicKey, append(bytes.Clone(spki), 0), ErrNonCanonical},
+		{"public key in BER", ParsePKIXPublicKey, ber, nil},
+		{"public key with NULL parameters", ParsePKIXPublicKey, null, nil},
+		{"public key with the Ed25519 OID", ParsePKIXPublicKey, ed25519, ErrUnknownAlgorithm},
+		{"ML-DSA-44 key with the ML-DSA-65 OID", ParsePKIXPublicKey, wrongSet, nil},
+		{"private key with the Ed25519 OID", ParsePKCS8PrivateKey, privateKey(asn1.ObjectIdentifier{1, 3, 101, 112}, seed), ErrUnknownAlgorithm},
+		{"ML-KEM seed with an ML-DSA OID", ParsePKCS8PrivateKey, privateKey(OIDMLDSA44, asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, Bytes: dk.Bytes()}), nil},
+		{"expanded-only private key", ParsePKCS8PrivateKey, privateKey(OIDMLDSA44, sk.ExpandedBytes()), nil},
+		{"expanded-only ML-KEM private key", ParsePKCS8PrivateKey, privateKey(OIDMLKEM768, dk.ExpandedBytes()), nil},
+		{"both form with another expanded key", ParsePKCS8PrivateKey, privateKey(OIDMLDSA44, bothForm{sk.Bytes(), flip(sk.ExpandedBytes())}), nil},
+		{"private key of version 1", ParsePKCS8PrivateKey, version1(t, p8), nil},
+	} {
+		key, err := tc.parse(tc.der)
+		if err == nil {
+			t.Errorf("%s: accepted as %T", tc.name, key)
+		} else if tc.want != nil && !errors.Is(err, tc.want) {
+			t.Errorf("%s: %v, want %v", tc.name, err, tc.want)
+		}
+	}
+
+	b, err := MarshalPEM(sk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, _ := pem.Decode(b)
+	block.Headers = map[string]string{"Proc-Type": "4,ENCRYPTED"}
+	if _, _, err := ParsePEM(pem.EncodeToMemory(block)); err == nil {
+		t.Error("accepted a PEM block with headers")
+	}
+	block.Headers, block.Type = nil, "EC PRIVATE KEY"
+	if _, _, err := ParsePEM(pem.EncodeToMemory(block)); err == nil {
+		t.Error("accepted an EC PRIVATE KEY block")
+	}
+}
+
+func flip(b []byte) []byte {
+	b = bytes.Clone(b)
+	b[len(b)-1] ^= 1
+	return b
+}
+
+// version1 returns p8 with the version field set to 1, the version of
+// keys with a public key attached.
+func version1(t *testing.T, p8 []byte) []byte {
+	var k oneAsymmetricKey
+	if _, err := asn1.Unmarshal(p8, &k); err != nil {
+		t.Fatal(err)
+	}
+	k.Version = 1
+	der, err := asn1.Marshal(k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return der
+}
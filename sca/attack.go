@@ -0,0 +1,238 @@
+package sca
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"math/bits"
+
+	"github.com/haopining/Learn-Lattice-Based-Cryptography/gauss"
+	"github.com/haopining/Learn-Lattice-Based-Cryptography/mlkem"
+)
+
+// A Campaign is what the attacker of a device sees: the traces of the
+// decryption of chosen ciphertexts, the ciphertexts, and the layout of the
+// trace.
+type Campaign struct {
+	Params      *mlkem.Params
+	Model       Model
+	Traces      [][]float64
+	Ciphertexts [][]byte
+	basemul     []int // offsets of the parts "basemul i"
+}
+
+// Collect records n decryptions of uniformly random ciphertexts, all of
+// which are valid for the compression parameters of ML-KEM.
+func Collect(dev *Device, p *Probe, n int, r *gauss.Rand) (*Campaign, error) {
+	params := dev.Params()
+	c := &Campaign{Params: params, Model: p.Model}
+	for range n {
+		ct := randomBytes(r, params.CiphertextSize())
+		trace, err := dev.Record(p, ct)
+		if err != nil {
+			return nil, err
+		}
+		c.Traces = append(c.Traces, trace)
+		c.Ciphertexts = append(c.Ciphertexts, ct)
+	}
+	for i := range params.K {
+		off, ok := p.Offset(fmt.Sprintf("basemul %d", i))
+		if !ok {
+			return nil, fmt.Errorf("sca: no basemul %d in the trace", i)
+		}
+		c.basemul = append(c.basemul, off)
+	}
+	return c, nil
+}
+
+// CPA recovers s-hat by correlation power analysis of the base
+// multiplications. For every coefficient it tries the q possible values:
+// the hypothetical leakage of the product of the guess with the known
+// coefficient of u-hat, by the model of the campaign, is correlated with
+// the sample of that product over all traces, and the guess of the
+// highest Pearson correlation wins. f0 is attacked through f0 g0 and f1
+// through f1 g0, each of which overwrites the load of g0; under the
+// Hamming distance model the hypothesis is the distance from g0.
+func (c *Campaign) CPA() []mlkem.Poly {
+	n := len(c.Traces)
+	u := make([][]mlkem.Poly, n)
+	for t, ct := range c.Ciphertexts {
+		u[t] = CiphertextNTT(c.Params, ct)
+	}
+	guess := make([]mlkem.Poly, c.Params.K)
+	b := make([]uint16, n)
+	x := make([]float64, n)
+	v := make([]uint16, n)
+	for i := range guess {
+		for j := range mlkem.N / 2 {
+			for t := range n {
+				b[t] = u[t][i][2*j]
+			}
+			for k, sample := range []int{1, 5} {
+				for t := range n {
+					x[t] = c.Traces[t][c.basemul[i]+11*j+sample]
+				}
+				guess[i][2*j+k] = c.best(b, x, v)
+			}
+		}
+	}
+	return guess
+}
+
+// best returns the guess g whose hypothesis on g b correlates best with x.
+// One guess, 0 under the Hamming weight model and 1 under the Hamming
+// distance model, leaks 0 whatever b is, and a constant has no
+// correlation: it is taken when the samples average close to 0, as no
+// other guess can, the products of the others averaging about six bits.
+func (c *Campaign) best(b []uint16, x []float64, v []uint16) uint16 {
+	n := float64(len(x))
+	var sx, sxx float64
+	for _, xt := range x {
+		sx += xt
+		sxx += xt * xt
+	}
+	vx := n*sxx - sx*sx
+	if vx <= 0 {
+		return 0
+	}
+	clear(v) // v[t] = g b[t] mod q, updated by additions
+	var best uint16
+	bestRho := math.Inf(-1)
+	for g := range uint16(q) {
+		var sh, shh, shx float64
+		for t := range x {
+			w := v[t]
+			if c.Model == HammingDistance {
+				w ^= b[t]
+			}
+			h := float64(bits.OnesCount16(w))
+			sh += h
+			shh += h * h
+			shx += h * x[t]
+			if v[t] += b[t]; v[t] >= q {
+				v[t] -= q
+			}
+		}
+		if vh := n*shh - sh*sh; vh <= 0 {
+			if math.Abs(sx-sh) < n {
+				return g
+			}
+		} else if rho := (n*shx - sh*sx) / math.Sqrt(vh*vx); rho > bestRho {
+			best, bestRho = g, rho
+		}
+	}
+	return best
+}
+
+// Correct returns the number of coefficients of guess equal to those of
+// secret.
+func Correct(guess, secret []mlkem.Poly) int {
+	n := 0
+	for i := range guess {
+		for j := range guess[i] {
+			if guess[i][j] == secret[i][j] {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// SampleSecret returns a polynomial of CBD_eta, a secret of ML-KEM key
+// generation before its NTT.
+func SampleSecret(eta int, r *gauss.Rand) mlkem.Poly {
+	return mlkem.SamplePolyCBD(eta, randomBytes(r, 64*eta), nil)
+}
+
+// nttClass returns the class of butterfly j of the first NTT layer, the
+// pair (f[j], f[j+128]) of coefficients in [-eta, eta].
+func nttClass(eta int, f *mlkem.Poly, j int) int {
+	center := func(x uint16) int {
+		if int(x) > eta {
+			return int(x) - q
+		}
+		return int(x)
+	}
+	return (center(f[j])+eta)*(2*eta+1) + center(f[j+128]) + eta
+}
+
+// ProfileNTT builds the template of the first layer of the NTT of a
+// secret of CBD_eta from n traces of NTTs of random secrets, on a device
+// the attacker controls. The first layer has one twiddle factor, so its
+// 128 butterflies compute the same function of their pair, and one
+// template of their five samples serves them all.
+func ProfileNTT(p *Probe, eta, n int, r *gauss.Rand) (*Template, error) {
+	t := NewTemplate((2*eta+1)*(2*eta+1), 5)
+	for range n {
+		s := SampleSecret(eta, r)
+		trace := p.Record(func() { NTT(p, s) })
+		for j := range 128 {
+			t.Add(nttClass(eta, &s, j), trace[5*j:5*j+5])
+		}
+	}
+	return t, t.Build()
+}
+
+// AttackNTT recovers a secret of CBD_eta from a single trace of its NTT,
+// butterfly by butterfly.
+func AttackNTT(t *Template, eta int, trace []float64) mlkem.Poly {
+	var s mlkem.Poly
+	for j := range 128 {
+		c := t.Classify(trace[5*j : 5*j+5])
+		a, b := c/(2*eta+1)-eta, c%(2*eta+1)-eta
+		s[j], s[j+128] = uint16((a+q)%q), uint16((b+q)%q)
+	}
+	return s
+}
+
+// A MessageTemplate recovers the message of a decapsulation from the
+// leakage of the message decoding of its re-encryption. Bit i is
+// classified from three samples: the previous coefficient, the mask of
+// the bit and its coefficient.
+type MessageTemplate struct {
+	t *Template
+}
+
+func messageSamples(trace []float64, off, i int) []float64 {
+	return trace[off+2*i-1 : off+2*i+2]
+}
+
+// ProfileMessage builds the message template from n decapsulations on a
+// device of the attacker, of ciphertexts of random messages that the
+// attacker encapsulates to ek, the device's key.
+func ProfileMessage(dev *Device, ek *mlkem.EncapsulationKey, p *Probe, n int, r *gauss.Rand) (*MessageTemplate, error) {
+	mt := &MessageTemplate{NewTemplate(2, 3)}
+	for range n {
+		m := randomBytes(r, 32)
+		_, c := ek.EncapsulateInternal(m)
+		trace, err := dev.Record(p, c)
+		if err != nil {
+			return nil, err
+		}
+		off, _ := p.Offset("decode")
+		for i := range 256 {
+			mt.t.Add(int(m[i/8]>>(i%8)&1), messageSamples(trace, off, i))
+		}
+	}
+	return mt, mt.t.Build()
+}
+
+// Attack returns the message of a decapsulation trace whose "decode" part
+// starts at off.
+func (mt *MessageTemplate) Attack(trace []float64, off int) []byte {
+	m := make([]byte, 32)
+	for i := range 256 {
+		m[i/8] |= byte(mt.t.Classify(messageSamples(trace, off, i))) << (i % 8)
+	}
+	return m
+}
+
+// RecoverSharedKey attacks a decapsulation of the ciphertext c under the
+// key ek from its trace, whose "decode" part starts at off: it recovers
+// the message, and returns the shared key G(m || H(ek)) if the message
+// re-encrypts to c, which confirms it.
+func (mt *MessageTemplate) RecoverSharedKey(ek *mlkem.EncapsulationKey, c []byte, trace []float64, off int) (key, m []byte, ok bool) {
+	m = mt.Attack(trace, off)
+	key, c2 := ek.EncapsulateInternal(m)
+	return key, m, bytes.Equal(c2, c)
+}
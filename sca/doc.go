@@ -0,0 +1,38 @@
+// Package sca simulates power analysis of ML-KEM, for coursework on side
+// channels: instrumented copies of the NTT, its inverse, the base
+// multiplications and the message encoding and decoding record a power
+// trace, one sample per intermediate value, and attacks read the secrets
+// back out of the traces.
+//
+// A sample is the Hamming weight of a value, or its Hamming distance from
+// the previous one, plus Gaussian noise of a chosen deviation: the
+// textbook models of CMOS power consumption, without the filtering,
+// misalignment and jitter of real measurements. A Device holds an ML-KEM
+// key and decrypts or decapsulates with the instrumented code, which
+// computes exactly what package mlkem does.
+//
+// Three attacks are provided, all against code that has no
+// countermeasures, which is the point:
+//
+//   - CPA, correlation power analysis of decryption: the products of the
+//     coefficients of s-hat with those of u-hat, the NTT of the
+//     ciphertext, leak the secret coefficient through a known input. For
+//     each coefficient, the guess whose hypothetical leakage correlates
+//     best with the traces is kept; with low noise, a few dozen traces of
+//     random ciphertexts give the whole of s-hat, and s = InvNTT(s-hat).
+//   - A template attack on the first layer of the NTT of key generation.
+//     On a device of their own, the attacker learns the distribution of
+//     the five samples of a butterfly for each pair of small secret
+//     coefficients; on the victim, a single trace then gives most of the
+//     secret. Even without noise a few pairs have the same Hamming weights
+//     and stay ambiguous, which a real attack would settle with the
+//     public key, for instance by lattice reduction, as left out here.
+//   - A template attack on message decoding, the first step of the
+//     re-encryption of decapsulation, where each message bit becomes a
+//     mask of 0 or 0xffff: a single trace gives the message, and so the
+//     shared key. The message is confirmed by re-encrypting it.
+//
+// Masking and shuffling are the usual countermeasures: masking makes the
+// leakage of each sample independent of the secret, and shuffling hides
+// which sample belongs to which coefficient.
+package sca
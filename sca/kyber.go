@@ -0,0 +1,268 @@
+package sca
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/haopining/Learn-Lattice-Based-Cryptography/encoding"
+	"github.com/haopining/Learn-Lattice-Based-Cryptography/gauss"
+	"github.com/haopining/Learn-Lattice-Based-Cryptography/mlkem"
+)
+
+// The instrumented routines are plain textbook versions of those of
+// package mlkem, with the same results, which leak every value they load
+// or compute through the probe. Their arithmetic reduces with % q: only
+// the values matter to the leakage models, not how they are computed.
+
+const q = mlkem.Q
+
+func add(a, b uint16) uint16 { return uint16((uint32(a) + uint32(b)) % q) }
+func sub(a, b uint16) uint16 { return uint16((uint32(a) + q - uint32(b)) % q) }
+func mul(a, b uint16) uint16 { return uint16(uint32(a) * uint32(b) % q) }
+
+// zetas[i] = 17^bitrev7(i) and gammas[i] = 17^(2 bitrev7(i) + 1), as in
+// package mlkem.
+var zetas, gammas = func() (z, g [128]uint16) {
+	pow := func(e int) uint16 {
+		r := uint16(1)
+		for range e {
+			r = mul(r, 17)
+		}
+		return r
+	}
+	for i := range z {
+		rev := 0
+		for b := range 7 {
+			rev |= (i >> b & 1) << (6 - b)
+		}
+		z[i] = pow(rev)
+		g[i] = pow(2*rev + 1)
+	}
+	return z, g
+}()
+
+// NTT is mlkem.NTT. Each butterfly leaks five samples: the two inputs
+// f[j] and f[j+l], the product t = zeta f[j+l], and the outputs f[j] - t
+// and f[j] + t. The first layer, l = 128, comes first, so butterfly j of
+// it, which mixes f[j] and f[j+128], leaks samples 5j to 5j+4.
+func NTT(p *Probe, f mlkem.Poly) mlkem.Poly {
+	k := 1
+	for l := 128; l >= 2; l /= 2 {
+		for start := 0; start < mlkem.N; start += 2 * l {
+			zeta := zetas[k]
+			k++
+			for j := start; j < start+l; j++ {
+				a, b := f[j], f[j+l]
+				p.leak(a)
+				p.leak(b)
+				t := mul(zeta, b)
+				p.leak(t)
+				f[j+l] = sub(a, t)
+				p.leak(f[j+l])
+				f[j] = add(a, t)
+				p.leak(f[j])
+			}
+		}
+	}
+	return f
+}
+
+// InvNTT is mlkem.InvNTT. Each butterfly leaks its inputs a and b, the sum
+// a + b, the difference b - a and its product with zeta; the final
+// scaling leaks every coefficient.
+func InvNTT(p *Probe, f mlkem.Poly) mlkem.Poly {
+	k := 127
+	for l := 2; l <= 128; l *= 2 {
+		for start := 0; start < mlkem.N; start += 2 * l {
+			zeta := zetas[k]
+			k--
+			for j := start; j < start+l; j++ {
+				a, b := f[j], f[j+l]
+				p.leak(a)
+				p.leak(b)
+				f[j] = add(a, b)
+				p.leak(f[j])
+				d := sub(b, a)
+				p.leak(d)
+				f[j+l] = mul(zeta, d)
+				p.leak(f[j+l])
+			}
+		}
+	}
+	for i := range f {
+		f[i] = mul(f[i], 3303)
+		p.leak(f[i])
+	}
+	return f
+}
+
+// MultiplyNTTs is mlkem.MultiplyNTTs for a secret f and a public g. Each
+// pair i leaks 11 samples, 11i to 11i+10: every product of a secret
+// coefficient is preceded by the load of the public one, as in
+//
+//	g0, f0 g0, g1, f0 g1, g0, f1 g0, g1, f1 g1, gamma f1 g1, h0, h1
+//
+// so that f0 g0 overwrites g0 and f1 g0 overwrites g0 in the register.
+func MultiplyNTTs(p *Probe, f, g *mlkem.Poly) mlkem.Poly {
+	var h mlkem.Poly
+	for i := range mlkem.N / 2 {
+		a0, a1, b0, b1 := f[2*i], f[2*i+1], g[2*i], g[2*i+1]
+		p.leak(b0)
+		a0b0 := mul(a0, b0)
+		p.leak(a0b0)
+		p.leak(b1)
+		a0b1 := mul(a0, b1)
+		p.leak(a0b1)
+		p.leak(b0)
+		a1b0 := mul(a1, b0)
+		p.leak(a1b0)
+		p.leak(b1)
+		a1b1 := mul(a1, b1)
+		p.leak(a1b1)
+		a1b1g := mul(a1b1, gammas[i])
+		p.leak(a1b1g)
+		h[2*i] = add(a0b0, a1b1g)
+		p.leak(h[2*i])
+		h[2*i+1] = add(a0b1, a1b0)
+		p.leak(h[2*i+1])
+	}
+	return h
+}
+
+// EncodeMessage rounds every coefficient of w to a bit, Compress_1, and
+// packs the bits into the 32-byte message, as decryption does. Like the
+// reference code it ORs each bit into its message byte, and the byte is
+// leaked after every OR.
+func EncodeMessage(p *Probe, w *mlkem.Poly) []byte {
+	m := make([]byte, 32)
+	for i, x := range w {
+		m[i/8] |= byte(encoding.Compress(1, x)) << (i % 8)
+		p.leak(uint16(m[i/8]))
+	}
+	return m
+}
+
+// DecodeMessage maps the 256 bits of m to 0 or round(q/2), the first
+// step of the re-encryption of decapsulation. It is branch-free, as in the
+// reference code: bit i becomes the mask 0 or 0xffff, which is ANDed with
+// round(q/2). Sample 2i is the mask and sample 2i+1 the coefficient.
+func DecodeMessage(p *Probe, m []byte) mlkem.Poly {
+	var f mlkem.Poly
+	for i := range f {
+		mask := -uint16(m[i/8] >> (i % 8) & 1)
+		p.leak(mask)
+		f[i] = mask & ((q + 1) / 2)
+		p.leak(f[i])
+	}
+	return f
+}
+
+// A Device is the victim: it holds an ML-KEM decryption key and decrypts
+// ciphertexts, leaking through a probe.
+type Device struct {
+	params *mlkem.Params
+	dk     *mlkem.DecapsulationKey
+	s      []mlkem.Poly // s-hat, NTT domain
+}
+
+// NewDevice returns a device with the key dk.
+func NewDevice(dk *mlkem.DecapsulationKey) (*Device, error) {
+	p := dk.Params()
+	b := dk.ExpandedBytes()
+	d := &Device{params: p, dk: dk, s: make([]mlkem.Poly, p.K)}
+	for i := range d.s {
+		f, err := encoding.ByteDecode(12, b[384*i:384*(i+1)])
+		if err != nil {
+			return nil, err
+		}
+		copy(d.s[i][:], f)
+	}
+	return d, nil
+}
+
+// Params returns the parameter set of the key.
+func (d *Device) Params() *mlkem.Params { return d.params }
+
+// Secret returns s-hat, to score the attacks.
+func (d *Device) Secret() []mlkem.Poly { return append([]mlkem.Poly(nil), d.s...) }
+
+// decompress returns Decompress_d(ByteDecode_d(b)).
+func decompress(d int, b []byte) mlkem.Poly {
+	f, err := encoding.ByteDecode(d, b)
+	if err != nil {
+		panic(err)
+	}
+	var g mlkem.Poly
+	copy(g[:], encoding.DecompressPoly(d, f))
+	return g
+}
+
+// CiphertextNTT returns u-hat, the NTT of the first part of a ciphertext,
+// which the attacker computes from the ciphertext as the device does.
+func CiphertextNTT(params *mlkem.Params, c []byte) []mlkem.Poly {
+	u := make([]mlkem.Poly, params.K)
+	n := 32 * params.Du
+	for i := range u {
+		u[i] = NTT(nil, decompress(params.Du, c[n*i:n*(i+1)]))
+	}
+	return u
+}
+
+var errCiphertextSize = errors.New("sca: invalid ciphertext size")
+
+// Decrypt is K-PKE.Decrypt. The trace has the parts "ntt i" for the NTT
+// of u_i, "basemul i" for the product of s-hat_i and u-hat_i, "invntt"
+// and "encode".
+func (d *Device) Decrypt(p *Probe, c []byte) ([]byte, error) {
+	params := d.params
+	if len(c) != params.CiphertextSize() {
+		return nil, errCiphertextSize
+	}
+	n := 32 * params.Du
+	u := make([]mlkem.Poly, params.K)
+	for i := range u {
+		p.mark(fmt.Sprintf("ntt %d", i))
+		u[i] = NTT(p, decompress(params.Du, c[n*i:n*(i+1)]))
+	}
+	v := decompress(params.Dv, c[n*params.K:])
+	var acc mlkem.Poly
+	for i := range u {
+		p.mark(fmt.Sprintf("basemul %d", i))
+		t := MultiplyNTTs(p, &d.s[i], &u[i])
+		acc = acc.Add(&t)
+	}
+	p.mark("invntt")
+	su := InvNTT(p, acc)
+	w := v.Sub(&su)
+	p.mark("encode")
+	return EncodeMessage(p, &w), nil
+}
+
+// Decapsulate returns the shared key of c. It is Decrypt followed by the
+// message decoding of the re-encryption, the part "decode" of the trace;
+// the rest of the re-encryption is left to package mlkem and does not
+// leak.
+func (d *Device) Decapsulate(p *Probe, c []byte) ([]byte, error) {
+	m, err := d.Decrypt(p, c)
+	if err != nil {
+		return nil, err
+	}
+	p.mark("decode")
+	DecodeMessage(p, m)
+	return d.dk.Decapsulate(c)
+}
+
+// Record records the decapsulation of c.
+func (d *Device) Record(p *Probe, c []byte) ([]float64, error) {
+	var err error
+	trace := p.Record(func() { _, err = d.Decapsulate(p, c) })
+	return trace, err
+}
+
+func randomBytes(r *gauss.Rand, n int) []byte {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = byte(r.Uint64())
+	}
+	return b
+}
@@ -0,0 +1,86 @@
+package sca
+
+import (
+	"math/bits"
+
+	"github.com/haopining/Learn-Lattice-Based-Cryptography/gauss"
+)
+
+// A Model is a leakage model: what a sample of the power trace reveals
+// about the value being processed.
+type Model int
+
+const (
+	// HammingWeight leaks the number of one bits of each value, as the
+	// charge of a precharged bus does.
+	HammingWeight Model = iota
+	// HammingDistance leaks the number of bits that change from the
+	// previous value to the next, as a register that is overwritten does.
+	HammingDistance
+)
+
+func (m Model) String() string {
+	if m == HammingDistance {
+		return "HD"
+	}
+	return "HW"
+}
+
+// A Probe records the power trace of the instrumented code: one sample for
+// every intermediate value, the leakage of the model plus Gaussian noise
+// of standard deviation Sigma. A nil Probe records nothing.
+type Probe struct {
+	Model Model
+	Sigma float64
+	Trace []float64
+	r     *gauss.Rand
+	prev  uint16
+	marks map[string]int
+}
+
+// NewProbe returns a probe drawing its noise from r.
+func NewProbe(m Model, sigma float64, r *gauss.Rand) *Probe {
+	return &Probe{Model: m, Sigma: sigma, r: r, marks: map[string]int{}}
+}
+
+func (p *Probe) leak(v uint16) {
+	if p == nil {
+		return
+	}
+	x := v
+	if p.Model == HammingDistance {
+		x ^= p.prev
+	}
+	p.prev = v
+	s := float64(bits.OnesCount16(x))
+	if p.Sigma > 0 {
+		s += p.Sigma * p.r.Normal()
+	}
+	p.Trace = append(p.Trace, s)
+}
+
+// mark names the next sample, so that attacks can find the operations
+// they target.
+func (p *Probe) mark(name string) {
+	if p != nil {
+		p.marks[name] = len(p.Trace)
+	}
+}
+
+// Offset returns the index of the first sample of the named part of the
+// trace, such as "basemul 0" or "decode"; the names are listed with the
+// functions that leak them.
+func (p *Probe) Offset(name string) (int, bool) {
+	i, ok := p.marks[name]
+	return i, ok
+}
+
+// Record runs fn on a fresh trace and returns the trace. Every trace of
+// the same code has the same layout, so the offsets are those of the last
+// trace recorded.
+func (p *Probe) Record(fn func()) []float64 {
+	p.Trace = nil
+	p.prev = 0
+	fn()
+	return p.Trace
+}
@@ -0,0 +1,142 @@
+package sca
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/haopining/Learn-Lattice-Based-Cryptography/gauss"
+	"github.com/haopining/Learn-Lattice-Based-Cryptography/mlkem"
+)
+
+func randomPoly(r *gauss.Rand) mlkem.Poly {
+	var f mlkem.Poly
+	for i := range f {
+		f[i] = uint16(r.Below(q))
+	}
+	return f
+}
+
+// testDevice returns a device with a key drawn from r.
+func testDevice(t *testing.T, params *mlkem.Params, r *gauss.Rand) *Device {
+	t.Helper()
+	dk, err := mlkem.NewDecapsulationKey(params, randomBytes(r, mlkem.SeedSize))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dev, err := NewDevice(dk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dev
+}
+
+// TestInstrumented checks that the instrumented routines compute what
+// those of package mlkem do, and that a device decrypts and decapsulates
+// the ciphertexts of its encapsulation key.
+func TestInstrumented(t *testing.T) {
+	r := gauss.NewSHAKE([]byte("sca"))
+	for range 20 {
+		f, g := randomPoly(r), randomPoly(r)
+		m := randomBytes(r, 32)
+		if w := DecodeMessage(nil, m); !bytes.Equal(EncodeMessage(nil, &w), m) {
+			t.Error("EncodeMessage does not invert DecodeMessage")
+		}
+		if NTT(nil, f) != mlkem.NTT(f) {
+			t.Error("NTT differs from mlkem.NTT")
+		}
+		if InvNTT(nil, f) != mlkem.InvNTT(f) {
+			t.Error("InvNTT differs from mlkem.InvNTT")
+		}
+		if MultiplyNTTs(nil, &f, &g) != mlkem.MultiplyNTTs(&f, &g) {
+			t.Error("MultiplyNTTs differs from mlkem.MultiplyNTTs")
+		}
+	}
+	for _, params := range []*mlkem.Params{mlkem.MLKEM512, mlkem.MLKEM768, mlkem.MLKEM1024} {
+		dev := testDevice(t, params, r)
+		m := randomBytes(r, 32)
+		k, c := dev.dk.EncapsulationKey().EncapsulateInternal(m)
+		if got, err := dev.Decrypt(nil, c); err != nil || !bytes.Equal(got, m) {
+			t.Errorf("%v: Decrypt = %x, %v, want %x", params, got, err, m)
+		}
+		if key, err := dev.Decapsulate(nil, c); err != nil || !bytes.Equal(key, k) {
+			t.Errorf("%v: Decapsulate = %x, %v, want %x", params, key, err, k)
+		}
+		if _, err := dev.Decrypt(nil, c[1:]); err == nil {
+			t.Errorf("%v: Decrypt accepted a short ciphertext", params)
+		}
+	}
+}
+
+// TestCPA checks that, with little noise, 60 traces give the whole of
+// s-hat under both leakage models. The first coefficients are set to 0
+// and 1, whose products leak nothing that correlates under one model or
+// the other.
+func TestCPA(t *testing.T) {
+	for _, m := range []Model{HammingWeight, HammingDistance} {
+		t.Run(m.String(), func(t *testing.T) {
+			r := gauss.NewSHAKE([]byte("cpa"))
+			dev := testDevice(t, mlkem.MLKEM512, r)
+			copy(dev.s[0][:], []uint16{0, 1, 1, 0})
+			c, err := Collect(dev, NewProbe(m, 0.5, r), 60, r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n, all := Correct(c.CPA(), dev.Secret()), 256*dev.Params().K; n != all {
+				t.Errorf("recovered %d of %d coefficients", n, all)
+			}
+		})
+	}
+}
+
+// TestAttackNTT checks that the single-trace template attack on the NTT
+// of a secret does much better than guessing each coefficient among the
+// 2 eta + 1 possible values.
+func TestAttackNTT(t *testing.T) {
+	r := gauss.NewSHAKE([]byte("ntt"))
+	const eta, targets = 2, 10
+	p := NewProbe(HammingWeight, 0.5, r)
+	tmpl, err := ProfileNTT(p, eta, 500, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	correct := 0
+	for range targets {
+		s := SampleSecret(eta, r)
+		trace := p.Record(func() { NTT(p, s) })
+		correct += Correct([]mlkem.Poly{AttackNTT(tmpl, eta, trace)}, []mlkem.Poly{s})
+	}
+	rate, chance := float64(correct)/(targets*mlkem.N), 1.0/(2*eta+1)
+	if rate < 3*chance {
+		t.Errorf("recovered %.1f%% of coefficients, chance is %.1f%%", 100*rate, 100*chance)
+	}
+}
+
+// TestMessageTemplate checks that a template profiled on the attacker's
+// own device recovers the shared key of the victim from single traces.
+func TestMessageTemplate(t *testing.T) {
+	r := gauss.NewSHAKE([]byte("message"))
+	params := mlkem.MLKEM768
+	p := NewProbe(HammingWeight, 0.5, r)
+	clone := testDevice(t, params, r)
+	mt, err := ProfileMessage(clone, clone.dk.EncapsulationKey(), p, 200, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	victim := testDevice(t, params, r)
+	ek := victim.dk.EncapsulationKey()
+	for range 5 {
+		want, c := ek.Encapsulate()
+		trace, err := victim.Record(p, c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		off, ok := p.Offset("decode")
+		if !ok {
+			t.Fatal("no decode part in the trace")
+		}
+		key, _, ok := mt.RecoverSharedKey(ek, c, trace, off)
+		if !ok || !bytes.Equal(key, want) {
+			t.Errorf("RecoverSharedKey = %x, %v, want %x", key, ok, want)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package sca
+
+import (
+	"errors"
+	"math"
+)
+
+// A Template is a Gaussian model of the leakage of each class of a secret
+// intermediate: a mean vector per class and one covariance matrix pooled
+// over the classes, estimated from profiling traces of a device whose
+// secrets are known. Pooling the covariance assumes that the noise does
+// not depend on the class, which holds for the additive noise of a Probe
+// and lets rare classes be profiled from few traces.
+type Template struct {
+	dim   int
+	n     []int
+	sum   [][]float64
+	outer [][]float64 // sum of x x^T over all classes
+	mean  [][]float64
+	prec  [][]float64 // inverse of the pooled covariance
+}
+
+// NewTemplate returns an empty template for classes classes of dim
+// samples each.
+func NewTemplate(classes, dim int) *Template {
+	t := &Template{dim: dim, n: make([]int, classes), sum: make([][]float64, classes), outer: make([][]float64, dim)}
+	for c := range t.sum {
+		t.sum[c] = make([]float64, dim)
+	}
+	for i := range t.outer {
+		t.outer[i] = make([]float64, dim)
+	}
+	return t
+}
+
+// Add adds the samples x of a profiling trace of class c.
+func (t *Template) Add(c int, x []float64) {
+	t.n[c]++
+	for i, xi := range x {
+		t.sum[c][i] += xi
+		for j, xj := range x {
+			t.outer[i][j] += xi * xj
+		}
+	}
+}
+
+// Build estimates the means and the pooled covariance. Classes that were
+// never seen are never chosen.
+func (t *Template) Build() error {
+	total, seen := 0, 0
+	t.mean = make([][]float64, len(t.n))
+	cov := make([][]float64, t.dim)
+	for i := range cov {
+		cov[i] = append([]float64(nil), t.outer[i]...)
+	}
+	for c, n := range t.n {
+		if n == 0 {
+			continue
+		}
+		total += n
+		seen++
+		t.mean[c] = make([]float64, t.dim)
+		for i := range t.mean[c] {
+			t.mean[c][i] = t.sum[c][i] / float64(n)
+		}
+		for i := range cov {
+			for j := range cov[i] {
+				cov[i][j] -= float64(n) * t.mean[c][i] * t.mean[c][j]
+			}
+		}
+	}
+	if total <= seen {
+		return errors.New("sca: too few profiling traces")
+	}
+	for i := range cov {
+		for j := range cov[i] {
+			cov[i][j] /= float64(total - seen)
+		}
+		// A small ridge keeps noiseless traces, whose covariance is
+		// singular, usable.
+		cov[i][i] += 1e-6
+	}
+	var ok bool
+	if t.prec, ok = invert(cov); !ok {
+		return errors.New("sca: singular covariance")
+	}
+	return nil
+}
+
+// LogLikelihood adds to ll, for every class, the log-likelihood of the
+// samples x up to a constant, -(x - m_c)^T S^-1 (x - m_c) / 2. Summing
+// over several traces of the same secret combines their evidence.
+func (t *Template) LogLikelihood(x []float64, ll []float64) {
+	d := make([]float64, t.dim)
+	for c, m := range t.mean {
+		if m == nil {
+			ll[c] = math.Inf(-1)
+			continue
+		}
+		for i := range d {
+			d[i] = x[i] - m[i]
+		}
+		var s float64
+		for i := range d {
+			for j := range d {
+				s += d[i] * t.prec[i][j] * d[j]
+			}
+		}
+		ll[c] -= s / 2
+	}
+}
+
+// Classify returns the most likely class of the samples x.
+func (t *Template) Classify(x []float64) int {
+	ll := make([]float64, len(t.n))
+	t.LogLikelihood(x, ll)
+	return argmax(ll)
+}
+
+func argmax(x []float64) int {
+	best := 0
+	for i, v := range x {
+		if v > x[best] {
+			best = i
+		}
+	}
+	return best
+}
+
+// invert returns the inverse of the square matrix a by Gauss–Jordan
+// elimination with partial pivoting.
+func invert(a [][]float64) ([][]float64, bool) {
+	n := len(a)
+	m := make([][]float64, n)
+	for i := range m {
+		m[i] = make([]float64, 2*n)
+		copy(m[i], a[i])
+		m[i][n+i] = 1
+	}
+	for c := range n {
+		p := c
+		for r := c + 1; r < n; r++ {
+			if math.Abs(m[r][c]) > math.Abs(m[p][c]) {
+				p = r
+			}
+		}
+		if m[p][c] == 0 {
+			return nil, false
+		}
+		m[c], m[p] = m[p], m[c]
+		for r := range n {
+			if r == c || m[r][c] == 0 {
+				continue
+			}
+			f := m[r][c] / m[c][c]
+			for k := c; k < 2*n; k++ {
+				m[r][k] -= f * m[c][k]
+			}
+		}
+	}
+	inv := make([][]float64, n)
+	for i := range inv {
+		inv[i] = make([]float64, n)
+		for j := range n {
+			inv[i][j] = m[i][n+j] / m[i][i]
+		}
+	}
+	return inv, true
+}
@@ -0,0 +1,42 @@
+// Package trapdoor implements the lattice trapdoors of Micciancio and
+// Peikert ("Trapdoors for lattices: simpler, tighter, faster, smaller",
+// MP12) over Z_q and over the rings R_q = Z_q[x]/(x^d + 1), the basis of
+// hash-and-sign signatures and identity-based encryption.
+//
+// The gadget vector g = (1, b, b^2, ..., b^(k-1)) with b^k >= q makes the
+// SIS problem g^T x = u (mod q) easy: the base-b digits of u are a
+// solution, and the lattice Lambda^perp(g^T) has the short basis S_k
+//
+//	[  b                 ]
+//	[ -1   b             ]
+//	[      ...  ...      ]
+//	[          -1   b    ]
+//	[ q_0 q_1 ... q_(k-1)]
+//
+// (written by rows, q_i the digits of q), so discrete Gaussian solutions
+// are sampled with Klein's algorithm in dimension k. The gadget matrix
+// G = I_n (x) g^T does the same for n equations at once.
+//
+// A trapdoor hides G in a uniform-looking matrix: with A-bar uniform and R
+// short,
+//
+//	A = [ A-bar | G - A-bar R ],   A [R; I] = G.
+//
+// To solve A x = u, Generate's trapdoor sampler (SamplePre) follows MP12,
+// Algorithm 3:
+//
+//  1. draw a perturbation p with covariance s^2 I - sigma_g^2 T T^T, where
+//     T = [R; I],
+//  2. sample z from the gadget lattice coset G z = u - A p,
+//  3. return x = p + T z.
+//
+// The covariances add up to s^2 I, so x is distributed as a spherical
+// discrete Gaussian over the coset of Lambda^perp(A) and reveals nothing
+// about R, which is what makes the signatures secure. The width s must
+// exceed sigma_g s_1(T), which Generate computes.
+//
+// The plain variant is the case d = 1. For d > 1 the entries of A are
+// ring elements, multiplied with a multiplier from package poly, and the
+// sampler works on their coefficient embedding; since g has integer
+// entries, gadget sampling is still coordinate by coordinate.
+package trapdoor
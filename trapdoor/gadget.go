@@ -0,0 +1,113 @@
+package trapdoor
+
+import (
+	"fmt"
+
+	"github.com/haopining/Learn-Lattice-Based-Cryptography/gauss"
+	"github.com/haopining/Learn-Lattice-Based-Cryptography/lattice"
+)
+
+// Gadget is the gadget vector g = (1, b, ..., b^(k-1)) modulo q, with the
+// smallest k such that b^k >= q.
+type Gadget struct {
+	Q, Base int64
+	K       int
+	rows    [][]int64
+	basis   lattice.Basis
+	gso     *lattice.GSOFloat
+	sigma   float64
+}
+
+// NewGadget returns the gadget for modulus q and base b >= 2.
+func NewGadget(q, base int64) (*Gadget, error) {
+	if q < 2 || base < 2 {
+		return nil, fmt.Errorf("trapdoor: invalid gadget modulus %d or base %d", q, base)
+	}
+	g := &Gadget{Q: q, Base: base}
+	for p := int64(1); p < q; p *= base {
+		g.K++
+	}
+	k := g.K
+	// The basis S_k of Lambda^perp(g^T): b e_i - e_(i+1), then the digits
+	// of q, with the last digit allowed to reach b when q = b^k.
+	g.rows = make([][]int64, k)
+	for i := 0; i < k-1; i++ {
+		g.rows[i] = make([]int64, k)
+		g.rows[i][i] = base
+		g.rows[i][i+1] = -1
+	}
+	g.rows[k-1] = make([]int64, k)
+	rest := q
+	for i := 0; i < k-1; i++ {
+		g.rows[k-1][i] = rest % base
+		rest /= base
+	}
+	g.rows[k-1][k-1] = rest
+	g.basis = lattice.NewBasis(g.rows)
+	g.gso = g.basis.GramSchmidtFloat()
+	kl, err := gauss.NewKlein(g.basis, g.gso, 1, nil)
+	if err != nil {
+		return nil, err
+	}
+	g.sigma = kl.MinWidth(gauss.DefaultEpsilon)
+	return g, nil
+}
+
+// Vector returns g.
+func (g *Gadget) Vector() []int64 {
+	v := make([]int64, g.K)
+	v[0] = 1
+	for i := 1; i < g.K; i++ {
+		v[i] = v[i-1] * g.Base
+	}
+	return v
+}
+
+// Basis returns the short basis S_k of Lambda^perp(g^T), by rows.
+func (g *Gadget) Basis() lattice.Basis { return g.basis.Clone() }
+
+// Width returns sigma_g, the width of the gadget samples: the smallest
+// one for which Klein's algorithm on S_k is close to the ideal
+// distribution.
+func (g *Gadget) Width() float64 { return g.sigma }
+
+// Decompose returns the base-b digits of u mod q, the canonical solution
+// of <g, x> = u (mod q), the "bit decomposition" G^-1.
+func (g *Gadget) Decompose(u int64) []int64 {
+	u %= g.Q
+	if u < 0 {
+		u += g.Q
+	}
+	x := make([]int64, g.K)
+	for i := range x {
+		x[i] = u % g.Base
+		u /= g.Base
+	}
+	return x
+}
+
+// Sample returns x with <g, x> = u (mod q) distributed as the discrete
+// Gaussian of width Width over that coset of Lambda^perp(g^T): with
+// t = Decompose(u), a lattice point v is drawn from D_{Lambda^perp, -t}
+// by Klein's algorithm and x = t + v.
+func (g *Gadget) Sample(u int64, r *gauss.Rand) []int64 {
+	t := g.Decompose(u)
+	center := make([]float64, g.K)
+	for i, ti := range t {
+		center[i] = -float64(ti)
+	}
+	kl, err := gauss.NewKlein(g.basis, g.gso, g.sigma, r)
+	if err != nil {
+		panic(err)
+	}
+	z, err := kl.SampleCoeffs(center)
+	if err != nil {
+		panic(err)
+	}
+	for i, zi := range z {
+		for j, s := range g.rows[i] {
+			t[j] += zi * s
+		}
+	}
+	return t
+}
@@ -0,0 +1,367 @@
+package trapdoor
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/haopining/Learn-Lattice-Based-Cryptography/gauss"
+	"github.com/haopining/Learn-Lattice-Based-Cryptography/poly"
+)
+
+// ErrShape is returned for a syndrome or preimage of the wrong size.
+var ErrShape = errors.New("trapdoor: wrong dimensions")
+
+// Params selects a trapdoor. Zero fields take the defaults noted.
+type Params struct {
+	// Degree is the ring degree d, a power of two; 1 (the default) gives
+	// the plain variant over Z_q.
+	Degree int
+	// Rank is the number n of rows of A: the SIS dimension for d = 1, the
+	// module rank otherwise (1 for the ring variant).
+	Rank int
+	Q    int64
+	// Base is the gadget base b. Default: 2.
+	Base int64
+	// Computational takes A-bar = [I | A-hat] with a uniform square A-hat,
+	// so that A is pseudorandom under (Ring/Module-)LWE with secrets the
+	// columns of R. Otherwise A-bar is uniform with MBar columns and A is
+	// statistically close to uniform by the leftover hash lemma, which
+	// needs MBar d log2(sigma_R) to exceed about n d log2 q.
+	Computational bool
+	// MBar is the number of columns of A-bar. Default: Rank K. It is
+	// ignored with Computational, where it is 2 Rank.
+	MBar int
+	// SigmaR is the width of the entries of R. Default:
+	// gauss.Smoothing(gauss.DefaultEpsilon).
+	SigmaR float64
+	// Mul multiplies ring elements. Default: poly.Karatsuba.
+	Mul poly.Multiplier
+}
+
+// Trapdoor is a public matrix A = [A-bar | G - A-bar R] together with its
+// trapdoor R. Matrices of ring elements are stored as [row][column][coeff];
+// for the plain variant the coefficient slices have length 1.
+type Trapdoor struct {
+	Params
+	Ring poly.Ring
+	G    *Gadget
+	// A is Rank x M with M = MBar + Rank K, entries in [0, q).
+	A [][][]int64
+	// R is MBar x Rank K with small signed entries.
+	R [][][]int64
+	// S is the width of the preimages; SamplePre returns vectors of norm
+	// about S sqrt(M d).
+	S float64
+
+	pert *gauss.Skewed
+	r    *gauss.Rand
+}
+
+// M returns the number of columns of A.
+func (t *Trapdoor) M() int { return t.MBar + t.Rank*t.G.K }
+
+// Generate returns a fresh trapdoor drawing from r, or from crypto/rand if
+// r is nil.
+func Generate(p Params, r *gauss.Rand) (*Trapdoor, error) {
+	if r == nil {
+		r = gauss.NewRand(nil)
+	}
+	if p.Degree == 0 {
+		p.Degree = 1
+	}
+	if p.Base == 0 {
+		p.Base = 2
+	}
+	if p.SigmaR == 0 {
+		p.SigmaR = gauss.Smoothing(gauss.DefaultEpsilon)
+	}
+	if p.Mul == nil {
+		p.Mul = poly.Karatsuba
+	}
+	if p.Degree&(p.Degree-1) != 0 || p.Rank < 1 {
+		return nil, fmt.Errorf("trapdoor: unsupported degree %d or rank %d", p.Degree, p.Rank)
+	}
+	ring, err := poly.NewRing(p.Degree, p.Q, p.Mul)
+	if err != nil {
+		return nil, err
+	}
+	g, err := NewGadget(p.Q, p.Base)
+	if err != nil {
+		return nil, err
+	}
+	n, d := p.Rank, p.Degree
+	w := n * g.K
+	switch {
+	case p.Computational:
+		p.MBar = 2 * n
+	case p.MBar == 0:
+		p.MBar = w
+	}
+	t := &Trapdoor{Params: p, Ring: ring, G: g, r: r}
+
+	// A-bar, uniform or [I | A-hat].
+	abar := matrix(n, p.MBar, d)
+	for i := range abar {
+		for j := range abar[i] {
+			if p.Computational && j < n {
+				if i == j {
+					abar[i][j][0] = 1
+				}
+				continue
+			}
+			for c := range abar[i][j] {
+				abar[i][j][c] = int64(r.Below(uint64(p.Q)))
+			}
+		}
+	}
+	// R with entries from D_{Z,sigma_R}.
+	zs, err := gauss.NewFACCT(p.SigmaR, 0, r)
+	if err != nil {
+		return nil, err
+	}
+	t.R = matrix(p.MBar, w, d)
+	for i := range t.R {
+		for j := range t.R[i] {
+			for c := range t.R[i][j] {
+				t.R[i][j][c] = zs.Sample()
+			}
+		}
+	}
+	// A = [A-bar | G - A-bar R].
+	ar := t.mulMatrix(abar, t.R)
+	gv := g.Vector()
+	t.A = make([][][]int64, n)
+	for i := range t.A {
+		t.A[i] = append(t.A[i], abar[i]...)
+		for j := range w {
+			e := ring.Neg(ar[i][j])
+			if j/g.K == i {
+				e[0] = (e[0] + gv[j%g.K]) % p.Q
+			}
+			t.A[i] = append(t.A[i], e)
+		}
+	}
+
+	// The perturbation covariance s^2 I - sigma_g^2 T T^T over the
+	// coefficient embedding of T = [R; I].
+	te := t.embedT()
+	s1 := largestSingular(te)
+	sg := g.Width()
+	rw := gauss.Smoothing(gauss.DefaultEpsilon)
+	// 5% over the minimum, which also covers the power iteration
+	// underestimating s_1.
+	t.S = 1.05 * math.Sqrt(sg*sg*s1*s1+rw*rw)
+	cov := make([][]float64, len(te))
+	for i := range cov {
+		cov[i] = make([]float64, len(te))
+		for j := range cov[i] {
+			dot := 0.0
+			for k := range te[i] {
+				dot += te[i][k] * te[j][k]
+			}
+			cov[i][j] = -sg * sg * dot
+		}
+		cov[i][i] += t.S * t.S
+	}
+	if t.pert, err = gauss.NewSkewed(cov, rw, r); err != nil {
+		return nil, fmt.Errorf("trapdoor: perturbation: %v", err)
+	}
+	return t, nil
+}
+
+// SamplePre returns a short x with A x = u (mod q), distributed as the
+// discrete Gaussian of width S over the solutions. u has Rank entries of
+// Degree coefficients; x has M, centered around zero.
+func (t *Trapdoor) SamplePre(u [][]int64) ([][]int64, error) {
+	n, d, m, k := t.Rank, t.Degree, t.M(), t.G.K
+	if len(u) != n {
+		return nil, ErrShape
+	}
+	for _, ui := range u {
+		if len(ui) != d {
+			return nil, ErrShape
+		}
+	}
+	// 1. The perturbation p and the syndrome u - A p left to G.
+	flat := t.pert.Sample()
+	p := make([][]int64, m)
+	for i := range p {
+		p[i] = flat[i*d : (i+1)*d]
+	}
+	ap := t.Syndrome(p)
+	// 2. z in the gadget coset, coefficient by coefficient.
+	z := matrix(1, n*k, d)[0]
+	for i := range n {
+		v := t.Ring.Sub(t.Ring.Reduce(u[i]), ap[i])
+		for c, vc := range v {
+			for j, zj := range t.G.Sample(vc, t.r) {
+				z[i*k+j][c] = zj
+			}
+		}
+	}
+	// 3. x = p + [R z; z].
+	rz := t.mulVector(t.R, z)
+	x := make([][]int64, m)
+	for i := range x {
+		x[i] = make([]int64, d)
+		for c := range x[i] {
+			var tz int64
+			if i < t.MBar {
+				tz = center(rz[i][c], t.Q)
+			} else {
+				tz = z[i-t.MBar][c]
+			}
+			x[i][c] = p[i][c] + tz
+		}
+	}
+	return x, nil
+}
+
+// Syndrome returns A x mod q.
+func (t *Trapdoor) Syndrome(x [][]int64) [][]int64 {
+	return t.mulVector(t.A, x)
+}
+
+// Verify checks that x is a preimage of u and returns its Euclidean norm.
+func (t *Trapdoor) Verify(u, x [][]int64) (float64, error) {
+	if len(x) != t.M() || len(u) != t.Rank {
+		return 0, ErrShape
+	}
+	ax := t.Syndrome(x)
+	for i := range ax {
+		want := t.Ring.Reduce(u[i])
+		for c := range want {
+			if ax[i][c] != want[c] {
+				return 0, errors.New("trapdoor: A x != u")
+			}
+		}
+	}
+	s := 0.0
+	for _, xi := range x {
+		for _, c := range xi {
+			s += float64(c) * float64(c)
+		}
+	}
+	return math.Sqrt(s), nil
+}
+
+// mulMatrix returns a b over R_q for matrices of ring elements.
+func (t *Trapdoor) mulMatrix(a, b [][][]int64) [][][]int64 {
+	out := make([][][]int64, len(a))
+	for i := range a {
+		out[i] = make([][]int64, len(b[0]))
+		for j := range out[i] {
+			acc := t.Ring.Zero()
+			for l := range a[i] {
+				acc = t.Ring.Add(acc, t.Ring.Mul(t.Ring.Reduce(a[i][l]), t.Ring.Reduce(b[l][j])))
+			}
+			out[i][j] = acc
+		}
+	}
+	return out
+}
+
+// mulVector returns a x over R_q for a vector x of ring elements.
+func (t *Trapdoor) mulVector(a [][][]int64, x [][]int64) [][]int64 {
+	out := make([][]int64, len(a))
+	for i := range a {
+		acc := t.Ring.Zero()
+		for l, xl := range x {
+			acc = t.Ring.Add(acc, t.Ring.Mul(t.Ring.Reduce(a[i][l]), t.Ring.Reduce(xl)))
+		}
+		out[i] = acc
+	}
+	return out
+}
+
+// embedT returns the coefficient embedding of T = [R; I]: row (i, c) and
+// column (j, e) hold the coefficient of x^c in R_ij x^e, negacyclically.
+func (t *Trapdoor) embedT() [][]float64 {
+	d, w := t.Degree, t.Rank*t.G.K
+	te := make([][]float64, t.M()*d)
+	for i := range te {
+		te[i] = make([]float64, w*d)
+	}
+	for i := range t.R {
+		for j, rij := range t.R[i] {
+			for c := range d {
+				for e := range d {
+					v := 0.0
+					if c >= e {
+						v = float64(rij[c-e])
+					} else {
+						v = -float64(rij[c-e+d])
+					}
+					te[i*d+c][j*d+e] = v
+				}
+			}
+		}
+	}
+	for j := range w * d {
+		te[t.MBar*d+j][j] = 1
+	}
+	return te
+}
+
+// largestSingular estimates s_1(T) by power iteration on T^T T.
+func largestSingular(te [][]float64) float64 {
+	cols := len(te[0])
+	v := make([]float64, cols)
+	for i := range v {
+		v[i] = 1 / math.Sqrt(float64(cols))
+	}
+	s := 0.0
+	for range 100 {
+		tv := make([]float64, len(te))
+		for i, row := range te {
+			for j, x := range row {
+				tv[i] += x * v[j]
+			}
+		}
+		next := make([]float64, cols)
+		for i, row := range te {
+			for j, x := range row {
+				next[j] += x * tv[i]
+			}
+		}
+		n := 0.0
+		for _, x := range next {
+			n += x * x
+		}
+		n = math.Sqrt(n)
+		prev := s
+		s = math.Sqrt(n)
+		for j := range next {
+			next[j] /= n
+		}
+		v = next
+		if math.Abs(s-prev) < 1e-9*s {
+			break
+		}
+	}
+	return s
+}
+
+func matrix(rows, cols, d int) [][][]int64 {
+	m := make([][][]int64, rows)
+	for i := range m {
+		m[i] = make([][]int64, cols)
+		for j := range m[i] {
+			m[i][j] = make([]int64, d)
+		}
+	}
+	return m
+}
+
+// center maps x in [0, q) to (-q/2, q/2].
+func center(x, q int64) int64 {
+	x %= q
+	if x < 0 {
+		x += q
+	}
+	if x > q/2 {
+		x -= q
+	}
+	return x
+}
@@ -0,0 +1,151 @@
+package trapdoor
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/haopining/Learn-Lattice-Based-Cryptography/gauss"
+)
+
+// dot returns <g, x> mod q in [0, q).
+func dot(g, x []int64, q int64) int64 {
+	var s int64
+	for i := range g {
+		s = (s + g[i]%q*(x[i]%q)) % q
+	}
+	return (s + q) % q
+}
+
+// TestGadget checks that the rows of S_k lie in Lambda^perp(g^T) and that
+// Decompose and Sample both solve <g, x> = u (mod q).
+func TestGadget(t *testing.T) {
+	r := gauss.NewSHAKE([]byte("gadget"))
+	for _, tc := range []struct{ q, base int64 }{{3329, 2}, {1 << 12, 2}, {1 << 12, 4}, {257, 3}, {8380417, 16}} {
+		g, err := NewGadget(tc.q, tc.base)
+		if err != nil {
+			t.Fatal(err)
+		}
+		gv := g.Vector()
+		if p := gv[g.K-1]; p >= tc.q || p*tc.base < tc.q {
+			t.Errorf("q=%d b=%d: k=%d is not the smallest with b^k >= q", tc.q, tc.base, g.K)
+		}
+		for _, row := range g.Basis() {
+			x := make([]int64, g.K)
+			for i, c := range row {
+				x[i] = c.Int64()
+			}
+			if d := dot(gv, x, tc.q); d != 0 {
+				t.Errorf("q=%d b=%d: <g, %v> = %d, want 0", tc.q, tc.base, x, d)
+			}
+		}
+		for _, u := range []int64{0, 1, tc.q - 1, tc.q, -1, int64(r.Below(uint64(tc.q)))} {
+			want := (u%tc.q + tc.q) % tc.q
+			x := g.Decompose(u)
+			for _, xi := range x {
+				if xi < 0 || xi >= tc.base {
+					t.Errorf("q=%d b=%d: Decompose(%d) = %v is not base-b digits", tc.q, tc.base, u, x)
+				}
+			}
+			if d := dot(gv, x, tc.q); d != want {
+				t.Errorf("q=%d b=%d: <g, Decompose(%d)> = %d", tc.q, tc.base, u, d)
+			}
+			for range 10 {
+				if x := g.Sample(u, r); dot(gv, x, tc.q) != want {
+					t.Errorf("q=%d b=%d: <g, Sample(%d)> = %d", tc.q, tc.base, u, dot(gv, x, tc.q))
+				}
+			}
+		}
+	}
+	if _, err := NewGadget(1, 2); err == nil {
+		t.Error("NewGadget accepted q = 1")
+	}
+	if _, err := NewGadget(17, 1); err == nil {
+		t.Error("NewGadget accepted base 1")
+	}
+}
+
+// TestSamplePre checks the plain, ring, module and computational
+// trapdoors: every preimage satisfies A x = u, and the mean norm is close
+// to S sqrt(M d).
+func TestSamplePre(t *testing.T) {
+	const samples = 20
+	for _, tc := range []struct {
+		name string
+		p    Params
+	}{
+		{"plain", Params{Rank: 4, Q: 3329}},
+		{"ring", Params{Degree: 8, Rank: 1, Q: 3329}},
+		{"module", Params{Degree: 4, Rank: 2, Q: 3329, Base: 4}},
+		{"computational", Params{Rank: 3, Q: 257, Computational: true}},
+		{"computational-ring", Params{Degree: 8, Rank: 1, Q: 1 << 12, Computational: true}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r := gauss.NewSHAKE([]byte("trapdoor"))
+			td, err := Generate(tc.p, r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sum := 0.0
+			for range samples {
+				u := matrix(1, td.Rank, td.Degree)[0]
+				for i := range u {
+					for c := range u[i] {
+						u[i][c] = int64(r.Below(uint64(td.Q)))
+					}
+				}
+				x, err := td.SamplePre(u)
+				if err != nil {
+					t.Fatal(err)
+				}
+				norm, err := td.Verify(u, x)
+				if err != nil {
+					t.Fatal(err)
+				}
+				sum += norm
+				x[0][0]++
+				if _, err := td.Verify(u, x); err == nil {
+					t.Error("Verify accepted a modified preimage")
+				}
+			}
+			want := td.S * math.Sqrt(float64(td.M()*td.Degree))
+			if ratio := sum / samples / want; ratio < 0.9 || ratio > 1.1 {
+				t.Errorf("mean norm is %.3f S sqrt(M d), want about 1", ratio)
+			}
+		})
+	}
+}
+
+// TestShape checks that malformed syndromes and preimages give ErrShape.
+func TestShape(t *testing.T) {
+	td, err := Generate(Params{Degree: 4, Rank: 2, Q: 3329}, gauss.NewSHAKE([]byte("shape")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	good := matrix(1, 2, 4)[0]
+	for name, u := range map[string][][]int64{
+		"short rank":   matrix(1, 1, 4)[0],
+		"long rank":    matrix(1, 3, 4)[0],
+		"short degree": {make([]int64, 4), make([]int64, 3)},
+		"long degree":  {make([]int64, 5), make([]int64, 4)},
+	} {
+		if _, err := td.SamplePre(u); !errors.Is(err, ErrShape) {
+			t.Errorf("SamplePre with %s: got %v, want ErrShape", name, err)
+		}
+	}
+	x, err := td.SamplePre(good)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := td.Verify(good, x[1:]); !errors.Is(err, ErrShape) {
+		t.Errorf("Verify with a short preimage: got %v, want ErrShape", err)
+	}
+	if _, err := td.Verify(good[1:], x); !errors.Is(err, ErrShape) {
+		t.Errorf("Verify with a short syndrome: got %v, want ErrShape", err)
+	}
+	for _, p := range []Params{{Degree: 3, Rank: 1, Q: 3329}, {Rank: 0, Q: 3329}, {Rank: 1, Q: 1}} {
+		if _, err := Generate(p, nil); err == nil {
+			t.Errorf("Generate(%+v) succeeded", p)
+		}
+	}
+}